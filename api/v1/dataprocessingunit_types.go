@@ -34,8 +34,81 @@ type DataProcessingUnitSpec struct {
 	IsDpuSide bool `json:"isDpuSide"`
 	// NodeName is the name of the node where this DPU is located
 	NodeName string `json:"nodeName"`
+	// EffectiveConfig is the configuration merged from the
+	// DataProcessingUnitConfigs matching this DPU. It is written by the
+	// DataProcessingUnitConfig controller and applied by the daemon.
+	// +optional
+	EffectiveConfig *DpuEffectiveConfig `json:"effectiveConfig,omitempty"`
+	// Firmware is the firmware to install on the DPU. It is written by the
+	// DpuFirmwarePolicy controller and installed by the daemon.
+	// +optional
+	Firmware *DpuFirmwareTarget `json:"firmware,omitempty"`
 }
 
+// DpuEffectiveConfig is the configuration of a DPU merged from the
+// DataProcessingUnitConfigs matching it, by their priority.
+type DpuEffectiveConfig struct {
+	// VfCount is the VF count to apply on the host side.
+	// +optional
+	VfCount *int32 `json:"vfCount,omitempty"`
+	// VfCountSource is the DataProcessingUnitConfig the VF count comes from, as namespace/name.
+	// +optional
+	VfCountSource string `json:"vfCountSource,omitempty"`
+	// Sources lists the DataProcessingUnitConfigs matching the DPU as
+	// namespace/name, from the highest precedence to the lowest.
+	// +optional
+	Sources []string `json:"sources,omitempty"`
+	// Drain is whether the daemon drains the node before it changes the VF
+	// count. It comes from the rollout strategy of VfCountSource.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+}
+
+// DpuFirmwareTarget is the firmware a DpuFirmwarePolicy sets for a DPU.
+type DpuFirmwareTarget struct {
+	// Policy is the DpuFirmwarePolicy the firmware comes from.
+	Policy string `json:"policy"`
+	// Component is the firmware component to update.
+	Component string `json:"component"`
+	// Version is the firmware version to install.
+	Version string `json:"version"`
+	// URL is where the vendor plugin downloads the firmware image from.
+	URL string `json:"url"`
+	// Checksum is the hex encoded SHA-256 digest of the firmware image.
+	Checksum string `json:"checksum"`
+	// Drain is whether the daemon drains the node for the update. It comes
+	// from the rollout strategy of the policy.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+}
+
+// Condition types reported on DataProcessingUnitStatus by the daemon, next to
+// Ready. They tell apart the reasons a DPU is not Ready.
+const (
+	// DpuConditionDetected is True while the daemon detects the DPU on its node.
+	DpuConditionDetected = "Detected"
+	// DpuConditionVspPodRunning is True when the vendor specific plugin pod of the DPU is running.
+	DpuConditionVspPodRunning = "VspPodRunning"
+	// DpuConditionPluginInitialized is True when the daemon initialized the vendor specific plugin.
+	DpuConditionPluginInitialized = "PluginInitialized"
+	// DpuConditionRegistryPluginHealthy is True when the health check of the
+	// vendor plugin of the operator passes. It is Unknown if the DPU has none,
+	// or if the check fails and no OPI endpoint is configured for the plugin.
+	DpuConditionRegistryPluginHealthy = "RegistryPluginHealthy"
+	// DpuConditionHeartbeatHealthy is True when the heartbeat between the host
+	// and the DPU side is healthy. When it is not, the message holds the time
+	// of the last successful ping.
+	DpuConditionHeartbeatHealthy = "HeartbeatHealthy"
+	// DpuConditionDevicePluginRegistered is True when the device plugin of the DPU is registered with the kubelet.
+	DpuConditionDevicePluginRegistered = "DevicePluginRegistered"
+	// DpuConditionVfCountApplied is True when the VF count requested by
+	// DataProcessingUnitConfigs is applied. Only reported on the host side.
+	DpuConditionVfCountApplied = "VfCountApplied"
+	// DpuConditionFirmwareUpdated is True when the DPU runs the firmware set
+	// by a DpuFirmwarePolicy. Only reported on the host side.
+	DpuConditionFirmwareUpdated = "FirmwareUpdated"
+)
+
 // DataProcessingUnitStatus defines the observed state of DataProcessingUnit
 type DataProcessingUnitStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -43,6 +116,332 @@ type DataProcessingUnitStatus struct {
 
 	// Status is the status of the DPU
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// OpiEndpoint is the OPI endpoint of the vendor plugin of the DPU, as
+	// host:port reachable from the cluster. The operator reaches the DPU on
+	// it for the storage and security offloads. Only reported while the
+	// plugin listens on an address other than loopback.
+	// +optional
+	OpiEndpoint string `json:"opiEndpoint,omitempty"`
+
+	// PhysicalFunctions maps the host physical functions of the DPU to the
+	// virtual functions that have a bridge port. Only reported on the host side.
+	// +optional
+	PhysicalFunctions []PhysicalFunctionStatus `json:"physicalFunctions,omitempty"`
+
+	// Inventory is the hardware inventory of the DPU as reported by its
+	// vendor plugin. It is refreshed at a low cadence.
+	// +optional
+	Inventory *DpuInventory `json:"inventory,omitempty"`
+
+	// VfCount reports the VF count requested by DataProcessingUnitConfigs and
+	// whether the daemon applied it. Only reported on the host side, while a
+	// DataProcessingUnitConfig requests a VF count.
+	// +optional
+	VfCount *VfCountStatus `json:"vfCount,omitempty"`
+
+	// Firmware reports the firmware update of the DPU. Only reported on the
+	// host side, while a DpuFirmwarePolicy sets the firmware of the DPU.
+	// +optional
+	Firmware *DpuFirmwareStatus `json:"firmware,omitempty"`
+
+	// Reboot reports the reboot of the DPU in progress, or the last one if it
+	// failed. Only reported on the host side.
+	// +optional
+	Reboot *DpuRebootState `json:"reboot,omitempty"`
+
+	// Manager reports the failures of the side manager the daemon runs for
+	// the DPU. Only reported once the side manager failed.
+	// +optional
+	Manager *DpuManagerStatus `json:"manager,omitempty"`
+}
+
+// DpuManagerStatus reports the restarts of the side manager of a DPU.
+type DpuManagerStatus struct {
+	// Restarts is how many times the daemon restarted the side manager after
+	// it failed, since the daemon started.
+	Restarts int32 `json:"restarts"`
+	// LastError is the error the side manager last failed with.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// LastFailureTime is when the side manager last failed.
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+}
+
+// DpuRebootTrigger is what started a reboot of a DPU.
+// +kubebuilder:validation:Enum=HeartbeatLost;DeviceLost;Requested
+type DpuRebootTrigger string
+
+const (
+	// DpuRebootTriggerHeartbeatLost means the heartbeat between the host and
+	// the DPU side stopped and the plugin reported the DPU resetting.
+	DpuRebootTriggerHeartbeatLost DpuRebootTrigger = "HeartbeatLost"
+	// DpuRebootTriggerDeviceLost means the DPU disappeared from the PCI bus
+	// of the host.
+	DpuRebootTriggerDeviceLost DpuRebootTrigger = "DeviceLost"
+	// DpuRebootTriggerRequested means a DpuReboot requested the reboot.
+	DpuRebootTriggerRequested DpuRebootTrigger = "Requested"
+)
+
+// DpuRebootState reports a reboot of a DPU.
+type DpuRebootState struct {
+	// Trigger is what started the reboot.
+	Trigger DpuRebootTrigger `json:"trigger"`
+	// Phase is the step of the reboot.
+	Phase DpuRebootPhase `json:"phase"`
+	// Request is the DpuReboot that requested the reboot.
+	// +optional
+	Request string `json:"request,omitempty"`
+	// StartTime is when the daemon noticed or started the reboot.
+	StartTime metav1.Time `json:"startTime"`
+	// LastError is why the reboot failed.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// VfCountState is the state of a VF count request on a DPU.
+// +kubebuilder:validation:Enum=Applied;Pending;Failed;Conflicted
+type VfCountState string
+
+const (
+	// VfCountStateApplied means the requested VF count is applied.
+	VfCountStateApplied VfCountState = "Applied"
+	// VfCountStatePending means the requested VF count is not applied yet.
+	VfCountStatePending VfCountState = "Pending"
+	// VfCountStateFailed means applying the requested VF count failed.
+	VfCountStateFailed VfCountState = "Failed"
+	// VfCountStateConflicted means the VF count of a DataProcessingUnitConfig
+	// is overridden by a config with a higher precedence.
+	VfCountStateConflicted VfCountState = "Conflicted"
+)
+
+// VfCountPhase is a step of a VF count change on a node that is drained.
+// +kubebuilder:validation:Enum=Draining;Applying;Verifying;Uncordoning
+type VfCountPhase string
+
+const (
+	// VfCountPhaseDraining means the node is cordoned and drained.
+	VfCountPhaseDraining VfCountPhase = "Draining"
+	// VfCountPhaseApplying means the VF count is being set.
+	VfCountPhaseApplying VfCountPhase = "Applying"
+	// VfCountPhaseVerifying means the daemon checks the VF count the plugin reports.
+	VfCountPhaseVerifying VfCountPhase = "Verifying"
+	// VfCountPhaseUncordoning means the node is made schedulable again.
+	VfCountPhaseUncordoning VfCountPhase = "Uncordoning"
+)
+
+// VfCountStatus reports the VF count of a DPU.
+type VfCountStatus struct {
+	// Desired is the VF count of the effective config of the DPU.
+	// +optional
+	Desired *int32 `json:"desired,omitempty"`
+	// Applied is the VF count the daemon last applied.
+	// +optional
+	Applied *int32 `json:"applied,omitempty"`
+	// Sources lists the DataProcessingUnitConfig the desired VF count comes from, as namespace/name.
+	// +optional
+	Sources []string `json:"sources,omitempty"`
+	// State is the state of the request.
+	State VfCountState `json:"state"`
+	// Phase is the step of the VF count change in progress on the node.
+	// +optional
+	Phase VfCountPhase `json:"phase,omitempty"`
+	// LastError is the error of the last failed request.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// FirmwareState is the state of a firmware update on a DPU.
+// +kubebuilder:validation:Enum=Updated;Pending;Failed;Conflicted
+type FirmwareState string
+
+const (
+	// FirmwareStateUpdated means the DPU runs the desired firmware.
+	FirmwareStateUpdated FirmwareState = "Updated"
+	// FirmwareStatePending means the DPU does not run the desired firmware yet.
+	FirmwareStatePending FirmwareState = "Pending"
+	// FirmwareStateFailed means the update failed.
+	FirmwareStateFailed FirmwareState = "Failed"
+	// FirmwareStateConflicted means another DpuFirmwarePolicy sets the
+	// firmware of the DPU.
+	FirmwareStateConflicted FirmwareState = "Conflicted"
+)
+
+// FirmwarePhase is a step of a firmware update on a node.
+// +kubebuilder:validation:Enum=Staging;Draining;Activating;Resetting;RebootRequired;Verifying;Uncordoning
+type FirmwarePhase string
+
+const (
+	// FirmwarePhaseStaging means the image is downloaded and written to the DPU.
+	FirmwarePhaseStaging FirmwarePhase = "Staging"
+	// FirmwarePhaseDraining means the node is cordoned and drained.
+	FirmwarePhaseDraining FirmwarePhase = "Draining"
+	// FirmwarePhaseActivating means the staged firmware is activated.
+	FirmwarePhaseActivating FirmwarePhase = "Activating"
+	// FirmwarePhaseResetting means the daemon waits for the DPU to come back
+	// from a reset.
+	FirmwarePhaseResetting FirmwarePhase = "Resetting"
+	// FirmwarePhaseRebootRequired means the firmware runs after the node is
+	// rebooted. The node is schedulable until then; the administrator drains
+	// and reboots it.
+	FirmwarePhaseRebootRequired FirmwarePhase = "RebootRequired"
+	// FirmwarePhaseVerifying means the daemon checks the firmware the plugin reports.
+	FirmwarePhaseVerifying FirmwarePhase = "Verifying"
+	// FirmwarePhaseUncordoning means the node is made schedulable again.
+	FirmwarePhaseUncordoning FirmwarePhase = "Uncordoning"
+)
+
+// DpuFirmwareStatus reports the firmware of a DPU.
+type DpuFirmwareStatus struct {
+	// Component is the firmware component that is updated.
+	Component string `json:"component"`
+	// Desired is the firmware version of spec.firmware.
+	Desired string `json:"desired"`
+	// Running is the firmware version the DPU runs.
+	// +optional
+	Running string `json:"running,omitempty"`
+	// Staged is the firmware version that runs after the next activation.
+	// +optional
+	Staged string `json:"staged,omitempty"`
+	// State is the state of the update.
+	State FirmwareState `json:"state"`
+	// Phase is the step of the update in progress on the node.
+	// +optional
+	Phase FirmwarePhase `json:"phase,omitempty"`
+	// LastError is the error of the last failed update.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// DpuInventory describes the hardware of a DPU.
+type DpuInventory struct {
+	// LastUpdated is when the vendor plugin last reported a changed inventory.
+	LastUpdated metav1.Time `json:"lastUpdated"`
+	// DeviceID is the identifier of the device in the vendor plugin.
+	// +optional
+	DeviceID string `json:"deviceID,omitempty"`
+	// PCIAddress is the PCI address of the DPU.
+	// +optional
+	PCIAddress string `json:"pciAddress,omitempty"`
+	// Vendor is the hardware vendor of the DPU.
+	// +optional
+	Vendor string `json:"vendor,omitempty"`
+	// Model is the model of the DPU.
+	// +optional
+	Model string `json:"model,omitempty"`
+	// SerialNumber is the serial number of the DPU.
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// FirmwareVersion is the running firmware version.
+	// +optional
+	FirmwareVersion string `json:"firmwareVersion,omitempty"`
+	// BIOSVersion is the BIOS/UEFI version.
+	// +optional
+	BIOSVersion string `json:"biosVersion,omitempty"`
+	// BMCVersion is the BMC firmware version, if the DPU has a BMC.
+	// +optional
+	BMCVersion string `json:"bmcVersion,omitempty"`
+	// Chassis describes the chassis of the DPU.
+	// +optional
+	Chassis *ChassisInfo `json:"chassis,omitempty"`
+	// CPU describes the CPU of the DPU.
+	// +optional
+	CPU *CPUInfo `json:"cpu,omitempty"`
+	// Memory describes the memory of the DPU.
+	// +optional
+	Memory *MemoryInfo `json:"memory,omitempty"`
+	// NetworkInterfaces lists the network interfaces of the DPU.
+	// +optional
+	NetworkInterfaces []NetworkInterfaceInfo `json:"networkInterfaces,omitempty"`
+	// StorageDevices lists the storage devices of the DPU.
+	// +optional
+	StorageDevices []StorageDeviceInfo `json:"storageDevices,omitempty"`
+}
+
+// ChassisInfo describes the chassis of a DPU.
+type ChassisInfo struct {
+	// +optional
+	Manufacturer string `json:"manufacturer,omitempty"`
+	// +optional
+	Model string `json:"model,omitempty"`
+	// +optional
+	SerialNumber string `json:"serialNumber,omitempty"`
+}
+
+// CPUInfo describes the CPU of a DPU.
+type CPUInfo struct {
+	// +optional
+	Model string `json:"model,omitempty"`
+	// +optional
+	CoreCount int32 `json:"coreCount,omitempty"`
+	// +optional
+	ThreadCount int32 `json:"threadCount,omitempty"`
+	// +optional
+	FrequencyMHz int32 `json:"frequencyMHz,omitempty"`
+}
+
+// MemoryInfo describes the memory of a DPU.
+type MemoryInfo struct {
+	// TotalBytes is the total memory in bytes.
+	// +optional
+	TotalBytes int64 `json:"totalBytes,omitempty"`
+	// Type is the memory type, e.g. DDR5.
+	// +optional
+	Type string `json:"type,omitempty"`
+}
+
+// NetworkInterfaceInfo describes a network interface of a DPU.
+type NetworkInterfaceInfo struct {
+	Name string `json:"name"`
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
+	// +optional
+	SpeedMbps int32 `json:"speedMbps,omitempty"`
+	// +optional
+	LinkUp bool `json:"linkUp,omitempty"`
+}
+
+// StorageDeviceInfo describes a storage device of a DPU.
+type StorageDeviceInfo struct {
+	Name string `json:"name"`
+	// +optional
+	Model string `json:"model,omitempty"`
+	// CapacityBytes is the capacity in bytes.
+	// +optional
+	CapacityBytes int64 `json:"capacityBytes,omitempty"`
+	// Type is the device type, e.g. NVMe.
+	// +optional
+	Type string `json:"type,omitempty"`
+}
+
+// PhysicalFunctionStatus describes a host physical function of the DPU.
+type PhysicalFunctionStatus struct {
+	// Index is the PF index used for the bridge ports, the PCI function number of the PF.
+	Index int `json:"index"`
+	// Name is the network device name of the PF on the host.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// PCIAddress is the PCI address of the PF.
+	// +optional
+	PCIAddress string `json:"pciAddress,omitempty"`
+	// VirtualFunctions lists the VFs of the PF that have a bridge port.
+	// +optional
+	VirtualFunctions []VirtualFunctionStatus `json:"virtualFunctions,omitempty"`
+}
+
+// VirtualFunctionStatus describes a host virtual function with a bridge port.
+type VirtualFunctionStatus struct {
+	// ID is the VF index on its PF.
+	ID int `json:"id"`
+	// PCIAddress is the PCI address of the VF.
+	// +optional
+	PCIAddress string `json:"pciAddress,omitempty"`
+	// BridgePort is the name of the bridge port created for the VF.
+	BridgePort string `json:"bridgePort"`
+	// MACAddress is the MAC address of the bridge port.
+	// +optional
+	MACAddress string `json:"macAddress,omitempty"`
 }
 
 //+kubebuilder:object:root=true
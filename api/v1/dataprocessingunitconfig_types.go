@@ -18,6 +18,7 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -37,6 +38,40 @@ type DataProcessingUnitConfigSpec struct {
 	// If omitted, no VF configuration is applied.
 	// +optional
 	VfCount *int32 `json:"vfCount,omitempty"`
+
+	// Priority orders the DataProcessingUnitConfigs matching the same DPU.
+	// For each setting, the config with the highest priority that sets it
+	// wins. Configs with the same priority are ordered by namespace and name.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// RolloutStrategy rolls VF count changes out node by node. Without it,
+	// changes apply on all matched DPUs at once and nodes are not drained.
+	// +optional
+	RolloutStrategy *DpuConfigRolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+// DpuConfigRolloutStrategy sets how VF count changes roll out to the nodes of
+// the matched DPUs.
+type DpuConfigRolloutStrategy struct {
+	// MaxUnavailable is the number or percentage of nodes that apply a change
+	// at the same time. A percentage is rounded down, to at least one node.
+	// Nodes disrupted by a VF count change or a firmware update all count.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Drain cordons and drains a node before the change and uncordons it once
+	// the change is verified. Defaults to true.
+	// +optional
+	// +kubebuilder:default=true
+	Drain *bool `json:"drain,omitempty"`
+
+	// Paused stops the rollout from reaching more nodes. Nodes applying the
+	// change finish it.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 }
 
 // DataProcessingUnitConfigStatus defines the observed state of DataProcessingUnitConfig.
@@ -51,8 +86,40 @@ type DataProcessingUnitConfigStatus struct {
 	// MatchedDPUs lists DPU names that currently match the selector.
 	// +optional
 	MatchedDPUs []string `json:"matchedDPUs,omitempty"`
+
+	// DPUs reports the outcome of the config on each matched DPU.
+	// +optional
+	DPUs []DataProcessingUnitConfigDpuStatus `json:"dpus,omitempty"`
+
+	// Conditions holds the Ready condition of the config, True once the config
+	// is applied on all matched DPUs.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DataProcessingUnitConfigDpuStatus is the outcome of a DataProcessingUnitConfig on a DPU.
+type DataProcessingUnitConfigDpuStatus struct {
+	// Name is the name of the DPU.
+	Name string `json:"name"`
+	// State is the state of the config on the DPU.
+	State VfCountState `json:"state"`
+	// Message explains a state other than Applied.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
+// Condition types and reasons reported on DataProcessingUnitConfigStatus.
+const (
+	// DpuConfigConditionReady is True when the config is applied on all matched DPUs.
+	DpuConfigConditionReady = "Ready"
+
+	DpuConfigReasonApplied    = "Applied"
+	DpuConfigReasonPending    = "Pending"
+	DpuConfigReasonFailed     = "Failed"
+	DpuConfigReasonConflicted = "Conflicted"
+	DpuConfigReasonPaused     = "Paused"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=dpuconfig
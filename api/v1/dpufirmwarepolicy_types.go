@@ -0,0 +1,148 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DpuFirmwarePolicySpec defines the desired firmware of DPUs.
+type DpuFirmwarePolicySpec struct {
+	// DpuSelector selects the DataProcessingUnits the policy applies to.
+	// If empty, the policy selects all DPUs.
+	// +optional
+	DpuSelector *metav1.LabelSelector `json:"dpuSelector,omitempty"`
+
+	// Firmware lists the desired firmware per DPU model. Selected DPUs of a
+	// model that is not listed keep their firmware.
+	// +listType=map
+	// +listMapKey=model
+	// +kubebuilder:validation:MinItems=1
+	Firmware []DpuModelFirmware `json:"firmware"`
+
+	// RolloutStrategy sets how the update rolls out to the nodes of the
+	// selected DPUs. Without it, one node updates at a time and is drained.
+	// +optional
+	RolloutStrategy *DpuConfigRolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+// DpuModelFirmware is the desired firmware of a DPU model.
+type DpuModelFirmware struct {
+	// Model is the DPU model, as reported in status.inventory.model of the DataProcessingUnit.
+	Model string `json:"model"`
+
+	// Component is the firmware component to update.
+	// +optional
+	// +kubebuilder:default=nic
+	Component string `json:"component,omitempty"`
+
+	// Version is the desired firmware version.
+	// +kubebuilder:validation:MinLength=1
+	Version string `json:"version"`
+
+	// URL is where the vendor plugin downloads the firmware image from.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// Checksum is the hex encoded SHA-256 digest of the firmware image.
+	// +kubebuilder:validation:Pattern=`^[a-fA-F0-9]{64}$`
+	Checksum string `json:"checksum"`
+}
+
+// DpuFirmwarePolicyStatus defines the observed state of DpuFirmwarePolicy.
+type DpuFirmwarePolicyStatus struct {
+	// ObservedGeneration is the last observed generation of the policy.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DPUs reports the firmware of each selected DPU of a listed model, and
+	// of the selected DPUs whose model is not known yet.
+	// +optional
+	DPUs []DpuFirmwarePolicyDpuStatus `json:"dpus,omitempty"`
+
+	// Conditions holds the Ready condition of the policy, True once all the
+	// DPUs run their desired firmware.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DpuFirmwarePolicyDpuStatus is the firmware of a DPU selected by a DpuFirmwarePolicy.
+type DpuFirmwarePolicyDpuStatus struct {
+	// Name is the name of the DPU.
+	Name string `json:"name"`
+	// NodeName is the node of the DPU.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	// Model is the model of the DPU.
+	// +optional
+	Model string `json:"model,omitempty"`
+	// DesiredVersion is the firmware version the policy sets for the model.
+	// +optional
+	DesiredVersion string `json:"desiredVersion,omitempty"`
+	// RunningVersion is the firmware version the DPU runs.
+	// +optional
+	RunningVersion string `json:"runningVersion,omitempty"`
+	// State is the state of the update on the DPU.
+	State FirmwareState `json:"state"`
+	// Phase is the step of the update in progress on the node.
+	// +optional
+	Phase FirmwarePhase `json:"phase,omitempty"`
+	// Message explains a state other than Updated.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Condition types and reasons reported on DpuFirmwarePolicyStatus.
+const (
+	// DpuFirmwarePolicyConditionReady is True when all the DPUs run their desired firmware.
+	DpuFirmwarePolicyConditionReady = "Ready"
+
+	DpuFirmwarePolicyReasonUpdated    = "Updated"
+	DpuFirmwarePolicyReasonPending    = "Pending"
+	DpuFirmwarePolicyReasonFailed     = "Failed"
+	DpuFirmwarePolicyReasonConflicted = "Conflicted"
+	DpuFirmwarePolicyReasonPaused     = "Paused"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,shortName=dpufw
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DpuFirmwarePolicy is the Schema for the dpufirmwarepolicies API
+type DpuFirmwarePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DpuFirmwarePolicySpec   `json:"spec,omitempty"`
+	Status DpuFirmwarePolicyStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DpuFirmwarePolicyList contains a list of DpuFirmwarePolicy
+type DpuFirmwarePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DpuFirmwarePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DpuFirmwarePolicy{}, &DpuFirmwarePolicyList{})
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Keys of the IPsec key material in the Secret referenced by a DpuIPsecTunnel.
+const (
+	// IPsecSecretEncryptionKey holds the hex encoded encryption key.
+	IPsecSecretEncryptionKey = "encryptionKey"
+	// IPsecSecretAuthenticationKey holds the hex encoded authentication key.
+	// It may be empty for combined mode algorithms such as AES-GCM.
+	IPsecSecretAuthenticationKey = "authenticationKey"
+	// IPsecSecretSPI holds the Security Parameter Index in decimal.
+	IPsecSecretSPI = "spi"
+)
+
+// IPsecKeyGenerationAnnotation is the annotation of the key Secret counting
+// the rekeys of the controller. Peers compare it to the keyGeneration in the
+// status of each tunnel to tell which keys each end of the tunnel runs.
+const IPsecKeyGenerationAnnotation = "config.openshift.io/ipsec-key-generation"
+
+// DpuIPsecTunnelSpec defines the desired state of DpuIPsecTunnel
+type DpuIPsecTunnelSpec struct {
+	// DpuSelector selects the DataProcessingUnit the tunnel is offloaded to by
+	// its labels. The first matching DPU by name is used. If empty, any DPU
+	// with a security capable plugin can be used.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="dpuSelector is immutable"
+	// +optional
+	DpuSelector *metav1.LabelSelector `json:"dpuSelector,omitempty"`
+
+	// LocalAddress is the IP address of the local tunnel endpoint.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="localAddress is immutable"
+	// +kubebuilder:validation:XValidation:rule="isIP(self)",message="localAddress must be an IP address"
+	LocalAddress string `json:"localAddress"`
+
+	// RemoteAddress is the IP address of the remote tunnel endpoint.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="remoteAddress is immutable"
+	// +kubebuilder:validation:XValidation:rule="isIP(self)",message="remoteAddress must be an IP address"
+	RemoteAddress string `json:"remoteAddress"`
+
+	// LocalSubnet is the local subnet protected by the tunnel, in CIDR notation.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="localSubnet is immutable"
+	// +kubebuilder:validation:XValidation:rule="isCIDR(self)",message="localSubnet must be a CIDR"
+	LocalSubnet string `json:"localSubnet"`
+
+	// RemoteSubnet is the remote subnet protected by the tunnel, in CIDR notation.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="remoteSubnet is immutable"
+	// +kubebuilder:validation:XValidation:rule="isCIDR(self)",message="remoteSubnet must be a CIDR"
+	RemoteSubnet string `json:"remoteSubnet"`
+
+	// Protocol is the IPsec protocol of the tunnel.
+	// +kubebuilder:default=ESP
+	// +kubebuilder:validation:Enum=ESP;AH
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="protocol is immutable"
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+
+	// EncryptionAlgorithm is the encryption algorithm of the tunnel.
+	// +kubebuilder:default=aes-gcm-256
+	// +kubebuilder:validation:Enum=aes-gcm-128;aes-gcm-256;aes-cbc-128;aes-cbc-256
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="encryptionAlgorithm is immutable"
+	// +optional
+	EncryptionAlgorithm string `json:"encryptionAlgorithm,omitempty"`
+
+	// IntegrityAlgorithm is the integrity algorithm of the tunnel. It is not
+	// needed with the combined mode AES-GCM algorithms.
+	// +kubebuilder:validation:Enum=sha256;sha384;sha512
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="integrityAlgorithm is immutable"
+	// +optional
+	IntegrityAlgorithm string `json:"integrityAlgorithm,omitempty"`
+
+	// KeySecretName is the name of the Secret in the namespace of the tunnel
+	// holding the key material, under the encryptionKey, authenticationKey
+	// and spi keys. Updating the Secret rekeys the tunnel.
+	// +kubebuilder:validation:MinLength=1
+	KeySecretName string `json:"keySecretName"`
+
+	// RekeyInterval is the lifetime of the keys. When it expires the
+	// controller generates a new SPI and keys, rekeys the tunnel and stores
+	// them in the Secret. If omitted, the tunnel is only rekeyed when the
+	// Secret changes. Of the tunnels sharing a Secret, only the first by
+	// name with a rekey interval rekeys it; the others apply its keys.
+	// +optional
+	RekeyInterval *metav1.Duration `json:"rekeyInterval,omitempty"`
+}
+
+// Condition types and reasons reported on DpuIPsecTunnelStatus.
+const (
+	// IPsecTunnelConditionReady is True when the tunnel is offloaded to the DPU.
+	IPsecTunnelConditionReady = "Ready"
+
+	IPsecTunnelReasonEstablished      = "Established"
+	IPsecTunnelReasonNoMatchingDpu    = "NoMatchingDpu"
+	IPsecTunnelReasonNoSecurityPlugin = "NoSecurityPlugin"
+	IPsecTunnelReasonInvalidKeys      = "InvalidKeys"
+	IPsecTunnelReasonTunnelFailed     = "TunnelFailed"
+	IPsecTunnelReasonRekeyFailed      = "RekeyFailed"
+)
+
+// IPsecTunnelStats holds the traffic counters of an IPsec tunnel.
+type IPsecTunnelStats struct {
+	BytesEncrypted   int64 `json:"bytesEncrypted"`
+	BytesDecrypted   int64 `json:"bytesDecrypted"`
+	PacketsEncrypted int64 `json:"packetsEncrypted"`
+	PacketsDecrypted int64 `json:"packetsDecrypted"`
+	Errors           int64 `json:"errors"`
+}
+
+// DpuIPsecTunnelStatus defines the observed state of DpuIPsecTunnel
+type DpuIPsecTunnelStatus struct {
+	// ObservedGeneration is the last generation of the spec reflected in this status.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DpuName is the DataProcessingUnit the tunnel is offloaded to.
+	// +optional
+	DpuName string `json:"dpuName,omitempty"`
+
+	// TunnelID is the ID of the tunnel in the vendor plugin.
+	// +optional
+	TunnelID string `json:"tunnelID,omitempty"`
+
+	// SPI is the Security Parameter Index currently in use.
+	// +optional
+	SPI int64 `json:"spi,omitempty"`
+
+	// KeyGeneration is the key generation annotation of the Secret the keys
+	// in use were read from.
+	// +optional
+	KeyGeneration int64 `json:"keyGeneration,omitempty"`
+
+	// KeySecretVersion is the resource version of the Secret the keys in use were read from.
+	// +optional
+	KeySecretVersion string `json:"keySecretVersion,omitempty"`
+
+	// LastRekeyTime is when the keys in use were applied to the tunnel.
+	// +optional
+	LastRekeyTime *metav1.Time `json:"lastRekeyTime,omitempty"`
+
+	// Stats mirrors the traffic counters reported by the vendor plugin.
+	// +optional
+	Stats *IPsecTunnelStats `json:"stats,omitempty"`
+
+	// Conditions holds the Ready condition of the tunnel.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=ipsectun
+//+kubebuilder:printcolumn:name="DPU",type="string",JSONPath=".status.dpuName"
+//+kubebuilder:printcolumn:name="Remote",type="string",JSONPath=".spec.remoteAddress"
+//+kubebuilder:printcolumn:name="SPI",type="integer",JSONPath=".status.spi"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DpuIPsecTunnel is the Schema for the dpuipsectunnels API. It offloads an
+// IPsec tunnel to a DPU, with keys taken from a Secret.
+type DpuIPsecTunnel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DpuIPsecTunnelSpec   `json:"spec,omitempty"`
+	Status DpuIPsecTunnelStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DpuIPsecTunnelList contains a list of DpuIPsecTunnel
+type DpuIPsecTunnelList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DpuIPsecTunnel `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DpuIPsecTunnel{}, &DpuIPsecTunnelList{})
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DpuNvmeVolumeSpec defines the desired state of DpuNvmeVolume
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type DpuNvmeVolumeSpec struct {
+	// DpuSelector selects the DataProcessingUnit the volume is exposed from by
+	// its labels. The first matching DPU by name is used. If empty, any DPU
+	// with a storage capable plugin can be used.
+	// +optional
+	DpuSelector *metav1.LabelSelector `json:"dpuSelector,omitempty"`
+
+	// SubsystemNQN is the NVMe Qualified Name of the subsystem holding the volume.
+	// +kubebuilder:validation:Pattern=`^nqn\.`
+	SubsystemNQN string `json:"subsystemNQN"`
+
+	// Size is the size of the NVMe namespace. It must be a multiple of the block size.
+	Size resource.Quantity `json:"size"`
+
+	// BlockSize is the logical block size of the NVMe namespace in bytes.
+	// +kubebuilder:default=512
+	// +kubebuilder:validation:Enum=512;4096
+	// +optional
+	BlockSize int32 `json:"blockSize,omitempty"`
+
+	// NSID is the namespace ID the host sees the volume as.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NSID int32 `json:"nsid,omitempty"`
+
+	// PCIeFunction is the host PCIe function the NVMe controller is emulated
+	// on, in the notation of the vendor plugin, such as pf0vf1 on BlueField.
+	PCIeFunction string `json:"pcieFunction"`
+
+	// Target is the namespace of the remote NVMe-oF subsystem backing the volume.
+	Target NvmeOFTarget `json:"target"`
+}
+
+// NvmeOFTarget identifies a namespace of a remote NVMe-oF subsystem the DPU
+// connects to.
+type NvmeOFTarget struct {
+	// Transport is the fabrics transport the DPU connects to the target over.
+	// +kubebuilder:default=tcp
+	// +kubebuilder:validation:Enum=tcp;rdma
+	// +optional
+	Transport string `json:"transport,omitempty"`
+
+	// Address is the IPv4 or IPv6 address of the target.
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Port is the transport service ID of the target.
+	// +kubebuilder:default=4420
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// NQN is the NVMe Qualified Name of the target subsystem.
+	// +kubebuilder:validation:Pattern=`^nqn\.`
+	NQN string `json:"nqn"`
+
+	// HostNQN is the NVMe Qualified Name the DPU connects to the target as.
+	// +kubebuilder:validation:Pattern=`^nqn\.`
+	// +optional
+	HostNQN string `json:"hostNQN,omitempty"`
+
+	// NSID is the ID of the namespace in the target subsystem.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NSID int32 `json:"nsid,omitempty"`
+}
+
+// Condition types and reasons reported on DpuNvmeVolumeStatus.
+const (
+	// NvmeVolumeConditionReady is True when the volume is exposed to the host.
+	NvmeVolumeConditionReady = "Ready"
+
+	NvmeVolumeReasonProvisioned        = "Provisioned"
+	NvmeVolumeReasonNoMatchingDpu      = "NoMatchingDpu"
+	NvmeVolumeReasonNoStoragePlugin    = "NoStoragePlugin"
+	NvmeVolumeReasonProvisioningFailed = "ProvisioningFailed"
+	// NvmeVolumeReasonNotSupported means the storage plugin of the DPU cannot
+	// provision the volume. Retrying does not help.
+	NvmeVolumeReasonNotSupported = "NotSupported"
+)
+
+// DpuNvmeVolumeStatus defines the observed state of DpuNvmeVolume
+type DpuNvmeVolumeStatus struct {
+	// ObservedGeneration is the last generation of the spec reflected in this status.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DpuName is the DataProcessingUnit the volume is exposed from.
+	// +optional
+	DpuName string `json:"dpuName,omitempty"`
+
+	// SubsystemID is the ID of the NVMe subsystem in the vendor plugin.
+	// +optional
+	SubsystemID string `json:"subsystemID,omitempty"`
+
+	// ControllerID is the ID of the NVMe controller in the vendor plugin.
+	// +optional
+	ControllerID string `json:"controllerID,omitempty"`
+
+	// NamespaceID is the ID of the NVMe namespace in the vendor plugin.
+	// +optional
+	NamespaceID string `json:"namespaceID,omitempty"`
+
+	// PCIeFunction is the host PCIe function the volume is exposed on.
+	// +optional
+	PCIeFunction string `json:"pcieFunction,omitempty"`
+
+	// Conditions holds the Ready condition of the volume.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=nvmevol
+//+kubebuilder:printcolumn:name="DPU",type="string",JSONPath=".status.dpuName"
+//+kubebuilder:printcolumn:name="Size",type="string",JSONPath=".spec.size"
+//+kubebuilder:printcolumn:name="PCIe Function",type="string",JSONPath=".status.pcieFunction"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DpuNvmeVolume is the Schema for the dpunvmevolumes API. It exposes an NVMe
+// namespace to the host through a controller emulated by the DPU.
+type DpuNvmeVolume struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DpuNvmeVolumeSpec   `json:"spec,omitempty"`
+	Status DpuNvmeVolumeStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DpuNvmeVolumeList contains a list of DpuNvmeVolume
+type DpuNvmeVolumeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DpuNvmeVolume `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DpuNvmeVolume{}, &DpuNvmeVolumeList{})
+}
@@ -18,6 +18,7 @@ package v1
 
 import (
 	"os"
+	"time"
 
 	"github.com/openshift/dpu-operator/pkgs/vars"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -35,6 +36,90 @@ type DpuOperatorConfigSpec struct {
 	// ResourceName overrides the DPU device plugin resource name (default "openshift.io/dpu").
 	// +optional
 	ResourceName string `json:"resourceName,omitempty"`
+
+	// VlanPolicy defines the VLAN of host-side bridge ports whose
+	// NetworkAttachmentDefinition does not configure one.
+	// +optional
+	VlanPolicy *VlanPolicy `json:"vlanPolicy,omitempty"`
+
+	// ResetPolicy defines what the daemon does while a DPU resets.
+	// +optional
+	ResetPolicy *DpuResetPolicy `json:"resetPolicy,omitempty"`
+
+	// DpuRemovalGracePeriod is how long a DPU is not detected on its node
+	// before the daemon deletes its DataProcessingUnit (default 10m). The
+	// DataProcessingUnits are kept while the daemon restarts.
+	// +optional
+	DpuRemovalGracePeriod *metav1.Duration `json:"dpuRemovalGracePeriod,omitempty"`
+}
+
+// DefaultDpuRemovalGracePeriod is how long a DPU is not detected before the
+// daemon deletes its DataProcessingUnit when the DpuOperatorConfig does not
+// set a grace period.
+const DefaultDpuRemovalGracePeriod = 10 * time.Minute
+
+// DefaultDpuResetTimeout is how long the daemon waits for a DPU to come back
+// from a reset when the ResetPolicy does not set a timeout.
+const DefaultDpuResetTimeout = 15 * time.Minute
+
+// DpuResetPolicy defines how the daemon handles a DPU that resets, whether
+// the reset is requested by a DpuReboot or noticed through a lost heartbeat
+// or a DPU that disappeared from the PCI bus. The devices of the DPU are
+// reported unhealthy to the kubelet in any case.
+type DpuResetPolicy struct {
+	// CordonNode is whether the node is cordoned while a DPU resets without
+	// a DpuReboot. A DpuReboot drains the node according to its own spec.
+	// +optional
+	CordonNode bool `json:"cordonNode,omitempty"`
+
+	// Timeout is how long the daemon waits for a DPU to come back from a
+	// reset before the reset is reported as failed (default 15m).
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// VlanDefaultMode selects how the default VLAN ID of a host-side bridge port is chosen.
+// +kubebuilder:validation:Enum=PerVF;Fixed
+type VlanDefaultMode string
+
+const (
+	// VlanDefaultPerVF gives every VF its own VLAN, the VF index plus VlanPerVFOffset.
+	VlanDefaultPerVF VlanDefaultMode = "PerVF"
+	// VlanDefaultFixed puts every VF on DefaultVlan.
+	VlanDefaultFixed VlanDefaultMode = "Fixed"
+
+	// VlanPerVFOffset skips VLAN 0 (untagged) and reserves VLAN 1 in PerVF mode.
+	VlanPerVFOffset = 2
+
+	VlanProto8021Q  = "802.1q"
+	VlanProto8021AD = "802.1ad"
+)
+
+// VlanPolicy defines the VLAN ID, QoS and protocol used for host-side bridge
+// ports when the network configuration does not set them.
+type VlanPolicy struct {
+	// Mode selects how the default VLAN ID is chosen (default PerVF).
+	// +optional
+	Mode VlanDefaultMode `json:"mode,omitempty"`
+
+	// DefaultVlan is the VLAN ID used in Fixed mode.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	// +optional
+	DefaultVlan int `json:"defaultVlan,omitempty"`
+
+	// DefaultQoS is the VLAN QoS (PCP) used when the network does not set one.
+	// Bridge ports cannot apply a QoS yet, so it must be 0.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=0
+	// +optional
+	DefaultQoS int `json:"defaultQoS,omitempty"`
+
+	// DefaultProto is the VLAN protocol used when the network does not set one (default 802.1q).
+	// Bridge ports cannot apply 802.1ad yet, so it must be 802.1q.
+	// +kubebuilder:validation:Enum="802.1q"
+	// +optional
+	DefaultProto string `json:"defaultProto,omitempty"`
 }
 
 // DpuOperatorConfigStatus defines the observed state of DpuOperatorConfig
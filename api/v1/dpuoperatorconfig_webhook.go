@@ -53,9 +53,42 @@ func (r *DpuOperatorConfig) validateDpuOperatorConfig() (admission.Warnings, err
 		return nil, fmt.Errorf("DpuOperatorConfig must have standard name \"%s\"", vars.DpuOperatorConfigName)
 	}
 
+	if r.Spec.VlanPolicy != nil {
+		if err := validateVlanPolicy(r.Spec.VlanPolicy); err != nil {
+			return nil, fmt.Errorf("invalid vlanPolicy: %v", err)
+		}
+	}
+
 	return nil, nil
 }
 
+func validateVlanPolicy(p *VlanPolicy) error {
+	switch p.Mode {
+	case "", VlanDefaultPerVF:
+		if p.DefaultVlan != 0 {
+			return fmt.Errorf("defaultVlan can only be set in %s mode", VlanDefaultFixed)
+		}
+	case VlanDefaultFixed:
+		if p.DefaultVlan < 1 || p.DefaultVlan > 4094 {
+			return fmt.Errorf("defaultVlan %d invalid: value must be in the range 1-4094", p.DefaultVlan)
+		}
+	default:
+		return fmt.Errorf("mode %q invalid: value must be %s or %s", p.Mode, VlanDefaultPerVF, VlanDefaultFixed)
+	}
+
+	// Bridge ports cannot apply a QoS or 802.1ad yet.
+	if p.DefaultQoS != 0 {
+		return fmt.Errorf("defaultQoS %d not supported: bridge ports only support QoS 0", p.DefaultQoS)
+	}
+
+	switch p.DefaultProto {
+	case "", VlanProto8021Q:
+	default:
+		return fmt.Errorf("defaultProto %q not supported: bridge ports only support %s", p.DefaultProto, VlanProto8021Q)
+	}
+	return nil
+}
+
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *DpuOperatorConfig) ValidateCreate(tx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	r = obj.(*DpuOperatorConfig)
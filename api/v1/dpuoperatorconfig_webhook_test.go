@@ -49,6 +49,38 @@ var _ = Describe("Webhook Unit Test", func() {
 			_, err = config.validateDpuOperatorConfig()
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("check validate VlanPolicy", func() {
+			config := &DpuOperatorConfig{}
+			config.SetName(vars.DpuOperatorConfigName)
+
+			valid := []VlanPolicy{
+				{},
+				{Mode: VlanDefaultPerVF, DefaultQoS: 0, DefaultProto: VlanProto8021Q},
+				{Mode: VlanDefaultFixed, DefaultVlan: 4094},
+			}
+			for _, policy := range valid {
+				config.Spec.VlanPolicy = &policy
+				_, err := config.validateDpuOperatorConfig()
+				Expect(err).NotTo(HaveOccurred(), "policy %+v", policy)
+			}
+
+			invalid := []VlanPolicy{
+				{Mode: VlanDefaultFixed},
+				{Mode: VlanDefaultFixed, DefaultVlan: 4095},
+				{Mode: VlanDefaultPerVF, DefaultVlan: 10},
+				{Mode: "Random"},
+				{DefaultQoS: 7},
+				{DefaultQoS: -1},
+				{DefaultProto: VlanProto8021AD},
+				{DefaultProto: "802.1x"},
+			}
+			for _, policy := range invalid {
+				config.Spec.VlanPolicy = &policy
+				_, err := config.validateDpuOperatorConfig()
+				Expect(err).To(HaveOccurred(), "policy %+v", policy)
+			}
+		})
 	})
 })
 
@@ -0,0 +1,112 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DpuRebootSpec defines the DPU to reboot.
+type DpuRebootSpec struct {
+	// DpuName is the name of the host-side DataProcessingUnit to reboot.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="dpuName is immutable"
+	DpuName string `json:"dpuName"`
+
+	// Drain is whether the daemon drains the node before the reboot. The
+	// node is uncordoned once the DPU is back.
+	// +optional
+	// +kubebuilder:default=true
+	Drain *bool `json:"drain,omitempty"`
+}
+
+// DpuRebootPhase is a step of a DPU reboot.
+// +kubebuilder:validation:Enum=Pending;Draining;Rebooting;WaitingForDpu;Reinitializing;Completed;Failed
+type DpuRebootPhase string
+
+const (
+	// DpuRebootPhasePending means the reboot has not started yet.
+	DpuRebootPhasePending DpuRebootPhase = "Pending"
+	// DpuRebootPhaseDraining means the node is cordoned and drained.
+	DpuRebootPhaseDraining DpuRebootPhase = "Draining"
+	// DpuRebootPhaseRebooting means the vendor plugin reboots the DPU.
+	DpuRebootPhaseRebooting DpuRebootPhase = "Rebooting"
+	// DpuRebootPhaseWaitingForDpu means the daemon waits for the DPU to be
+	// detected again.
+	DpuRebootPhaseWaitingForDpu DpuRebootPhase = "WaitingForDpu"
+	// DpuRebootPhaseReinitializing means the daemon initializes the vendor
+	// specific plugin again and waits for the heartbeat.
+	DpuRebootPhaseReinitializing DpuRebootPhase = "Reinitializing"
+	// DpuRebootPhaseCompleted means the DPU is back and its devices are healthy.
+	DpuRebootPhaseCompleted DpuRebootPhase = "Completed"
+	// DpuRebootPhaseFailed means the DPU did not come back in time, or the
+	// reboot could not be started.
+	DpuRebootPhaseFailed DpuRebootPhase = "Failed"
+)
+
+// DpuRebootStatus defines the observed state of DpuReboot.
+type DpuRebootStatus struct {
+	// Phase is the step of the reboot.
+	// +optional
+	Phase DpuRebootPhase `json:"phase,omitempty"`
+
+	// NodeName is the node of the DPU.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is when the daemon started the reboot.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the reboot completed or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="DPU",type="string",JSONPath=".spec.dpuName"
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DpuReboot is the Schema for the dpureboots API. It requests a reboot of a
+// DPU, carried out by the daemon of the node of the DPU.
+type DpuReboot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DpuRebootSpec   `json:"spec,omitempty"`
+	Status DpuRebootStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DpuRebootList contains a list of DpuReboot
+type DpuRebootList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DpuReboot `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DpuReboot{}, &DpuRebootList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,6 +31,9 @@ type ServiceFunctionChainSpec struct {
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
 
+	// NetworkFunctions is the ordered list of network functions in the chain.
+	// The order is the traffic order: the egress of each network function is
+	// wired to the ingress of the next one.
 	NetworkFunctions []NetworkFunction `json:"networkFunctions"`
 }
 
@@ -59,9 +63,86 @@ type DpuResourceRequirements struct {
 	Limits int32 `json:"limits,omitempty"`
 }
 
+// Condition types reported on ServiceFunctionChainStatus.
+const (
+	// SfcConditionReady is True when every network function pod is running and wired.
+	SfcConditionReady = "Ready"
+	// SfcConditionProgressing is True while network function pods are being created or wired.
+	SfcConditionProgressing = "Progressing"
+	// SfcConditionDegraded is True when a network function pod or its wiring has failed.
+	SfcConditionDegraded = "Degraded"
+)
+
+// NetworkFunctionWiringResult is the outcome of wiring a network function on the DPU.
+type NetworkFunctionWiringResult string
+
+const (
+	// WiringPending means the network function interfaces are not all attached yet.
+	WiringPending NetworkFunctionWiringResult = "Pending"
+	// WiringSucceeded means the VSP accepted the CreateNetworkFunction call.
+	WiringSucceeded NetworkFunctionWiringResult = "Succeeded"
+	// WiringFailed means the VSP rejected the CreateNetworkFunction call.
+	WiringFailed NetworkFunctionWiringResult = "Failed"
+)
+
+// NetworkFunctionStatus defines the observed state of a single network function.
+type NetworkFunctionStatus struct {
+	// Name is the name of the network function in the spec.
+	Name string `json:"name"`
+
+	// PodName is the name of the pod running the network function.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// NodeName is the node the network function pod was scheduled to.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// Phase is the phase of the network function pod.
+	// +optional
+	Phase corev1.PodPhase `json:"phase,omitempty"`
+
+	// BridgePorts lists the DPU ports (by MAC address) attached to the network function.
+	// +optional
+	BridgePorts []string `json:"bridgePorts,omitempty"`
+
+	// Wiring is the result of wiring this network function into the chain through the VSP.
+	// It is only reported by the daemon on the DPU side.
+	// +optional
+	Wiring NetworkFunctionWiringResult `json:"wiring,omitempty"`
+
+	// WiringMessage gives details about the wiring result, such as the VSP error.
+	// +optional
+	WiringMessage string `json:"wiringMessage,omitempty"`
+
+	// Message gives details about the current pod state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ServiceFunctionChainHop is a VSP network function wiring two DPU ports of the chain.
+type ServiceFunctionChainHop struct {
+	// Input is the MAC address of the hop input port.
+	Input string `json:"input"`
+
+	// Output is the MAC address of the hop output port.
+	Output string `json:"output"`
+
+	// InputFunction is the network function owning the input port.
+	// +optional
+	InputFunction string `json:"inputFunction,omitempty"`
+
+	// OutputFunction is the network function owning the output port.
+	// +optional
+	OutputFunction string `json:"outputFunction,omitempty"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:shortName=sfc
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].reason"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ServiceFunctionChain is the Schema for the servicefunctionchains API
 type ServiceFunctionChain struct {
@@ -72,7 +153,23 @@ type ServiceFunctionChain struct {
 	Status ServiceFunctionChainStatus `json:"status,omitempty"`
 }
 
+// ServiceFunctionChainStatus defines the observed state of ServiceFunctionChain
 type ServiceFunctionChainStatus struct {
+	// ObservedGeneration is the last generation of the spec reflected in this status.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// NetworkFunctions reports the state of each network function, in spec order.
+	// +optional
+	NetworkFunctions []NetworkFunctionStatus `json:"networkFunctions,omitempty"`
+
+	// Hops lists the VSP network functions currently wiring the chain on the DPU.
+	// +optional
+	Hops []ServiceFunctionChainHop `json:"hops,omitempty"`
+
+	// Conditions holds the aggregate Ready, Progressing and Degraded conditions.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
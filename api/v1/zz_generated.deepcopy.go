@@ -23,14 +23,45 @@ package v1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CPUInfo) DeepCopyInto(out *CPUInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPUInfo.
+func (in *CPUInfo) DeepCopy() *CPUInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(CPUInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChassisInfo) DeepCopyInto(out *ChassisInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChassisInfo.
+func (in *ChassisInfo) DeepCopy() *ChassisInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(ChassisInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataProcessingUnit) DeepCopyInto(out *DataProcessingUnit) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -79,6 +110,21 @@ func (in *DataProcessingUnitConfig) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataProcessingUnitConfigDpuStatus) DeepCopyInto(out *DataProcessingUnitConfigDpuStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProcessingUnitConfigDpuStatus.
+func (in *DataProcessingUnitConfigDpuStatus) DeepCopy() *DataProcessingUnitConfigDpuStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataProcessingUnitConfigDpuStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataProcessingUnitConfigList) DeepCopyInto(out *DataProcessingUnitConfigList) {
 	*out = *in
@@ -124,6 +170,11 @@ func (in *DataProcessingUnitConfigSpec) DeepCopyInto(out *DataProcessingUnitConf
 		*out = new(int32)
 		**out = **in
 	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(DpuConfigRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProcessingUnitConfigSpec.
@@ -144,6 +195,18 @@ func (in *DataProcessingUnitConfigStatus) DeepCopyInto(out *DataProcessingUnitCo
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.DPUs != nil {
+		in, out := &in.DPUs, &out.DPUs
+		*out = make([]DataProcessingUnitConfigDpuStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProcessingUnitConfigStatus.
@@ -191,6 +254,16 @@ func (in *DataProcessingUnitList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataProcessingUnitSpec) DeepCopyInto(out *DataProcessingUnitSpec) {
 	*out = *in
+	if in.EffectiveConfig != nil {
+		in, out := &in.EffectiveConfig, &out.EffectiveConfig
+		*out = new(DpuEffectiveConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(DpuFirmwareTarget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProcessingUnitSpec.
@@ -213,6 +286,38 @@ func (in *DataProcessingUnitStatus) DeepCopyInto(out *DataProcessingUnitStatus)
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PhysicalFunctions != nil {
+		in, out := &in.PhysicalFunctions, &out.PhysicalFunctions
+		*out = make([]PhysicalFunctionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(DpuInventory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VfCount != nil {
+		in, out := &in.VfCount, &out.VfCount
+		*out = new(VfCountStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(DpuFirmwareStatus)
+		**out = **in
+	}
+	if in.Reboot != nil {
+		in, out := &in.Reboot, &out.Reboot
+		*out = new(DpuRebootState)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Manager != nil {
+		in, out := &in.Manager, &out.Manager
+		*out = new(DpuManagerStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProcessingUnitStatus.
@@ -226,26 +331,76 @@ func (in *DataProcessingUnitStatus) DeepCopy() *DataProcessingUnitStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DpuOperatorConfig) DeepCopyInto(out *DpuOperatorConfig) {
+func (in *DpuConfigRolloutStrategy) DeepCopyInto(out *DpuConfigRolloutStrategy) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuConfigRolloutStrategy.
+func (in *DpuConfigRolloutStrategy) DeepCopy() *DpuConfigRolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuConfigRolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuEffectiveConfig) DeepCopyInto(out *DpuEffectiveConfig) {
+	*out = *in
+	if in.VfCount != nil {
+		in, out := &in.VfCount, &out.VfCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuEffectiveConfig.
+func (in *DpuEffectiveConfig) DeepCopy() *DpuEffectiveConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuEffectiveConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuFirmwarePolicy) DeepCopyInto(out *DpuFirmwarePolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfig.
-func (in *DpuOperatorConfig) DeepCopy() *DpuOperatorConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwarePolicy.
+func (in *DpuFirmwarePolicy) DeepCopy() *DpuFirmwarePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(DpuOperatorConfig)
+	out := new(DpuFirmwarePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DpuOperatorConfig) DeepCopyObject() runtime.Object {
+func (in *DpuFirmwarePolicy) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -253,31 +408,46 @@ func (in *DpuOperatorConfig) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DpuOperatorConfigList) DeepCopyInto(out *DpuOperatorConfigList) {
+func (in *DpuFirmwarePolicyDpuStatus) DeepCopyInto(out *DpuFirmwarePolicyDpuStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwarePolicyDpuStatus.
+func (in *DpuFirmwarePolicyDpuStatus) DeepCopy() *DpuFirmwarePolicyDpuStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuFirmwarePolicyDpuStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuFirmwarePolicyList) DeepCopyInto(out *DpuFirmwarePolicyList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]DpuOperatorConfig, len(*in))
+		*out = make([]DpuFirmwarePolicy, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigList.
-func (in *DpuOperatorConfigList) DeepCopy() *DpuOperatorConfigList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwarePolicyList.
+func (in *DpuFirmwarePolicyList) DeepCopy() *DpuFirmwarePolicyList {
 	if in == nil {
 		return nil
 	}
-	out := new(DpuOperatorConfigList)
+	out := new(DpuFirmwarePolicyList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DpuOperatorConfigList) DeepCopyObject() runtime.Object {
+func (in *DpuFirmwarePolicyList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -285,23 +455,43 @@ func (in *DpuOperatorConfigList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DpuOperatorConfigSpec) DeepCopyInto(out *DpuOperatorConfigSpec) {
+func (in *DpuFirmwarePolicySpec) DeepCopyInto(out *DpuFirmwarePolicySpec) {
 	*out = *in
+	if in.DpuSelector != nil {
+		in, out := &in.DpuSelector, &out.DpuSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = make([]DpuModelFirmware, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(DpuConfigRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigSpec.
-func (in *DpuOperatorConfigSpec) DeepCopy() *DpuOperatorConfigSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwarePolicySpec.
+func (in *DpuFirmwarePolicySpec) DeepCopy() *DpuFirmwarePolicySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(DpuOperatorConfigSpec)
+	out := new(DpuFirmwarePolicySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DpuOperatorConfigStatus) DeepCopyInto(out *DpuOperatorConfigStatus) {
+func (in *DpuFirmwarePolicyStatus) DeepCopyInto(out *DpuFirmwarePolicyStatus) {
 	*out = *in
+	if in.DPUs != nil {
+		in, out := &in.DPUs, &out.DPUs
+		*out = make([]DpuFirmwarePolicyDpuStatus, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]metav1.Condition, len(*in))
@@ -311,77 +501,67 @@ func (in *DpuOperatorConfigStatus) DeepCopyInto(out *DpuOperatorConfigStatus) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigStatus.
-func (in *DpuOperatorConfigStatus) DeepCopy() *DpuOperatorConfigStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwarePolicyStatus.
+func (in *DpuFirmwarePolicyStatus) DeepCopy() *DpuFirmwarePolicyStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DpuOperatorConfigStatus)
+	out := new(DpuFirmwarePolicyStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DpuResourceRequirements) DeepCopyInto(out *DpuResourceRequirements) {
+func (in *DpuFirmwareStatus) DeepCopyInto(out *DpuFirmwareStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuResourceRequirements.
-func (in *DpuResourceRequirements) DeepCopy() *DpuResourceRequirements {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwareStatus.
+func (in *DpuFirmwareStatus) DeepCopy() *DpuFirmwareStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(DpuResourceRequirements)
+	out := new(DpuFirmwareStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkFunction) DeepCopyInto(out *NetworkFunction) {
+func (in *DpuFirmwareTarget) DeepCopyInto(out *DpuFirmwareTarget) {
 	*out = *in
-	if in.Networks != nil {
-		in, out := &in.Networks, &out.Networks
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.DpuResources != nil {
-		in, out := &in.DpuResources, &out.DpuResources
-		*out = new(DpuResourceRequirements)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkFunction.
-func (in *NetworkFunction) DeepCopy() *NetworkFunction {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuFirmwareTarget.
+func (in *DpuFirmwareTarget) DeepCopy() *DpuFirmwareTarget {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkFunction)
+	out := new(DpuFirmwareTarget)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceFunctionChain) DeepCopyInto(out *ServiceFunctionChain) {
+func (in *DpuIPsecTunnel) DeepCopyInto(out *DpuIPsecTunnel) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChain.
-func (in *ServiceFunctionChain) DeepCopy() *ServiceFunctionChain {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuIPsecTunnel.
+func (in *DpuIPsecTunnel) DeepCopy() *DpuIPsecTunnel {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceFunctionChain)
+	out := new(DpuIPsecTunnel)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ServiceFunctionChain) DeepCopyObject() runtime.Object {
+func (in *DpuIPsecTunnel) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -389,31 +569,31 @@ func (in *ServiceFunctionChain) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceFunctionChainList) DeepCopyInto(out *ServiceFunctionChainList) {
+func (in *DpuIPsecTunnelList) DeepCopyInto(out *DpuIPsecTunnelList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ServiceFunctionChain, len(*in))
+		*out = make([]DpuIPsecTunnel, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainList.
-func (in *ServiceFunctionChainList) DeepCopy() *ServiceFunctionChainList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuIPsecTunnelList.
+func (in *DpuIPsecTunnelList) DeepCopy() *DpuIPsecTunnelList {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceFunctionChainList)
+	out := new(DpuIPsecTunnelList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ServiceFunctionChainList) DeepCopyObject() runtime.Object {
+func (in *DpuIPsecTunnelList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -421,43 +601,834 @@ func (in *ServiceFunctionChainList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceFunctionChainSpec) DeepCopyInto(out *ServiceFunctionChainSpec) {
+func (in *DpuIPsecTunnelSpec) DeepCopyInto(out *DpuIPsecTunnelSpec) {
 	*out = *in
-	if in.NodeSelector != nil {
-		in, out := &in.NodeSelector, &out.NodeSelector
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	if in.DpuSelector != nil {
+		in, out := &in.DpuSelector, &out.DpuSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RekeyInterval != nil {
+		in, out := &in.RekeyInterval, &out.RekeyInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuIPsecTunnelSpec.
+func (in *DpuIPsecTunnelSpec) DeepCopy() *DpuIPsecTunnelSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuIPsecTunnelSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuIPsecTunnelStatus) DeepCopyInto(out *DpuIPsecTunnelStatus) {
+	*out = *in
+	if in.LastRekeyTime != nil {
+		in, out := &in.LastRekeyTime, &out.LastRekeyTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Stats != nil {
+		in, out := &in.Stats, &out.Stats
+		*out = new(IPsecTunnelStats)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.NetworkFunctions != nil {
-		in, out := &in.NetworkFunctions, &out.NetworkFunctions
-		*out = make([]NetworkFunction, len(*in))
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuIPsecTunnelStatus.
+func (in *DpuIPsecTunnelStatus) DeepCopy() *DpuIPsecTunnelStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuIPsecTunnelStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuInventory) DeepCopyInto(out *DpuInventory) {
+	*out = *in
+	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	if in.Chassis != nil {
+		in, out := &in.Chassis, &out.Chassis
+		*out = new(ChassisInfo)
+		**out = **in
+	}
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(CPUInfo)
+		**out = **in
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(MemoryInfo)
+		**out = **in
+	}
+	if in.NetworkInterfaces != nil {
+		in, out := &in.NetworkInterfaces, &out.NetworkInterfaces
+		*out = make([]NetworkInterfaceInfo, len(*in))
+		copy(*out, *in)
+	}
+	if in.StorageDevices != nil {
+		in, out := &in.StorageDevices, &out.StorageDevices
+		*out = make([]StorageDeviceInfo, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainSpec.
-func (in *ServiceFunctionChainSpec) DeepCopy() *ServiceFunctionChainSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuInventory.
+func (in *DpuInventory) DeepCopy() *DpuInventory {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceFunctionChainSpec)
+	out := new(DpuInventory)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceFunctionChainStatus) DeepCopyInto(out *ServiceFunctionChainStatus) {
+func (in *DpuManagerStatus) DeepCopyInto(out *DpuManagerStatus) {
 	*out = *in
+	if in.LastFailureTime != nil {
+		in, out := &in.LastFailureTime, &out.LastFailureTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainStatus.
-func (in *ServiceFunctionChainStatus) DeepCopy() *ServiceFunctionChainStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuManagerStatus.
+func (in *DpuManagerStatus) DeepCopy() *DpuManagerStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceFunctionChainStatus)
+	out := new(DpuManagerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuModelFirmware) DeepCopyInto(out *DpuModelFirmware) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuModelFirmware.
+func (in *DpuModelFirmware) DeepCopy() *DpuModelFirmware {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuModelFirmware)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNvmeVolume) DeepCopyInto(out *DpuNvmeVolume) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNvmeVolume.
+func (in *DpuNvmeVolume) DeepCopy() *DpuNvmeVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNvmeVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuNvmeVolume) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNvmeVolumeList) DeepCopyInto(out *DpuNvmeVolumeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DpuNvmeVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNvmeVolumeList.
+func (in *DpuNvmeVolumeList) DeepCopy() *DpuNvmeVolumeList {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNvmeVolumeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuNvmeVolumeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNvmeVolumeSpec) DeepCopyInto(out *DpuNvmeVolumeSpec) {
+	*out = *in
+	if in.DpuSelector != nil {
+		in, out := &in.DpuSelector, &out.DpuSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Size = in.Size.DeepCopy()
+	out.Target = in.Target
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNvmeVolumeSpec.
+func (in *DpuNvmeVolumeSpec) DeepCopy() *DpuNvmeVolumeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNvmeVolumeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuNvmeVolumeStatus) DeepCopyInto(out *DpuNvmeVolumeStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuNvmeVolumeStatus.
+func (in *DpuNvmeVolumeStatus) DeepCopy() *DpuNvmeVolumeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuNvmeVolumeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuOperatorConfig) DeepCopyInto(out *DpuOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfig.
+func (in *DpuOperatorConfig) DeepCopy() *DpuOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuOperatorConfigList) DeepCopyInto(out *DpuOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DpuOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigList.
+func (in *DpuOperatorConfigList) DeepCopy() *DpuOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuOperatorConfigSpec) DeepCopyInto(out *DpuOperatorConfigSpec) {
+	*out = *in
+	if in.VlanPolicy != nil {
+		in, out := &in.VlanPolicy, &out.VlanPolicy
+		*out = new(VlanPolicy)
+		**out = **in
+	}
+	if in.ResetPolicy != nil {
+		in, out := &in.ResetPolicy, &out.ResetPolicy
+		*out = new(DpuResetPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DpuRemovalGracePeriod != nil {
+		in, out := &in.DpuRemovalGracePeriod, &out.DpuRemovalGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigSpec.
+func (in *DpuOperatorConfigSpec) DeepCopy() *DpuOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuOperatorConfigStatus) DeepCopyInto(out *DpuOperatorConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuOperatorConfigStatus.
+func (in *DpuOperatorConfigStatus) DeepCopy() *DpuOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuReboot) DeepCopyInto(out *DpuReboot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuReboot.
+func (in *DpuReboot) DeepCopy() *DpuReboot {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuReboot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuReboot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuRebootList) DeepCopyInto(out *DpuRebootList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DpuReboot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuRebootList.
+func (in *DpuRebootList) DeepCopy() *DpuRebootList {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuRebootList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DpuRebootList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuRebootSpec) DeepCopyInto(out *DpuRebootSpec) {
+	*out = *in
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuRebootSpec.
+func (in *DpuRebootSpec) DeepCopy() *DpuRebootSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuRebootSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuRebootState) DeepCopyInto(out *DpuRebootState) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuRebootState.
+func (in *DpuRebootState) DeepCopy() *DpuRebootState {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuRebootState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuRebootStatus) DeepCopyInto(out *DpuRebootStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuRebootStatus.
+func (in *DpuRebootStatus) DeepCopy() *DpuRebootStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuRebootStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuResetPolicy) DeepCopyInto(out *DpuResetPolicy) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuResetPolicy.
+func (in *DpuResetPolicy) DeepCopy() *DpuResetPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuResetPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DpuResourceRequirements) DeepCopyInto(out *DpuResourceRequirements) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DpuResourceRequirements.
+func (in *DpuResourceRequirements) DeepCopy() *DpuResourceRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(DpuResourceRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPsecTunnelStats) DeepCopyInto(out *IPsecTunnelStats) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPsecTunnelStats.
+func (in *IPsecTunnelStats) DeepCopy() *IPsecTunnelStats {
+	if in == nil {
+		return nil
+	}
+	out := new(IPsecTunnelStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryInfo) DeepCopyInto(out *MemoryInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryInfo.
+func (in *MemoryInfo) DeepCopy() *MemoryInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkFunction) DeepCopyInto(out *NetworkFunction) {
+	*out = *in
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DpuResources != nil {
+		in, out := &in.DpuResources, &out.DpuResources
+		*out = new(DpuResourceRequirements)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkFunction.
+func (in *NetworkFunction) DeepCopy() *NetworkFunction {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkFunction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkFunctionStatus) DeepCopyInto(out *NetworkFunctionStatus) {
+	*out = *in
+	if in.BridgePorts != nil {
+		in, out := &in.BridgePorts, &out.BridgePorts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkFunctionStatus.
+func (in *NetworkFunctionStatus) DeepCopy() *NetworkFunctionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkFunctionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterfaceInfo) DeepCopyInto(out *NetworkInterfaceInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterfaceInfo.
+func (in *NetworkInterfaceInfo) DeepCopy() *NetworkInterfaceInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterfaceInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NvmeOFTarget) DeepCopyInto(out *NvmeOFTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NvmeOFTarget.
+func (in *NvmeOFTarget) DeepCopy() *NvmeOFTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(NvmeOFTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PhysicalFunctionStatus) DeepCopyInto(out *PhysicalFunctionStatus) {
+	*out = *in
+	if in.VirtualFunctions != nil {
+		in, out := &in.VirtualFunctions, &out.VirtualFunctions
+		*out = make([]VirtualFunctionStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PhysicalFunctionStatus.
+func (in *PhysicalFunctionStatus) DeepCopy() *PhysicalFunctionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PhysicalFunctionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceFunctionChain) DeepCopyInto(out *ServiceFunctionChain) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChain.
+func (in *ServiceFunctionChain) DeepCopy() *ServiceFunctionChain {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceFunctionChain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceFunctionChain) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceFunctionChainList) DeepCopyInto(out *ServiceFunctionChainList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceFunctionChain, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainList.
+func (in *ServiceFunctionChainList) DeepCopy() *ServiceFunctionChainList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceFunctionChainList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceFunctionChainList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceFunctionChainHop) DeepCopyInto(out *ServiceFunctionChainHop) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainHop.
+func (in *ServiceFunctionChainHop) DeepCopy() *ServiceFunctionChainHop {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceFunctionChainHop)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceFunctionChainSpec) DeepCopyInto(out *ServiceFunctionChainSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NetworkFunctions != nil {
+		in, out := &in.NetworkFunctions, &out.NetworkFunctions
+		*out = make([]NetworkFunction, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainSpec.
+func (in *ServiceFunctionChainSpec) DeepCopy() *ServiceFunctionChainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceFunctionChainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceFunctionChainStatus) DeepCopyInto(out *ServiceFunctionChainStatus) {
+	*out = *in
+	if in.NetworkFunctions != nil {
+		in, out := &in.NetworkFunctions, &out.NetworkFunctions
+		*out = make([]NetworkFunctionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Hops != nil {
+		in, out := &in.Hops, &out.Hops
+		*out = make([]ServiceFunctionChainHop, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceFunctionChainStatus.
+func (in *ServiceFunctionChainStatus) DeepCopy() *ServiceFunctionChainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceFunctionChainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageDeviceInfo) DeepCopyInto(out *StorageDeviceInfo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageDeviceInfo.
+func (in *StorageDeviceInfo) DeepCopy() *StorageDeviceInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageDeviceInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VfCountStatus) DeepCopyInto(out *VfCountStatus) {
+	*out = *in
+	if in.Desired != nil {
+		in, out := &in.Desired, &out.Desired
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Applied != nil {
+		in, out := &in.Applied, &out.Applied
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VfCountStatus.
+func (in *VfCountStatus) DeepCopy() *VfCountStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VfCountStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualFunctionStatus) DeepCopyInto(out *VirtualFunctionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualFunctionStatus.
+func (in *VirtualFunctionStatus) DeepCopy() *VirtualFunctionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualFunctionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanPolicy) DeepCopyInto(out *VlanPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanPolicy.
+func (in *VlanPolicy) DeepCopy() *VlanPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -159,6 +159,21 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "DataProcessingUnitConfig")
 		os.Exit(1)
 	}
+	if err := controller.NewDpuNvmeVolumeReconciler(mgr.GetClient(), mgr.GetScheme()).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DpuNvmeVolume")
+		os.Exit(1)
+	}
+	if err := controller.NewDpuIPsecTunnelReconciler(mgr.GetClient(), mgr.GetScheme()).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DpuIPsecTunnel")
+		os.Exit(1)
+	}
+	if err := (&controller.DpuFirmwarePolicyReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DpuFirmwarePolicy")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -213,6 +213,96 @@ func (*Empty) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{3}
 }
 
+type NFList struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	NetworkFunctions []*NFRequest           `protobuf:"bytes,1,rep,name=network_functions,json=networkFunctions,proto3" json:"network_functions,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *NFList) Reset() {
+	*x = NFList{}
+	mi := &file_api_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NFList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NFList) ProtoMessage() {}
+
+func (x *NFList) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NFList.ProtoReflect.Descriptor instead.
+func (*NFList) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *NFList) GetNetworkFunctions() []*NFRequest {
+	if x != nil {
+		return x.NetworkFunctions
+	}
+	return nil
+}
+
+type NFCapabilities struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// chaining is set if the network functions are independent, so that the
+	// output of one can be wired to the input of another with CreateNetworkFunction.
+	Chaining      bool `protobuf:"varint,1,opt,name=chaining,proto3" json:"chaining,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NFCapabilities) Reset() {
+	*x = NFCapabilities{}
+	mi := &file_api_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NFCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NFCapabilities) ProtoMessage() {}
+
+func (x *NFCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NFCapabilities.ProtoReflect.Descriptor instead.
+func (*NFCapabilities) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *NFCapabilities) GetChaining() bool {
+	if x != nil {
+		return x.Chaining
+	}
+	return false
+}
+
 type VfCount struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	VfCnt         int32                  `protobuf:"varint,1,opt,name=vf_cnt,json=vfCnt,proto3" json:"vf_cnt,omitempty"`
@@ -222,7 +312,7 @@ type VfCount struct {
 
 func (x *VfCount) Reset() {
 	*x = VfCount{}
-	mi := &file_api_proto_msgTypes[4]
+	mi := &file_api_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -234,7 +324,7 @@ func (x *VfCount) String() string {
 func (*VfCount) ProtoMessage() {}
 
 func (x *VfCount) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[4]
+	mi := &file_api_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -247,7 +337,7 @@ func (x *VfCount) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VfCount.ProtoReflect.Descriptor instead.
 func (*VfCount) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{4}
+	return file_api_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *VfCount) GetVfCnt() int32 {
@@ -266,7 +356,7 @@ type TopologyInfo struct {
 
 func (x *TopologyInfo) Reset() {
 	*x = TopologyInfo{}
-	mi := &file_api_proto_msgTypes[5]
+	mi := &file_api_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -278,7 +368,7 @@ func (x *TopologyInfo) String() string {
 func (*TopologyInfo) ProtoMessage() {}
 
 func (x *TopologyInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[5]
+	mi := &file_api_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -291,7 +381,7 @@ func (x *TopologyInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TopologyInfo.ProtoReflect.Descriptor instead.
 func (*TopologyInfo) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{5}
+	return file_api_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *TopologyInfo) GetNode() string {
@@ -312,7 +402,7 @@ type Device struct {
 
 func (x *Device) Reset() {
 	*x = Device{}
-	mi := &file_api_proto_msgTypes[6]
+	mi := &file_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -324,7 +414,7 @@ func (x *Device) String() string {
 func (*Device) ProtoMessage() {}
 
 func (x *Device) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[6]
+	mi := &file_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -337,7 +427,7 @@ func (x *Device) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Device.ProtoReflect.Descriptor instead.
 func (*Device) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{6}
+	return file_api_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Device) GetID() string {
@@ -370,7 +460,7 @@ type DeviceListResponse struct {
 
 func (x *DeviceListResponse) Reset() {
 	*x = DeviceListResponse{}
-	mi := &file_api_proto_msgTypes[7]
+	mi := &file_api_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -382,7 +472,7 @@ func (x *DeviceListResponse) String() string {
 func (*DeviceListResponse) ProtoMessage() {}
 
 func (x *DeviceListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[7]
+	mi := &file_api_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -395,7 +485,7 @@ func (x *DeviceListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeviceListResponse.ProtoReflect.Descriptor instead.
 func (*DeviceListResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{7}
+	return file_api_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *DeviceListResponse) GetDevices() map[string]*Device {
@@ -415,7 +505,7 @@ type PingRequest struct {
 
 func (x *PingRequest) Reset() {
 	*x = PingRequest{}
-	mi := &file_api_proto_msgTypes[8]
+	mi := &file_api_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -427,7 +517,7 @@ func (x *PingRequest) String() string {
 func (*PingRequest) ProtoMessage() {}
 
 func (x *PingRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[8]
+	mi := &file_api_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -440,7 +530,7 @@ func (x *PingRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{8}
+	return file_api_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *PingRequest) GetTimestamp() int64 {
@@ -468,7 +558,7 @@ type PingResponse struct {
 
 func (x *PingResponse) Reset() {
 	*x = PingResponse{}
-	mi := &file_api_proto_msgTypes[9]
+	mi := &file_api_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -480,7 +570,7 @@ func (x *PingResponse) String() string {
 func (*PingResponse) ProtoMessage() {}
 
 func (x *PingResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[9]
+	mi := &file_api_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -493,7 +583,7 @@ func (x *PingResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{9}
+	return file_api_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *PingResponse) GetTimestamp() int64 {
@@ -531,7 +621,11 @@ const file_api_proto_rawDesc = "" +
 	"\tNFRequest\x12\x14\n" +
 	"\x05input\x18\x01 \x01(\tR\x05input\x12\x16\n" +
 	"\x06output\x18\x02 \x01(\tR\x06output\"\a\n" +
-	"\x05Empty\" \n" +
+	"\x05Empty\"H\n" +
+	"\x06NFList\x12>\n" +
+	"\x11network_functions\x18\x01 \x03(\v2\x11.Vendor.NFRequestR\x10networkFunctions\",\n" +
+	"\x0eNFCapabilities\x12\x1a\n" +
+	"\bchaining\x18\x01 \x01(\bR\bchaining\" \n" +
 	"\aVfCount\x12\x15\n" +
 	"\x06vf_cnt\x18\x01 \x01(\x05R\x05vfCnt\"\"\n" +
 	"\fTopologyInfo\x12\x12\n" +
@@ -553,10 +647,12 @@ const file_api_proto_rawDesc = "" +
 	"\fresponder_id\x18\x02 \x01(\tR\vresponderId\x12\x18\n" +
 	"\ahealthy\x18\x03 \x01(\bR\ahealthy2?\n" +
 	"\x10LifeCycleService\x12+\n" +
-	"\x04Init\x12\x13.Vendor.InitRequest\x1a\x0e.Vendor.IpPort2\x8e\x01\n" +
+	"\x04Init\x12\x13.Vendor.InitRequest\x1a\x0e.Vendor.IpPort2\xff\x01\n" +
 	"\x16NetworkFunctionService\x129\n" +
 	"\x15CreateNetworkFunction\x12\x11.Vendor.NFRequest\x1a\r.Vendor.Empty\x129\n" +
-	"\x15DeleteNetworkFunction\x12\x11.Vendor.NFRequest\x1a\r.Vendor.Empty2w\n" +
+	"\x15DeleteNetworkFunction\x12\x11.Vendor.NFRequest\x1a\r.Vendor.Empty\x125\n" +
+	"\x14ListNetworkFunctions\x12\r.Vendor.Empty\x1a\x0e.Vendor.NFList\x128\n" +
+	"\x0fGetCapabilities\x12\r.Vendor.Empty\x1a\x16.Vendor.NFCapabilities2w\n" +
 	"\rDeviceService\x127\n" +
 	"\n" +
 	"GetDevices\x12\r.Vendor.Empty\x1a\x1a.Vendor.DeviceListResponse\x12-\n" +
@@ -576,41 +672,48 @@ func file_api_proto_rawDescGZIP() []byte {
 	return file_api_proto_rawDescData
 }
 
-var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_api_proto_goTypes = []any{
 	(*InitRequest)(nil),        // 0: Vendor.InitRequest
 	(*IpPort)(nil),             // 1: Vendor.IpPort
 	(*NFRequest)(nil),          // 2: Vendor.NFRequest
 	(*Empty)(nil),              // 3: Vendor.Empty
-	(*VfCount)(nil),            // 4: Vendor.VfCount
-	(*TopologyInfo)(nil),       // 5: Vendor.TopologyInfo
-	(*Device)(nil),             // 6: Vendor.Device
-	(*DeviceListResponse)(nil), // 7: Vendor.DeviceListResponse
-	(*PingRequest)(nil),        // 8: Vendor.PingRequest
-	(*PingResponse)(nil),       // 9: Vendor.PingResponse
-	nil,                        // 10: Vendor.DeviceListResponse.DevicesEntry
+	(*NFList)(nil),             // 4: Vendor.NFList
+	(*NFCapabilities)(nil),     // 5: Vendor.NFCapabilities
+	(*VfCount)(nil),            // 6: Vendor.VfCount
+	(*TopologyInfo)(nil),       // 7: Vendor.TopologyInfo
+	(*Device)(nil),             // 8: Vendor.Device
+	(*DeviceListResponse)(nil), // 9: Vendor.DeviceListResponse
+	(*PingRequest)(nil),        // 10: Vendor.PingRequest
+	(*PingResponse)(nil),       // 11: Vendor.PingResponse
+	nil,                        // 12: Vendor.DeviceListResponse.DevicesEntry
 }
 var file_api_proto_depIdxs = []int32{
-	5,  // 0: Vendor.Device.topology:type_name -> Vendor.TopologyInfo
-	10, // 1: Vendor.DeviceListResponse.devices:type_name -> Vendor.DeviceListResponse.DevicesEntry
-	6,  // 2: Vendor.DeviceListResponse.DevicesEntry.value:type_name -> Vendor.Device
-	0,  // 3: Vendor.LifeCycleService.Init:input_type -> Vendor.InitRequest
-	2,  // 4: Vendor.NetworkFunctionService.CreateNetworkFunction:input_type -> Vendor.NFRequest
-	2,  // 5: Vendor.NetworkFunctionService.DeleteNetworkFunction:input_type -> Vendor.NFRequest
-	3,  // 6: Vendor.DeviceService.GetDevices:input_type -> Vendor.Empty
-	4,  // 7: Vendor.DeviceService.SetNumVfs:input_type -> Vendor.VfCount
-	8,  // 8: Vendor.HeartbeatService.Ping:input_type -> Vendor.PingRequest
-	1,  // 9: Vendor.LifeCycleService.Init:output_type -> Vendor.IpPort
-	3,  // 10: Vendor.NetworkFunctionService.CreateNetworkFunction:output_type -> Vendor.Empty
-	3,  // 11: Vendor.NetworkFunctionService.DeleteNetworkFunction:output_type -> Vendor.Empty
-	7,  // 12: Vendor.DeviceService.GetDevices:output_type -> Vendor.DeviceListResponse
-	4,  // 13: Vendor.DeviceService.SetNumVfs:output_type -> Vendor.VfCount
-	9,  // 14: Vendor.HeartbeatService.Ping:output_type -> Vendor.PingResponse
-	9,  // [9:15] is the sub-list for method output_type
-	3,  // [3:9] is the sub-list for method input_type
-	3,  // [3:3] is the sub-list for extension type_name
-	3,  // [3:3] is the sub-list for extension extendee
-	0,  // [0:3] is the sub-list for field type_name
+	2,  // 0: Vendor.NFList.network_functions:type_name -> Vendor.NFRequest
+	7,  // 1: Vendor.Device.topology:type_name -> Vendor.TopologyInfo
+	12, // 2: Vendor.DeviceListResponse.devices:type_name -> Vendor.DeviceListResponse.DevicesEntry
+	8,  // 3: Vendor.DeviceListResponse.DevicesEntry.value:type_name -> Vendor.Device
+	0,  // 4: Vendor.LifeCycleService.Init:input_type -> Vendor.InitRequest
+	2,  // 5: Vendor.NetworkFunctionService.CreateNetworkFunction:input_type -> Vendor.NFRequest
+	2,  // 6: Vendor.NetworkFunctionService.DeleteNetworkFunction:input_type -> Vendor.NFRequest
+	3,  // 7: Vendor.NetworkFunctionService.ListNetworkFunctions:input_type -> Vendor.Empty
+	3,  // 8: Vendor.NetworkFunctionService.GetCapabilities:input_type -> Vendor.Empty
+	3,  // 9: Vendor.DeviceService.GetDevices:input_type -> Vendor.Empty
+	6,  // 10: Vendor.DeviceService.SetNumVfs:input_type -> Vendor.VfCount
+	10, // 11: Vendor.HeartbeatService.Ping:input_type -> Vendor.PingRequest
+	1,  // 12: Vendor.LifeCycleService.Init:output_type -> Vendor.IpPort
+	3,  // 13: Vendor.NetworkFunctionService.CreateNetworkFunction:output_type -> Vendor.Empty
+	3,  // 14: Vendor.NetworkFunctionService.DeleteNetworkFunction:output_type -> Vendor.Empty
+	4,  // 15: Vendor.NetworkFunctionService.ListNetworkFunctions:output_type -> Vendor.NFList
+	5,  // 16: Vendor.NetworkFunctionService.GetCapabilities:output_type -> Vendor.NFCapabilities
+	9,  // 17: Vendor.DeviceService.GetDevices:output_type -> Vendor.DeviceListResponse
+	6,  // 18: Vendor.DeviceService.SetNumVfs:output_type -> Vendor.VfCount
+	11, // 19: Vendor.HeartbeatService.Ping:output_type -> Vendor.PingResponse
+	12, // [12:20] is the sub-list for method output_type
+	4,  // [4:12] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_init() }
@@ -624,7 +727,7 @@ func file_api_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_rawDesc), len(file_api_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   11,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   4,
 		},
@@ -123,6 +123,8 @@ var LifeCycleService_ServiceDesc = grpc.ServiceDesc{
 const (
 	NetworkFunctionService_CreateNetworkFunction_FullMethodName = "/Vendor.NetworkFunctionService/CreateNetworkFunction"
 	NetworkFunctionService_DeleteNetworkFunction_FullMethodName = "/Vendor.NetworkFunctionService/DeleteNetworkFunction"
+	NetworkFunctionService_ListNetworkFunctions_FullMethodName  = "/Vendor.NetworkFunctionService/ListNetworkFunctions"
+	NetworkFunctionService_GetCapabilities_FullMethodName       = "/Vendor.NetworkFunctionService/GetCapabilities"
 )
 
 // NetworkFunctionServiceClient is the client API for NetworkFunctionService service.
@@ -131,6 +133,8 @@ const (
 type NetworkFunctionServiceClient interface {
 	CreateNetworkFunction(ctx context.Context, in *NFRequest, opts ...grpc.CallOption) (*Empty, error)
 	DeleteNetworkFunction(ctx context.Context, in *NFRequest, opts ...grpc.CallOption) (*Empty, error)
+	ListNetworkFunctions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NFList, error)
+	GetCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NFCapabilities, error)
 }
 
 type networkFunctionServiceClient struct {
@@ -161,12 +165,34 @@ func (c *networkFunctionServiceClient) DeleteNetworkFunction(ctx context.Context
 	return out, nil
 }
 
+func (c *networkFunctionServiceClient) ListNetworkFunctions(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NFList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NFList)
+	err := c.cc.Invoke(ctx, NetworkFunctionService_ListNetworkFunctions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkFunctionServiceClient) GetCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NFCapabilities, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NFCapabilities)
+	err := c.cc.Invoke(ctx, NetworkFunctionService_GetCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NetworkFunctionServiceServer is the server API for NetworkFunctionService service.
 // All implementations must embed UnimplementedNetworkFunctionServiceServer
 // for forward compatibility.
 type NetworkFunctionServiceServer interface {
 	CreateNetworkFunction(context.Context, *NFRequest) (*Empty, error)
 	DeleteNetworkFunction(context.Context, *NFRequest) (*Empty, error)
+	ListNetworkFunctions(context.Context, *Empty) (*NFList, error)
+	GetCapabilities(context.Context, *Empty) (*NFCapabilities, error)
 	mustEmbedUnimplementedNetworkFunctionServiceServer()
 }
 
@@ -183,6 +209,12 @@ func (UnimplementedNetworkFunctionServiceServer) CreateNetworkFunction(context.C
 func (UnimplementedNetworkFunctionServiceServer) DeleteNetworkFunction(context.Context, *NFRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteNetworkFunction not implemented")
 }
+func (UnimplementedNetworkFunctionServiceServer) ListNetworkFunctions(context.Context, *Empty) (*NFList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNetworkFunctions not implemented")
+}
+func (UnimplementedNetworkFunctionServiceServer) GetCapabilities(context.Context, *Empty) (*NFCapabilities, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCapabilities not implemented")
+}
 func (UnimplementedNetworkFunctionServiceServer) mustEmbedUnimplementedNetworkFunctionServiceServer() {
 }
 func (UnimplementedNetworkFunctionServiceServer) testEmbeddedByValue() {}
@@ -241,6 +273,42 @@ func _NetworkFunctionService_DeleteNetworkFunction_Handler(srv interface{}, ctx
 	return interceptor(ctx, in, info, handler)
 }
 
+func _NetworkFunctionService_ListNetworkFunctions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkFunctionServiceServer).ListNetworkFunctions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkFunctionService_ListNetworkFunctions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkFunctionServiceServer).ListNetworkFunctions(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkFunctionService_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkFunctionServiceServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NetworkFunctionService_GetCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkFunctionServiceServer).GetCapabilities(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // NetworkFunctionService_ServiceDesc is the grpc.ServiceDesc for NetworkFunctionService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -256,6 +324,14 @@ var NetworkFunctionService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteNetworkFunction",
 			Handler:    _NetworkFunctionService_DeleteNetworkFunction_Handler,
 		},
+		{
+			MethodName: "ListNetworkFunctions",
+			Handler:    _NetworkFunctionService_ListNetworkFunctions_Handler,
+		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _NetworkFunctionService_GetCapabilities_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
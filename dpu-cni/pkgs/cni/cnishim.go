@@ -134,6 +134,59 @@ func (p *Plugin) CmdDel(args *skel.CmdArgs) error {
 	return nil
 }
 
-func (p *Plugin) CmdCheck(_ *skel.CmdArgs) error {
+// CmdCheck is the callback for 'check' cni calls from skel. The daemon verifies
+// that the interface is still set up as ADD left it.
+func (p *Plugin) CmdCheck(args *skel.CmdArgs) error {
+	if err := SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+		return err
+	}
+
+	cnilogging.Info("function called",
+		"func", "cmdCheck",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData), "args.Args", args.Args)
+
+	_, _, err := p.PostRequest(args)
+	if err != nil {
+		return fmt.Errorf("failed to post request for cmdCheck: %v", err)
+	}
+
+	return nil
+}
+
+// CmdGC is the callback for 'gc' cni calls from skel. The daemon releases the
+// resources of attachments that are not in the valid attachments of the config.
+func (p *Plugin) CmdGC(args *skel.CmdArgs) error {
+	if err := SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+		return err
+	}
+
+	cnilogging.Info("function called",
+		"func", "cmdGC",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData))
+
+	_, _, err := p.PostRequest(args)
+	if err != nil {
+		return fmt.Errorf("failed to post request for cmdGC: %v", err)
+	}
+
+	return nil
+}
+
+// CmdStatus is the callback for 'status' cni calls from skel. The plugin is
+// reported as not available when the daemon cannot be reached or is not ready.
+func (p *Plugin) CmdStatus(args *skel.CmdArgs) error {
+	if err := SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+		return err
+	}
+
+	cnilogging.Info("function called",
+		"func", "cmdStatus",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData))
+
+	_, _, err := p.PostRequest(args)
+	if err != nil {
+		return types.NewError(cnitypes.ErrPluginNotAvailable, "DPU daemon is not available", err.Error())
+	}
+
 	return nil
 }
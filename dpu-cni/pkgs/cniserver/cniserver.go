@@ -26,10 +26,13 @@ type processRequestFunc func(request *cnitypes.PodRequest) (*cni100.Result, erro
 
 type Server struct {
 	http.Server
-	cniCmdAddHandler processRequestFunc
-	cniCmdDelHandler processRequestFunc
-	pathManager      utils.PathManager
-	cniMutex         sync.Mutex
+	cniCmdAddHandler    processRequestFunc
+	cniCmdDelHandler    processRequestFunc
+	cniCmdCheckHandler  processRequestFunc
+	cniCmdGCHandler     processRequestFunc
+	cniCmdStatusHandler processRequestFunc
+	pathManager         utils.PathManager
+	cniMutex            sync.Mutex
 }
 
 // Start starts the server and begins serving on the given listener
@@ -130,6 +133,13 @@ func cniRequestToPodRequest(cr *cnitypes.Request) (*cnitypes.PodRequest, error)
 		Command: cmd,
 	}
 
+	// GC and STATUS act on the network as a whole rather than on a single
+	// container, so only the configuration is passed.
+	if cmd == cnitypes.CNIGC || cmd == cnitypes.CNIStatus {
+		req.Path = cr.Env["CNI_PATH"]
+		return finishPodRequest(cr, req)
+	}
+
 	req.ContainerId, ok = cr.Env["CNI_CONTAINERID"]
 	if !ok {
 		return nil, fmt.Errorf("missing CNI_CONTAINERID")
@@ -173,6 +183,11 @@ func cniRequestToPodRequest(cr *cnitypes.Request) (*cnitypes.PodRequest, error)
 	// containerd 1.5: https://github.com/containerd/containerd/pull/5643
 	req.PodUID = cniArgs["K8S_POD_UID"]
 
+	return finishPodRequest(cr, req)
+}
+
+// finishPodRequest parses the CNI configuration of the request.
+func finishPodRequest(cr *cnitypes.Request, req *cnitypes.PodRequest) (*cnitypes.PodRequest, error) {
 	conf, err := cnihelper.ReadCNIConfig(cr.Config)
 	if err != nil {
 		return nil, fmt.Errorf("broken stdin args")
@@ -226,10 +241,19 @@ func (s *Server) handleCNIRequest(r *http.Request) ([]byte, error) {
 	defer cniRequestEnvCleanup()
 
 	var result *cni100.Result = nil
-	if req.Command == cnitypes.CNIAdd {
+	switch req.Command {
+	case cnitypes.CNIAdd:
 		result, err = s.cniCmdAddHandler(req)
-	} else if req.Command == cnitypes.CNIDel {
+	case cnitypes.CNIDel:
 		result, err = s.cniCmdDelHandler(req)
+	case cnitypes.CNICheck:
+		result, err = runOptionalHandler(s.cniCmdCheckHandler, req)
+	case cnitypes.CNIGC:
+		result, err = runOptionalHandler(s.cniCmdGCHandler, req)
+	case cnitypes.CNIStatus:
+		result, err = runOptionalHandler(s.cniCmdStatusHandler, req)
+	default:
+		err = fmt.Errorf("unsupported CNI command %q", req.Command)
 	}
 	if err != nil {
 		klog.Errorf("Error occured in handler: %v", err)
@@ -240,6 +264,15 @@ func (s *Server) handleCNIRequest(r *http.Request) ([]byte, error) {
 	return json.Marshal(&response)
 }
 
+// runOptionalHandler runs the handler of a command that does not need to be
+// handled, succeeding when no handler is set.
+func runOptionalHandler(handler processRequestFunc, req *cnitypes.PodRequest) (*cni100.Result, error) {
+	if handler == nil {
+		return nil, nil
+	}
+	return handler(req)
+}
+
 // HttpCNIPost is a callback functions to handle "/cni" requests.
 func (s *Server) HttpCNIPost(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -289,3 +322,26 @@ func WithPathManager(pathManager utils.PathManager) func(*Server) {
 		s.pathManager = pathManager
 	}
 }
+
+// WithCheckHandler sets the handler for CHECK requests. Without it, CHECK
+// always succeeds.
+func WithCheckHandler(handler processRequestFunc) func(*Server) {
+	return func(s *Server) {
+		s.cniCmdCheckHandler = handler
+	}
+}
+
+// WithGCHandler sets the handler for GC requests. Without it, GC is a no-op.
+func WithGCHandler(handler processRequestFunc) func(*Server) {
+	return func(s *Server) {
+		s.cniCmdGCHandler = handler
+	}
+}
+
+// WithStatusHandler sets the handler for STATUS requests. Without it, the
+// plugin always reports itself as available.
+func WithStatusHandler(handler processRequestFunc) func(*Server) {
+	return func(s *Server) {
+		s.cniCmdStatusHandler = handler
+	}
+}
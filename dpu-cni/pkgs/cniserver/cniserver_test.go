@@ -1,10 +1,12 @@
 package cniserver_test
 
 import (
+	"errors"
 	"net"
 	"os"
 
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
 	g "github.com/onsi/ginkgo/v2"
 	o "github.com/onsi/gomega"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cni"
@@ -44,6 +46,8 @@ var _ = g.Describe("Cniserver", func() {
 		listener         net.Listener
 		addHandlerCalled bool
 		delHandlerCalled bool
+		gcRequest        *cnitypes.PodRequest
+		statusErr        error
 		testCluster      testutils.KindCluster
 	)
 
@@ -81,9 +85,22 @@ var _ = g.Describe("Cniserver", func() {
 				return result, nil
 			}
 
+			gcRequest = nil
+			gcHandler := func(request *cnitypes.PodRequest) (*current.Result, error) {
+				gcRequest = request
+				return nil, nil
+			}
+
+			statusErr = nil
+			statusHandler := func(request *cnitypes.PodRequest) (*current.Result, error) {
+				return nil, statusErr
+			}
+
 			pathManager := utils.NewPathManager(testCluster.TempDirPath())
 			cniServer = cniserver.NewCNIServer(addHandler, delHandler,
-				cniserver.WithPathManager(*pathManager))
+				cniserver.WithPathManager(*pathManager),
+				cniserver.WithGCHandler(gcHandler),
+				cniserver.WithStatusHandler(statusHandler))
 			listener, err = cniServer.Listen()
 			o.Expect(err).NotTo(o.HaveOccurred())
 			go utilwait.Forever(func() {
@@ -116,6 +133,37 @@ var _ = g.Describe("Cniserver", func() {
 				})
 
 			})
+			g.When("CHECK, GC and STATUS requests", func() {
+				cniVersion := "1.1.0"
+				g.It("should succeed on CHECK without a check handler", func() {
+					_, _, err := plugin.PostRequest(PrepArgs(cniVersion, cnitypes.CNICheck))
+					o.Expect(err).NotTo(o.HaveOccurred())
+					o.Expect(addHandlerCalled).To(o.Equal(false))
+					o.Expect(delHandlerCalled).To(o.Equal(false))
+				})
+				g.It("should pass the valid attachments to the GC handler", func() {
+					args := PrepArgs(cniVersion, cnitypes.CNIGC)
+					args.StdinData = []byte(`{"cniVersion": "1.1.0", "name": "dpucni", "type": "dpucni", "cni.dev/valid-attachments": [{"containerID": "fakecontainerid", "ifname": "fakeeth0"}]}`)
+					os.Unsetenv("CNI_CONTAINERID")
+					os.Unsetenv("CNI_NETNS")
+					os.Unsetenv("CNI_ARGS")
+					_, _, err := plugin.PostRequest(args)
+					o.Expect(err).NotTo(o.HaveOccurred())
+					o.Expect(gcRequest).NotTo(o.BeNil())
+					o.Expect(gcRequest.CNIConf.ValidAttachments).To(o.HaveLen(1))
+					o.Expect(gcRequest.CNIConf.ValidAttachments[0].ContainerID).To(o.Equal("fakecontainerid"))
+				})
+				g.It("should report STATUS errors", func() {
+					o.Expect(plugin.CmdStatus(PrepArgs(cniVersion, cnitypes.CNIStatus))).To(o.Succeed())
+
+					statusErr = errors.New("DPU daemon is not reachable")
+					err := plugin.CmdStatus(PrepArgs(cniVersion, cnitypes.CNIStatus))
+					o.Expect(err).To(o.HaveOccurred())
+					cniErr, ok := err.(*types.Error)
+					o.Expect(ok).To(o.BeTrue())
+					o.Expect(cniErr.Code).To(o.Equal(cnitypes.ErrPluginNotAvailable))
+				})
+			})
 		})
 	})
 })
@@ -37,6 +37,12 @@ const CNIAdd string = "ADD"
 const CNIUpdate string = "UPDATE"
 const CNIDel string = "DEL"
 const CNICheck string = "CHECK"
+const CNIGC string = "GC"
+const CNIStatus string = "STATUS"
+
+// ErrPluginNotAvailable is the CNI error code returned by STATUS when the
+// plugin cannot serve ADD requests.
+const ErrPluginNotAvailable uint = 50
 
 // PodRequest structure built from Request which is passed to the
 // handler function given to the Server at creation time
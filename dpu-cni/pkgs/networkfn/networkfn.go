@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ipam"
@@ -359,3 +360,53 @@ func CmdDel(req *cnitypes.PodRequest) error {
 
 	return nil
 }
+
+// CmdCheck verifies that the network function interface is still in the pod
+// netns with the MAC address reported by CmdAdd, and records that MAC address
+// in the request configuration.
+func CmdCheck(req *cnitypes.PodRequest) error {
+	klog.Info("CmdCheck called for networkfn")
+
+	conf := req.CNIConf
+
+	containerNs, err := ns.GetNS(req.Netns)
+	if err != nil {
+		return fmt.Errorf("failed to open netns %q: %v", req.Netns, err)
+	}
+	defer containerNs.Close()
+
+	var mac string
+	err = containerNs.Do(func(_ ns.NetNS) error {
+		contDev, err := netlink.LinkByName(req.IfName)
+		if err != nil {
+			return fmt.Errorf("failed to find %q: %v", req.IfName, err)
+		}
+		mac = contDev.Attrs().HardwareAddr.String()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if conf.PrevResult != nil {
+		prevResult, err := current.NewResultFromResult(conf.PrevResult)
+		if err != nil {
+			return fmt.Errorf("failed to convert prevResult: %v", err)
+		}
+		for _, intf := range prevResult.Interfaces {
+			if intf.Name == req.IfName && intf.Sandbox != "" && !strings.EqualFold(intf.Mac, mac) {
+				return fmt.Errorf("interface %q has MAC address %s, expected %s", req.IfName, mac, intf.Mac)
+			}
+		}
+	}
+
+	if conf.IPAM.Type != "" {
+		klog.Infof("CmdCheck: Running IPAM %q", conf.IPAM.Type)
+		if err := ipam.ExecCheck(conf.IPAM.Type, req.CNIReq.Config); err != nil {
+			return err
+		}
+	}
+
+	req.CNIConf.MAC = mac
+	return nil
+}
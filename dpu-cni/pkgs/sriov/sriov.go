@@ -3,11 +3,14 @@ package sriov
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/containernetworking/plugins/pkg/ns"
 	"k8s.io/klog/v2"
 
+	"github.com/containernetworking/cni/pkg/invoke"
 	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ipam"
@@ -56,6 +59,9 @@ type Manager interface {
 	FillOriginalVfInfo(conf *cnitypes.NetConf) error
 	CmdAdd(req *cnitypes.PodRequest) (*current.Result, error)
 	CmdDel(req *cnitypes.PodRequest) (bool, error)
+	CmdCheck(req *cnitypes.PodRequest) (*cnitypes.NetConf, error)
+	CmdGC(req *cnitypes.PodRequest) ([]string, error)
+	AllocatedVFs() ([]string, error)
 }
 
 type sriovManager struct {
@@ -591,3 +597,146 @@ func (sm *sriovManager) CmdDel(req *cnitypes.PodRequest) (bool, error) {
 
 	return true, nil
 }
+
+// CmdCheck verifies that the VF of an attachment is still allocated and set up
+// in the Pod netns the way CmdAdd left it. Returns the cached NetConf of the
+// attachment, so that the DPU side of the VF can be verified too.
+func (sm *sriovManager) CmdCheck(req *cnitypes.PodRequest) (*cnitypes.NetConf, error) {
+	klog.Info("CmdCheck called")
+
+	netConf, _, err := sriovconfig.LoadConfFromCache(req.ContainerId, req.IfName)
+	if err != nil {
+		return nil, fmt.Errorf("cmdCheck() failed to load cached netconf: %v", err)
+	}
+
+	allocated, err := sm.allocator.IsAllocated(netConf.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("cmdCheck() error checking the pci allocation for vf pci address %s: %v", netConf.DeviceID, err)
+	}
+	if !allocated {
+		return nil, fmt.Errorf("cmdCheck() vf pci address %s is not allocated anymore", netConf.DeviceID)
+	}
+
+	if host_vlans {
+		if err := sm.checkVFVlan(netConf); err != nil {
+			return nil, fmt.Errorf("cmdCheck() %v", err)
+		}
+	}
+
+	if netConf.IPAM.Type != "" {
+		klog.Infof("CmdCheck(): Executing IPAM plugin. IPAM type: %s", netConf.IPAM.Type)
+		if err := ipam.ExecCheck(netConf.IPAM.Type, req.CNIReq.Config); err != nil {
+			return nil, fmt.Errorf("cmdCheck() IPAM plugin type %q check failed: %v", netConf.IPAM.Type, err)
+		}
+	}
+
+	if netConf.DPDKMode {
+		return netConf, nil
+	}
+
+	netns, err := ns.GetNS(req.Netns)
+	if err != nil {
+		return nil, fmt.Errorf("cmdCheck() failed to open netns %q: %v", req.Netns, err)
+	}
+	defer netns.Close()
+
+	expectedMAC := sriovconfig.GetMacAddressForResult(netConf)
+	err = netns.Do(func(_ ns.NetNS) error {
+		linkObj, err := sm.nLink.LinkByName(req.IfName)
+		if err != nil {
+			return fmt.Errorf("failed to get VF netdevice with name %s: %q", req.IfName, err)
+		}
+		mac := linkObj.Attrs().HardwareAddr.String()
+		if expectedMAC != "" && !strings.EqualFold(mac, expectedMAC) {
+			return fmt.Errorf("VF netdevice %s has MAC address %s, expected %s", req.IfName, mac, expectedMAC)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cmdCheck() %v", err)
+	}
+
+	return netConf, nil
+}
+
+// checkVFVlan verifies the VLAN configuration of a VF on its PF.
+func (sm *sriovManager) checkVFVlan(conf *cnitypes.NetConf) error {
+	pfLink, err := sm.nLink.LinkByName(conf.Master)
+	if err != nil {
+		return fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
+	}
+	vfState := getVfInfo(pfLink, conf.VFID)
+	if vfState == nil {
+		return fmt.Errorf("failed to find vf %d", conf.VFID)
+	}
+	if conf.Vlan != nil && vfState.Vlan != *conf.Vlan {
+		return fmt.Errorf("vf %d has vlan %d, expected %d", conf.VFID, vfState.Vlan, *conf.Vlan)
+	}
+	if conf.VlanQoS != nil && vfState.Qos != *conf.VlanQoS {
+		return fmt.Errorf("vf %d has vlan QoS %d, expected %d", conf.VFID, vfState.Qos, *conf.VlanQoS)
+	}
+	return nil
+}
+
+// CmdGC releases the VFs of the cached attachments of the network that are
+// not in the valid attachments of the request, as well as the PCI allocations
+// whose netns is gone, such as after a node crash. Returns the PCI addresses
+// of the released VFs, so that their DPU side can be cleaned up too.
+func (sm *sriovManager) CmdGC(req *cnitypes.PodRequest) ([]string, error) {
+	klog.Info("CmdGC called")
+
+	valid := make(map[string]bool)
+	for _, attachment := range req.CNIConf.ValidAttachments {
+		valid[sriovconfig.CacheRef(attachment.ContainerID, attachment.IfName)] = true
+	}
+
+	netConfs, err := sriovconfig.LoadConfsFromCache()
+	if err != nil {
+		return nil, fmt.Errorf("cmdGC() failed to load cached netconfs: %v", err)
+	}
+
+	var released []string
+	var errs []error
+	for cRefPath, netConf := range netConfs {
+		if netConf.Name != req.CNIConf.Name || valid[filepath.Base(cRefPath)] {
+			continue
+		}
+
+		klog.Infof("CmdGC(): Releasing stale attachment %s of VF %s", filepath.Base(cRefPath), netConf.DeviceID)
+		if err := sm.ResetVFConfig(netConf); err != nil {
+			klog.Errorf("CmdGC(): failed to reset VF %s: %v", netConf.DeviceID, err)
+		}
+		if err := sm.allocator.DeleteAllocatedPCI(netConf.DeviceID); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		if err := sriovutils.CleanCachedNetConf(cRefPath); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		released = append(released, netConf.DeviceID)
+	}
+
+	stale, err := sm.allocator.ReleaseStale()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, pciAddress := range stale {
+		klog.Infof("CmdGC(): Released the PCI address %s of a netns that does not exist anymore", pciAddress)
+	}
+	released = append(released, stale...)
+
+	if req.CNIConf.IPAM.Type != "" {
+		klog.Infof("CmdGC(): Executing IPAM plugin. IPAM type: %s", req.CNIConf.IPAM.Type)
+		if err := invoke.DelegateGC(req.Ctx, req.CNIConf.IPAM.Type, req.CNIReq.Config, nil); err != nil {
+			errs = append(errs, fmt.Errorf("IPAM plugin type %q GC failed: %v", req.CNIConf.IPAM.Type, err))
+		}
+	}
+
+	return released, errors.Join(errs...)
+}
+
+// AllocatedVFs returns the PCI addresses of the VFs currently allocated to pods.
+func (sm *sriovManager) AllocatedVFs() ([]string, error) {
+	return sm.allocator.Allocations()
+}
@@ -3,6 +3,7 @@ package sriovconfig
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -139,22 +140,61 @@ func getVfInfo(vfPci string) (string, int, error) {
 
 // LoadConfFromCache retrieves cached NetConf returns it along with a handle for removal
 func LoadConfFromCache(containerID string, ifName string) (*cnitypes.NetConf, string, error) {
-	netConf := &cnitypes.NetConf{}
+	cRef := CacheRef(containerID, ifName)
+	cRefPath := filepath.Join(DefaultCNIDir, cRef)
+
+	netConf, err := loadConfFromCachePath(cRefPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading cached NetConf in %s with name %s: %v", DefaultCNIDir, cRef, err)
+	}
+
+	return netConf, cRefPath, nil
+}
 
+// CacheRef returns the name of the cached NetConf of an attachment.
+func CacheRef(containerID string, ifName string) string {
 	s := []string{containerID, ifName}
-	cRef := strings.Join(s, "-")
-	cRefPath := filepath.Join(DefaultCNIDir, cRef)
+	return strings.Join(s, "-")
+}
 
+// LoadConfsFromCache retrieves all cached NetConfs, by the path of their cache
+// file. Cache files that cannot be parsed are skipped.
+func LoadConfsFromCache() (map[string]*cnitypes.NetConf, error) {
+	entries, err := os.ReadDir(DefaultCNIDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading NetConf cache %s: %v", DefaultCNIDir, err)
+	}
+
+	netConfs := make(map[string]*cnitypes.NetConf)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		cRefPath := filepath.Join(DefaultCNIDir, entry.Name())
+		netConf, err := loadConfFromCachePath(cRefPath)
+		if err != nil {
+			klog.Errorf("LoadConfsFromCache(): skipping %s: %v", cRefPath, err)
+			continue
+		}
+		netConfs[cRefPath] = netConf
+	}
+	return netConfs, nil
+}
+
+func loadConfFromCachePath(cRefPath string) (*cnitypes.NetConf, error) {
 	netConfBytes, err := sriovutils.ReadScratchNetConf(cRefPath)
 	if err != nil {
-		return nil, "", fmt.Errorf("error reading cached NetConf in %s with name %s", DefaultCNIDir, cRef)
+		return nil, err
 	}
 
+	netConf := &cnitypes.NetConf{}
 	if err = json.Unmarshal(netConfBytes, netConf); err != nil {
-		return nil, "", fmt.Errorf("failed to parse NetConf: %q", err)
+		return nil, fmt.Errorf("failed to parse NetConf: %q", err)
 	}
-
-	return netConf, cRefPath, nil
+	return netConf, nil
 }
 
 // GetMacAddressForResult return the mac address we should report to the CNI call return object
@@ -50,7 +50,7 @@ func (p *PCIAllocator) SaveAllocatedPCI(pciAddress, ns string) error {
 func (p *PCIAllocator) DeleteAllocatedPCI(pciAddress string) error {
 	path := filepath.Join(p.dataDir, pciAddress)
 	if err := p.fs.Remove(path); err != nil {
-		return fmt.Errorf("error removing PCI address lock file %s: %v", path, err)
+		return fmt.Errorf("error removing PCI address lock file %s: %w", path, err)
 	}
 	return nil
 }
@@ -95,3 +95,44 @@ func (p *PCIAllocator) IsAllocated(pciAddress string) (bool, error) {
 	networkNamespace.Close()
 	return true, nil
 }
+
+// Allocations returns the PCI addresses that have an allocation file.
+func (p *PCIAllocator) Allocations() ([]string, error) {
+	entries, err := afero.ReadDir(p.fs, p.dataDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the sriov data directory(%q): %v", p.dataDir, err)
+	}
+
+	var pciAddresses []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			pciAddresses = append(pciAddresses, entry.Name())
+		}
+	}
+	return pciAddresses, nil
+}
+
+// ReleaseStale releases the allocations whose network namespace does not exist
+// anymore, as left behind when cmdDel was never called, and returns their PCI
+// addresses.
+func (p *PCIAllocator) ReleaseStale() ([]string, error) {
+	pciAddresses, err := p.Allocations()
+	if err != nil {
+		return nil, err
+	}
+
+	var released []string
+	for _, pciAddress := range pciAddresses {
+		allocated, err := p.IsAllocated(pciAddress)
+		if err != nil {
+			return released, err
+		}
+		if !allocated {
+			released = append(released, pciAddress)
+		}
+	}
+	return released, nil
+}
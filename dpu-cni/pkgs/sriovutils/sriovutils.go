@@ -132,6 +132,20 @@ func GetPfName(vf string) (string, error) {
 	return strings.TrimSpace(files[0].Name()), nil
 }
 
+// GetPciFunction takes in a PCI address(pciAddr) and returns its function number as int,
+// e.g. 1 for 0000:3b:00.1
+func GetPciFunction(pciAddr string) (int, error) {
+	idx := strings.LastIndex(pciAddr, ".")
+	if idx < 0 {
+		return 0, fmt.Errorf("invalid PCI address %q", pciAddr)
+	}
+	function, err := strconv.Atoi(pciAddr[idx+1:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid PCI function in address %q: %v", pciAddr, err)
+	}
+	return function, nil
+}
+
 // GetPciAddress takes in a interface(ifName) and VF id and returns its pci addr as string
 func GetPciAddress(ifName string, vf int) (string, error) {
 	var pciaddr string
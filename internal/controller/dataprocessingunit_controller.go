@@ -20,7 +20,9 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 	configv1 "github.com/openshift/dpu-operator/api/v1"
@@ -32,6 +34,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -71,10 +75,11 @@ func (r *DataProcessingUnitReconciler) WithImagePullPolicy(policy string) *DataP
 
 // getPluginForDPU finds the appropriate plugin for a given DPU based on its product name
 func (r *DataProcessingUnitReconciler) getPluginForDPU(logger logr.Logger, dpu *configv1.DataProcessingUnit) plugin.Plugin {
-	plugins := r.pluginRegistry.List()
-
-	productName := dpu.Spec.DpuProductName
+	return pluginForProductName(logger, r.pluginRegistry.List(), dpu.Spec.DpuProductName)
+}
 
+// pluginForProductName finds the plugin among plugins that manages DPUs of the given product name
+func pluginForProductName(logger logr.Logger, plugins []plugin.Plugin, productName string) plugin.Plugin {
 	// First, try to match via detector metadata (authoritative mapping)
 	detectorManager := platform.NewDpuDetectorManager(platform.NewHardwarePlatform())
 	if vendorName, err := detectorManager.GetVendorNameByProductName(productName); err == nil {
@@ -100,6 +105,124 @@ func (r *DataProcessingUnitReconciler) getPluginForDPU(logger logr.Logger, dpu *
 	return nil
 }
 
+// dpuPlugins keeps a plugin instance per DPU. The registered plugins are
+// shared by every DPU of their vendor, so each DPU gets its own instance
+// initialized on the OPI endpoint its daemon reports in its status.
+type dpuPlugins struct {
+	registry     *plugin.Registry
+	pluginConfig func(vendor string) plugin.PluginConfig
+
+	mu        sync.Mutex
+	instances map[string]*dpuPluginInstance
+}
+
+type dpuPluginInstance struct {
+	plugin   plugin.Plugin
+	endpoint string
+}
+
+func newDpuPlugins(registry *plugin.Registry, pluginConfig func(vendor string) plugin.PluginConfig) *dpuPlugins {
+	return &dpuPlugins{
+		registry:     registry,
+		pluginConfig: pluginConfig,
+		instances:    make(map[string]*dpuPluginInstance),
+	}
+}
+
+// forDpu returns the plugin instance of dpu, initialized on the OPI endpoint
+// of dpu. The instance is replaced when the endpoint changes.
+func (d *dpuPlugins) forDpu(ctx context.Context, logger logr.Logger, dpu *configv1.DataProcessingUnit) (plugin.Plugin, error) {
+	registered := pluginForProductName(logger, d.registry.List(), dpu.Spec.DpuProductName)
+	if registered == nil {
+		return nil, fmt.Errorf("no plugin for DPU product %q", dpu.Spec.DpuProductName)
+	}
+	endpoint := dpu.Status.OpiEndpoint
+	if endpoint == "" {
+		return nil, fmt.Errorf("DPU %s reports no OPI endpoint reachable from the operator", dpu.Name)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if current, ok := d.instances[dpu.Name]; ok {
+		if current.endpoint == endpoint && current.plugin.Info().Name == registered.Info().Name {
+			return current.plugin, nil
+		}
+		d.shutdown(ctx, logger, dpu.Name, current)
+	}
+
+	instancePlugin, ok := registered.(plugin.InstancePlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s cannot serve several DPUs", registered.Info().Name)
+	}
+	p := instancePlugin.NewInstance()
+	config := d.pluginConfig(p.Info().Vendor)
+	config.OPIEndpoint = endpoint
+	config.NetworkEndpoint = ""
+	if err := p.Initialize(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to initialize plugin %s for DPU %s: %v", p.Info().Name, dpu.Name, err)
+	}
+	logger.Info("Initialized plugin for DPU", "plugin", p.Info().Name, "dpu", dpu.Name, "endpoint", endpoint)
+	d.instances[dpu.Name] = &dpuPluginInstance{plugin: p, endpoint: endpoint}
+	return p, nil
+}
+
+// retain shuts down the plugin instances of the DPUs that are not in dpus.
+func (d *dpuPlugins) retain(ctx context.Context, logger logr.Logger, dpus []configv1.DataProcessingUnit) {
+	names := make(map[string]bool, len(dpus))
+	for i := range dpus {
+		names[dpus[i].Name] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, instance := range d.instances {
+		if !names[name] {
+			d.shutdown(ctx, logger, name, instance)
+		}
+	}
+}
+
+func (d *dpuPlugins) shutdown(ctx context.Context, logger logr.Logger, name string, instance *dpuPluginInstance) {
+	delete(d.instances, name)
+	if err := instance.plugin.Shutdown(ctx); err != nil {
+		logger.Error(err, "Failed to shut down plugin of DPU", "plugin", instance.plugin.Info().Name, "dpu", name)
+	}
+}
+
+// selectDpu returns the DPU a resource is placed on. A resource stays on the
+// DPU it is already placed on; otherwise the first DPU by name matching the
+// selector is used. It returns nil if no DPU matches.
+func selectDpu(dpuSelector *metav1.LabelSelector, current string, dpus []configv1.DataProcessingUnit) (*configv1.DataProcessingUnit, error) {
+	selector := labels.Everything()
+	if dpuSelector != nil {
+		parsed, err := metav1.LabelSelectorAsSelector(dpuSelector)
+		if err != nil {
+			return nil, err
+		}
+		selector = parsed
+	}
+
+	var candidates []*configv1.DataProcessingUnit
+	for i := range dpus {
+		dpu := &dpus[i]
+		if current != "" && dpu.Name == current {
+			return dpu, nil
+		}
+		if selector.Matches(labels.Set(dpu.Labels)) {
+			candidates = append(candidates, dpu)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Name < candidates[j].Name
+	})
+	return candidates[0], nil
+}
+
 // +kubebuilder:rbac:groups="",resources=pods,verbs=*
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=*
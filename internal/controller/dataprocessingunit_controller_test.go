@@ -17,8 +17,135 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// endpointPlugin records the endpoint each of its instances is initialized on.
+type endpointPlugin struct {
+	endpoint string
+	shutDown bool
+}
+
+func (p *endpointPlugin) Info() plugin.PluginInfo {
+	return plugin.PluginInfo{Name: "endpoint-test", Vendor: "EndpointTest"}
+}
+
+func (p *endpointPlugin) Initialize(ctx context.Context, config plugin.PluginConfig) error {
+	if p.endpoint != "" {
+		return plugin.ErrAlreadyInitialized
+	}
+	p.endpoint = config.OPIEndpoint
+	return nil
+}
+
+func (p *endpointPlugin) Shutdown(ctx context.Context) error {
+	p.shutDown = true
+	return nil
+}
+
+func (p *endpointPlugin) HealthCheck(ctx context.Context) error { return nil }
+
+func (p *endpointPlugin) DiscoverDevices(ctx context.Context) ([]plugin.Device, error) {
+	return nil, nil
+}
+
+func (p *endpointPlugin) GetInventory(ctx context.Context, deviceID string) (*plugin.InventoryResponse, error) {
+	return nil, plugin.ErrNotImplemented
+}
+
+func (p *endpointPlugin) NewInstance() plugin.Plugin {
+	return &endpointPlugin{}
+}
+
+func endpointDpu(name, endpoint string) *configv1.DataProcessingUnit {
+	return &configv1.DataProcessingUnit{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       configv1.DataProcessingUnitSpec{DpuProductName: "EndpointTest"},
+		Status:     configv1.DataProcessingUnitStatus{OpiEndpoint: endpoint},
+	}
+}
+
+// fakeDpuPlugins returns the plugin instances of the DPUs of product "fake",
+// all served by p.
+func fakeDpuPlugins(p plugin.InstancePlugin) *dpuPlugins {
+	registry := plugin.NewRegistry()
+	Expect(registry.Register(p)).To(Succeed())
+	return newDpuPlugins(registry, func(string) plugin.PluginConfig { return plugin.PluginConfig{} })
+}
+
+// fakeDpu returns a host side DPU of product "fake" on node worker-<name>,
+// reporting an OPI endpoint.
+func fakeDpu(name string, labels map[string]string) *configv1.DataProcessingUnit {
+	return &configv1.DataProcessingUnit{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       configv1.DataProcessingUnitSpec{DpuProductName: "fake", NodeName: "worker-" + name},
+		Status:     configv1.DataProcessingUnitStatus{OpiEndpoint: "192.0.2.10:50051"},
+	}
+}
+
 var _ = Describe("DataProcessingUnit Controller", func() {
+	Describe("dpuPlugins", func() {
+		var (
+			ctx     context.Context
+			plugins *dpuPlugins
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			registry := plugin.NewRegistry()
+			Expect(registry.Register(&endpointPlugin{})).To(Succeed())
+			plugins = newDpuPlugins(registry, func(string) plugin.PluginConfig {
+				return plugin.PluginConfig{OPIEndpoint: "localhost:50051", NetworkEndpoint: "localhost:50052"}
+			})
+		})
+
+		It("gives each DPU an instance on the endpoint it reports", func() {
+			first, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-a", "10.0.0.1:50051"))
+			Expect(err).NotTo(HaveOccurred())
+			second, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-b", "10.0.0.2:50051"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.(*endpointPlugin).endpoint).To(Equal("10.0.0.1:50051"))
+			Expect(second.(*endpointPlugin).endpoint).To(Equal("10.0.0.2:50051"))
+
+			again, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-a", "10.0.0.1:50051"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(again).To(BeIdenticalTo(first))
+		})
+
+		It("replaces the instance of a DPU whose endpoint changes", func() {
+			first, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-a", "10.0.0.1:50051"))
+			Expect(err).NotTo(HaveOccurred())
+			moved, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-a", "10.0.0.9:50051"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.(*endpointPlugin).shutDown).To(BeTrue())
+			Expect(moved.(*endpointPlugin).endpoint).To(Equal("10.0.0.9:50051"))
+		})
+
+		It("fails for a DPU that reports no endpoint", func() {
+			_, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-a", ""))
+			Expect(err).To(MatchError(ContainSubstring("reports no OPI endpoint")))
+		})
+
+		It("shuts down the instances of removed DPUs", func() {
+			kept, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-a", "10.0.0.1:50051"))
+			Expect(err).NotTo(HaveOccurred())
+			removed, err := plugins.forDpu(ctx, logr.Discard(), endpointDpu("dpu-b", "10.0.0.2:50051"))
+			Expect(err).NotTo(HaveOccurred())
+
+			plugins.retain(ctx, logr.Discard(), []configv1.DataProcessingUnit{*endpointDpu("dpu-a", "10.0.0.1:50051")})
+
+			Expect(kept.(*endpointPlugin).shutDown).To(BeFalse())
+			Expect(removed.(*endpointPlugin).shutDown).To(BeTrue())
+		})
+	})
 })
@@ -17,18 +17,25 @@ limitations under the License.
 package controller
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
+	"strings"
 
 	configv1 "github.com/openshift/dpu-operator/api/v1"
 	"github.com/openshift/dpu-operator/pkgs/vars"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // DataProcessingUnitConfigReconciler reconciles a DataProcessingUnitConfig object
@@ -40,6 +47,10 @@ type DataProcessingUnitConfigReconciler struct {
 // +kubebuilder:rbac:groups=config.openshift.io,resources=dataprocessingunitconfigs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=config.openshift.io,resources=dataprocessingunitconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=config.openshift.io,resources=dataprocessingunitconfigs/finalizers,verbs=update
+// The daemon drains nodes for VF count rollouts with these permissions.
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=pods/eviction,verbs=create
+// +kubebuilder:rbac:groups=machineconfiguration.openshift.io,resources=machineconfigpools,verbs=get;list;watch;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -53,19 +64,12 @@ type DataProcessingUnitConfigReconciler struct {
 func (r *DataProcessingUnitConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := logf.FromContext(ctx)
 
-	cfg := &configv1.DataProcessingUnitConfig{}
-	if err := r.Get(ctx, req.NamespacedName, cfg); err != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(err)
-	}
-
-	selector := labels.Everything()
-	if cfg.Spec.DpuSelector != nil {
-		parsed, err := metav1.LabelSelectorAsSelector(cfg.Spec.DpuSelector)
-		if err != nil {
-			logger.Error(err, "Invalid DPU selector")
-			return ctrl.Result{}, err
-		}
-		selector = parsed
+	// The effective config of a DPU depends on all the configs matching it, so
+	// every reconcile merges them again, including when a config was deleted.
+	cfgList := &configv1.DataProcessingUnitConfigList{}
+	if err := r.List(ctx, cfgList); err != nil {
+		logger.Error(err, "Failed to list DataProcessingUnitConfigs")
+		return ctrl.Result{}, err
 	}
 
 	dpuList := &configv1.DataProcessingUnitList{}
@@ -74,81 +78,93 @@ func (r *DataProcessingUnitConfigReconciler) Reconcile(ctx context.Context, req
 		return ctrl.Result{}, err
 	}
 
-	annotationKey := fmt.Sprintf("dpu.config.openshift.io/config-%s.%s", cfg.Namespace, cfg.Name)
-	vfKey := fmt.Sprintf("%s%s.%s", vars.DpuConfigVFCountAnnotationPrefix, cfg.Namespace, cfg.Name)
-	legacyAnnotationKey := fmt.Sprintf("dpu.config.openshift.io/config-%s", cfg.Name)
-	legacyVfKey := vars.DpuConfigVFCountAnnotationPrefix + cfg.Name
-
-	matchedNames := make([]string, 0, len(dpuList.Items))
+	selectors := make(map[string]labels.Selector, len(cfgList.Items))
+	for i := range cfgList.Items {
+		cfg := &cfgList.Items[i]
+		selector, err := dpuConfigSelector(cfg)
+		if err != nil {
+			// The config is left out of the merge until its selector is fixed.
+			logger.Error(err, "Invalid DPU selector", "config", client.ObjectKeyFromObject(cfg))
+			continue
+		}
+		selectors[dpuConfigSource(cfg)] = selector
+	}
 
+	// merged holds the effective config of each DPU before the rollouts hold
+	// back VF count changes.
+	merged := make(map[string]*configv1.DpuEffectiveConfig, len(dpuList.Items))
+	targets := make([]*configv1.DpuEffectiveConfig, len(dpuList.Items))
 	for i := range dpuList.Items {
 		dpu := &dpuList.Items[i]
-		matches := selector.Matches(labels.Set(dpu.Labels))
-		changed := false
-
-		if dpu.Annotations == nil {
-			dpu.Annotations = map[string]string{}
-		}
-
-		if matches {
-			matchedNames = append(matchedNames, dpu.Name)
-			if dpu.Annotations[annotationKey] != "true" {
-				dpu.Annotations[annotationKey] = "true"
-				changed = true
-			}
-			if _, exists := dpu.Annotations[legacyAnnotationKey]; exists {
-				delete(dpu.Annotations, legacyAnnotationKey)
-				changed = true
-			}
-			if cfg.Spec.VfCount != nil {
-				vfValue := fmt.Sprintf("%d", *cfg.Spec.VfCount)
-				if dpu.Annotations[vfKey] != vfValue {
-					dpu.Annotations[vfKey] = vfValue
-					changed = true
-				}
-				if _, exists := dpu.Annotations[legacyVfKey]; exists {
-					delete(dpu.Annotations, legacyVfKey)
-					changed = true
-				}
-			} else if _, exists := dpu.Annotations[vfKey]; exists {
-				delete(dpu.Annotations, vfKey)
-				changed = true
-			} else if _, exists := dpu.Annotations[legacyVfKey]; exists {
-				delete(dpu.Annotations, legacyVfKey)
-				changed = true
-			}
-		} else {
-			if _, exists := dpu.Annotations[annotationKey]; exists {
-				delete(dpu.Annotations, annotationKey)
-				changed = true
-			}
-			if _, exists := dpu.Annotations[legacyAnnotationKey]; exists {
-				delete(dpu.Annotations, legacyAnnotationKey)
-				changed = true
-			}
-			if _, exists := dpu.Annotations[vfKey]; exists {
-				delete(dpu.Annotations, vfKey)
-				changed = true
-			}
-			if _, exists := dpu.Annotations[legacyVfKey]; exists {
-				delete(dpu.Annotations, legacyVfKey)
-				changed = true
+		var matching []*configv1.DataProcessingUnitConfig
+		for j := range cfgList.Items {
+			selector, ok := selectors[dpuConfigSource(&cfgList.Items[j])]
+			if ok && selector.Matches(labels.Set(dpu.Labels)) {
+				matching = append(matching, &cfgList.Items[j])
 			}
 		}
+		merged[dpu.Name] = effectiveDpuConfig(matching)
+		targets[i] = merged[dpu.Name].DeepCopy()
+	}
+	gateVfCountRollouts(cfgList.Items, dpuList.Items, targets)
 
+	for i := range dpuList.Items {
+		dpu := &dpuList.Items[i]
+		changed := removeLegacyConfigAnnotations(dpu)
+		if !reflect.DeepEqual(dpu.Spec.EffectiveConfig, targets[i]) {
+			dpu.Spec.EffectiveConfig = targets[i]
+			changed = true
+		}
 		if changed {
 			if err := r.Update(ctx, dpu); err != nil {
-				logger.Error(err, "Failed to update DPU annotations", "dpu", dpu.Name)
+				logger.Error(err, "Failed to update DPU effective config", "dpu", dpu.Name)
 				return ctrl.Result{}, err
 			}
 		}
 	}
 
+	var cfg *configv1.DataProcessingUnitConfig
+	for i := range cfgList.Items {
+		if client.ObjectKeyFromObject(&cfgList.Items[i]) == req.NamespacedName {
+			cfg = &cfgList.Items[i]
+		}
+	}
+	if cfg == nil {
+		return ctrl.Result{}, nil
+	}
+	selector, err := dpuConfigSelector(cfg)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	matchedNames := make([]string, 0, len(dpuList.Items))
+	outcomes := make([]configv1.DataProcessingUnitConfigDpuStatus, 0, len(dpuList.Items))
+	for i := range dpuList.Items {
+		dpu := &dpuList.Items[i]
+		if selector.Matches(labels.Set(dpu.Labels)) {
+			matchedNames = append(matchedNames, dpu.Name)
+			outcomes = append(outcomes, dpuConfigOutcome(cfg, dpu, merged[dpu.Name]))
+		}
+	}
+
+	if len(outcomes) == 0 {
+		outcomes = nil
+	}
+	paused := cfg.Spec.RolloutStrategy != nil && cfg.Spec.RolloutStrategy.Paused
+	ready := dpuConfigReadyCondition(cfg.Generation, paused, outcomes)
+	currentReady := meta.FindStatusCondition(cfg.Status.Conditions, configv1.DpuConfigConditionReady)
+
 	statusChanged := cfg.Status.ObservedGeneration != cfg.Generation ||
-		!reflect.DeepEqual(cfg.Status.MatchedDPUs, matchedNames)
+		!reflect.DeepEqual(cfg.Status.MatchedDPUs, matchedNames) ||
+		!reflect.DeepEqual(cfg.Status.DPUs, outcomes) ||
+		currentReady == nil || currentReady.Status != ready.Status ||
+		currentReady.Reason != ready.Reason || currentReady.Message != ready.Message ||
+		currentReady.ObservedGeneration != ready.ObservedGeneration
 	if statusChanged {
 		cfg.Status.ObservedGeneration = cfg.Generation
 		cfg.Status.MatchedDPUs = matchedNames
+		cfg.Status.DPUs = outcomes
+		meta.SetStatusCondition(&cfg.Status.Conditions, ready)
 		if err := r.Status().Update(ctx, cfg); err != nil {
 			logger.Error(err, "Failed to update DataProcessingUnitConfig status")
 			return ctrl.Result{}, err
@@ -158,10 +174,274 @@ func (r *DataProcessingUnitConfigReconciler) Reconcile(ctx context.Context, req
 	return ctrl.Result{}, nil
 }
 
+func dpuConfigSelector(cfg *configv1.DataProcessingUnitConfig) (labels.Selector, error) {
+	if cfg.Spec.DpuSelector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(cfg.Spec.DpuSelector)
+}
+
+// dpuConfigSource identifies a config in the effective config of a DPU.
+func dpuConfigSource(cfg *configv1.DataProcessingUnitConfig) string {
+	return cfg.Namespace + "/" + cfg.Name
+}
+
+// sortDpuConfigsByPrecedence sorts configs from the highest precedence to the
+// lowest: by descending priority, then by namespace and name.
+func sortDpuConfigsByPrecedence(cfgs []*configv1.DataProcessingUnitConfig) {
+	slices.SortStableFunc(cfgs, func(a, b *configv1.DataProcessingUnitConfig) int {
+		if a.Spec.Priority != b.Spec.Priority {
+			return cmp.Compare(b.Spec.Priority, a.Spec.Priority)
+		}
+		return cmp.Compare(dpuConfigSource(a), dpuConfigSource(b))
+	})
+}
+
+// effectiveDpuConfig merges the configs matching a DPU. Each setting comes
+// from the config with the highest precedence that sets it. It returns nil
+// when no config matches.
+func effectiveDpuConfig(matching []*configv1.DataProcessingUnitConfig) *configv1.DpuEffectiveConfig {
+	if len(matching) == 0 {
+		return nil
+	}
+	cfgs := slices.Clone(matching)
+	sortDpuConfigsByPrecedence(cfgs)
+
+	effective := &configv1.DpuEffectiveConfig{}
+	for _, cfg := range cfgs {
+		effective.Sources = append(effective.Sources, dpuConfigSource(cfg))
+		if effective.VfCount == nil && cfg.Spec.VfCount != nil {
+			vfCount := *cfg.Spec.VfCount
+			effective.VfCount = &vfCount
+			effective.VfCountSource = dpuConfigSource(cfg)
+			effective.Drain = rolloutDrain(cfg.Spec.RolloutStrategy)
+		}
+	}
+	return effective
+}
+
+// rolloutDrain returns whether nodes are drained for a VF count change.
+func rolloutDrain(strategy *configv1.DpuConfigRolloutStrategy) bool {
+	return strategy != nil && (strategy.Drain == nil || *strategy.Drain)
+}
+
+// vfCountRollingOut returns whether the daemon has not applied the VF count
+// of the effective config of a DPU yet. A failed change keeps the DPU rolling
+// out, which stops the rollout from reaching more nodes.
+func vfCountRollingOut(dpu *configv1.DataProcessingUnit) bool {
+	effective := dpu.Spec.EffectiveConfig
+	if dpu.Spec.IsDpuSide || effective == nil || effective.VfCount == nil {
+		return false
+	}
+	status := dpu.Status.VfCount
+	return status == nil || status.Desired == nil || *status.Desired != *effective.VfCount ||
+		status.State != configv1.VfCountStateApplied
+}
+
+// gateVfCountRollouts holds back the VF count changes of configs with a
+// rollout strategy, so that at most maxUnavailable nodes are disrupted at the
+// same time, by a VF count change or a firmware update, and no node starts
+// while the rollout is paused. A DPU held back keeps the VF count of its
+// current effective config. Nodes are picked by name.
+func gateVfCountRollouts(cfgs []configv1.DataProcessingUnitConfig, dpus []configv1.DataProcessingUnit, targets []*configv1.DpuEffectiveConfig) {
+	strategies := make(map[string]*configv1.DpuConfigRolloutStrategy, len(cfgs))
+	for i := range cfgs {
+		strategies[dpuConfigSource(&cfgs[i])] = cfgs[i].Spec.RolloutStrategy
+	}
+
+	rollouts := map[string]*nodeRollout{}
+	var sources []string
+	for i := range dpus {
+		dpu, target := &dpus[i], targets[i]
+		if dpu.Spec.IsDpuSide || target == nil || target.VfCount == nil {
+			continue
+		}
+		strategy := strategies[target.VfCountSource]
+		if strategy == nil {
+			continue
+		}
+		r, ok := rollouts[target.VfCountSource]
+		if !ok {
+			r = newNodeRollout(strategy)
+			rollouts[target.VfCountSource] = r
+			sources = append(sources, target.VfCountSource)
+		}
+		r.nodes[dpu.Spec.NodeName] = true
+		current := dpu.Spec.EffectiveConfig
+		if current == nil || current.VfCount == nil || *current.VfCount != *target.VfCount {
+			r.changes = append(r.changes, i)
+		}
+	}
+
+	slices.Sort(sources)
+	ordered := make([]*nodeRollout, 0, len(sources))
+	for _, source := range sources {
+		ordered = append(ordered, rollouts[source])
+	}
+	gateNodeRollouts(ordered, dpus, func(i int) {
+		holdVfCount(dpus[i].Spec.EffectiveConfig, targets[i])
+	})
+}
+
+// holdVfCount keeps the VF count of the current effective config in the target.
+func holdVfCount(current, target *configv1.DpuEffectiveConfig) {
+	target.VfCount, target.VfCountSource, target.Drain = nil, "", false
+	if current != nil && current.VfCount != nil {
+		vfCount := *current.VfCount
+		target.VfCount = &vfCount
+		target.VfCountSource = current.VfCountSource
+		target.Drain = current.Drain
+	}
+}
+
+// removeLegacyConfigAnnotations removes the annotations that carried the
+// configs before the effective config, and returns whether any was removed.
+func removeLegacyConfigAnnotations(dpu *configv1.DataProcessingUnit) bool {
+	removed := false
+	for key := range dpu.Annotations {
+		if strings.HasPrefix(key, vars.LegacyDpuConfigAnnotationPrefix) ||
+			strings.HasPrefix(key, vars.LegacyDpuConfigVFCountAnnotationPrefix) {
+			delete(dpu.Annotations, key)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// migrateLegacyConfigAnnotations removes the legacy annotations from all DPUs
+// once the manager starts, as the reconciles do not run without configs.
+func (r *DataProcessingUnitConfigReconciler) migrateLegacyConfigAnnotations(ctx context.Context) error {
+	logger := logf.FromContext(ctx).WithName("dataprocessingunitconfig-migration")
+
+	dpuList := &configv1.DataProcessingUnitList{}
+	if err := r.List(ctx, dpuList); err != nil {
+		return fmt.Errorf("failed to list DPUs: %w", err)
+	}
+	for i := range dpuList.Items {
+		dpu := &dpuList.Items[i]
+		if !removeLegacyConfigAnnotations(dpu) {
+			continue
+		}
+		if err := r.Update(ctx, dpu); err != nil {
+			return fmt.Errorf("failed to remove legacy config annotations from DPU %s: %w", dpu.Name, err)
+		}
+		logger.Info("Removed legacy config annotations", "dpu", dpu.Name)
+	}
+	return nil
+}
+
+// dpuConfigOutcome returns the state of the config on a matched DPU, from the
+// merged config of the DPU, its effective config and the VF count status the
+// daemon reports.
+func dpuConfigOutcome(cfg *configv1.DataProcessingUnitConfig, dpu *configv1.DataProcessingUnit, merged *configv1.DpuEffectiveConfig) configv1.DataProcessingUnitConfigDpuStatus {
+	outcome := configv1.DataProcessingUnitConfigDpuStatus{Name: dpu.Name, State: configv1.VfCountStateApplied}
+	// The VF count is only applied on the host side.
+	if cfg.Spec.VfCount == nil || dpu.Spec.IsDpuSide {
+		return outcome
+	}
+	vfCount := *cfg.Spec.VfCount
+
+	// A config with a higher precedence asking for the same VF count does not
+	// override this one.
+	if merged != nil && merged.VfCount != nil && *merged.VfCount != vfCount {
+		outcome.State = configv1.VfCountStateConflicted
+		outcome.Message = fmt.Sprintf("VF count %d is overridden by %s with VF count %d.",
+			vfCount, merged.VfCountSource, *merged.VfCount)
+		return outcome
+	}
+
+	effective := dpu.Spec.EffectiveConfig
+	if effective == nil || effective.VfCount == nil || *effective.VfCount != vfCount {
+		outcome.State = configv1.VfCountStatePending
+		switch {
+		case merged == nil || merged.VfCount == nil:
+			outcome.Message = "Waiting for the effective config of the DPU."
+		case cfg.Spec.RolloutStrategy != nil && cfg.Spec.RolloutStrategy.Paused:
+			outcome.Message = fmt.Sprintf("Rollout is paused before node %s.", dpu.Spec.NodeName)
+		default:
+			outcome.Message = fmt.Sprintf("Waiting for the rollout to reach node %s.", dpu.Spec.NodeName)
+		}
+		return outcome
+	}
+
+	status := dpu.Status.VfCount
+	if status == nil || status.Desired == nil || *status.Desired != vfCount {
+		outcome.State = configv1.VfCountStatePending
+		outcome.Message = "Waiting for the daemon to pick up the VF count."
+		return outcome
+	}
+
+	switch {
+	case status.State == configv1.VfCountStateFailed:
+		outcome.State = status.State
+		outcome.Message = status.LastError
+	case status.Phase != "":
+		outcome.State = configv1.VfCountStatePending
+		outcome.Message = fmt.Sprintf("%s node %s for VF count %d.", status.Phase, dpu.Spec.NodeName, vfCount)
+	case status.State != configv1.VfCountStateApplied || status.Applied == nil || *status.Applied != vfCount:
+		outcome.State = configv1.VfCountStatePending
+		outcome.Message = fmt.Sprintf("Waiting for VF count %d to be applied.", vfCount)
+	}
+	return outcome
+}
+
+// dpuConfigReadyCondition aggregates the outcomes on the matched DPUs. The
+// reason is the worst state of any DPU: Conflicted, then Failed, then
+// Pending, which is reported as Paused while the rollout is paused.
+func dpuConfigReadyCondition(generation int64, paused bool, outcomes []configv1.DataProcessingUnitConfigDpuStatus) metav1.Condition {
+	counts := map[configv1.VfCountState]int{}
+	for _, outcome := range outcomes {
+		counts[outcome.State]++
+	}
+
+	condition := metav1.Condition{
+		Type:               configv1.DpuConfigConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Message: fmt.Sprintf("%d applied, %d pending, %d failed, %d conflicted.",
+			counts[configv1.VfCountStateApplied], counts[configv1.VfCountStatePending],
+			counts[configv1.VfCountStateFailed], counts[configv1.VfCountStateConflicted]),
+	}
+	switch {
+	case counts[configv1.VfCountStateConflicted] > 0:
+		condition.Reason = configv1.DpuConfigReasonConflicted
+	case counts[configv1.VfCountStateFailed] > 0:
+		condition.Reason = configv1.DpuConfigReasonFailed
+	case counts[configv1.VfCountStatePending] > 0 && paused:
+		condition.Reason = configv1.DpuConfigReasonPaused
+	case counts[configv1.VfCountStatePending] > 0:
+		condition.Reason = configv1.DpuConfigReasonPending
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = configv1.DpuConfigReasonApplied
+	}
+	return condition
+}
+
+// configsForDpu maps a DPU to all DataProcessingUnitConfigs, so the DPU gets
+// its effective config and their status follows the VF count status the
+// daemon reports on the DPU.
+func (r *DataProcessingUnitConfigReconciler) configsForDpu(ctx context.Context, _ client.Object) []reconcile.Request {
+	cfgList := &configv1.DataProcessingUnitConfigList{}
+	if err := r.List(ctx, cfgList); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list DataProcessingUnitConfigs")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(cfgList.Items))
+	for _, cfg := range cfgList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cfg)})
+	}
+	return requests
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *DataProcessingUnitConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(manager.RunnableFunc(r.migrateLegacyConfigAnnotations)); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1.DataProcessingUnitConfig{}).
+		Watches(&configv1.DataProcessingUnit{}, handler.EnqueueRequestsFromMapFunc(r.configsForDpu)).
 		Named("dataprocessingunitconfig").
 		Complete(r)
 }
@@ -17,8 +17,346 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
 var _ = Describe("DataProcessingUnitConfig Controller", func() {
+	Describe("Reconcile", func() {
+		var (
+			ctx        context.Context
+			c          client.Client
+			namespace  string
+			reconciler *DataProcessingUnitConfigReconciler
+		)
+
+		vfsSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"vfs": "enabled"}}
+
+		newConfig := func(name string, priority int32, vfCount *int32, strategy *configv1.DpuConfigRolloutStrategy) *configv1.DataProcessingUnitConfig {
+			cfg := &configv1.DataProcessingUnitConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: configv1.DataProcessingUnitConfigSpec{
+					DpuSelector:     vfsSelector,
+					Priority:        priority,
+					VfCount:         vfCount,
+					RolloutStrategy: strategy,
+				},
+			}
+			Expect(c.Create(ctx, cfg)).To(Succeed())
+			return cfg
+		}
+
+		reconcileConfig := func(cfg *configv1.DataProcessingUnitConfig) {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cfg)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(cfg), cfg)).To(Succeed())
+		}
+
+		getDpu := func(name string) *configv1.DataProcessingUnit {
+			dpu := &configv1.DataProcessingUnit{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: name}, dpu)).To(Succeed())
+			return dpu
+		}
+
+		vfCountOf := func(name string) *int32 {
+			effective := getDpu(name).Spec.EffectiveConfig
+			if effective == nil {
+				return nil
+			}
+			return effective.VfCount
+		}
+
+		// reportVfCount reports the VF count of the effective config of a
+		// DPU in the given state, as the daemon does.
+		reportVfCount := func(name string, state configv1.VfCountState, phase configv1.VfCountPhase, lastError string) {
+			dpu := getDpu(name)
+			effective := dpu.Spec.EffectiveConfig
+			dpu.Status.VfCount = &configv1.VfCountStatus{
+				Desired:   effective.VfCount,
+				Sources:   effective.Sources,
+				State:     state,
+				Phase:     phase,
+				LastError: lastError,
+			}
+			if state == configv1.VfCountStateApplied {
+				dpu.Status.VfCount.Applied = effective.VfCount
+			}
+			Expect(c.Status().Update(ctx, dpu)).To(Succeed())
+		}
+
+		// createDpuWithVfCount creates a DPU selected by the configs on which
+		// the daemon applied vfCount.
+		createDpuWithVfCount := func(name string, vfCount int32) {
+			dpu := fakeDpu(name, map[string]string{"vfs": "enabled"})
+			dpu.Spec.EffectiveConfig = &configv1.DpuEffectiveConfig{
+				VfCount:       int32Ptr(vfCount),
+				VfCountSource: namespace + "/vfs",
+				Sources:       []string{namespace + "/vfs"},
+			}
+			dpu.Status.VfCount = &configv1.VfCountStatus{
+				Desired: int32Ptr(vfCount),
+				Applied: int32Ptr(vfCount),
+				Sources: []string{namespace + "/vfs"},
+				State:   configv1.VfCountStateApplied,
+			}
+			createTestDpu(ctx, dpu)
+		}
+
+		readyCondition := func(cfg *configv1.DataProcessingUnitConfig) *metav1.Condition {
+			return meta.FindStatusCondition(cfg.Status.Conditions, configv1.DpuConfigConditionReady)
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			c = startTestEnv()
+			namespace = createTestNamespace(ctx)
+			reconciler = &DataProcessingUnitConfigReconciler{Client: c, Scheme: scheme.Scheme}
+		})
+
+		AfterEach(func() {
+			Expect(c.DeleteAllOf(ctx, &configv1.DataProcessingUnitConfig{}, client.InNamespace(namespace))).To(Succeed())
+			deleteTestDpus(ctx)
+		})
+
+		It("writes the effective config to the matching DPUs and reports when the daemon applied it", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"vfs": "enabled"}))
+			createTestDpu(ctx, fakeDpu("dpu-b", nil))
+			cfg := newConfig("vfs", 0, int32Ptr(8), nil)
+
+			reconcileConfig(cfg)
+			Expect(getDpu("dpu-a").Spec.EffectiveConfig).To(Equal(&configv1.DpuEffectiveConfig{
+				VfCount:       int32Ptr(8),
+				VfCountSource: namespace + "/vfs",
+				Sources:       []string{namespace + "/vfs"},
+			}))
+			Expect(getDpu("dpu-b").Spec.EffectiveConfig).To(BeNil())
+			Expect(cfg.Status.MatchedDPUs).To(Equal([]string{"dpu-a"}))
+			Expect(cfg.Status.DPUs).To(Equal([]configv1.DataProcessingUnitConfigDpuStatus{{
+				Name:    "dpu-a",
+				State:   configv1.VfCountStatePending,
+				Message: "Waiting for the daemon to pick up the VF count.",
+			}}))
+			Expect(readyCondition(cfg).Reason).To(Equal(configv1.DpuConfigReasonPending))
+
+			reportVfCount("dpu-a", configv1.VfCountStatePending, configv1.VfCountPhaseDraining, "")
+			reconcileConfig(cfg)
+			Expect(cfg.Status.DPUs[0].Message).To(Equal("Draining node worker-dpu-a for VF count 8."))
+
+			reportVfCount("dpu-a", configv1.VfCountStateApplied, "", "")
+			reconcileConfig(cfg)
+			Expect(cfg.Status.DPUs[0].State).To(Equal(configv1.VfCountStateApplied))
+			Expect(cfg.Status.ObservedGeneration).To(Equal(cfg.Generation))
+			ready := readyCondition(cfg)
+			Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+			Expect(ready.Reason).To(Equal(configv1.DpuConfigReasonApplied))
+			Expect(ready.Message).To(Equal("1 applied, 0 pending, 0 failed, 0 conflicted."))
+		})
+
+		It("reports the error of a failed VF count", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"vfs": "enabled"}))
+			cfg := newConfig("vfs", 0, int32Ptr(8), nil)
+			reconcileConfig(cfg)
+
+			reportVfCount("dpu-a", configv1.VfCountStateFailed, "", "Failed to apply VF count 8: device busy")
+			reconcileConfig(cfg)
+			Expect(cfg.Status.DPUs[0].State).To(Equal(configv1.VfCountStateFailed))
+			Expect(cfg.Status.DPUs[0].Message).To(Equal("Failed to apply VF count 8: device busy"))
+			Expect(readyCondition(cfg).Reason).To(Equal(configv1.DpuConfigReasonFailed))
+		})
+
+		It("takes the VF count from the config with the highest priority and reports the one it overrides", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"vfs": "enabled"}))
+			low := newConfig("low", 0, int32Ptr(4), nil)
+			high := newConfig("high", 10, int32Ptr(8), nil)
+			other := newConfig("other", 20, nil, nil)
+
+			reconcileConfig(low)
+			effective := getDpu("dpu-a").Spec.EffectiveConfig
+			Expect(*effective.VfCount).To(Equal(int32(8)))
+			Expect(effective.VfCountSource).To(Equal(namespace + "/high"))
+			Expect(effective.Sources).To(Equal([]string{namespace + "/other", namespace + "/high", namespace + "/low"}))
+
+			Expect(low.Status.DPUs[0].State).To(Equal(configv1.VfCountStateConflicted))
+			Expect(low.Status.DPUs[0].Message).To(Equal("VF count 4 is overridden by " + namespace + "/high with VF count 8."))
+			Expect(readyCondition(low).Reason).To(Equal(configv1.DpuConfigReasonConflicted))
+
+			reconcileConfig(other)
+			Expect(other.Status.DPUs[0].State).To(Equal(configv1.VfCountStateApplied), "a config without a VF count has nothing to apply")
+			reconcileConfig(high)
+			Expect(high.Status.DPUs[0].State).To(Equal(configv1.VfCountStatePending))
+		})
+
+		It("orders configs with the same priority by namespace and name", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"vfs": "enabled"}))
+			newConfig("vfs-b", 5, int32Ptr(4), nil)
+			cfg := newConfig("vfs-a", 5, int32Ptr(8), nil)
+
+			reconcileConfig(cfg)
+			Expect(getDpu("dpu-a").Spec.EffectiveConfig.VfCountSource).To(Equal(namespace + "/vfs-a"))
+		})
+
+		It("applies the VF count on the DPU side without waiting for the daemon", func() {
+			dpu := fakeDpu("dpu-a", map[string]string{"vfs": "enabled"})
+			dpu.Spec.IsDpuSide = true
+			createTestDpu(ctx, dpu)
+			cfg := newConfig("vfs", 0, int32Ptr(8), &configv1.DpuConfigRolloutStrategy{Paused: true})
+
+			reconcileConfig(cfg)
+			Expect(cfg.Status.DPUs[0].State).To(Equal(configv1.VfCountStateApplied))
+			Expect(readyCondition(cfg).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("clears the effective config of the DPUs when the config is deleted", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"vfs": "enabled"}))
+			cfg := newConfig("vfs", 0, int32Ptr(8), nil)
+			reconcileConfig(cfg)
+			Expect(vfCountOf("dpu-a")).To(Equal(int32Ptr(8)))
+
+			Expect(c.Delete(ctx, cfg)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cfg)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getDpu("dpu-a").Spec.EffectiveConfig).To(BeNil())
+		})
+
+		It("leaves a config with an invalid selector out of the merge", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"vfs": "enabled"}))
+			cfg := &configv1.DataProcessingUnitConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: namespace},
+				Spec: configv1.DataProcessingUnitConfigSpec{
+					DpuSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "vfs", Operator: "Bogus"}},
+					},
+					VfCount: int32Ptr(8),
+				},
+			}
+			Expect(c.Create(ctx, cfg)).To(Succeed())
+			valid := newConfig("vfs", 0, int32Ptr(4), nil)
+
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cfg)})
+			Expect(err).To(HaveOccurred())
+			Expect(vfCountOf("dpu-a")).To(Equal(int32Ptr(4)))
+
+			reconcileConfig(valid)
+			Expect(valid.Status.DPUs[0].State).NotTo(Equal(configv1.VfCountStateConflicted))
+		})
+
+		It("removes the legacy config annotations from the DPUs", func() {
+			dpu := fakeDpu("dpu-a", nil)
+			dpu.Annotations = map[string]string{
+				"dpu.config.openshift.io/config-default.vfs":   "true",
+				"dpu.config.openshift.io/vf-count/default.vfs": "8",
+				"dpu.config.openshift.io/unrelated":            "kept",
+			}
+			createTestDpu(ctx, dpu)
+
+			Expect(reconciler.migrateLegacyConfigAnnotations(ctx)).To(Succeed())
+			Expect(getDpu("dpu-a").Annotations).To(Equal(map[string]string{"dpu.config.openshift.io/unrelated": "kept"}))
+		})
+
+		It("maps a DPU to all configs", func() {
+			createTestDpu(ctx, fakeDpu("dpu-a", nil))
+			first := newConfig("vfs", 0, int32Ptr(8), nil)
+			second := newConfig("other", 0, nil, nil)
+
+			Expect(reconciler.configsForDpu(ctx, getDpu("dpu-a"))).To(ContainElements(
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(first)},
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(second)},
+			))
+		})
+
+		Context("with a rollout strategy", func() {
+			BeforeEach(func() {
+				createDpuWithVfCount("dpu-c", 4)
+				createDpuWithVfCount("dpu-a", 4)
+				createDpuWithVfCount("dpu-b", 4)
+			})
+
+			It("changes one node at a time, by node name", func() {
+				cfg := newConfig("vfs", 0, int32Ptr(8), &configv1.DpuConfigRolloutStrategy{})
+
+				reconcileConfig(cfg)
+				Expect(vfCountOf("dpu-a")).To(Equal(int32Ptr(8)))
+				Expect(getDpu("dpu-a").Spec.EffectiveConfig.Drain).To(BeTrue(), "nodes are drained by default")
+				Expect(vfCountOf("dpu-b")).To(Equal(int32Ptr(4)))
+				Expect(vfCountOf("dpu-c")).To(Equal(int32Ptr(4)))
+				Expect(cfg.Status.DPUs).To(ContainElement(configv1.DataProcessingUnitConfigDpuStatus{
+					Name:    "dpu-b",
+					State:   configv1.VfCountStatePending,
+					Message: "Waiting for the rollout to reach node worker-dpu-b.",
+				}))
+
+				reconcileConfig(cfg)
+				Expect(vfCountOf("dpu-b")).To(Equal(int32Ptr(4)), "the rollout waits for the node applying the change")
+
+				reportVfCount("dpu-a", configv1.VfCountStateApplied, "", "")
+				reconcileConfig(cfg)
+				Expect(vfCountOf("dpu-b")).To(Equal(int32Ptr(8)))
+				Expect(vfCountOf("dpu-c")).To(Equal(int32Ptr(4)))
+			})
+
+			It("does not reach more nodes after a failed change", func() {
+				cfg := newConfig("vfs", 0, int32Ptr(8), &configv1.DpuConfigRolloutStrategy{})
+				reconcileConfig(cfg)
+				reportVfCount("dpu-a", configv1.VfCountStateFailed, "", "Failed to apply VF count 8: device busy")
+
+				reconcileConfig(cfg)
+				Expect(vfCountOf("dpu-b")).To(Equal(int32Ptr(4)))
+				Expect(readyCondition(cfg).Reason).To(Equal(configv1.DpuConfigReasonFailed))
+			})
+
+			It("changes up to maxUnavailable nodes", func() {
+				maxUnavailable := intstr.FromString("67%")
+				cfg := newConfig("vfs", 0, int32Ptr(8), &configv1.DpuConfigRolloutStrategy{MaxUnavailable: &maxUnavailable})
+
+				reconcileConfig(cfg)
+				Expect(vfCountOf("dpu-a")).To(Equal(int32Ptr(8)))
+				Expect(vfCountOf("dpu-b")).To(Equal(int32Ptr(8)))
+				Expect(vfCountOf("dpu-c")).To(Equal(int32Ptr(4)))
+			})
+
+			It("counts the nodes updating their firmware against maxUnavailable", func() {
+				dpu := getDpu("dpu-c")
+				dpu.Spec.Firmware = &configv1.DpuFirmwareTarget{
+					Policy:    "bf3",
+					Component: "nic",
+					Version:   "32.43.1014",
+					URL:       "https://firmware.example.com/bf3.bin",
+					Checksum:  testFirmwareChecksum,
+				}
+				Expect(c.Update(ctx, dpu)).To(Succeed())
+				cfg := newConfig("vfs", 0, int32Ptr(8), &configv1.DpuConfigRolloutStrategy{})
+
+				reconcileConfig(cfg)
+				Expect(vfCountOf("dpu-c")).To(Equal(int32Ptr(8)), "the node is already disrupted")
+				Expect(vfCountOf("dpu-a")).To(Equal(int32Ptr(4)))
+				Expect(vfCountOf("dpu-b")).To(Equal(int32Ptr(4)))
+			})
+
+			It("holds all changes while paused", func() {
+				cfg := newConfig("vfs", 0, int32Ptr(8), &configv1.DpuConfigRolloutStrategy{Paused: true})
+
+				reconcileConfig(cfg)
+				for _, name := range []string{"dpu-a", "dpu-b", "dpu-c"} {
+					Expect(vfCountOf(name)).To(Equal(int32Ptr(4)))
+				}
+				Expect(cfg.Status.DPUs[0].Message).To(Equal("Rollout is paused before node worker-dpu-a."))
+				Expect(readyCondition(cfg).Reason).To(Equal(configv1.DpuConfigReasonPaused))
+			})
+		})
+	})
 })
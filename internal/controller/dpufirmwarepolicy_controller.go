@@ -0,0 +1,391 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// DpuFirmwarePolicyReconciler reconciles a DpuFirmwarePolicy object. It sets
+// the firmware of the selected DPUs in their spec, node by node, and the
+// daemon of each node installs it.
+type DpuFirmwarePolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpufirmwarepolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpufirmwarepolicies/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpufirmwarepolicies/finalizers,verbs=update
+
+// Reconcile sets the firmware target of every DPU from the policies and
+// reports the firmware of the DPUs on the status of the requested policy.
+func (r *DpuFirmwarePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := logf.FromContext(ctx)
+
+	// The firmware of a DPU depends on all the policies selecting it, so every
+	// reconcile computes the targets again, including when a policy was deleted.
+	policyList := &configv1.DpuFirmwarePolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		logger.Error(err, "Failed to list DpuFirmwarePolicies")
+		return ctrl.Result{}, err
+	}
+	policies := policyList.Items
+	slices.SortFunc(policies, func(a, b configv1.DpuFirmwarePolicy) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	dpuList := &configv1.DataProcessingUnitList{}
+	if err := r.List(ctx, dpuList); err != nil {
+		logger.Error(err, "Failed to list DPUs")
+		return ctrl.Result{}, err
+	}
+
+	selectors := make(map[string]labels.Selector, len(policies))
+	for i := range policies {
+		selector, err := dpuFirmwarePolicySelector(&policies[i])
+		if err != nil {
+			// The policy is left out until its selector is fixed.
+			logger.Error(err, "Invalid DPU selector", "policy", policies[i].Name)
+			continue
+		}
+		selectors[policies[i].Name] = selector
+	}
+
+	targets := make([]*configv1.DpuFirmwareTarget, len(dpuList.Items))
+	for i := range dpuList.Items {
+		targets[i] = dpuFirmwareTarget(policies, selectors, &dpuList.Items[i])
+	}
+	gateFirmwareRollouts(policies, dpuList.Items, targets)
+
+	for i := range dpuList.Items {
+		dpu := &dpuList.Items[i]
+		if reflect.DeepEqual(dpu.Spec.Firmware, targets[i]) {
+			continue
+		}
+		dpu.Spec.Firmware = targets[i]
+		if err := r.Update(ctx, dpu); err != nil {
+			logger.Error(err, "Failed to update DPU firmware", "dpu", dpu.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	var policy *configv1.DpuFirmwarePolicy
+	for i := range policies {
+		if policies[i].Name == req.Name {
+			policy = &policies[i]
+		}
+	}
+	if policy == nil {
+		return ctrl.Result{}, nil
+	}
+	selector, err := dpuFirmwarePolicySelector(policy)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var outcomes []configv1.DpuFirmwarePolicyDpuStatus
+	for i := range dpuList.Items {
+		dpu := &dpuList.Items[i]
+		if dpu.Spec.IsDpuSide || !selector.Matches(labels.Set(dpu.Labels)) {
+			continue
+		}
+		if outcome, ok := dpuFirmwareOutcome(policy, dpu, targets[i]); ok {
+			outcomes = append(outcomes, outcome)
+		}
+	}
+
+	paused := policy.Spec.RolloutStrategy != nil && policy.Spec.RolloutStrategy.Paused
+	ready := dpuFirmwarePolicyReadyCondition(policy.Generation, paused, outcomes)
+	currentReady := meta.FindStatusCondition(policy.Status.Conditions, configv1.DpuFirmwarePolicyConditionReady)
+
+	statusChanged := policy.Status.ObservedGeneration != policy.Generation ||
+		!reflect.DeepEqual(policy.Status.DPUs, outcomes) ||
+		currentReady == nil || currentReady.Status != ready.Status ||
+		currentReady.Reason != ready.Reason || currentReady.Message != ready.Message ||
+		currentReady.ObservedGeneration != ready.ObservedGeneration
+	if statusChanged {
+		policy.Status.ObservedGeneration = policy.Generation
+		policy.Status.DPUs = outcomes
+		meta.SetStatusCondition(&policy.Status.Conditions, ready)
+		if err := r.Status().Update(ctx, policy); err != nil {
+			logger.Error(err, "Failed to update DpuFirmwarePolicy status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func dpuFirmwarePolicySelector(policy *configv1.DpuFirmwarePolicy) (labels.Selector, error) {
+	if policy.Spec.DpuSelector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(policy.Spec.DpuSelector)
+}
+
+// dpuModel returns the model of a DPU from its inventory, empty until the
+// daemon reported it.
+func dpuModel(dpu *configv1.DataProcessingUnit) string {
+	if dpu.Status.Inventory == nil {
+		return ""
+	}
+	return dpu.Status.Inventory.Model
+}
+
+// modelFirmware returns the firmware a policy lists for a model.
+func modelFirmware(policy *configv1.DpuFirmwarePolicy, model string) (*configv1.DpuModelFirmware, bool) {
+	for i := range policy.Spec.Firmware {
+		if model != "" && policy.Spec.Firmware[i].Model == model {
+			return &policy.Spec.Firmware[i], true
+		}
+	}
+	return nil, false
+}
+
+// dpuFirmwareTarget returns the firmware of a DPU from the first policy, by
+// name, that selects it and lists its model. Policies without a valid
+// selector are ignored. The firmware is only installed from the host side.
+func dpuFirmwareTarget(policies []configv1.DpuFirmwarePolicy, selectors map[string]labels.Selector, dpu *configv1.DataProcessingUnit) *configv1.DpuFirmwareTarget {
+	if dpu.Spec.IsDpuSide {
+		return nil
+	}
+	for i := range policies {
+		policy := &policies[i]
+		selector, ok := selectors[policy.Name]
+		if !ok || !selector.Matches(labels.Set(dpu.Labels)) {
+			continue
+		}
+		firmware, ok := modelFirmware(policy, dpuModel(dpu))
+		if !ok {
+			continue
+		}
+		component := firmware.Component
+		if component == "" {
+			component = plugin.FirmwareComponentNIC
+		}
+		return &configv1.DpuFirmwareTarget{
+			Policy:    policy.Name,
+			Component: component,
+			Version:   firmware.Version,
+			URL:       firmware.URL,
+			Checksum:  firmware.Checksum,
+			Drain:     firmwareRolloutDrain(policy.Spec.RolloutStrategy),
+		}
+	}
+	return nil
+}
+
+// firmwareRolloutDrain returns whether nodes are drained for a firmware
+// update. Unlike VF count changes, they are drained without a rollout strategy.
+func firmwareRolloutDrain(strategy *configv1.DpuConfigRolloutStrategy) bool {
+	return strategy == nil || strategy.Drain == nil || *strategy.Drain
+}
+
+// firmwareUpdating returns whether the daemon is installing the firmware
+// target of a DPU. The node of a failed update, or of firmware that runs once
+// the node reboots, is schedulable again and does not hold the rollout back;
+// the DPU reports the failure or the reboot it needs.
+func firmwareUpdating(dpu *configv1.DataProcessingUnit) bool {
+	target := dpu.Spec.Firmware
+	if target == nil {
+		return false
+	}
+	status := dpu.Status.Firmware
+	if status == nil || status.Desired != target.Version {
+		return true
+	}
+	return status.State == configv1.FirmwareStatePending && status.Phase != configv1.FirmwarePhaseRebootRequired
+}
+
+// gateFirmwareRollouts holds back the firmware updates of each policy, so that
+// at most maxUnavailable nodes are disrupted at the same time, by a firmware
+// update or a VF count change, and no node starts while the rollout is
+// paused. A DPU held back keeps its current firmware target. Nodes are picked
+// by name.
+func gateFirmwareRollouts(policies []configv1.DpuFirmwarePolicy, dpus []configv1.DataProcessingUnit, targets []*configv1.DpuFirmwareTarget) {
+	rollouts := map[string]*nodeRollout{}
+	for i := range dpus {
+		dpu, target := &dpus[i], targets[i]
+		if target == nil {
+			continue
+		}
+		r, ok := rollouts[target.Policy]
+		if !ok {
+			r = newNodeRollout(nil)
+			rollouts[target.Policy] = r
+		}
+		r.nodes[dpu.Spec.NodeName] = true
+		if !reflect.DeepEqual(dpu.Spec.Firmware, target) {
+			r.changes = append(r.changes, i)
+		}
+	}
+
+	ordered := make([]*nodeRollout, 0, len(rollouts))
+	for i := range policies {
+		r, ok := rollouts[policies[i].Name]
+		if !ok {
+			continue
+		}
+		r.strategy = policies[i].Spec.RolloutStrategy
+		if r.strategy == nil {
+			r.strategy = &configv1.DpuConfigRolloutStrategy{}
+		}
+		ordered = append(ordered, r)
+	}
+	gateNodeRollouts(ordered, dpus, func(i int) {
+		targets[i] = dpus[i].Spec.Firmware.DeepCopy()
+	})
+}
+
+// dpuFirmwareOutcome returns the firmware of a DPU selected by the policy,
+// from the firmware target the policies give it and the firmware status the
+// daemon reports. It returns false when the policy does not list the model
+// of the DPU.
+func dpuFirmwareOutcome(policy *configv1.DpuFirmwarePolicy, dpu *configv1.DataProcessingUnit, target *configv1.DpuFirmwareTarget) (configv1.DpuFirmwarePolicyDpuStatus, bool) {
+	outcome := configv1.DpuFirmwarePolicyDpuStatus{
+		Name:     dpu.Name,
+		NodeName: dpu.Spec.NodeName,
+		Model:    dpuModel(dpu),
+		State:    configv1.FirmwareStatePending,
+	}
+	if outcome.Model == "" {
+		outcome.Message = "Waiting for the inventory of the DPU."
+		return outcome, true
+	}
+	firmware, ok := modelFirmware(policy, outcome.Model)
+	if !ok {
+		return outcome, false
+	}
+	outcome.DesiredVersion = firmware.Version
+	status := dpu.Status.Firmware
+	if status != nil {
+		outcome.RunningVersion = status.Running
+	} else {
+		outcome.RunningVersion = dpu.Status.Inventory.FirmwareVersion
+	}
+
+	// The target is the one the DPU gets once the rollout reaches it.
+	if target != nil && target.Policy != policy.Name {
+		outcome.State = configv1.FirmwareStateConflicted
+		outcome.Message = fmt.Sprintf("Firmware is set by DpuFirmwarePolicy %s.", target.Policy)
+		return outcome, true
+	}
+
+	current := dpu.Spec.Firmware
+	if current == nil || current.Policy != policy.Name || current.Version != firmware.Version {
+		if policy.Spec.RolloutStrategy != nil && policy.Spec.RolloutStrategy.Paused {
+			outcome.Message = fmt.Sprintf("Rollout is paused before node %s.", dpu.Spec.NodeName)
+		} else {
+			outcome.Message = fmt.Sprintf("Waiting for the rollout to reach node %s.", dpu.Spec.NodeName)
+		}
+		return outcome, true
+	}
+
+	switch {
+	case status == nil || status.Desired != firmware.Version:
+		outcome.Message = "Waiting for the daemon to pick up the firmware."
+	case status.State == configv1.FirmwareStateFailed:
+		outcome.State = configv1.FirmwareStateFailed
+		outcome.Message = status.LastError
+	case status.Phase == configv1.FirmwarePhaseRebootRequired:
+		outcome.Phase = status.Phase
+		outcome.Message = fmt.Sprintf("Firmware %s runs once node %s is rebooted.", firmware.Version, dpu.Spec.NodeName)
+	case status.Phase != "":
+		outcome.Phase = status.Phase
+		outcome.Message = fmt.Sprintf("%s node %s for firmware %s.", status.Phase, dpu.Spec.NodeName, firmware.Version)
+	case status.State != configv1.FirmwareStateUpdated:
+		outcome.Message = fmt.Sprintf("Waiting for firmware %s to be installed.", firmware.Version)
+	default:
+		outcome.State = configv1.FirmwareStateUpdated
+	}
+	return outcome, true
+}
+
+// dpuFirmwarePolicyReadyCondition aggregates the firmware of the DPUs. The
+// reason is the worst state of any DPU: Conflicted, then Failed, then
+// Pending, which is reported as Paused while the rollout is paused.
+func dpuFirmwarePolicyReadyCondition(generation int64, paused bool, outcomes []configv1.DpuFirmwarePolicyDpuStatus) metav1.Condition {
+	counts := map[configv1.FirmwareState]int{}
+	for _, outcome := range outcomes {
+		counts[outcome.State]++
+	}
+
+	condition := metav1.Condition{
+		Type:               configv1.DpuFirmwarePolicyConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Message: fmt.Sprintf("%d updated, %d pending, %d failed, %d conflicted.",
+			counts[configv1.FirmwareStateUpdated], counts[configv1.FirmwareStatePending],
+			counts[configv1.FirmwareStateFailed], counts[configv1.FirmwareStateConflicted]),
+	}
+	switch {
+	case counts[configv1.FirmwareStateConflicted] > 0:
+		condition.Reason = configv1.DpuFirmwarePolicyReasonConflicted
+	case counts[configv1.FirmwareStateFailed] > 0:
+		condition.Reason = configv1.DpuFirmwarePolicyReasonFailed
+	case counts[configv1.FirmwareStatePending] > 0 && paused:
+		condition.Reason = configv1.DpuFirmwarePolicyReasonPaused
+	case counts[configv1.FirmwareStatePending] > 0:
+		condition.Reason = configv1.DpuFirmwarePolicyReasonPending
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = configv1.DpuFirmwarePolicyReasonUpdated
+	}
+	return condition
+}
+
+// policiesForDpu maps a DPU to all DpuFirmwarePolicies, so the DPU gets its
+// firmware target and their status follows the firmware status the daemon
+// reports on the DPU.
+func (r *DpuFirmwarePolicyReconciler) policiesForDpu(ctx context.Context, _ client.Object) []reconcile.Request {
+	policyList := &configv1.DpuFirmwarePolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		logf.FromContext(ctx).Error(err, "Failed to list DpuFirmwarePolicies")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(policyList.Items))
+	for _, policy := range policyList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&policy)})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DpuFirmwarePolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&configv1.DpuFirmwarePolicy{}).
+		Watches(&configv1.DataProcessingUnit{}, handler.EnqueueRequestsFromMapFunc(r.policiesForDpu)).
+		Named("dpufirmwarepolicy").
+		Complete(r)
+}
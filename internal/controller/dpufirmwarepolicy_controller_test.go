@@ -0,0 +1,338 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const testFirmwareChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+var _ = Describe("DpuFirmwarePolicy Controller", func() {
+	Describe("Reconcile", func() {
+		var (
+			ctx        context.Context
+			c          client.Client
+			reconciler *DpuFirmwarePolicyReconciler
+		)
+
+		firmwareTarget := func(policy, version string) *configv1.DpuFirmwareTarget {
+			return &configv1.DpuFirmwareTarget{
+				Policy:    policy,
+				Component: "nic",
+				Version:   version,
+				URL:       "https://firmware.example.com/bf3-" + version + ".bin",
+				Checksum:  testFirmwareChecksum,
+				Drain:     true,
+			}
+		}
+
+		newPolicy := func(name, version string, strategy *configv1.DpuConfigRolloutStrategy) *configv1.DpuFirmwarePolicy {
+			policy := &configv1.DpuFirmwarePolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: configv1.DpuFirmwarePolicySpec{
+					DpuSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"firmware": "managed"}},
+					Firmware: []configv1.DpuModelFirmware{{
+						Model:     "BlueField-3",
+						Component: "nic",
+						Version:   version,
+						URL:       "https://firmware.example.com/bf3-" + version + ".bin",
+						Checksum:  testFirmwareChecksum,
+					}},
+					RolloutStrategy: strategy,
+				},
+			}
+			Expect(c.Create(ctx, policy)).To(Succeed())
+			return policy
+		}
+
+		reconcilePolicy := func(policy *configv1.DpuFirmwarePolicy) {
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(policy), policy)).To(Succeed())
+		}
+
+		// firmwareDpu returns a DPU selected by the policies that reported the
+		// inventory of model, or no inventory yet if model is empty.
+		firmwareDpu := func(name, model string) *configv1.DataProcessingUnit {
+			dpu := fakeDpu(name, map[string]string{"firmware": "managed"})
+			if model != "" {
+				dpu.Status.Inventory = &configv1.DpuInventory{
+					LastUpdated:     metav1.Now(),
+					Model:           model,
+					FirmwareVersion: "32.41.1000",
+				}
+			}
+			return dpu
+		}
+
+		getDpu := func(name string) *configv1.DataProcessingUnit {
+			dpu := &configv1.DataProcessingUnit{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: name}, dpu)).To(Succeed())
+			return dpu
+		}
+
+		versionOf := func(name string) string {
+			target := getDpu(name).Spec.Firmware
+			if target == nil {
+				return ""
+			}
+			return target.Version
+		}
+
+		// reportFirmware reports the firmware target of a DPU in the given
+		// state, as the daemon does.
+		reportFirmware := func(name string, state configv1.FirmwareState, phase configv1.FirmwarePhase) {
+			dpu := getDpu(name)
+			dpu.Status.Firmware = &configv1.DpuFirmwareStatus{
+				Component: dpu.Spec.Firmware.Component,
+				Desired:   dpu.Spec.Firmware.Version,
+				State:     state,
+				Phase:     phase,
+			}
+			if state == configv1.FirmwareStateUpdated {
+				dpu.Status.Firmware.Running = dpu.Spec.Firmware.Version
+			}
+			if state == configv1.FirmwareStateFailed {
+				dpu.Status.Firmware.LastError = "checksum mismatch"
+			}
+			Expect(c.Status().Update(ctx, dpu)).To(Succeed())
+		}
+
+		outcomeOf := func(policy *configv1.DpuFirmwarePolicy, name string) configv1.DpuFirmwarePolicyDpuStatus {
+			for _, outcome := range policy.Status.DPUs {
+				if outcome.Name == name {
+					return outcome
+				}
+			}
+			Fail("no firmware status for DPU " + name)
+			return configv1.DpuFirmwarePolicyDpuStatus{}
+		}
+
+		readyCondition := func(policy *configv1.DpuFirmwarePolicy) *metav1.Condition {
+			return meta.FindStatusCondition(policy.Status.Conditions, configv1.DpuFirmwarePolicyConditionReady)
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			c = startTestEnv()
+			reconciler = &DpuFirmwarePolicyReconciler{Client: c, Scheme: scheme.Scheme}
+		})
+
+		AfterEach(func() {
+			Expect(c.DeleteAllOf(ctx, &configv1.DpuFirmwarePolicy{})).To(Succeed())
+			deleteTestDpus(ctx)
+		})
+
+		It("sets the firmware of the model of the DPU and reports when it is installed", func() {
+			createTestDpu(ctx, firmwareDpu("dpu-a", "BlueField-3"))
+			createTestDpu(ctx, firmwareDpu("dpu-b", "IPU E2100"))
+			createTestDpu(ctx, firmwareDpu("dpu-c", ""))
+			policy := newPolicy("bf3", "32.43.1014", nil)
+
+			reconcilePolicy(policy)
+			Expect(getDpu("dpu-a").Spec.Firmware).To(Equal(firmwareTarget("bf3", "32.43.1014")))
+			Expect(getDpu("dpu-b").Spec.Firmware).To(BeNil())
+			Expect(getDpu("dpu-c").Spec.Firmware).To(BeNil())
+
+			Expect(policy.Status.DPUs).To(HaveLen(2), "the policy does not list the model of dpu-b")
+			outcome := outcomeOf(policy, "dpu-a")
+			Expect(outcome.NodeName).To(Equal("worker-dpu-a"))
+			Expect(outcome.Model).To(Equal("BlueField-3"))
+			Expect(outcome.DesiredVersion).To(Equal("32.43.1014"))
+			Expect(outcome.RunningVersion).To(Equal("32.41.1000"))
+			Expect(outcome.Message).To(Equal("Waiting for the daemon to pick up the firmware."))
+			Expect(outcomeOf(policy, "dpu-c").Message).To(Equal("Waiting for the inventory of the DPU."))
+			Expect(readyCondition(policy).Reason).To(Equal(configv1.DpuFirmwarePolicyReasonPending))
+
+			reportFirmware("dpu-a", configv1.FirmwareStatePending, configv1.FirmwarePhaseRebootRequired)
+			reconcilePolicy(policy)
+			Expect(outcomeOf(policy, "dpu-a").Phase).To(Equal(configv1.FirmwarePhaseRebootRequired))
+			Expect(outcomeOf(policy, "dpu-a").Message).To(Equal("Firmware 32.43.1014 runs once node worker-dpu-a is rebooted."))
+
+			reportFirmware("dpu-a", configv1.FirmwareStateUpdated, "")
+			reconcilePolicy(policy)
+			Expect(outcomeOf(policy, "dpu-a").State).To(Equal(configv1.FirmwareStateUpdated))
+			Expect(outcomeOf(policy, "dpu-a").RunningVersion).To(Equal("32.43.1014"))
+			Expect(readyCondition(policy).Message).To(Equal("1 updated, 1 pending, 0 failed, 0 conflicted."))
+
+			Expect(c.Delete(ctx, getDpu("dpu-c"))).To(Succeed())
+			reconcilePolicy(policy)
+			Expect(policy.Status.ObservedGeneration).To(Equal(policy.Generation))
+			Expect(readyCondition(policy).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("reports a failed update", func() {
+			createTestDpu(ctx, firmwareDpu("dpu-a", "BlueField-3"))
+			policy := newPolicy("bf3", "32.43.1014", nil)
+			reconcilePolicy(policy)
+
+			reportFirmware("dpu-a", configv1.FirmwareStateFailed, "")
+			reconcilePolicy(policy)
+			Expect(outcomeOf(policy, "dpu-a").State).To(Equal(configv1.FirmwareStateFailed))
+			Expect(outcomeOf(policy, "dpu-a").Message).To(Equal("checksum mismatch"))
+			Expect(readyCondition(policy).Reason).To(Equal(configv1.DpuFirmwarePolicyReasonFailed))
+		})
+
+		It("takes the firmware from the first policy by name and reports the conflict on the others", func() {
+			createTestDpu(ctx, firmwareDpu("dpu-a", "BlueField-3"))
+			first := newPolicy("a-bf3", "32.43.1014", nil)
+			second := newPolicy("b-bf3", "32.44.1000", nil)
+
+			reconcilePolicy(second)
+			Expect(getDpu("dpu-a").Spec.Firmware.Policy).To(Equal("a-bf3"))
+			Expect(outcomeOf(second, "dpu-a").State).To(Equal(configv1.FirmwareStateConflicted))
+			Expect(outcomeOf(second, "dpu-a").Message).To(Equal("Firmware is set by DpuFirmwarePolicy a-bf3."))
+			Expect(readyCondition(second).Reason).To(Equal(configv1.DpuFirmwarePolicyReasonConflicted))
+
+			reconcilePolicy(first)
+			Expect(outcomeOf(first, "dpu-a").State).To(Equal(configv1.FirmwareStatePending))
+		})
+
+		It("leaves the DPU side alone", func() {
+			dpu := firmwareDpu("dpu-a", "BlueField-3")
+			dpu.Spec.IsDpuSide = true
+			createTestDpu(ctx, dpu)
+			policy := newPolicy("bf3", "32.43.1014", nil)
+
+			reconcilePolicy(policy)
+			Expect(getDpu("dpu-a").Spec.Firmware).To(BeNil())
+			Expect(policy.Status.DPUs).To(BeEmpty())
+		})
+
+		It("clears the firmware target of the DPUs when the policy is deleted", func() {
+			createTestDpu(ctx, firmwareDpu("dpu-a", "BlueField-3"))
+			policy := newPolicy("bf3", "32.43.1014", nil)
+			reconcilePolicy(policy)
+			Expect(versionOf("dpu-a")).To(Equal("32.43.1014"))
+
+			Expect(c.Delete(ctx, policy)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(policy)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(getDpu("dpu-a").Spec.Firmware).To(BeNil())
+		})
+
+		It("maps a DPU to all policies", func() {
+			createTestDpu(ctx, firmwareDpu("dpu-a", "BlueField-3"))
+			first := newPolicy("a-bf3", "32.43.1014", nil)
+			second := newPolicy("b-bf3", "32.44.1000", nil)
+
+			Expect(reconciler.policiesForDpu(ctx, getDpu("dpu-a"))).To(ConsistOf(
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(first)},
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(second)},
+			))
+		})
+
+		Context("when the policy moves to a new version", func() {
+			BeforeEach(func() {
+				for _, name := range []string{"dpu-c", "dpu-a", "dpu-b"} {
+					dpu := firmwareDpu(name, "BlueField-3")
+					dpu.Spec.Firmware = firmwareTarget("bf3", "32.42.1000")
+					dpu.Status.Firmware = &configv1.DpuFirmwareStatus{
+						Component: "nic",
+						Desired:   "32.42.1000",
+						Running:   "32.42.1000",
+						State:     configv1.FirmwareStateUpdated,
+					}
+					createTestDpu(ctx, dpu)
+				}
+			})
+
+			It("updates one node at a time by default, by node name", func() {
+				policy := newPolicy("bf3", "32.43.1014", nil)
+
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-a")).To(Equal("32.43.1014"))
+				Expect(versionOf("dpu-b")).To(Equal("32.42.1000"))
+				Expect(versionOf("dpu-c")).To(Equal("32.42.1000"))
+				Expect(outcomeOf(policy, "dpu-b").Message).To(Equal("Waiting for the rollout to reach node worker-dpu-b."))
+
+				reportFirmware("dpu-a", configv1.FirmwareStatePending, configv1.FirmwarePhaseDraining)
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-b")).To(Equal("32.42.1000"), "the rollout waits for the node updating its firmware")
+
+				reportFirmware("dpu-a", configv1.FirmwareStateUpdated, "")
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-b")).To(Equal("32.43.1014"))
+				Expect(versionOf("dpu-c")).To(Equal("32.42.1000"))
+			})
+
+			It("moves on from a node whose update failed", func() {
+				policy := newPolicy("bf3", "32.43.1014", nil)
+				reconcilePolicy(policy)
+
+				reportFirmware("dpu-a", configv1.FirmwareStateFailed, "")
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-b")).To(Equal("32.43.1014"))
+			})
+
+			It("moves on from a node waiting for a reboot", func() {
+				policy := newPolicy("bf3", "32.43.1014", nil)
+				reconcilePolicy(policy)
+
+				reportFirmware("dpu-a", configv1.FirmwareStatePending, configv1.FirmwarePhaseRebootRequired)
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-b")).To(Equal("32.43.1014"))
+			})
+
+			It("updates up to maxUnavailable nodes", func() {
+				maxUnavailable := intstr.FromInt32(2)
+				policy := newPolicy("bf3", "32.43.1014", &configv1.DpuConfigRolloutStrategy{MaxUnavailable: &maxUnavailable})
+
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-a")).To(Equal("32.43.1014"))
+				Expect(versionOf("dpu-b")).To(Equal("32.43.1014"))
+				Expect(versionOf("dpu-c")).To(Equal("32.42.1000"))
+			})
+
+			It("counts the nodes changing their VF count against maxUnavailable", func() {
+				dpu := getDpu("dpu-c")
+				dpu.Spec.EffectiveConfig = &configv1.DpuEffectiveConfig{VfCount: int32Ptr(8), VfCountSource: "default/vfs"}
+				Expect(c.Update(ctx, dpu)).To(Succeed())
+				policy := newPolicy("bf3", "32.43.1014", nil)
+
+				reconcilePolicy(policy)
+				Expect(versionOf("dpu-c")).To(Equal("32.43.1014"), "the node is already disrupted")
+				Expect(versionOf("dpu-a")).To(Equal("32.42.1000"))
+				Expect(versionOf("dpu-b")).To(Equal("32.42.1000"))
+			})
+
+			It("holds all updates while paused", func() {
+				policy := newPolicy("bf3", "32.43.1014", &configv1.DpuConfigRolloutStrategy{Paused: true})
+
+				reconcilePolicy(policy)
+				for _, name := range []string{"dpu-a", "dpu-b", "dpu-c"} {
+					Expect(versionOf(name)).To(Equal("32.42.1000"))
+				}
+				Expect(outcomeOf(policy, "dpu-a").Message).To(Equal("Rollout is paused before node worker-dpu-a."))
+				Expect(readyCondition(policy).Reason).To(Equal(configv1.DpuFirmwarePolicyReasonPaused))
+			})
+		})
+	})
+})
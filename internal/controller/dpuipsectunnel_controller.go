@@ -0,0 +1,571 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/platform"
+	"github.com/openshift/dpu-operator/pkg/metrics"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	dpuIPsecTunnelFinalizer = "config.openshift.io/dpuipsectunnel-finalizer"
+
+	// ipsecTunnelRetryInterval is how often a tunnel waiting for a DPU, a
+	// security plugin or its Secret is reconciled again.
+	ipsecTunnelRetryInterval = 30 * time.Second
+
+	// ipsecStatsInterval is how often the tunnel statistics are mirrored.
+	ipsecStatsInterval = time.Minute
+
+	// ipsecMinSPI is the lowest SPI that may be used, 1-255 are reserved by IANA.
+	ipsecMinSPI = 256
+)
+
+// ipsecEncryptionKeyLengths is the key length in bytes of each encryption
+// algorithm. The AES-GCM keys include the 4 byte salt.
+var ipsecEncryptionKeyLengths = map[string]int{
+	"aes-gcm-128": 20,
+	"aes-gcm-256": 36,
+	"aes-cbc-128": 16,
+	"aes-cbc-256": 32,
+}
+
+// ipsecAuthenticationKeyLengths is the key length in bytes of each integrity algorithm.
+var ipsecAuthenticationKeyLengths = map[string]int{
+	"sha256": 32,
+	"sha384": 48,
+	"sha512": 64,
+}
+
+// DpuIPsecTunnelReconciler reconciles a DpuIPsecTunnel object
+type DpuIPsecTunnelReconciler struct {
+	client.Client
+	Scheme  *runtime.Scheme
+	plugins *dpuPlugins
+	now     func() time.Time
+}
+
+func NewDpuIPsecTunnelReconciler(client client.Client, scheme *runtime.Scheme) *DpuIPsecTunnelReconciler {
+	return &DpuIPsecTunnelReconciler{
+		Client:  client,
+		Scheme:  scheme,
+		plugins: newDpuPlugins(plugin.DefaultRegistry(), platform.PluginConfigFromEnv),
+		now:     time.Now,
+	}
+}
+
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpuipsectunnels,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpuipsectunnels/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpuipsectunnels/finalizers,verbs=update
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dataprocessingunits,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
+
+// Reconcile offloads a DpuIPsecTunnel to the security plugin of the selected
+// DPU, keeps its keys in sync with the referenced Secret, rekeys it when the
+// key lifetime expires and mirrors its statistics. Key material is never logged.
+func (r *DpuIPsecTunnelReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	tunnel := &configv1.DpuIPsecTunnel{}
+	if err := r.Get(ctx, req.NamespacedName, tunnel); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !tunnel.DeletionTimestamp.IsZero() {
+		return r.handleDeletion(ctx, tunnel)
+	}
+
+	if !controllerutil.ContainsFinalizer(tunnel, dpuIPsecTunnelFinalizer) {
+		controllerutil.AddFinalizer(tunnel, dpuIPsecTunnelFinalizer)
+		if err := r.Update(ctx, tunnel); err != nil {
+			logger.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	dpuList := &configv1.DataProcessingUnitList{}
+	if err := r.List(ctx, dpuList); err != nil {
+		logger.Error(err, "Failed to list DPUs")
+		return ctrl.Result{}, err
+	}
+	r.plugins.retain(ctx, logger, dpuList.Items)
+	dpu, err := selectDpu(tunnel.Spec.DpuSelector, tunnel.Status.DpuName, dpuList.Items)
+	if err != nil {
+		logger.Error(err, "Invalid DPU selector")
+		return ctrl.Result{}, err
+	}
+	if dpu == nil {
+		logger.Info("No DPU matches the tunnel, retrying later")
+		setIPsecTunnelReady(tunnel, metav1.ConditionFalse, configv1.IPsecTunnelReasonNoMatchingDpu, "No DataProcessingUnit matches the DPU selector")
+		return ctrl.Result{RequeueAfter: ipsecTunnelRetryInterval}, r.updateStatus(ctx, tunnel)
+	}
+
+	sp, err := r.securityPluginForDPU(ctx, logger, dpu)
+	if sp == nil {
+		logger.Info("No security plugin for DPU, retrying later", "dpu", dpu.Name, "reason", err)
+		setIPsecTunnelReady(tunnel, metav1.ConditionFalse, configv1.IPsecTunnelReasonNoSecurityPlugin, err.Error())
+		return ctrl.Result{RequeueAfter: ipsecTunnelRetryInterval}, r.updateStatus(ctx, tunnel)
+	}
+	tunnel.Status.DpuName = dpu.Name
+	vendor := sp.Info().Vendor
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: tunnel.Namespace, Name: tunnel.Spec.KeySecretName}, secret); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.Error(err, "Failed to get key Secret", "secret", tunnel.Spec.KeySecretName)
+			return ctrl.Result{}, err
+		}
+		setIPsecTunnelReady(tunnel, metav1.ConditionFalse, configv1.IPsecTunnelReasonInvalidKeys,
+			fmt.Sprintf("Secret %s not found", tunnel.Spec.KeySecretName))
+		return ctrl.Result{RequeueAfter: ipsecTunnelRetryInterval}, r.updateStatus(ctx, tunnel)
+	}
+	keys, err := ipsecKeysFromSecret(secret, &tunnel.Spec)
+	if err != nil {
+		logger.Info("Invalid key Secret", "secret", secret.Name, "reason", err.Error())
+		setIPsecTunnelReady(tunnel, metav1.ConditionFalse, configv1.IPsecTunnelReasonInvalidKeys, err.Error())
+		return ctrl.Result{}, r.updateStatus(ctx, tunnel)
+	}
+
+	if tunnel.Status.TunnelID != "" {
+		if _, err := sp.GetIPsecTunnel(ctx, tunnel.Status.TunnelID); plugin.IsNotFound(err) {
+			logger.Info("Tunnel is gone from the DPU, recreating it", "tunnelID", tunnel.Status.TunnelID)
+			tunnel.Status.TunnelID = ""
+		}
+	}
+
+	rekeys, err := r.rekeysSecret(ctx, tunnel)
+	if err != nil {
+		logger.Error(err, "Failed to list the tunnels sharing the key Secret", "secret", secret.Name)
+		return ctrl.Result{}, err
+	}
+
+	now := r.now()
+	switch {
+	case tunnel.Status.TunnelID == "":
+		created, err := sp.CreateIPsecTunnel(ctx, ipsecTunnelRequest(tunnel, keys))
+		metrics.RecordSecurityOperation(vendor, "CreateIPsecTunnel", err == nil)
+		if err != nil {
+			logger.Error(err, "Failed to create IPsec tunnel", "dpu", dpu.Name)
+			return r.failTunnel(ctx, tunnel, configv1.IPsecTunnelReasonTunnelFailed, err)
+		}
+		logger.Info("Created IPsec tunnel", "dpu", dpu.Name, "tunnelID", created.ID, "spi", keys.SPI)
+		tunnel.Status.TunnelID = created.ID
+		setIPsecTunnelKeys(tunnel, keys, secret, now)
+
+	case rekeys && ipsecRekeyDue(tunnel, now):
+		newKeys, err := generateIPsecKeys(&tunnel.Spec, keys.SPI)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// Apply the new keys before storing them, so that the Secret never
+		// holds keys the tunnel does not run.
+		err = sp.UpdateIPsecKeys(ctx, tunnel.Status.TunnelID, newKeys)
+		metrics.RecordSecurityOperation(vendor, "RekeyIPsecTunnel", err == nil)
+		if err != nil {
+			logger.Error(err, "Failed to rekey IPsec tunnel", "tunnelID", tunnel.Status.TunnelID)
+			return r.failTunnel(ctx, tunnel, configv1.IPsecTunnelReasonRekeyFailed, err)
+		}
+		setIPsecKeysInSecret(secret, newKeys)
+		bumpIPsecKeyGeneration(secret)
+		if err := r.Update(ctx, secret); err != nil {
+			// Nobody else knows the new keys, so go back to those of the
+			// Secret. The rekey is still due and is retried.
+			logger.Error(err, "Failed to store the new keys, restoring the keys of the Secret", "secret", secret.Name)
+			restoreErr := sp.UpdateIPsecKeys(ctx, tunnel.Status.TunnelID, keys)
+			metrics.RecordSecurityOperation(vendor, "UpdateIPsecKeys", restoreErr == nil)
+			if restoreErr != nil {
+				logger.Error(restoreErr, "Failed to restore the keys of the Secret", "tunnelID", tunnel.Status.TunnelID)
+			}
+			return r.failTunnel(ctx, tunnel, configv1.IPsecTunnelReasonRekeyFailed,
+				fmt.Errorf("failed to store the new keys in Secret %s: %v", secret.Name, err))
+		}
+		logger.Info("Rekeyed IPsec tunnel", "tunnelID", tunnel.Status.TunnelID, "spi", newKeys.SPI,
+			"keyGeneration", ipsecKeyGeneration(secret))
+		setIPsecTunnelKeys(tunnel, newKeys, secret, now)
+
+	case secret.ResourceVersion != tunnel.Status.KeySecretVersion:
+		err := sp.UpdateIPsecKeys(ctx, tunnel.Status.TunnelID, keys)
+		metrics.RecordSecurityOperation(vendor, "UpdateIPsecKeys", err == nil)
+		if err != nil {
+			logger.Error(err, "Failed to apply the Secret keys", "tunnelID", tunnel.Status.TunnelID)
+			return r.failTunnel(ctx, tunnel, configv1.IPsecTunnelReasonRekeyFailed, err)
+		}
+		logger.Info("Applied the Secret keys to IPsec tunnel", "tunnelID", tunnel.Status.TunnelID, "spi", keys.SPI)
+		setIPsecTunnelKeys(tunnel, keys, secret, now)
+	}
+
+	stats, err := sp.GetIPsecStats(ctx, tunnel.Status.TunnelID)
+	switch {
+	case err == nil:
+		tunnel.Status.Stats = ipsecTunnelStats(stats)
+		metrics.RecordIPsecTunnelStats(tunnel.Namespace, tunnel.Name,
+			stats.BytesEncrypted, stats.BytesDecrypted, stats.PacketsEncrypted, stats.PacketsDecrypted, stats.Errors)
+	case !plugin.IsNotImplemented(err):
+		logger.Error(err, "Failed to get IPsec tunnel statistics", "tunnelID", tunnel.Status.TunnelID)
+	}
+
+	setIPsecTunnelReady(tunnel, metav1.ConditionTrue, configv1.IPsecTunnelReasonEstablished,
+		fmt.Sprintf("Tunnel to %s is offloaded to %s", tunnel.Spec.RemoteAddress, dpu.Name))
+	requeueAfter := ipsecStatsInterval
+	if rekeys {
+		requeueAfter = ipsecRequeueAfter(tunnel, now)
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, r.updateStatus(ctx, tunnel)
+}
+
+func (r *DpuIPsecTunnelReconciler) handleDeletion(ctx context.Context, tunnel *configv1.DpuIPsecTunnel) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(tunnel, dpuIPsecTunnelFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if tunnel.Status.TunnelID != "" {
+		dpu := &configv1.DataProcessingUnit{}
+		err := r.Get(ctx, types.NamespacedName{Name: tunnel.Status.DpuName}, dpu)
+		switch {
+		case apierrors.IsNotFound(err):
+			logger.Info("DPU of the tunnel is gone, nothing to clean up", "dpu", tunnel.Status.DpuName)
+		case err != nil:
+			logger.Error(err, "Failed to get DPU", "dpu", tunnel.Status.DpuName)
+			return ctrl.Result{}, err
+		default:
+			sp, err := r.securityPluginForDPU(ctx, logger, dpu)
+			if sp == nil {
+				logger.Info("No security plugin for DPU, retrying cleanup later", "dpu", dpu.Name, "reason", err)
+				return ctrl.Result{RequeueAfter: ipsecTunnelRetryInterval}, nil
+			}
+			err = sp.DeleteIPsecTunnel(ctx, tunnel.Status.TunnelID)
+			if err != nil && !plugin.IsNotFound(err) {
+				metrics.RecordSecurityOperation(sp.Info().Vendor, "DeleteIPsecTunnel", false)
+				logger.Error(err, "Failed to delete IPsec tunnel", "tunnelID", tunnel.Status.TunnelID)
+				return ctrl.Result{}, err
+			}
+			metrics.RecordSecurityOperation(sp.Info().Vendor, "DeleteIPsecTunnel", true)
+		}
+	}
+	metrics.DeleteIPsecTunnelStats(tunnel.Namespace, tunnel.Name)
+
+	controllerutil.RemoveFinalizer(tunnel, dpuIPsecTunnelFinalizer)
+	if err := r.Update(ctx, tunnel); err != nil {
+		logger.Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	logger.Info("Cleanup completed for DpuIPsecTunnel")
+	return ctrl.Result{}, nil
+}
+
+// rekeysSecret reports whether tunnel rekeys its Secret when its keys expire.
+// Of the tunnels sharing a Secret only one rekeys it, so that they do not
+// overwrite each other's keys; the others apply the keys it stores.
+func (r *DpuIPsecTunnelReconciler) rekeysSecret(ctx context.Context, tunnel *configv1.DpuIPsecTunnel) (bool, error) {
+	if !ipsecRekeyEnabled(tunnel) {
+		return false, nil
+	}
+	tunnels := &configv1.DpuIPsecTunnelList{}
+	if err := r.List(ctx, tunnels, client.InNamespace(tunnel.Namespace)); err != nil {
+		return false, err
+	}
+	return ipsecSecretRekeyer(tunnels.Items, tunnel.Spec.KeySecretName) == tunnel.Name, nil
+}
+
+// securityPluginForDPU returns the initialized security plugin managing dpu.
+// If there is none, it returns nil and an error describing why.
+func (r *DpuIPsecTunnelReconciler) securityPluginForDPU(ctx context.Context, logger logr.Logger, dpu *configv1.DataProcessingUnit) (plugin.SecurityPlugin, error) {
+	p, err := r.plugins.forDpu(ctx, logger, dpu)
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := p.(plugin.SecurityPlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s does not support security offload", p.Info().Name)
+	}
+	return sp, nil
+}
+
+func (r *DpuIPsecTunnelReconciler) failTunnel(ctx context.Context, tunnel *configv1.DpuIPsecTunnel, reason string, err error) (ctrl.Result, error) {
+	setIPsecTunnelReady(tunnel, metav1.ConditionFalse, reason, err.Error())
+	if statusErr := r.updateStatus(ctx, tunnel); statusErr != nil {
+		log.FromContext(ctx).Error(statusErr, "Failed to update DpuIPsecTunnel status")
+	}
+	return ctrl.Result{}, err
+}
+
+func (r *DpuIPsecTunnelReconciler) updateStatus(ctx context.Context, tunnel *configv1.DpuIPsecTunnel) error {
+	tunnel.Status.ObservedGeneration = tunnel.Generation
+	return r.Status().Update(ctx, tunnel)
+}
+
+func ipsecTunnelRequest(tunnel *configv1.DpuIPsecTunnel, keys *plugin.IPsecKeys) *plugin.IPsecTunnelRequest {
+	return &plugin.IPsecTunnelRequest{
+		Name:                fmt.Sprintf("%s-%s", tunnel.Namespace, tunnel.Name),
+		LocalAddress:        tunnel.Spec.LocalAddress,
+		RemoteAddress:       tunnel.Spec.RemoteAddress,
+		LocalSubnet:         tunnel.Spec.LocalSubnet,
+		RemoteSubnet:        tunnel.Spec.RemoteSubnet,
+		Protocol:            tunnel.Spec.Protocol,
+		EncryptionAlgorithm: tunnel.Spec.EncryptionAlgorithm,
+		IntegrityAlgorithm:  tunnel.Spec.IntegrityAlgorithm,
+		Keys:                keys,
+	}
+}
+
+// ipsecKeysFromSecret reads and validates the key material of the tunnel
+// from its Secret. The returned errors never contain key material.
+func ipsecKeysFromSecret(secret *corev1.Secret, spec *configv1.DpuIPsecTunnelSpec) (*plugin.IPsecKeys, error) {
+	keys := &plugin.IPsecKeys{
+		EncryptionKey:     string(secret.Data[configv1.IPsecSecretEncryptionKey]),
+		AuthenticationKey: string(secret.Data[configv1.IPsecSecretAuthenticationKey]),
+	}
+
+	if err := validateIPsecKey(configv1.IPsecSecretEncryptionKey, keys.EncryptionKey, ipsecEncryptionKeyLengths[spec.EncryptionAlgorithm]); err != nil {
+		return nil, err
+	}
+	if spec.IntegrityAlgorithm != "" {
+		if err := validateIPsecKey(configv1.IPsecSecretAuthenticationKey, keys.AuthenticationKey, ipsecAuthenticationKeyLengths[spec.IntegrityAlgorithm]); err != nil {
+			return nil, err
+		}
+	}
+
+	rawSPI := string(secret.Data[configv1.IPsecSecretSPI])
+	spi, err := strconv.ParseUint(rawSPI, 10, 32)
+	if err != nil || spi < ipsecMinSPI {
+		return nil, fmt.Errorf("%s %q invalid: must be a number in the range %d-%d", configv1.IPsecSecretSPI, rawSPI, ipsecMinSPI, uint32(1<<32-1))
+	}
+	keys.SPI = uint32(spi)
+	return keys, nil
+}
+
+func validateIPsecKey(name, key string, length int) error {
+	if key == "" {
+		return fmt.Errorf("%s is missing", name)
+	}
+	raw, err := hex.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("%s is not hex encoded", name)
+	}
+	if length != 0 && len(raw) != length {
+		return fmt.Errorf("%s must be %d bytes long, got %d", name, length, len(raw))
+	}
+	return nil
+}
+
+// generateIPsecKeys returns random keys for the algorithms of the tunnel,
+// with a random SPI different from the current one.
+func generateIPsecKeys(spec *configv1.DpuIPsecTunnelSpec, currentSPI uint32) (*plugin.IPsecKeys, error) {
+	keys := &plugin.IPsecKeys{}
+
+	encryptionKey := make([]byte, ipsecEncryptionKeyLengths[spec.EncryptionAlgorithm])
+	if _, err := rand.Read(encryptionKey); err != nil {
+		return nil, fmt.Errorf("failed to generate encryption key: %v", err)
+	}
+	keys.EncryptionKey = hex.EncodeToString(encryptionKey)
+
+	if length := ipsecAuthenticationKeyLengths[spec.IntegrityAlgorithm]; length != 0 {
+		authenticationKey := make([]byte, length)
+		if _, err := rand.Read(authenticationKey); err != nil {
+			return nil, fmt.Errorf("failed to generate authentication key: %v", err)
+		}
+		keys.AuthenticationKey = hex.EncodeToString(authenticationKey)
+	}
+
+	for keys.SPI < ipsecMinSPI || keys.SPI == currentSPI {
+		var raw [4]byte
+		if _, err := rand.Read(raw[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate SPI: %v", err)
+		}
+		keys.SPI = binary.BigEndian.Uint32(raw[:])
+	}
+	return keys, nil
+}
+
+func setIPsecKeysInSecret(secret *corev1.Secret, keys *plugin.IPsecKeys) {
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[configv1.IPsecSecretEncryptionKey] = []byte(keys.EncryptionKey)
+	secret.Data[configv1.IPsecSecretAuthenticationKey] = []byte(keys.AuthenticationKey)
+	secret.Data[configv1.IPsecSecretSPI] = []byte(strconv.FormatUint(uint64(keys.SPI), 10))
+}
+
+// ipsecKeyGeneration returns the key generation annotation of secret, or 0
+// if it has none.
+func ipsecKeyGeneration(secret *corev1.Secret) int64 {
+	generation, err := strconv.ParseInt(secret.Annotations[configv1.IPsecKeyGenerationAnnotation], 10, 64)
+	if err != nil || generation < 0 {
+		return 0
+	}
+	return generation
+}
+
+// bumpIPsecKeyGeneration increments the key generation annotation of secret.
+func bumpIPsecKeyGeneration(secret *corev1.Secret) {
+	generation := ipsecKeyGeneration(secret) + 1
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[configv1.IPsecKeyGenerationAnnotation] = strconv.FormatInt(generation, 10)
+}
+
+// setIPsecTunnelKeys records that the keys read from secret were applied at now.
+func setIPsecTunnelKeys(tunnel *configv1.DpuIPsecTunnel, keys *plugin.IPsecKeys, secret *corev1.Secret, now time.Time) {
+	applied := metav1.NewTime(now)
+	tunnel.Status.SPI = int64(keys.SPI)
+	tunnel.Status.KeyGeneration = ipsecKeyGeneration(secret)
+	tunnel.Status.KeySecretVersion = secret.ResourceVersion
+	tunnel.Status.LastRekeyTime = &applied
+}
+
+// ipsecRekeyEnabled reports whether the keys of the tunnel have a lifetime.
+func ipsecRekeyEnabled(tunnel *configv1.DpuIPsecTunnel) bool {
+	return tunnel.Spec.RekeyInterval != nil && tunnel.Spec.RekeyInterval.Duration > 0
+}
+
+// ipsecSecretRekeyer returns the name of the tunnel rekeying the Secret named
+// secretName: the first by name of the tunnels with a rekey interval taking
+// their keys from it, leaving out those being deleted. It returns "" if
+// there is none.
+func ipsecSecretRekeyer(tunnels []configv1.DpuIPsecTunnel, secretName string) string {
+	rekeyer := ""
+	for i := range tunnels {
+		tunnel := &tunnels[i]
+		if tunnel.Spec.KeySecretName != secretName || !tunnel.DeletionTimestamp.IsZero() || !ipsecRekeyEnabled(tunnel) {
+			continue
+		}
+		if rekeyer == "" || tunnel.Name < rekeyer {
+			rekeyer = tunnel.Name
+		}
+	}
+	return rekeyer
+}
+
+// ipsecRekeyDue reports whether the lifetime of the keys in use has expired.
+func ipsecRekeyDue(tunnel *configv1.DpuIPsecTunnel, now time.Time) bool {
+	if !ipsecRekeyEnabled(tunnel) || tunnel.Status.LastRekeyTime == nil {
+		return false
+	}
+	return !now.Before(tunnel.Status.LastRekeyTime.Add(tunnel.Spec.RekeyInterval.Duration))
+}
+
+// ipsecRequeueAfter returns when the tunnel has to be reconciled again, to
+// mirror its statistics or to rekey it, whichever comes first.
+func ipsecRequeueAfter(tunnel *configv1.DpuIPsecTunnel, now time.Time) time.Duration {
+	after := ipsecStatsInterval
+	if !ipsecRekeyEnabled(tunnel) || tunnel.Status.LastRekeyTime == nil {
+		return after
+	}
+	untilRekey := tunnel.Status.LastRekeyTime.Add(tunnel.Spec.RekeyInterval.Duration).Sub(now)
+	if untilRekey < time.Second {
+		untilRekey = time.Second
+	}
+	if untilRekey < after {
+		after = untilRekey
+	}
+	return after
+}
+
+func ipsecTunnelStats(stats *plugin.IPsecStats) *configv1.IPsecTunnelStats {
+	return &configv1.IPsecTunnelStats{
+		BytesEncrypted:   int64(stats.BytesEncrypted),
+		BytesDecrypted:   int64(stats.BytesDecrypted),
+		PacketsEncrypted: int64(stats.PacketsEncrypted),
+		PacketsDecrypted: int64(stats.PacketsDecrypted),
+		Errors:           int64(stats.Errors),
+	}
+}
+
+func setIPsecTunnelReady(tunnel *configv1.DpuIPsecTunnel, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&tunnel.Status.Conditions, metav1.Condition{
+		Type:               configv1.IPsecTunnelConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: tunnel.Generation,
+	})
+}
+
+// tunnelsForSecret maps a Secret event to the tunnels taking their keys from it.
+func (r *DpuIPsecTunnelReconciler) tunnelsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	tunnels := &configv1.DpuIPsecTunnelList{}
+	if err := r.List(ctx, tunnels, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list DpuIPsecTunnels")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range tunnels.Items {
+		if tunnels.Items[i].Spec.KeySecretName == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&tunnels.Items[i])})
+		}
+	}
+	return requests
+}
+
+// tunnelsForDPU maps a DataProcessingUnit event to the tunnels that may be
+// offloaded to it, so tunnels waiting for a DPU are created once it appears.
+func (r *DpuIPsecTunnelReconciler) tunnelsForDPU(ctx context.Context, obj client.Object) []reconcile.Request {
+	tunnels := &configv1.DpuIPsecTunnelList{}
+	if err := r.List(ctx, tunnels); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list DpuIPsecTunnels")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range tunnels.Items {
+		tunnel := &tunnels.Items[i]
+		if tunnel.Status.DpuName == "" || tunnel.Status.DpuName == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(tunnel)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DpuIPsecTunnelReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// The statistics mirrored into the status change on every reconcile,
+		// so status updates must not trigger another one.
+		For(&configv1.DpuIPsecTunnel{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.tunnelsForSecret)).
+		Watches(&configv1.DataProcessingUnit{}, handler.EnqueueRequestsFromMapFunc(r.tunnelsForDPU)).
+		Named("dpuipsectunnel").
+		Complete(r)
+}
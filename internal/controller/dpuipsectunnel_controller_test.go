@@ -0,0 +1,500 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	testEncryptionKey     = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20212223"
+	testAuthenticationKey = "a0a1a2a3a4a5a6a7a8a9aaabacadaeafb0b1b2b3b4b5b6b7b8b9babbbcbdbebf"
+)
+
+// fakeSecurityPlugin keeps IPsec tunnels and their keys in memory.
+type fakeSecurityPlugin struct {
+	requests  map[string]*plugin.IPsecTunnelRequest
+	keys      map[string]plugin.IPsecKeys
+	updateErr error
+}
+
+func newFakeSecurityPlugin() *fakeSecurityPlugin {
+	return &fakeSecurityPlugin{
+		requests: map[string]*plugin.IPsecTunnelRequest{},
+		keys:     map[string]plugin.IPsecKeys{},
+	}
+}
+
+func (f *fakeSecurityPlugin) Info() plugin.PluginInfo {
+	return plugin.PluginInfo{Name: "fake-security", Vendor: "fake"}
+}
+func (f *fakeSecurityPlugin) Initialize(context.Context, plugin.PluginConfig) error { return nil }
+func (f *fakeSecurityPlugin) Shutdown(context.Context) error                        { return nil }
+func (f *fakeSecurityPlugin) HealthCheck(context.Context) error                     { return nil }
+func (f *fakeSecurityPlugin) DiscoverDevices(context.Context) ([]plugin.Device, error) {
+	return nil, nil
+}
+func (f *fakeSecurityPlugin) GetInventory(context.Context, string) (*plugin.InventoryResponse, error) {
+	return nil, plugin.ErrNotImplemented
+}
+
+// NewInstance returns the plugin itself, so that the specs see the tunnels
+// of all DPUs.
+func (f *fakeSecurityPlugin) NewInstance() plugin.Plugin { return f }
+
+func (f *fakeSecurityPlugin) CreateIPsecTunnel(_ context.Context, req *plugin.IPsecTunnelRequest) (*plugin.IPsecTunnel, error) {
+	id := "ipsecTunnels/" + req.Name
+	f.requests[id] = req
+	f.keys[id] = *req.Keys
+	return &plugin.IPsecTunnel{ID: id, Name: req.Name}, nil
+}
+
+func (f *fakeSecurityPlugin) DeleteIPsecTunnel(_ context.Context, id string) error {
+	if _, ok := f.keys[id]; !ok {
+		return plugin.ErrResourceNotFound
+	}
+	delete(f.requests, id)
+	delete(f.keys, id)
+	return nil
+}
+
+func (f *fakeSecurityPlugin) GetIPsecTunnel(_ context.Context, id string) (*plugin.IPsecTunnel, error) {
+	if _, ok := f.keys[id]; !ok {
+		return nil, plugin.ErrResourceNotFound
+	}
+	return &plugin.IPsecTunnel{ID: id}, nil
+}
+
+func (f *fakeSecurityPlugin) ListIPsecTunnels(context.Context) ([]*plugin.IPsecTunnel, error) {
+	return nil, nil
+}
+
+func (f *fakeSecurityPlugin) UpdateIPsecKeys(_ context.Context, id string, keys *plugin.IPsecKeys) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	if _, ok := f.keys[id]; !ok {
+		return plugin.ErrResourceNotFound
+	}
+	f.keys[id] = *keys
+	return nil
+}
+
+func (f *fakeSecurityPlugin) GetIPsecStats(context.Context, string) (*plugin.IPsecStats, error) {
+	return &plugin.IPsecStats{BytesEncrypted: 2048, PacketsEncrypted: 16}, nil
+}
+
+func ipsecKeySecret(namespace, name, encryptionKey, authenticationKey, spi string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data: map[string][]byte{
+			configv1.IPsecSecretEncryptionKey:     []byte(encryptionKey),
+			configv1.IPsecSecretAuthenticationKey: []byte(authenticationKey),
+			configv1.IPsecSecretSPI:               []byte(spi),
+		},
+	}
+}
+
+var _ = Describe("DpuIPsecTunnel controller", func() {
+	Describe("Reconcile", func() {
+		var (
+			ctx        context.Context
+			c          client.Client
+			namespace  string
+			sp         *fakeSecurityPlugin
+			reconciler *DpuIPsecTunnelReconciler
+			clock      time.Time
+			tunnels    []*configv1.DpuIPsecTunnel
+		)
+
+		newTunnel := func(name string, rekeyInterval time.Duration) *configv1.DpuIPsecTunnel {
+			tunnel := &configv1.DpuIPsecTunnel{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: configv1.DpuIPsecTunnelSpec{
+					DpuSelector:        &metav1.LabelSelector{MatchLabels: map[string]string{"ipsec": "enabled"}},
+					LocalAddress:       "192.0.2.1",
+					RemoteAddress:      "198.51.100.1",
+					LocalSubnet:        "10.0.0.0/24",
+					RemoteSubnet:       "10.1.0.0/24",
+					IntegrityAlgorithm: "sha256",
+					KeySecretName:      "site-b-keys",
+				},
+			}
+			if rekeyInterval > 0 {
+				tunnel.Spec.RekeyInterval = &metav1.Duration{Duration: rekeyInterval}
+			}
+			Expect(c.Create(ctx, tunnel)).To(Succeed())
+			tunnels = append(tunnels, tunnel)
+			return tunnel
+		}
+
+		reconcileTunnel := func(tunnel *configv1.DpuIPsecTunnel) (ctrl.Result, error) {
+			return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tunnel)})
+		}
+
+		// establish reconciles a new tunnel until it is offloaded.
+		establish := func(tunnel *configv1.DpuIPsecTunnel) ctrl.Result {
+			result, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue(), "the first reconcile adds the finalizer")
+			result, err = reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)).To(Succeed())
+			Expect(tunnel.Status.TunnelID).NotTo(BeEmpty())
+			return result
+		}
+
+		getSecret := func() *corev1.Secret {
+			secret := &corev1.Secret{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "site-b-keys"}, secret)).To(Succeed())
+			return secret
+		}
+
+		readyCondition := func(tunnel *configv1.DpuIPsecTunnel) *metav1.Condition {
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)).To(Succeed())
+			return meta.FindStatusCondition(tunnel.Status.Conditions, configv1.IPsecTunnelConditionReady)
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			c = startTestEnv()
+			namespace = createTestNamespace(ctx)
+			sp = newFakeSecurityPlugin()
+			clock = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			reconciler = NewDpuIPsecTunnelReconciler(c, scheme.Scheme)
+			reconciler.plugins = fakeDpuPlugins(sp)
+			reconciler.now = func() time.Time { return clock }
+			tunnels = nil
+
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"ipsec": "enabled"}))
+			Expect(c.Create(ctx, ipsecKeySecret(namespace, "site-b-keys", testEncryptionKey, testAuthenticationKey, "4096"))).To(Succeed())
+		})
+
+		AfterEach(func() {
+			for _, tunnel := range tunnels {
+				forceDelete(ctx, tunnel)
+			}
+			deleteTestDpus(ctx)
+		})
+
+		It("offloads the tunnel with the keys of its Secret", func() {
+			tunnel := newTunnel("site-b", 0)
+			result := establish(tunnel)
+			Expect(result.RequeueAfter).To(Equal(ipsecStatsInterval))
+
+			Expect(tunnel.Finalizers).To(ContainElement(dpuIPsecTunnelFinalizer))
+			Expect(tunnel.Status.DpuName).To(Equal("dpu-a"))
+			Expect(tunnel.Status.SPI).To(Equal(int64(4096)))
+			Expect(tunnel.Status.KeySecretVersion).To(Equal(getSecret().ResourceVersion))
+			Expect(tunnel.Status.Stats.BytesEncrypted).To(Equal(int64(2048)))
+			Expect(tunnel.Status.ObservedGeneration).To(Equal(tunnel.Generation))
+
+			request := sp.requests[tunnel.Status.TunnelID]
+			Expect(request.Name).To(Equal(namespace + "-site-b"))
+			Expect(request.Protocol).To(Equal("ESP"), "the CRD defaults the protocol")
+			Expect(request.EncryptionAlgorithm).To(Equal("aes-gcm-256"), "the CRD defaults the encryption algorithm")
+			Expect(request.RemoteSubnet).To(Equal("10.1.0.0/24"))
+			Expect(sp.keys[tunnel.Status.TunnelID].EncryptionKey).To(Equal(testEncryptionKey))
+
+			ready := readyCondition(tunnel)
+			Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+			Expect(ready.Reason).To(Equal(configv1.IPsecTunnelReasonEstablished))
+			Expect(ready.Message).NotTo(ContainSubstring(testEncryptionKey))
+		})
+
+		It("waits for a DPU matching the selector", func() {
+			deleteTestDpus(ctx)
+			tunnel := newTunnel("site-b", 0)
+			_, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(ipsecTunnelRetryInterval))
+
+			ready := readyCondition(tunnel)
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(configv1.IPsecTunnelReasonNoMatchingDpu))
+			Expect(sp.keys).To(BeEmpty())
+		})
+
+		It("waits for the DPU to report an OPI endpoint", func() {
+			deleteTestDpus(ctx)
+			dpu := fakeDpu("dpu-a", map[string]string{"ipsec": "enabled"})
+			dpu.Status.OpiEndpoint = ""
+			createTestDpu(ctx, dpu)
+
+			tunnel := newTunnel("site-b", 0)
+			_, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(ipsecTunnelRetryInterval))
+
+			ready := readyCondition(tunnel)
+			Expect(ready.Reason).To(Equal(configv1.IPsecTunnelReasonNoSecurityPlugin))
+			Expect(ready.Message).To(ContainSubstring("reports no OPI endpoint"))
+		})
+
+		It("rejects invalid key material without revealing it", func() {
+			secret := getSecret()
+			secret.Data[configv1.IPsecSecretEncryptionKey] = []byte(testEncryptionKey[:64])
+			Expect(c.Update(ctx, secret)).To(Succeed())
+
+			tunnel := newTunnel("site-b", 0)
+			_, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+
+			ready := readyCondition(tunnel)
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(configv1.IPsecTunnelReasonInvalidKeys))
+			Expect(ready.Message).To(ContainSubstring("encryptionKey must be 36 bytes long"))
+			Expect(ready.Message).NotTo(ContainSubstring(testEncryptionKey[:64]))
+			Expect(sp.keys).To(BeEmpty())
+		})
+
+		It("applies the keys of the Secret when it changes", func() {
+			tunnel := newTunnel("site-b", 0)
+			establish(tunnel)
+
+			newKeys, err := generateIPsecKeys(&tunnel.Spec, 4096)
+			Expect(err).NotTo(HaveOccurred())
+			secret := getSecret()
+			setIPsecKeysInSecret(secret, newKeys)
+			Expect(c.Update(ctx, secret)).To(Succeed())
+
+			_, err = reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sp.keys[tunnel.Status.TunnelID]).To(Equal(*newKeys))
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)).To(Succeed())
+			Expect(tunnel.Status.SPI).To(Equal(int64(newKeys.SPI)))
+			Expect(tunnel.Status.KeySecretVersion).To(Equal(secret.ResourceVersion))
+		})
+
+		It("maps a Secret to the tunnels taking their keys from it", func() {
+			tunnel := newTunnel("site-b", 0)
+			other := newTunnel("site-c", 0)
+			other.Spec.KeySecretName = "site-c-keys"
+			Expect(c.Update(ctx, other)).To(Succeed())
+
+			requests := reconciler.tunnelsForSecret(ctx, getSecret())
+			Expect(requests).To(ConsistOf(ctrl.Request{NamespacedName: client.ObjectKeyFromObject(tunnel)}))
+		})
+
+		Context("with a rekey interval", func() {
+			It("rekeys the tunnel and then stores the keys in the Secret once they expire", func() {
+				tunnel := newTunnel("site-b", time.Hour)
+				result := establish(tunnel)
+				Expect(result.RequeueAfter).To(Equal(ipsecStatsInterval))
+
+				clock = clock.Add(59*time.Minute + 30*time.Second)
+				result, err := reconcileTunnel(tunnel)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(30*time.Second), "the rekey comes before the statistics")
+				Expect(sp.keys[tunnel.Status.TunnelID].SPI).To(Equal(uint32(4096)))
+
+				clock = clock.Add(30 * time.Second)
+				_, err = reconcileTunnel(tunnel)
+				Expect(err).NotTo(HaveOccurred())
+
+				secret := getSecret()
+				stored, err := ipsecKeysFromSecret(secret, &tunnel.Spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.SPI).NotTo(Equal(uint32(4096)))
+				Expect(sp.keys[tunnel.Status.TunnelID]).To(Equal(*stored))
+				Expect(secret.Annotations).To(HaveKeyWithValue(configv1.IPsecKeyGenerationAnnotation, "1"))
+
+				Expect(c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)).To(Succeed())
+				Expect(tunnel.Status.SPI).To(Equal(int64(stored.SPI)))
+				Expect(tunnel.Status.KeyGeneration).To(Equal(int64(1)))
+				Expect(tunnel.Status.KeySecretVersion).To(Equal(secret.ResourceVersion))
+				Expect(tunnel.Status.LastRekeyTime.Time).To(BeTemporally("==", clock))
+			})
+
+			It("keeps the Secret when the plugin fails to rekey", func() {
+				tunnel := newTunnel("site-b", time.Hour)
+				establish(tunnel)
+				before := getSecret()
+
+				sp.updateErr = plugin.ErrOperationFailed
+				clock = clock.Add(time.Hour)
+				_, err := reconcileTunnel(tunnel)
+				Expect(err).To(MatchError(plugin.ErrOperationFailed))
+
+				Expect(getSecret().ResourceVersion).To(Equal(before.ResourceVersion))
+				Expect(sp.keys[tunnel.Status.TunnelID].SPI).To(Equal(uint32(4096)))
+				Expect(readyCondition(tunnel).Reason).To(Equal(configv1.IPsecTunnelReasonRekeyFailed))
+			})
+
+			It("goes back to the keys of the Secret when it cannot store the new ones", func() {
+				tunnel := newTunnel("site-b", time.Hour)
+				establish(tunnel)
+				secret := getSecret()
+				immutable := true
+				secret.Immutable = &immutable
+				Expect(c.Update(ctx, secret)).To(Succeed())
+
+				clock = clock.Add(time.Hour)
+				_, err := reconcileTunnel(tunnel)
+				Expect(err).To(MatchError(ContainSubstring("failed to store the new keys in Secret site-b-keys")))
+
+				Expect(sp.keys[tunnel.Status.TunnelID].SPI).To(Equal(uint32(4096)))
+				Expect(sp.keys[tunnel.Status.TunnelID].EncryptionKey).To(Equal(testEncryptionKey))
+				ready := readyCondition(tunnel)
+				Expect(ready.Reason).To(Equal(configv1.IPsecTunnelReasonRekeyFailed))
+				Expect(ready.Message).NotTo(ContainSubstring(testEncryptionKey))
+			})
+
+			It("lets only the first tunnel by name rekey a shared Secret", func() {
+				first := newTunnel("site-b", time.Hour)
+				second := newTunnel("site-c", time.Hour)
+				establish(first)
+				result := establish(second)
+				Expect(result.RequeueAfter).To(Equal(ipsecStatsInterval))
+
+				clock = clock.Add(time.Hour)
+				result, err := reconcileTunnel(second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(ipsecStatsInterval), "the tunnel does not wait for a rekey it does not do")
+				Expect(getSecret().Annotations).NotTo(HaveKey(configv1.IPsecKeyGenerationAnnotation))
+
+				_, err = reconcileTunnel(first)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = reconcileTunnel(second)
+				Expect(err).NotTo(HaveOccurred())
+
+				stored, err := ipsecKeysFromSecret(getSecret(), &first.Spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sp.keys[first.Status.TunnelID]).To(Equal(*stored))
+				Expect(c.Get(ctx, client.ObjectKeyFromObject(second), second)).To(Succeed())
+				Expect(sp.keys[second.Status.TunnelID]).To(Equal(*stored))
+				Expect(second.Status.KeyGeneration).To(Equal(int64(1)))
+			})
+		})
+
+		It("recreates a tunnel that is gone from the DPU", func() {
+			tunnel := newTunnel("site-b", 0)
+			establish(tunnel)
+			Expect(sp.DeleteIPsecTunnel(ctx, tunnel.Status.TunnelID)).To(Succeed())
+
+			_, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)).To(Succeed())
+			Expect(sp.keys).To(HaveKey(tunnel.Status.TunnelID))
+		})
+
+		It("removes the tunnel from the DPU when it is deleted", func() {
+			tunnel := newTunnel("site-b", 0)
+			establish(tunnel)
+			tunnelID := tunnel.Status.TunnelID
+
+			Expect(c.Delete(ctx, tunnel)).To(Succeed())
+			_, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sp.keys).NotTo(HaveKey(tunnelID))
+			err = c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("releases a tunnel whose DPU is gone", func() {
+			tunnel := newTunnel("site-b", 0)
+			establish(tunnel)
+			deleteTestDpus(ctx)
+
+			Expect(c.Delete(ctx, tunnel)).To(Succeed())
+			_, err := reconcileTunnel(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			err = c.Get(ctx, client.ObjectKeyFromObject(tunnel), tunnel)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("ipsecKeysFromSecret", func() {
+		spec := &configv1.DpuIPsecTunnelSpec{EncryptionAlgorithm: "aes-gcm-256", IntegrityAlgorithm: "sha256"}
+
+		It("reads the keys and SPI", func() {
+			keys, err := ipsecKeysFromSecret(ipsecKeySecret("default", "keys", testEncryptionKey, testAuthenticationKey, "4096"), spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys.EncryptionKey).To(Equal(testEncryptionKey))
+			Expect(keys.AuthenticationKey).To(Equal(testAuthenticationKey))
+			Expect(keys.SPI).To(Equal(uint32(4096)))
+		})
+
+		It("does not need an authentication key without an integrity algorithm", func() {
+			_, err := ipsecKeysFromSecret(ipsecKeySecret("default", "keys", testEncryptionKey, "", "4096"),
+				&configv1.DpuIPsecTunnelSpec{EncryptionAlgorithm: "aes-gcm-256"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		DescribeTable("rejects invalid key material without revealing it",
+			func(encryptionKey, authenticationKey, spi, message string) {
+				_, err := ipsecKeysFromSecret(ipsecKeySecret("default", "keys", encryptionKey, authenticationKey, spi), spec)
+				Expect(err).To(MatchError(ContainSubstring(message)))
+				for _, key := range []string{encryptionKey, authenticationKey} {
+					if key != "" {
+						Expect(err.Error()).NotTo(ContainSubstring(key))
+					}
+				}
+			},
+			Entry("missing encryption key", "", testAuthenticationKey, "4096", "encryptionKey is missing"),
+			Entry("encryption key not hex", "zz"+testEncryptionKey[2:], testAuthenticationKey, "4096", "encryptionKey is not hex encoded"),
+			Entry("encryption key too short", testEncryptionKey[:64], testAuthenticationKey, "4096", "encryptionKey must be 36 bytes long"),
+			Entry("missing authentication key", testEncryptionKey, "", "4096", "authenticationKey is missing"),
+			Entry("reserved SPI", testEncryptionKey, testAuthenticationKey, "255", "spi \"255\" invalid"),
+			Entry("SPI not a number", testEncryptionKey, testAuthenticationKey, "abc", "spi \"abc\" invalid"),
+		)
+	})
+
+	Describe("generateIPsecKeys", func() {
+		It("generates keys the Secret validation accepts, with a new SPI", func() {
+			spec := &configv1.DpuIPsecTunnelSpec{EncryptionAlgorithm: "aes-gcm-256", IntegrityAlgorithm: "sha256"}
+			keys, err := generateIPsecKeys(spec, 4096)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys.SPI).NotTo(Equal(uint32(4096)))
+			Expect(keys.SPI).To(BeNumerically(">=", ipsecMinSPI))
+
+			secret := ipsecKeySecret("default", "keys", "", "", "")
+			setIPsecKeysInSecret(secret, keys)
+			read, err := ipsecKeysFromSecret(secret, spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read).To(Equal(keys))
+		})
+
+		It("generates no authentication key without an integrity algorithm", func() {
+			keys, err := generateIPsecKeys(&configv1.DpuIPsecTunnelSpec{EncryptionAlgorithm: "aes-gcm-128"}, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys.EncryptionKey).To(HaveLen(40))
+			Expect(keys.AuthenticationKey).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,357 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/platform"
+	"github.com/openshift/dpu-operator/pkg/metrics"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	dpuNvmeVolumeFinalizer = "config.openshift.io/dpunvmevolume-finalizer"
+
+	// nvmeVolumeRetryInterval is how often a volume waiting for a DPU or a
+	// storage plugin is reconciled again.
+	nvmeVolumeRetryInterval = 30 * time.Second
+)
+
+// DpuNvmeVolumeReconciler reconciles a DpuNvmeVolume object
+type DpuNvmeVolumeReconciler struct {
+	client.Client
+	Scheme  *runtime.Scheme
+	plugins *dpuPlugins
+}
+
+func NewDpuNvmeVolumeReconciler(client client.Client, scheme *runtime.Scheme) *DpuNvmeVolumeReconciler {
+	return &DpuNvmeVolumeReconciler{
+		Client:  client,
+		Scheme:  scheme,
+		plugins: newDpuPlugins(plugin.DefaultRegistry(), platform.PluginConfigFromEnv),
+	}
+}
+
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpunvmevolumes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpunvmevolumes/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dpunvmevolumes/finalizers,verbs=update
+// +kubebuilder:rbac:groups=config.openshift.io,resources=dataprocessingunits,verbs=get;list;watch
+
+// Reconcile provisions the NVMe subsystem, controller and namespace of a
+// DpuNvmeVolume through the storage plugin of the selected DPU, and removes
+// them again when the volume is deleted.
+func (r *DpuNvmeVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	vol := &configv1.DpuNvmeVolume{}
+	if err := r.Get(ctx, req.NamespacedName, vol); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !vol.DeletionTimestamp.IsZero() {
+		return r.handleDeletion(ctx, vol)
+	}
+
+	if !controllerutil.ContainsFinalizer(vol, dpuNvmeVolumeFinalizer) {
+		controllerutil.AddFinalizer(vol, dpuNvmeVolumeFinalizer)
+		if err := r.Update(ctx, vol); err != nil {
+			logger.Error(err, "Failed to add finalizer")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	dpuList := &configv1.DataProcessingUnitList{}
+	if err := r.List(ctx, dpuList); err != nil {
+		logger.Error(err, "Failed to list DPUs")
+		return ctrl.Result{}, err
+	}
+	r.plugins.retain(ctx, logger, dpuList.Items)
+	dpu, err := selectDpu(vol.Spec.DpuSelector, vol.Status.DpuName, dpuList.Items)
+	if err != nil {
+		logger.Error(err, "Invalid DPU selector")
+		return ctrl.Result{}, err
+	}
+	if dpu == nil {
+		logger.Info("No DPU matches the volume, retrying later")
+		setNvmeVolumeReady(vol, metav1.ConditionFalse, configv1.NvmeVolumeReasonNoMatchingDpu, "No DataProcessingUnit matches the DPU selector")
+		return ctrl.Result{RequeueAfter: nvmeVolumeRetryInterval}, r.updateStatus(ctx, vol)
+	}
+
+	sp, err := r.storagePluginForDPU(ctx, logger, dpu)
+	if sp == nil {
+		logger.Info("No storage plugin for DPU, retrying later", "dpu", dpu.Name, "reason", err)
+		setNvmeVolumeReady(vol, metav1.ConditionFalse, configv1.NvmeVolumeReasonNoStoragePlugin, err.Error())
+		return ctrl.Result{RequeueAfter: nvmeVolumeRetryInterval}, r.updateStatus(ctx, vol)
+	}
+
+	vendor := sp.Info().Vendor
+	vol.Status.DpuName = dpu.Name
+	provisioned := vol.Status.NamespaceID != ""
+	err = provisionNvmeVolume(ctx, sp, vol)
+	metrics.RecordStorageOperation(vendor, "ProvisionVolume", err == nil)
+	if err != nil {
+		logger.Error(err, "Failed to provision NVMe volume", "dpu", dpu.Name)
+		// Do not leave a half provisioned volume behind, but never tear down
+		// one the host already uses.
+		if !provisioned {
+			if releaseErr := r.releaseNvmeVolume(ctx, sp, vol); releaseErr != nil {
+				logger.Error(releaseErr, "Failed to release partially provisioned NVMe volume", "dpu", dpu.Name)
+			}
+		}
+		if plugin.IsNotImplemented(err) {
+			setNvmeVolumeReady(vol, metav1.ConditionFalse, configv1.NvmeVolumeReasonNotSupported, err.Error())
+			return ctrl.Result{}, r.updateStatus(ctx, vol)
+		}
+		setNvmeVolumeReady(vol, metav1.ConditionFalse, configv1.NvmeVolumeReasonProvisioningFailed, err.Error())
+		if statusErr := r.updateStatus(ctx, vol); statusErr != nil {
+			logger.Error(statusErr, "Failed to update DpuNvmeVolume status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	setNvmeVolumeReady(vol, metav1.ConditionTrue, configv1.NvmeVolumeReasonProvisioned,
+		fmt.Sprintf("NVMe namespace %d is exposed on %s", vol.Spec.NSID, vol.Status.PCIeFunction))
+	return ctrl.Result{}, r.updateStatus(ctx, vol)
+}
+
+func (r *DpuNvmeVolumeReconciler) handleDeletion(ctx context.Context, vol *configv1.DpuNvmeVolume) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(vol, dpuNvmeVolumeFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if vol.Status.DpuName != "" {
+		dpu := &configv1.DataProcessingUnit{}
+		err := r.Get(ctx, types.NamespacedName{Name: vol.Status.DpuName}, dpu)
+		switch {
+		case apierrors.IsNotFound(err):
+			logger.Info("DPU of the volume is gone, nothing to clean up", "dpu", vol.Status.DpuName)
+		case err != nil:
+			logger.Error(err, "Failed to get DPU", "dpu", vol.Status.DpuName)
+			return ctrl.Result{}, err
+		default:
+			sp, err := r.storagePluginForDPU(ctx, logger, dpu)
+			if sp == nil {
+				// Keep the finalizer: the DPU still exposes the volume to the host.
+				logger.Info("No storage plugin for DPU, retrying cleanup later", "dpu", dpu.Name, "reason", err)
+				return ctrl.Result{RequeueAfter: nvmeVolumeRetryInterval}, nil
+			}
+
+			volumes := &configv1.DpuNvmeVolumeList{}
+			if err := r.List(ctx, volumes); err != nil {
+				logger.Error(err, "Failed to list DpuNvmeVolumes")
+				return ctrl.Result{}, err
+			}
+			controllerShared, subsystemShared := sharedNvmeVolumeResources(vol, volumes.Items)
+
+			err = deprovisionNvmeVolume(ctx, sp, vol, controllerShared, subsystemShared)
+			metrics.RecordStorageOperation(sp.Info().Vendor, "DeprovisionVolume", err == nil)
+			if err != nil {
+				logger.Error(err, "Failed to deprovision NVMe volume", "dpu", dpu.Name)
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(vol, dpuNvmeVolumeFinalizer)
+	if err := r.Update(ctx, vol); err != nil {
+		logger.Error(err, "Failed to remove finalizer")
+		return ctrl.Result{}, err
+	}
+	logger.Info("Cleanup completed for DpuNvmeVolume")
+	return ctrl.Result{}, nil
+}
+
+// storagePluginForDPU returns the initialized storage plugin managing dpu.
+// If there is none, it returns nil and an error describing why.
+func (r *DpuNvmeVolumeReconciler) storagePluginForDPU(ctx context.Context, logger logr.Logger, dpu *configv1.DataProcessingUnit) (plugin.StoragePlugin, error) {
+	p, err := r.plugins.forDpu(ctx, logger, dpu)
+	if err != nil {
+		return nil, err
+	}
+	sp, ok := p.(plugin.StoragePlugin)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s does not support storage", p.Info().Name)
+	}
+	return sp, nil
+}
+
+// releaseNvmeVolume removes the resources a failed provisioning of vol
+// created, except the subsystem and controller other volumes use, and
+// clears their IDs from its status.
+func (r *DpuNvmeVolumeReconciler) releaseNvmeVolume(ctx context.Context, sp plugin.StoragePlugin, vol *configv1.DpuNvmeVolume) error {
+	volumes := &configv1.DpuNvmeVolumeList{}
+	if err := r.List(ctx, volumes); err != nil {
+		return err
+	}
+	controllerShared, subsystemShared := sharedNvmeVolumeResources(vol, volumes.Items)
+	if err := deprovisionNvmeVolume(ctx, sp, vol, controllerShared, subsystemShared); err != nil {
+		return err
+	}
+	vol.Status.SubsystemID = ""
+	vol.Status.ControllerID = ""
+	vol.Status.NamespaceID = ""
+	vol.Status.PCIeFunction = ""
+	return nil
+}
+
+func (r *DpuNvmeVolumeReconciler) updateStatus(ctx context.Context, vol *configv1.DpuNvmeVolume) error {
+	vol.Status.ObservedGeneration = vol.Generation
+	return r.Status().Update(ctx, vol)
+}
+
+// provisionNvmeVolume creates the subsystem, controller and namespace of
+// the volume and records their IDs in its status. The storage plugins
+// return the existing resources on repeated calls.
+func provisionNvmeVolume(ctx context.Context, sp plugin.StoragePlugin, vol *configv1.DpuNvmeVolume) error {
+	subsystem, err := sp.CreateNVMeSubsystem(ctx, &plugin.NVMeSubsystemRequest{
+		NQN: vol.Spec.SubsystemNQN,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create NVMe subsystem %s: %w", vol.Spec.SubsystemNQN, err)
+	}
+	vol.Status.SubsystemID = subsystem.ID
+
+	controller, err := sp.CreateNVMeController(ctx, &plugin.NVMeControllerRequest{
+		SubsystemID: subsystem.ID,
+		PCIeAddress: vol.Spec.PCIeFunction,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create NVMe controller on %s: %w", vol.Spec.PCIeFunction, err)
+	}
+	vol.Status.ControllerID = controller.ID
+	vol.Status.PCIeFunction = controller.PCIeAddress
+	if vol.Status.PCIeFunction == "" {
+		vol.Status.PCIeFunction = vol.Spec.PCIeFunction
+	}
+
+	namespace, err := sp.CreateNVMeNamespace(ctx, &plugin.NVMeNamespaceRequest{
+		SubsystemID: subsystem.ID,
+		NSID:        int(vol.Spec.NSID),
+		Size:        uint64(vol.Spec.Size.Value()),
+		BlockSize:   int(vol.Spec.BlockSize),
+		Target: &plugin.NVMeOFTarget{
+			Transport: vol.Spec.Target.Transport,
+			Address:   vol.Spec.Target.Address,
+			Port:      int(vol.Spec.Target.Port),
+			NQN:       vol.Spec.Target.NQN,
+			HostNQN:   vol.Spec.Target.HostNQN,
+			NSID:      int(vol.Spec.Target.NSID),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create NVMe namespace %d: %w", vol.Spec.NSID, err)
+	}
+	vol.Status.NamespaceID = namespace.ID
+	return nil
+}
+
+// deprovisionNvmeVolume removes the namespace of the volume, and its
+// controller and subsystem unless other volumes still use them. Resources
+// that are already gone are ignored.
+func deprovisionNvmeVolume(ctx context.Context, sp plugin.StoragePlugin, vol *configv1.DpuNvmeVolume, controllerShared, subsystemShared bool) error {
+	if vol.Status.NamespaceID != "" {
+		if err := sp.DeleteNVMeNamespace(ctx, vol.Status.NamespaceID); err != nil && !plugin.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NVMe namespace %s: %w", vol.Status.NamespaceID, err)
+		}
+	}
+	if vol.Status.ControllerID != "" && !controllerShared {
+		if err := sp.DeleteNVMeController(ctx, vol.Status.ControllerID); err != nil && !plugin.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NVMe controller %s: %w", vol.Status.ControllerID, err)
+		}
+	}
+	if vol.Status.SubsystemID != "" && !subsystemShared {
+		if err := sp.DeleteNVMeSubsystem(ctx, vol.Status.SubsystemID); err != nil && !plugin.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NVMe subsystem %s: %w", vol.Status.SubsystemID, err)
+		}
+	}
+	return nil
+}
+
+// sharedNvmeVolumeResources reports whether the NVMe controller and subsystem
+// of vol are also used by another volume on the same DPU that is not being
+// deleted.
+func sharedNvmeVolumeResources(vol *configv1.DpuNvmeVolume, volumes []configv1.DpuNvmeVolume) (controllerShared, subsystemShared bool) {
+	for i := range volumes {
+		other := &volumes[i]
+		if other.UID == vol.UID || !other.DeletionTimestamp.IsZero() || other.Status.DpuName != vol.Status.DpuName {
+			continue
+		}
+		if vol.Status.ControllerID != "" && other.Status.ControllerID == vol.Status.ControllerID {
+			controllerShared = true
+		}
+		if vol.Status.SubsystemID != "" && other.Status.SubsystemID == vol.Status.SubsystemID {
+			subsystemShared = true
+		}
+	}
+	return controllerShared, subsystemShared
+}
+
+func setNvmeVolumeReady(vol *configv1.DpuNvmeVolume, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&vol.Status.Conditions, metav1.Condition{
+		Type:               configv1.NvmeVolumeConditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: vol.Generation,
+	})
+}
+
+// volumesForDPU maps a DataProcessingUnit event to the volumes that may be
+// exposed from it, so volumes waiting for a DPU are provisioned once it appears.
+func (r *DpuNvmeVolumeReconciler) volumesForDPU(ctx context.Context, obj client.Object) []reconcile.Request {
+	volumes := &configv1.DpuNvmeVolumeList{}
+	if err := r.List(ctx, volumes); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list DpuNvmeVolumes")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range volumes.Items {
+		vol := &volumes.Items[i]
+		if vol.Status.DpuName == "" || vol.Status.DpuName == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(vol)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DpuNvmeVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&configv1.DpuNvmeVolume{}).
+		Watches(&configv1.DataProcessingUnit{}, handler.EnqueueRequestsFromMapFunc(r.volumesForDPU)).
+		Named("dpunvmevolume").
+		Complete(r)
+}
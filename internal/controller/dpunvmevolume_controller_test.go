@@ -0,0 +1,468 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeStoragePlugin keeps NVMe resources in memory.
+type fakeStoragePlugin struct {
+	subsystems  map[string]bool
+	controllers map[string]bool
+	namespaces  map[string]bool
+	createErr   error
+	// lastNamespace is the last namespace requested.
+	lastNamespace *plugin.NVMeNamespaceRequest
+}
+
+func newFakeStoragePlugin() *fakeStoragePlugin {
+	return &fakeStoragePlugin{
+		subsystems:  map[string]bool{},
+		controllers: map[string]bool{},
+		namespaces:  map[string]bool{},
+	}
+}
+
+func (f *fakeStoragePlugin) Info() plugin.PluginInfo {
+	return plugin.PluginInfo{Name: "fake-storage", Vendor: "fake"}
+}
+func (f *fakeStoragePlugin) Initialize(context.Context, plugin.PluginConfig) error { return nil }
+func (f *fakeStoragePlugin) Shutdown(context.Context) error                        { return nil }
+func (f *fakeStoragePlugin) HealthCheck(context.Context) error                     { return nil }
+func (f *fakeStoragePlugin) DiscoverDevices(context.Context) ([]plugin.Device, error) {
+	return nil, nil
+}
+func (f *fakeStoragePlugin) GetInventory(context.Context, string) (*plugin.InventoryResponse, error) {
+	return nil, plugin.ErrNotImplemented
+}
+
+// NewInstance returns the plugin itself, so that the specs see the volumes
+// of all DPUs.
+func (f *fakeStoragePlugin) NewInstance() plugin.Plugin { return f }
+
+func (f *fakeStoragePlugin) CreateNVMeSubsystem(_ context.Context, req *plugin.NVMeSubsystemRequest) (*plugin.NVMeSubsystem, error) {
+	id := "nvmeSubsystems/" + req.NQN
+	f.subsystems[id] = true
+	return &plugin.NVMeSubsystem{ID: id, NQN: req.NQN}, nil
+}
+
+func (f *fakeStoragePlugin) DeleteNVMeSubsystem(_ context.Context, id string) error {
+	return f.delete(f.subsystems, id)
+}
+
+func (f *fakeStoragePlugin) GetNVMeSubsystem(_ context.Context, id string) (*plugin.NVMeSubsystem, error) {
+	if !f.subsystems[id] {
+		return nil, plugin.ErrResourceNotFound
+	}
+	return &plugin.NVMeSubsystem{ID: id}, nil
+}
+
+func (f *fakeStoragePlugin) ListNVMeSubsystems(context.Context) ([]*plugin.NVMeSubsystem, error) {
+	return nil, nil
+}
+
+func (f *fakeStoragePlugin) CreateNVMeController(_ context.Context, req *plugin.NVMeControllerRequest) (*plugin.NVMeController, error) {
+	id := "nvmeControllers/" + req.PCIeAddress
+	f.controllers[id] = true
+	return &plugin.NVMeController{ID: id, SubsystemID: req.SubsystemID, PCIeAddress: req.PCIeAddress}, nil
+}
+
+func (f *fakeStoragePlugin) DeleteNVMeController(_ context.Context, id string) error {
+	return f.delete(f.controllers, id)
+}
+
+func (f *fakeStoragePlugin) CreateNVMeNamespace(_ context.Context, req *plugin.NVMeNamespaceRequest) (*plugin.NVMeNamespace, error) {
+	f.lastNamespace = req
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	id := fmt.Sprintf("%s/nvmeNamespaces/%d", req.SubsystemID, req.NSID)
+	f.namespaces[id] = true
+	return &plugin.NVMeNamespace{ID: id, NSID: req.NSID, Size: req.Size}, nil
+}
+
+func (f *fakeStoragePlugin) DeleteNVMeNamespace(_ context.Context, id string) error {
+	return f.delete(f.namespaces, id)
+}
+
+func (f *fakeStoragePlugin) delete(resources map[string]bool, id string) error {
+	if !resources[id] {
+		return plugin.NewPluginErrorWithDetails("fake-storage", "Delete", plugin.ErrResourceNotFound, id)
+	}
+	delete(resources, id)
+	return nil
+}
+
+var _ = Describe("DpuNvmeVolume controller", func() {
+	Describe("Reconcile", func() {
+		var (
+			ctx        context.Context
+			c          client.Client
+			namespace  string
+			sp         *fakeStoragePlugin
+			reconciler *DpuNvmeVolumeReconciler
+			volumes    []*configv1.DpuNvmeVolume
+		)
+
+		newVolume := func(name string, nsid int32, pcieFunction string) *configv1.DpuNvmeVolume {
+			vol := &configv1.DpuNvmeVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: configv1.DpuNvmeVolumeSpec{
+					DpuSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"storage": "enabled"}},
+					SubsystemNQN: "nqn.2024-01.io.openshift:" + namespace,
+					Size:         resource.MustParse("1Gi"),
+					NSID:         nsid,
+					PCIeFunction: pcieFunction,
+					Target: configv1.NvmeOFTarget{
+						Address: "192.0.2.10",
+						NQN:     "nqn.2024-01.io.example:storage",
+						NSID:    nsid,
+					},
+				},
+			}
+			Expect(c.Create(ctx, vol)).To(Succeed())
+			volumes = append(volumes, vol)
+			return vol
+		}
+
+		reconcileVolume := func(vol *configv1.DpuNvmeVolume) (ctrl.Result, error) {
+			return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(vol)})
+		}
+
+		// provision reconciles a new volume until it is exposed to the host.
+		provision := func(vol *configv1.DpuNvmeVolume) {
+			result, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeTrue(), "the first reconcile adds the finalizer")
+			result, err = reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(vol), vol)).To(Succeed())
+			Expect(vol.Status.NamespaceID).NotTo(BeEmpty())
+		}
+
+		readyCondition := func(vol *configv1.DpuNvmeVolume) *metav1.Condition {
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(vol), vol)).To(Succeed())
+			return meta.FindStatusCondition(vol.Status.Conditions, configv1.NvmeVolumeConditionReady)
+		}
+
+		expectDeleted := func(vol *configv1.DpuNvmeVolume) {
+			err := c.Get(ctx, client.ObjectKeyFromObject(vol), vol)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), "the finalizer is removed")
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			c = startTestEnv()
+			namespace = createTestNamespace(ctx)
+			sp = newFakeStoragePlugin()
+			reconciler = NewDpuNvmeVolumeReconciler(c, scheme.Scheme)
+			reconciler.plugins = fakeDpuPlugins(sp)
+			volumes = nil
+
+			createTestDpu(ctx, fakeDpu("dpu-a", map[string]string{"storage": "enabled"}))
+		})
+
+		AfterEach(func() {
+			for _, vol := range volumes {
+				forceDelete(ctx, vol)
+			}
+			deleteTestDpus(ctx)
+		})
+
+		It("exposes the volume on the selected DPU", func() {
+			vol := newVolume("vol1", 1, "pf0vf0")
+			provision(vol)
+
+			Expect(vol.Finalizers).To(ContainElement(dpuNvmeVolumeFinalizer))
+			Expect(vol.Status.DpuName).To(Equal("dpu-a"))
+			Expect(vol.Status.SubsystemID).To(Equal("nvmeSubsystems/" + vol.Spec.SubsystemNQN))
+			Expect(vol.Status.ControllerID).To(Equal("nvmeControllers/pf0vf0"))
+			Expect(vol.Status.NamespaceID).To(Equal(vol.Status.SubsystemID + "/nvmeNamespaces/1"))
+			Expect(vol.Status.PCIeFunction).To(Equal("pf0vf0"))
+			Expect(vol.Status.ObservedGeneration).To(Equal(vol.Generation))
+			Expect(vol.Spec.BlockSize).To(Equal(int32(512)), "the CRD defaults the block size")
+			Expect(sp.lastNamespace.Target).To(Equal(&plugin.NVMeOFTarget{
+				Transport: plugin.NVMeOFTransportTCP,
+				Address:   "192.0.2.10",
+				Port:      4420,
+				NQN:       "nqn.2024-01.io.example:storage",
+				NSID:      1,
+			}), "the CRD defaults the transport and port of the target")
+
+			ready := readyCondition(vol)
+			Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+			Expect(ready.Reason).To(Equal(configv1.NvmeVolumeReasonProvisioned))
+			Expect(ready.Message).To(Equal("NVMe namespace 1 is exposed on pf0vf0"))
+		})
+
+		It("waits for a DPU matching the selector", func() {
+			deleteTestDpus(ctx)
+			vol := newVolume("vol1", 1, "pf0vf0")
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(nvmeVolumeRetryInterval))
+
+			ready := readyCondition(vol)
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(configv1.NvmeVolumeReasonNoMatchingDpu))
+			Expect(vol.Status.DpuName).To(BeEmpty())
+			Expect(sp.subsystems).To(BeEmpty())
+		})
+
+		It("waits for the DPU to report an OPI endpoint", func() {
+			deleteTestDpus(ctx)
+			dpu := fakeDpu("dpu-a", map[string]string{"storage": "enabled"})
+			dpu.Status.OpiEndpoint = ""
+			createTestDpu(ctx, dpu)
+
+			vol := newVolume("vol1", 1, "pf0vf0")
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(nvmeVolumeRetryInterval))
+
+			ready := readyCondition(vol)
+			Expect(ready.Reason).To(Equal(configv1.NvmeVolumeReasonNoStoragePlugin))
+			Expect(ready.Message).To(ContainSubstring("reports no OPI endpoint"))
+		})
+
+		It("reports a plugin without storage support", func() {
+			reconciler.plugins = fakeDpuPlugins(newFakeSecurityPlugin())
+			vol := newVolume("vol1", 1, "pf0vf0")
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+
+			ready := readyCondition(vol)
+			Expect(ready.Reason).To(Equal(configv1.NvmeVolumeReasonNoStoragePlugin))
+			Expect(ready.Message).To(Equal("plugin fake-security does not support storage"))
+		})
+
+		It("reports the failing provisioning step", func() {
+			sp.createErr = plugin.ErrOperationFailed
+			vol := newVolume("vol1", 1, "pf0vf0")
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = reconcileVolume(vol)
+			Expect(err).To(MatchError(plugin.ErrOperationFailed))
+
+			ready := readyCondition(vol)
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(configv1.NvmeVolumeReasonProvisioningFailed))
+			Expect(ready.Message).To(ContainSubstring("NVMe namespace 1"))
+			Expect(vol.Status.ControllerID).To(BeEmpty())
+			Expect(vol.Status.NamespaceID).To(BeEmpty())
+			Expect(sp.controllers).To(BeEmpty(), "the controller of the failed volume is removed")
+			Expect(sp.subsystems).To(BeEmpty(), "the subsystem of the failed volume is removed")
+
+			sp.createErr = nil
+			_, err = reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readyCondition(vol).Reason).To(Equal(configv1.NvmeVolumeReasonProvisioned))
+		})
+
+		It("does not retry a volume the plugin cannot provision", func() {
+			sp.createErr = plugin.NewPluginError("fake-storage", "CreateNVMeNamespace", plugin.ErrNotImplemented)
+			vol := newVolume("vol1", 1, "pf0vf0")
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			result, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(ctrl.Result{}))
+
+			ready := readyCondition(vol)
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Reason).To(Equal(configv1.NvmeVolumeReasonNotSupported))
+			Expect(vol.Status.SubsystemID).To(BeEmpty())
+			Expect(sp.controllers).To(BeEmpty())
+			Expect(sp.subsystems).To(BeEmpty())
+		})
+
+		It("keeps the subsystem of another volume when provisioning fails", func() {
+			first := newVolume("vol1", 1, "pf0vf0")
+			provision(first)
+			sp.createErr = plugin.ErrOperationFailed
+			second := newVolume("vol2", 2, "pf0vf1")
+			_, err := reconcileVolume(second)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = reconcileVolume(second)
+			Expect(err).To(MatchError(plugin.ErrOperationFailed))
+
+			Expect(sp.subsystems).To(And(HaveLen(1), HaveKey(first.Status.SubsystemID)))
+			Expect(sp.controllers).To(And(HaveLen(1), HaveKey(first.Status.ControllerID)))
+			Expect(sp.namespaces).To(And(HaveLen(1), HaveKey(first.Status.NamespaceID)))
+		})
+
+		It("keeps a provisioned volume when reprovisioning fails", func() {
+			vol := newVolume("vol1", 1, "pf0vf0")
+			provision(vol)
+			sp.createErr = plugin.ErrOperationFailed
+
+			_, err := reconcileVolume(vol)
+			Expect(err).To(MatchError(plugin.ErrOperationFailed))
+			Expect(sp.controllers).To(HaveKey("nvmeControllers/pf0vf0"))
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(vol), vol)).To(Succeed())
+			Expect(vol.Status.NamespaceID).NotTo(BeEmpty())
+		})
+
+		It("rejects an invalid DPU selector", func() {
+			vol := &configv1.DpuNvmeVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "vol1", Namespace: namespace},
+				Spec: configv1.DpuNvmeVolumeSpec{
+					DpuSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "storage", Operator: "Bogus"}},
+					},
+					SubsystemNQN: "nqn.2024-01.io.openshift:vol1",
+					Size:         resource.MustParse("1Gi"),
+					PCIeFunction: "pf0vf0",
+					Target:       configv1.NvmeOFTarget{Address: "192.0.2.10", NQN: "nqn.2024-01.io.example:storage"},
+				},
+			}
+			Expect(c.Create(ctx, vol)).To(Succeed())
+			volumes = append(volumes, vol)
+
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = reconcileVolume(vol)
+			Expect(err).To(HaveOccurred())
+			Expect(sp.subsystems).To(BeEmpty())
+		})
+
+		It("stays on its DPU when another one matches the selector", func() {
+			vol := newVolume("vol1", 1, "pf0vf0")
+			provision(vol)
+			createTestDpu(ctx, fakeDpu("dpu-0", map[string]string{"storage": "enabled"}))
+
+			_, err := reconcileVolume(vol)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(vol), vol)).To(Succeed())
+			Expect(vol.Status.DpuName).To(Equal("dpu-a"))
+		})
+
+		It("maps a DPU to the volumes waiting for one and those exposed from it", func() {
+			waiting := newVolume("waiting", 1, "pf0vf0")
+			exposed := newVolume("exposed", 2, "pf0vf1")
+			provision(exposed)
+			elsewhere := newVolume("elsewhere", 3, "pf0vf2")
+			elsewhere.Status.DpuName = "dpu-b"
+			Expect(c.Status().Update(ctx, elsewhere)).To(Succeed())
+
+			dpu := &configv1.DataProcessingUnit{}
+			Expect(c.Get(ctx, client.ObjectKey{Name: "dpu-a"}, dpu)).To(Succeed())
+			Expect(reconciler.volumesForDPU(ctx, dpu)).To(ContainElements(
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(waiting)},
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(exposed)},
+			))
+			Expect(reconciler.volumesForDPU(ctx, dpu)).NotTo(ContainElement(
+				reconcile.Request{NamespacedName: client.ObjectKeyFromObject(elsewhere)},
+			))
+		})
+
+		Context("when the volume is deleted", func() {
+			It("removes its namespace, controller and subsystem", func() {
+				vol := newVolume("vol1", 1, "pf0vf0")
+				provision(vol)
+
+				Expect(c.Delete(ctx, vol)).To(Succeed())
+				_, err := reconcileVolume(vol)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sp.namespaces).To(BeEmpty())
+				Expect(sp.controllers).To(BeEmpty())
+				Expect(sp.subsystems).To(BeEmpty())
+				expectDeleted(vol)
+			})
+
+			It("ignores resources that are already gone", func() {
+				vol := newVolume("vol1", 1, "pf0vf0")
+				provision(vol)
+				delete(sp.namespaces, vol.Status.NamespaceID)
+
+				Expect(c.Delete(ctx, vol)).To(Succeed())
+				_, err := reconcileVolume(vol)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sp.subsystems).To(BeEmpty())
+				expectDeleted(vol)
+			})
+
+			It("keeps the subsystem shared with another volume", func() {
+				first := newVolume("vol1", 1, "pf0vf0")
+				second := newVolume("vol2", 2, "pf0vf1")
+				provision(first)
+				provision(second)
+
+				Expect(c.Delete(ctx, first)).To(Succeed())
+				_, err := reconcileVolume(first)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sp.namespaces).To(And(HaveLen(1), HaveKey(second.Status.NamespaceID)))
+				Expect(sp.controllers).To(And(HaveLen(1), HaveKey(second.Status.ControllerID)))
+				Expect(sp.subsystems).To(And(HaveLen(1), HaveKey(second.Status.SubsystemID)))
+				expectDeleted(first)
+			})
+
+			It("keeps the finalizer while the DPU has no plugin", func() {
+				vol := newVolume("vol1", 1, "pf0vf0")
+				provision(vol)
+				dpu := &configv1.DataProcessingUnit{}
+				Expect(c.Get(ctx, client.ObjectKey{Name: "dpu-a"}, dpu)).To(Succeed())
+				dpu.Status.OpiEndpoint = ""
+				Expect(c.Status().Update(ctx, dpu)).To(Succeed())
+
+				Expect(c.Delete(ctx, vol)).To(Succeed())
+				result, err := reconcileVolume(vol)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(Equal(nvmeVolumeRetryInterval))
+				Expect(c.Get(ctx, client.ObjectKeyFromObject(vol), vol)).To(Succeed())
+				Expect(vol.Finalizers).To(ContainElement(dpuNvmeVolumeFinalizer))
+				Expect(sp.namespaces).To(HaveLen(1))
+			})
+
+			It("releases a volume whose DPU is gone", func() {
+				vol := newVolume("vol1", 1, "pf0vf0")
+				provision(vol)
+				deleteTestDpus(ctx)
+
+				Expect(c.Delete(ctx, vol)).To(Succeed())
+				_, err := reconcileVolume(vol)
+				Expect(err).NotTo(HaveOccurred())
+				expectDeleted(vol)
+			})
+		})
+	})
+})
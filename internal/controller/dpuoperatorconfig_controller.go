@@ -21,6 +21,7 @@ import (
 	"embed"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -110,6 +111,8 @@ func (r *DpuOperatorConfigReconciler) logDiscoveredPlugins(logger logr.Logger) {
 //+kubebuilder:rbac:groups=config.openshift.io,resources=servicefunctionchains,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=config.openshift.io,resources=servicefunctionchains/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=config.openshift.io,resources=servicefunctionchains/finalizers,verbs=create;delete;get;list;patch;update;watch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpureboots,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=config.openshift.io,resources=dpureboots/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=*
 //+kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=*
 //+kubebuilder:rbac:groups="",resources=pods,verbs=*
@@ -347,6 +350,22 @@ func (r *DpuOperatorConfigReconciler) yamlVars(cfg *configv1.DpuOperatorConfig)
 	if cfg != nil {
 		logLevel = cfg.Spec.LogLevel
 	}
+	vlanPolicy := configv1.VlanPolicy{}
+	if cfg != nil && cfg.Spec.VlanPolicy != nil {
+		vlanPolicy = *cfg.Spec.VlanPolicy
+	}
+	resetPolicy := configv1.DpuResetPolicy{}
+	if cfg != nil && cfg.Spec.ResetPolicy != nil {
+		resetPolicy = *cfg.Spec.ResetPolicy
+	}
+	resetTimeout := ""
+	if resetPolicy.Timeout != nil {
+		resetTimeout = resetPolicy.Timeout.Duration.String()
+	}
+	dpuRemovalGracePeriod := ""
+	if cfg != nil && cfg.Spec.DpuRemovalGracePeriod != nil {
+		dpuRemovalGracePeriod = cfg.Spec.DpuRemovalGracePeriod.Duration.String()
+	}
 
 	data := map[string]string{
 		"Namespace":       vars.Namespace,
@@ -355,6 +374,14 @@ func (r *DpuOperatorConfigReconciler) yamlVars(cfg *configv1.DpuOperatorConfig)
 		"CniDir":          p,
 		"PluginLogLevel":  fmt.Sprintf("%d", logLevel),
 		"DaemonLogLevel":  fmt.Sprintf("%d", logLevel),
+		"VlanMode":        string(vlanPolicy.Mode),
+		"VlanID":          fmt.Sprintf("%d", vlanPolicy.DefaultVlan),
+		"VlanQoS":         fmt.Sprintf("%d", vlanPolicy.DefaultQoS),
+		"VlanProto":       vlanPolicy.DefaultProto,
+		"ResetCordonNode": strconv.FormatBool(resetPolicy.CordonNode),
+		"ResetTimeout":    resetTimeout,
+
+		"DpuRemovalGracePeriod": dpuRemovalGracePeriod,
 
 		"PluginOPIEndpoint":                 os.Getenv("DPU_PLUGIN_OPI_ENDPOINT"),
 		"PluginOPINetworkEndpoint":          os.Getenv("DPU_PLUGIN_OPI_NETWORK_ENDPOINT"),
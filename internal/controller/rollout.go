@@ -0,0 +1,101 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"cmp"
+	"slices"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// nodeRollout rolls changes that disrupt nodes out to the DPUs of a config or
+// a policy, according to its rollout strategy.
+type nodeRollout struct {
+	strategy *configv1.DpuConfigRolloutStrategy
+	// nodes are the nodes of the DPUs the rollout covers, which
+	// maxUnavailable is scaled to.
+	nodes map[string]bool
+	// changes are the indexes of the DPUs the rollout changes.
+	changes []int
+}
+
+func newNodeRollout(strategy *configv1.DpuConfigRolloutStrategy) *nodeRollout {
+	return &nodeRollout{strategy: strategy, nodes: map[string]bool{}}
+}
+
+// rolloutMaxUnavailable returns how many of the nodes may apply a change at
+// the same time.
+func rolloutMaxUnavailable(strategy *configv1.DpuConfigRolloutStrategy, nodes int) int {
+	if strategy.MaxUnavailable == nil {
+		return 1
+	}
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(strategy.MaxUnavailable, nodes, false)
+	if err != nil || maxUnavailable < 1 {
+		return 1
+	}
+	return maxUnavailable
+}
+
+// disruptedNodes returns the nodes a DPU disrupts with a change in progress,
+// whether a VF count change or a firmware update.
+func disruptedNodes(dpus []configv1.DataProcessingUnit) map[string]bool {
+	disrupted := map[string]bool{}
+	for i := range dpus {
+		if vfCountRollingOut(&dpus[i]) || firmwareUpdating(&dpus[i]) {
+			disrupted[dpus[i].Spec.NodeName] = true
+		}
+	}
+	return disrupted
+}
+
+// gateNodeRollouts lets the changes of the rollouts through, in the order of
+// the rollouts, so that at most maxUnavailable of the nodes of each rollout
+// are disrupted at the same time and no node starts while a rollout is
+// paused. A node counts against the budget of every rollout covering it,
+// whichever change disrupts it. Changes on a node that is already disrupted
+// go through, the others are picked by node name. hold is called with the
+// index of every DPU whose change is held back.
+func gateNodeRollouts(rollouts []*nodeRollout, dpus []configv1.DataProcessingUnit, hold func(i int)) {
+	disrupted := disruptedNodes(dpus)
+	for _, r := range rollouts {
+		slices.SortFunc(r.changes, func(a, b int) int {
+			return cmp.Or(cmp.Compare(dpus[a].Spec.NodeName, dpus[b].Spec.NodeName),
+				cmp.Compare(dpus[a].Name, dpus[b].Name))
+		})
+		busy := 0
+		for node := range r.nodes {
+			if disrupted[node] {
+				busy++
+			}
+		}
+		maxUnavailable := rolloutMaxUnavailable(r.strategy, len(r.nodes))
+		for _, i := range r.changes {
+			node := dpus[i].Spec.NodeName
+			if disrupted[node] {
+				continue
+			}
+			if !r.strategy.Paused && busy < maxUnavailable {
+				disrupted[node] = true
+				busy++
+				continue
+			}
+			hold(i)
+		}
+	}
+}
@@ -22,12 +22,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
 	configv1 "github.com/openshift/dpu-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -119,20 +121,154 @@ func (r *ServiceFunctionChainReconciler) Reconcile(ctx context.Context, req ctrl
 		}
 	}
 
+	requeue := false
 	for _, pod := range desiredPods {
-		requeue, err := r.reconcilePod(ctx, pod)
+		podRequeue, err := r.reconcilePod(ctx, pod)
 		if err != nil {
 			logger.Error(err, "Failed to reconcile pod", "pod", pod.Name)
 			return ctrl.Result{}, err
 		}
-		if requeue {
-			return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+		if podRequeue {
+			requeue = true
+			break
 		}
 	}
 
+	if err := r.updateStatus(ctx, sfc); err != nil {
+		logger.Error(err, "Failed to update ServiceFunctionChain status")
+		return ctrl.Result{}, err
+	}
+
+	if requeue {
+		return ctrl.Result{RequeueAfter: 2 * time.Second}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// updateStatus refreshes the per network function status and the aggregate
+// conditions from the pods currently owned by the ServiceFunctionChain.
+func (r *ServiceFunctionChainReconciler) updateStatus(ctx context.Context, sfc *configv1.ServiceFunctionChain) error {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods,
+		client.InNamespace(sfc.Namespace),
+		client.MatchingLabels{sfcLabelKey: sfc.Name},
+	); err != nil {
+		return err
+	}
+
+	desired := sfcStatus(sfc, pods.Items)
+	if reflect.DeepEqual(sfc.Status, desired) {
+		return nil
+	}
+	sfc.Status = desired
+	return r.Status().Update(ctx, sfc)
+}
+
+// sfcStatus computes the status of a ServiceFunctionChain from its pods. The
+// wiring fields are owned by the DPU daemon and are carried over unchanged.
+func sfcStatus(sfc *configv1.ServiceFunctionChain, pods []corev1.Pod) configv1.ServiceFunctionChainStatus {
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Name] = &pods[i]
+	}
+	previous := make(map[string]configv1.NetworkFunctionStatus, len(sfc.Status.NetworkFunctions))
+	for _, nfStatus := range sfc.Status.NetworkFunctions {
+		previous[nfStatus.Name] = nfStatus
+	}
+
+	status := sfc.Status.DeepCopy()
+	status.ObservedGeneration = sfc.Generation
+	status.NetworkFunctions = make([]configv1.NetworkFunctionStatus, 0, len(sfc.Spec.NetworkFunctions))
+
+	var pending, failed []string
+	for _, nf := range sfc.Spec.NetworkFunctions {
+		nfStatus := configv1.NetworkFunctionStatus{
+			Name:    nf.Name,
+			PodName: fmt.Sprintf("%s-%s", sfc.Name, nf.Name),
+		}
+		if prev, ok := previous[nf.Name]; ok {
+			nfStatus.BridgePorts = prev.BridgePorts
+			nfStatus.Wiring = prev.Wiring
+			nfStatus.WiringMessage = prev.WiringMessage
+		}
+
+		pod, ok := podsByName[nfStatus.PodName]
+		switch {
+		case !ok:
+			nfStatus.Message = "Pod has not been created yet"
+		case pod.DeletionTimestamp != nil:
+			nfStatus.NodeName = pod.Spec.NodeName
+			nfStatus.Phase = pod.Status.Phase
+			nfStatus.Message = "Pod is being recreated"
+		default:
+			nfStatus.NodeName = pod.Spec.NodeName
+			nfStatus.Phase = pod.Status.Phase
+			nfStatus.Message = pod.Status.Message
+		}
+
+		switch {
+		case nfStatus.Phase == corev1.PodFailed || nfStatus.Wiring == configv1.WiringFailed:
+			failed = append(failed, nf.Name)
+		case nfStatus.Phase != corev1.PodRunning || nfStatus.Wiring == configv1.WiringPending || (ok && pod.DeletionTimestamp != nil):
+			pending = append(pending, nf.Name)
+		}
+		status.NetworkFunctions = append(status.NetworkFunctions, nfStatus)
+	}
+
+	setSfcConditions(status, sfc.Generation, pending, failed)
+	return *status
+}
+
+func setSfcConditions(status *configv1.ServiceFunctionChainStatus, generation int64, pending, failed []string) {
+	degraded := metav1.Condition{
+		Type:               configv1.SfcConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             "AsExpected",
+		Message:            "No network function has failed",
+		ObservedGeneration: generation,
+	}
+	if len(failed) > 0 {
+		degraded.Status = metav1.ConditionTrue
+		degraded.Reason = "NetworkFunctionFailed"
+		degraded.Message = fmt.Sprintf("Network functions failed: %s", strings.Join(failed, ", "))
+	}
+
+	progressing := metav1.Condition{
+		Type:               configv1.SfcConditionProgressing,
+		Status:             metav1.ConditionFalse,
+		Reason:             "AsExpected",
+		Message:            "All network functions are settled",
+		ObservedGeneration: generation,
+	}
+	if len(pending) > 0 {
+		progressing.Status = metav1.ConditionTrue
+		progressing.Reason = "NetworkFunctionPending"
+		progressing.Message = fmt.Sprintf("Network functions pending: %s", strings.Join(pending, ", "))
+	}
+
+	ready := metav1.Condition{
+		Type:               configv1.SfcConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "NetworkFunctionsReady",
+		Message:            "All network functions are running",
+		ObservedGeneration: generation,
+	}
+	switch {
+	case len(failed) > 0:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = degraded.Reason
+		ready.Message = degraded.Message
+	case len(pending) > 0:
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = progressing.Reason
+		ready.Message = progressing.Message
+	}
+
+	meta.SetStatusCondition(&status.Conditions, ready)
+	meta.SetStatusCondition(&status.Conditions, progressing)
+	meta.SetStatusCondition(&status.Conditions, degraded)
+}
+
 func networkFunctionPod(sfc *configv1.ServiceFunctionChain, nf configv1.NetworkFunction, resourceName string) *corev1.Pod {
 	trueVar := true
 	podName := fmt.Sprintf("%s-%s", sfc.Name, nf.Name)
@@ -308,5 +444,6 @@ func setPodSpecHash(pod *corev1.Pod) error {
 func (r *ServiceFunctionChainReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1.ServiceFunctionChain{}).
+		Owns(&corev1.Pod{}).
 		Complete(r)
 }
@@ -0,0 +1,237 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("ServiceFunctionChain Controller", func() {
+	Describe("Reconcile", func() {
+		var (
+			ctx        context.Context
+			c          client.Client
+			namespace  string
+			reconciler *ServiceFunctionChainReconciler
+			sfc        *configv1.ServiceFunctionChain
+		)
+
+		reconcileSfc := func() ctrl.Result {
+			result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sfc)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(sfc), sfc)).To(Succeed())
+			return result
+		}
+
+		getPod := func(name string) *corev1.Pod {
+			pod := &corev1.Pod{}
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pod)).To(Succeed())
+			return pod
+		}
+
+		// setPodPhase reports the phase of a network function pod, as the
+		// kubelet does.
+		setPodPhase := func(name string, phase corev1.PodPhase) {
+			pod := getPod(name)
+			pod.Status.Phase = phase
+			Expect(c.Status().Update(ctx, pod)).To(Succeed())
+		}
+
+		// setWiring reports the wiring of a network function, as the DPU
+		// daemon does.
+		setWiring := func(name string, wiring configv1.NetworkFunctionWiringResult, message string) {
+			for i := range sfc.Status.NetworkFunctions {
+				nfStatus := &sfc.Status.NetworkFunctions[i]
+				if nfStatus.Name == name {
+					nfStatus.BridgePorts = []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}
+					nfStatus.Wiring = wiring
+					nfStatus.WiringMessage = message
+				}
+			}
+			Expect(c.Status().Update(ctx, sfc)).To(Succeed())
+		}
+
+		condition := func(conditionType string) *metav1.Condition {
+			return meta.FindStatusCondition(sfc.Status.Conditions, conditionType)
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			c = startTestEnv()
+			namespace = createTestNamespace(ctx)
+			reconciler = &ServiceFunctionChainReconciler{Client: c, Scheme: scheme.Scheme}
+			sfc = &configv1.ServiceFunctionChain{
+				ObjectMeta: metav1.ObjectMeta{Name: "chain", Namespace: namespace},
+				Spec: configv1.ServiceFunctionChainSpec{
+					NetworkFunctions: []configv1.NetworkFunction{
+						{Name: "fw", Image: "fw:latest"},
+						{Name: "lb", Image: "lb:latest"},
+					},
+				},
+			}
+			Expect(c.Create(ctx, sfc)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(c.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(namespace))).To(Succeed())
+			Expect(client.IgnoreNotFound(c.Delete(ctx, sfc))).To(Succeed())
+		})
+
+		It("creates a pod owned by the chain for each network function", func() {
+			Expect(reconcileSfc()).To(Equal(ctrl.Result{}))
+
+			pod := getPod("chain-fw")
+			Expect(metav1.IsControlledBy(pod, sfc)).To(BeTrue())
+			Expect(pod.Labels).To(HaveKeyWithValue(sfcLabelKey, "chain"))
+			Expect(pod.Labels).To(HaveKeyWithValue(sfcFunctionLabel, "fw"))
+			Expect(pod.Annotations).To(HaveKeyWithValue(sfcNetworksAnnoKey, defaultHostNAD))
+			Expect(pod.Annotations).To(HaveKey(sfcSpecHashKey))
+			Expect(pod.Spec.Containers[0].Image).To(Equal("fw:latest"))
+			Expect(pod.Spec.Containers[0].Resources.Limits).To(HaveKeyWithValue(
+				corev1.ResourceName(defaultDpuResource), resource.MustParse("2")))
+			getPod("chain-lb")
+
+			Expect(sfc.Status.ObservedGeneration).To(Equal(sfc.Generation))
+			Expect(sfc.Status.NetworkFunctions).To(HaveLen(2))
+			Expect(sfc.Status.NetworkFunctions[0].PodName).To(Equal("chain-fw"))
+			Expect(sfc.Status.NetworkFunctions[0].Phase).To(Equal(corev1.PodPending))
+			Expect(condition(configv1.SfcConditionReady).Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition(configv1.SfcConditionProgressing).Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition(configv1.SfcConditionProgressing).Message).To(Equal("Network functions pending: fw, lb"))
+			Expect(condition(configv1.SfcConditionDegraded).Status).To(Equal(metav1.ConditionFalse))
+		})
+
+		It("attaches the pods of DPU side chains to the DPU network", func() {
+			sfc.Spec.NodeSelector = map[string]string{nodeSideLabelKey: "dpu"}
+			Expect(c.Update(ctx, sfc)).To(Succeed())
+
+			reconcileSfc()
+			Expect(getPod("chain-fw").Annotations).To(HaveKeyWithValue(sfcNetworksAnnoKey, defaultDpuNAD))
+			Expect(getPod("chain-fw").Spec.NodeSelector).To(Equal(sfc.Spec.NodeSelector))
+		})
+
+		It("requests the DPU resource named in the DpuOperatorConfig", func() {
+			cfg := &configv1.DpuOperatorConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: configv1.DpuOperatorConfigNamespacedName.Name},
+				Spec:       configv1.DpuOperatorConfigSpec{ResourceName: "example.com/dpu"},
+			}
+			Expect(c.Create(ctx, cfg)).To(Succeed())
+			DeferCleanup(func() { Expect(c.Delete(ctx, cfg)).To(Succeed()) })
+
+			reconcileSfc()
+			Expect(getPod("chain-fw").Spec.Containers[0].Resources.Requests).To(HaveKey(corev1.ResourceName("example.com/dpu")))
+		})
+
+		It("is Ready once all pods run and the DPU daemon wired them", func() {
+			reconcileSfc()
+			setPodPhase("chain-fw", corev1.PodRunning)
+			setPodPhase("chain-lb", corev1.PodRunning)
+			setWiring("fw", configv1.WiringPending, "")
+
+			reconcileSfc()
+			Expect(condition(configv1.SfcConditionProgressing).Message).To(Equal("Network functions pending: fw"))
+
+			setWiring("fw", configv1.WiringSucceeded, "")
+			reconcileSfc()
+			Expect(sfc.Status.NetworkFunctions[0].BridgePorts).To(HaveLen(2), "the wiring of the daemon is kept")
+			Expect(sfc.Status.NetworkFunctions[0].Phase).To(Equal(corev1.PodRunning))
+			Expect(condition(configv1.SfcConditionReady).Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition(configv1.SfcConditionProgressing).Status).To(Equal(metav1.ConditionFalse))
+		})
+
+		It("is Degraded when a pod failed", func() {
+			reconcileSfc()
+			setPodPhase("chain-fw", corev1.PodRunning)
+			setPodPhase("chain-lb", corev1.PodFailed)
+
+			reconcileSfc()
+			degraded := condition(configv1.SfcConditionDegraded)
+			Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+			Expect(degraded.Reason).To(Equal("NetworkFunctionFailed"))
+			Expect(degraded.Message).To(Equal("Network functions failed: lb"))
+			Expect(condition(configv1.SfcConditionReady).Reason).To(Equal("NetworkFunctionFailed"))
+		})
+
+		It("is Degraded when the wiring failed", func() {
+			reconcileSfc()
+			setPodPhase("chain-fw", corev1.PodRunning)
+			setPodPhase("chain-lb", corev1.PodRunning)
+			setWiring("lb", configv1.WiringFailed, "vsp unavailable")
+
+			reconcileSfc()
+			Expect(sfc.Status.NetworkFunctions[1].WiringMessage).To(Equal("vsp unavailable"))
+			Expect(condition(configv1.SfcConditionDegraded).Message).To(Equal("Network functions failed: lb"))
+			Expect(condition(configv1.SfcConditionReady).Status).To(Equal(metav1.ConditionFalse))
+		})
+
+		It("recreates the pod of a network function whose spec changed", func() {
+			reconcileSfc()
+			oldUID := getPod("chain-fw").UID
+
+			sfc.Spec.NetworkFunctions[0].Image = "fw:v2"
+			Expect(c.Update(ctx, sfc)).To(Succeed())
+			result := reconcileSfc()
+			Expect(result.RequeueAfter).To(Equal(2 * time.Second))
+
+			reconcileSfc()
+			pod := getPod("chain-fw")
+			Expect(pod.UID).NotTo(Equal(oldUID))
+			Expect(pod.Spec.Containers[0].Image).To(Equal("fw:v2"))
+		})
+
+		It("restores the annotations of its pods", func() {
+			reconcileSfc()
+			pod := getPod("chain-fw")
+			pod.Annotations[sfcNetworksAnnoKey] = "other-net"
+			Expect(c.Update(ctx, pod)).To(Succeed())
+
+			reconcileSfc()
+			Expect(getPod("chain-fw").Annotations).To(HaveKeyWithValue(sfcNetworksAnnoKey, defaultHostNAD))
+		})
+
+		It("deletes the pods of network functions removed from the chain", func() {
+			reconcileSfc()
+			sfc.Spec.NetworkFunctions = sfc.Spec.NetworkFunctions[:1]
+			Expect(c.Update(ctx, sfc)).To(Succeed())
+
+			reconcileSfc()
+			err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: "chain-lb"}, &corev1.Pod{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			Expect(sfc.Status.NetworkFunctions).To(HaveLen(1))
+		})
+
+		It("ignores a chain that is gone", func() {
+			Expect(c.Delete(ctx, sfc)).To(Succeed())
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sfc)})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -17,12 +17,21 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+	"path/filepath"
+	"sync"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -34,6 +43,8 @@ import (
 
 var cfg *rest.Config
 var testEnv *envtest.Environment
+var k8sClient client.Client
+var testEnvOnce sync.Once
 
 func TestControllers(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -46,5 +57,68 @@ var _ = BeforeSuite(func() {
 })
 
 var _ = AfterSuite(func() {
-	// Nothing needed
+	if cfg != nil {
+		Expect(testEnv.Stop()).To(Succeed())
+	}
 })
+
+// startTestEnv starts the API server the Reconcile specs run against, with
+// the CRDs of the operator, and returns a client for it. It is started once,
+// by the first spec needing it, as the Main Controller specs use a kind
+// cluster instead.
+func startTestEnv() client.Client {
+	testEnvOnce.Do(func() {
+		testEnv = &envtest.Environment{
+			CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+			ErrorIfCRDPathMissing: true,
+		}
+		var err error
+		cfg, err = testEnv.Start()
+		Expect(err).NotTo(HaveOccurred())
+		k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
+		Expect(err).NotTo(HaveOccurred())
+	})
+	Expect(k8sClient).NotTo(BeNil(), "the test API server failed to start")
+	return k8sClient
+}
+
+// createTestNamespace creates a namespace with a generated name, so that the
+// namespaced objects of a spec are not seen by the others. The test API
+// server cannot remove namespaces, so they are left behind.
+func createTestNamespace(ctx context.Context) string {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "test-"}}
+	Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+	return ns.Name
+}
+
+// createTestDpu creates a DataProcessingUnit along with its status, as the
+// daemon reports it.
+func createTestDpu(ctx context.Context, dpu *configv1.DataProcessingUnit) *configv1.DataProcessingUnit {
+	status := dpu.Status.DeepCopy()
+	Expect(k8sClient.Create(ctx, dpu)).To(Succeed())
+	dpu.Status = *status
+	Expect(k8sClient.Status().Update(ctx, dpu)).To(Succeed())
+	return dpu
+}
+
+// deleteTestDpus deletes all DataProcessingUnits. They are cluster scoped
+// and the controllers consider all of them, so every spec creating DPUs
+// removes them again.
+func deleteTestDpus(ctx context.Context) {
+	Expect(k8sClient.DeleteAllOf(ctx, &configv1.DataProcessingUnit{})).To(Succeed())
+}
+
+// forceDelete removes the finalizers of obj and deletes it, for objects whose
+// controller does not run any more at the end of a spec.
+func forceDelete(ctx context.Context, obj client.Object) {
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	if apierrors.IsNotFound(err) {
+		return
+	}
+	Expect(err).NotTo(HaveOccurred())
+	if len(obj.GetFinalizers()) > 0 {
+		obj.SetFinalizers(nil)
+		Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+	}
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, obj))).To(Succeed())
+}
@@ -0,0 +1,289 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// conditionCheckInterval is how often the conditions that need a call to
+	// the API server or to the registry plugin are refreshed. The others are
+	// refreshed on every iteration of the daemon loop.
+	conditionCheckInterval = 10 * time.Second
+	// conditionCheckTimeout bounds the calls made for a condition check.
+	conditionCheckTimeout = 5 * time.Second
+
+	// Labels of the VSP pod rendered by the DataProcessingUnit controller.
+	vspPodAppLabel     = "app"
+	vspPodAppLabelVsp  = "vsp"
+	vspPodDpuNameLabel = "dpu-name"
+)
+
+// heartbeatReporter is implemented by side managers that track the heartbeat
+// between the host and the DPU side.
+type heartbeatReporter interface {
+	LastPing() time.Time
+}
+
+// devicePluginReporter is implemented by side managers that run a device plugin.
+type devicePluginReporter interface {
+	DevicePluginRegistered() bool
+}
+
+// deviceHealthSetter is implemented by side managers whose device plugin
+// reports the health of the DPU on its devices.
+type deviceHealthSetter interface {
+	SetDeviceHealth(source string, err error)
+}
+
+// updateConditions sets the conditions of a managed DPU. Ready keeps its
+// meaning as the summary of the DPU; the other conditions tell apart a dead
+// VSP from a flaky link between the host and the DPU.
+func (d *Daemon) updateConditions(ctx context.Context, managed *ManagedDpu, now time.Time) {
+	conditions := &managed.DpuCR.Status.Conditions
+
+	if managed.Missing {
+		setDpuCondition(conditions, configv1.DpuConditionDetected, metav1.ConditionFalse,
+			"NotDetected", fmt.Sprintf("DPU is not detected on node %s, waiting for it to come back.", managed.DpuCR.Spec.NodeName))
+	} else {
+		setDpuCondition(conditions, configv1.DpuConditionDetected, metav1.ConditionTrue,
+			"Detected", fmt.Sprintf("DPU detected on node %s.", managed.DpuCR.Spec.NodeName))
+	}
+
+	initialized := managed.Plugin.IsInitialized()
+	if initialized {
+		setDpuCondition(conditions, configv1.DpuConditionPluginInitialized, metav1.ConditionTrue,
+			"Initialized", "DPU plugin is initialized.")
+	} else {
+		setDpuCondition(conditions, configv1.DpuConditionPluginInitialized, metav1.ConditionFalse,
+			"NotInitialized", "DPU plugin is not yet initialized.")
+	}
+
+	pingOk := managed.Manager != nil && managed.Manager.CheckPing()
+	var lastPing time.Time
+	if reporter, ok := managed.Manager.(heartbeatReporter); ok {
+		lastPing = reporter.LastPing()
+	}
+	meta.SetStatusCondition(conditions, heartbeatCondition(pingOk, lastPing))
+
+	if reporter, ok := managed.Manager.(devicePluginReporter); ok {
+		if reporter.DevicePluginRegistered() {
+			setDpuCondition(conditions, configv1.DpuConditionDevicePluginRegistered, metav1.ConditionTrue,
+				"Registered", "Device plugin is registered with the kubelet.")
+		} else {
+			setDpuCondition(conditions, configv1.DpuConditionDevicePluginRegistered, metav1.ConditionFalse,
+				"NotRegistered", "Device plugin is not registered with the kubelet.")
+		}
+	}
+
+	if conditionCheckDue(managed.ConditionsChecked, now) {
+		managed.ConditionsChecked = now
+		checkCtx, cancel := context.WithTimeout(ctx, conditionCheckTimeout)
+		meta.SetStatusCondition(conditions, d.vspPodCondition(checkCtx, managed.DpuCR))
+		meta.SetStatusCondition(conditions, registryPluginCondition(managed.Plugin.RegistryHealthCheck(checkCtx),
+			managed.Plugin.RegistryEndpointConfigured()))
+		cancel()
+	}
+
+	var ready metav1.Condition
+	if initialized {
+		if pingOk {
+			ready = metav1.Condition{
+				Type:    plugin.ReadyConditionType,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Initialized",
+				Message: "DPU plugin is initialized and ping successful.",
+			}
+		} else {
+			ready = metav1.Condition{
+				Type:    plugin.ReadyConditionType,
+				Status:  metav1.ConditionFalse,
+				Reason:  "PingFailed",
+				Message: "DPU plugin is initialized but ping failed.",
+			}
+		}
+	} else {
+		ready = metav1.Condition{
+			Type:    plugin.ReadyConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NotInitialized",
+			Message: "DPU plugin is not yet initialized.",
+		}
+	}
+	if reboot := managed.DpuCR.Status.Reboot; reboot != nil && reboot.Phase != configv1.DpuRebootPhaseFailed {
+		ready = metav1.Condition{
+			Type:    plugin.ReadyConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Rebooting",
+			Message: fmt.Sprintf("DPU is rebooting (%s), phase %s.", reboot.Trigger, reboot.Phase),
+		}
+	}
+	// Always set a transition time
+	ready.LastTransitionTime = metav1.Now()
+	meta.SetStatusCondition(conditions, ready)
+}
+
+func setDpuCondition(conditions *[]metav1.Condition, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.Now(),
+	})
+}
+
+// updateDeviceHealth folds the heartbeat and the health check of the vendor
+// plugin of a DPU into the health of its devices, so that the kubelet stops
+// scheduling pods on the devices of an unreachable DPU. The devices are
+// healthy again once the conditions recover.
+func updateDeviceHealth(managed *ManagedDpu) {
+	setter, ok := managed.Manager.(deviceHealthSetter)
+	if !ok {
+		return
+	}
+	conditions := managed.DpuCR.Status.Conditions
+	setter.SetDeviceHealth(deviceplugin.HealthSourceHeartbeat,
+		conditionError(conditions, configv1.DpuConditionHeartbeatHealthy))
+	setter.SetDeviceHealth(deviceplugin.HealthSourceHealthCheck,
+		conditionError(conditions, configv1.DpuConditionRegistryPluginHealthy))
+}
+
+// conditionError returns the message of a condition that is False as an
+// error, and nil for a condition that is True, Unknown or not set.
+func conditionError(conditions []metav1.Condition, conditionType string) error {
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		return nil
+	}
+	return errors.New(condition.Message)
+}
+
+func conditionCheckDue(lastCheck, now time.Time) bool {
+	return lastCheck.IsZero() || now.Sub(lastCheck) >= conditionCheckInterval
+}
+
+// heartbeatCondition reports the heartbeat. The message of a failing
+// heartbeat holds the time of the last successful ping, which stays the same
+// while the heartbeat keeps failing.
+func heartbeatCondition(pingOk bool, lastPing time.Time) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               configv1.DpuConditionHeartbeatHealthy,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch {
+	case pingOk:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PingSucceeded"
+		condition.Message = "Heartbeat between the host and the DPU is healthy."
+	case lastPing.IsZero():
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoPing"
+		condition.Message = "No successful ping between the host and the DPU yet."
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "PingFailed"
+		condition.Message = fmt.Sprintf("Last successful ping between the host and the DPU at %s.",
+			lastPing.UTC().Format(time.RFC3339))
+	}
+	return condition
+}
+
+// vspPodCondition reports whether the VSP pod of the DPU is running.
+func (d *Daemon) vspPodCondition(ctx context.Context, dpuCR *configv1.DataProcessingUnit) metav1.Condition {
+	pods := &corev1.PodList{}
+	err := d.client.List(ctx, pods, client.InNamespace(vars.Namespace), client.MatchingLabels{
+		vspPodAppLabel:     vspPodAppLabelVsp,
+		vspPodDpuNameLabel: dpuCR.Name,
+	})
+	if err != nil {
+		return metav1.Condition{
+			Type:               configv1.DpuConditionVspPodRunning,
+			Status:             metav1.ConditionUnknown,
+			Reason:             "ListFailed",
+			Message:            fmt.Sprintf("Failed to list the VSP pods: %v", err),
+			LastTransitionTime: metav1.Now(),
+		}
+	}
+	return vspPodConditionFromPods(pods.Items)
+}
+
+func vspPodConditionFromPods(pods []corev1.Pod) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               configv1.DpuConditionVspPodRunning,
+		LastTransitionTime: metav1.Now(),
+	}
+	if len(pods) == 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "PodNotFound"
+		condition.Message = "No VSP pod found for the DPU."
+		return condition
+	}
+	for i := range pods {
+		if vspPodRunning(&pods[i]) {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "Running"
+			condition.Message = fmt.Sprintf("VSP pod %s is running.", pods[i].Name)
+			return condition
+		}
+	}
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = "PodNotRunning"
+	condition.Message = fmt.Sprintf("VSP pod %s is %s and not ready.", pods[0].Name, pods[0].Status.Phase)
+	return condition
+}
+
+func vspPodRunning(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// registryPluginCondition reports the result of the registry plugin health
+// check. A failing check is only reported as False, and makes the devices
+// unhealthy, if an OPI endpoint is configured for the plugin. Otherwise the
+// plugin checked the default endpoint, which the DPU may not serve at all.
+func registryPluginCondition(err error, endpointConfigured bool) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               configv1.DpuConditionRegistryPluginHealthy,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch {
+	case errors.Is(err, pkgplugin.ErrPluginNotFound):
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "NoRegistryPlugin"
+		condition.Message = "The DPU has no vendor plugin in the operator."
+	case err != nil && !endpointConfigured:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "NoEndpointConfigured"
+		condition.Message = fmt.Sprintf("Vendor plugin health check on the default OPI endpoint failed: %v", err)
+	case err != nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "HealthCheckFailed"
+		condition.Message = fmt.Sprintf("Vendor plugin health check failed: %v", err)
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Healthy"
+		condition.Message = "Vendor plugin health check passed."
+	}
+	return condition
+}
@@ -0,0 +1,221 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// conditionsSideManager reports a fixed heartbeat and device plugin state.
+type conditionsSideManager struct {
+	pingOk       bool
+	lastPing     time.Time
+	dpRegistered bool
+}
+
+func (m *conditionsSideManager) StartVsp(context.Context) error                       { return nil }
+func (m *conditionsSideManager) SetupDevices() error                                  { return nil }
+func (m *conditionsSideManager) Listen() (net.Listener, error)                        { return nil, nil }
+func (m *conditionsSideManager) Serve(context.Context, net.Listener) error            { return nil }
+func (m *conditionsSideManager) CheckPing() bool                                      { return m.pingOk }
+func (m *conditionsSideManager) LastPing() time.Time                                  { return m.lastPing }
+func (m *conditionsSideManager) DevicePluginRegistered() bool                         { return m.dpRegistered }
+func (m *conditionsSideManager) PhysicalFunctions() []configv1.PhysicalFunctionStatus { return nil }
+
+// healthSideManager records the health of the DPU reported to its devices.
+type healthSideManager struct {
+	conditionsSideManager
+	health map[string]error
+}
+
+func (m *healthSideManager) SetDeviceHealth(source string, err error) {
+	m.health[source] = err
+}
+
+func condition(conditionType string, status metav1.ConditionStatus, reason string) metav1.Condition {
+	return metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            reason,
+		LastTransitionTime: metav1.Now(),
+	}
+}
+
+var _ = g.Describe("DPU conditions", func() {
+	var d *Daemon
+
+	g.BeforeEach(func() {
+		d = &Daemon{}
+	})
+
+	g.Describe("conditionsNeedUpdate", func() {
+		var current []metav1.Condition
+
+		g.BeforeEach(func() {
+			current = []metav1.Condition{
+				condition(plugin.ReadyConditionType, metav1.ConditionTrue, "Initialized"),
+				condition(configv1.DpuConditionHeartbeatHealthy, metav1.ConditionTrue, "PingSucceeded"),
+			}
+		})
+
+		g.It("ignores the transition time", func() {
+			desired := []metav1.Condition{current[1], current[0]}
+			desired[0].LastTransitionTime = metav1.NewTime(time.Now().Add(time.Hour))
+			Expect(d.conditionsNeedUpdate(current, desired)).To(BeFalse())
+		})
+
+		g.It("tracks conditions other than Ready", func() {
+			desired := []metav1.Condition{
+				current[0],
+				condition(configv1.DpuConditionHeartbeatHealthy, metav1.ConditionFalse, "PingFailed"),
+			}
+			Expect(d.conditionsNeedUpdate(current, desired)).To(BeTrue())
+		})
+
+		g.It("detects added and removed conditions", func() {
+			Expect(d.conditionsNeedUpdate(current, current[:1])).To(BeTrue())
+			Expect(d.conditionsNeedUpdate(current[:1], current)).To(BeTrue())
+			Expect(d.conditionsNeedUpdate(nil, nil)).To(BeFalse())
+		})
+	})
+
+	g.It("reports the last successful ping of a failing heartbeat", func() {
+		lastPing := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+		Expect(heartbeatCondition(true, lastPing).Status).To(Equal(metav1.ConditionTrue))
+		Expect(heartbeatCondition(false, time.Time{}).Reason).To(Equal("NoPing"))
+
+		failing := heartbeatCondition(false, lastPing)
+		Expect(failing.Status).To(Equal(metav1.ConditionFalse))
+		Expect(failing.Reason).To(Equal("PingFailed"))
+		Expect(failing.Message).To(ContainSubstring("2024-01-01T12:00:00Z"))
+	})
+
+	g.Describe("vspPodConditionFromPods", func() {
+		vspPod := func(name string, phase corev1.PodPhase, ready corev1.ConditionStatus) corev1.Pod {
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status: corev1.PodStatus{
+					Phase:      phase,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+				},
+			}
+		}
+
+		g.It("is False without a VSP pod", func() {
+			Expect(vspPodConditionFromPods(nil).Reason).To(Equal("PodNotFound"))
+		})
+
+		g.It("is False while the VSP pod is not ready", func() {
+			c := vspPodConditionFromPods([]corev1.Pod{vspPod("vsp-a", corev1.PodRunning, corev1.ConditionFalse)})
+			Expect(c.Status).To(Equal(metav1.ConditionFalse))
+			Expect(c.Reason).To(Equal("PodNotRunning"))
+		})
+
+		g.It("is True with a running and ready VSP pod", func() {
+			c := vspPodConditionFromPods([]corev1.Pod{
+				vspPod("vsp-old", corev1.PodFailed, corev1.ConditionFalse),
+				vspPod("vsp-new", corev1.PodRunning, corev1.ConditionTrue),
+			})
+			Expect(c.Status).To(Equal(metav1.ConditionTrue))
+			Expect(c.Message).To(ContainSubstring("vsp-new"))
+		})
+	})
+
+	g.It("reports the registry plugin health", func() {
+		Expect(registryPluginCondition(nil, true).Status).To(Equal(metav1.ConditionTrue))
+		Expect(registryPluginCondition(pkgplugin.ErrPluginNotFound, false).Status).To(Equal(metav1.ConditionUnknown))
+
+		failed := registryPluginCondition(fmt.Errorf("OPI client not connected"), true)
+		Expect(failed.Status).To(Equal(metav1.ConditionFalse))
+		Expect(failed.Message).To(ContainSubstring("OPI client not connected"))
+
+		unconfigured := registryPluginCondition(fmt.Errorf("OPI client not connected"), false)
+		Expect(unconfigured.Status).To(Equal(metav1.ConditionUnknown))
+		Expect(unconfigured.Reason).To(Equal("NoEndpointConfigured"))
+		Expect(registryPluginCondition(nil, false).Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	g.It("reports the devices unhealthy while the heartbeat or the health check fails", func() {
+		manager := &healthSideManager{health: map[string]error{}}
+		managed := &ManagedDpu{
+			DpuCR:   &configv1.DataProcessingUnit{},
+			Manager: manager,
+		}
+		conditions := &managed.DpuCR.Status.Conditions
+		meta.SetStatusCondition(conditions, heartbeatCondition(false, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)))
+		meta.SetStatusCondition(conditions, registryPluginCondition(pkgplugin.ErrPluginNotFound, false))
+
+		updateDeviceHealth(managed)
+		Expect(manager.health[deviceplugin.HealthSourceHeartbeat]).To(MatchError(ContainSubstring("2024-01-01T12:00:00Z")))
+		Expect(manager.health).To(HaveKeyWithValue(deviceplugin.HealthSourceHealthCheck, BeNil()),
+			"a DPU without a registry plugin is not unhealthy")
+
+		meta.SetStatusCondition(conditions, heartbeatCondition(true, time.Now()))
+		meta.SetStatusCondition(conditions, registryPluginCondition(fmt.Errorf("OPI client not connected"), true))
+		updateDeviceHealth(managed)
+		Expect(manager.health[deviceplugin.HealthSourceHeartbeat]).To(BeNil())
+		Expect(manager.health[deviceplugin.HealthSourceHealthCheck]).To(MatchError(ContainSubstring("OPI client not connected")))
+
+		meta.SetStatusCondition(conditions, registryPluginCondition(nil, true))
+		updateDeviceHealth(managed)
+		Expect(manager.health[deviceplugin.HealthSourceHealthCheck]).To(BeNil())
+
+		meta.SetStatusCondition(conditions, registryPluginCondition(fmt.Errorf("OPI client not connected"), false))
+		updateDeviceHealth(managed)
+		Expect(manager.health[deviceplugin.HealthSourceHealthCheck]).To(BeNil(),
+			"a plugin without a configured endpoint does not make the devices unhealthy")
+	})
+
+	g.It("tells a failing heartbeat apart from an uninitialized plugin", func() {
+		grpcPlugin, err := plugin.NewGrpcPlugin(false, "bf3-0", nil)
+		Expect(err).NotTo(HaveOccurred())
+		grpcPlugin.SetInitDone(true)
+
+		now := time.Now()
+		managed := &ManagedDpu{
+			DpuCR:   &configv1.DataProcessingUnit{ObjectMeta: metav1.ObjectMeta{Name: "bf3-0"}},
+			Plugin:  grpcPlugin,
+			Manager: &conditionsSideManager{lastPing: now.Add(-time.Minute), dpRegistered: true},
+			// Skip the checks that need the API server.
+			ConditionsChecked: now,
+		}
+		d.updateConditions(context.Background(), managed, now)
+
+		conditions := managed.DpuCR.Status.Conditions
+		Expect(meta.IsStatusConditionTrue(conditions, configv1.DpuConditionDetected)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(conditions, configv1.DpuConditionPluginInitialized)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(conditions, configv1.DpuConditionDevicePluginRegistered)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(conditions, configv1.DpuConditionHeartbeatHealthy)).To(BeTrue())
+
+		ready := meta.FindStatusCondition(conditions, plugin.ReadyConditionType)
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal("PingFailed"))
+	})
+})
+
+var _ = g.Describe("DPU OPI endpoint", func() {
+	g.It("is only reported when reachable from another node", func() {
+		Expect(reachableEndpoint("")).To(BeEmpty())
+		Expect(reachableEndpoint("localhost:50051")).To(BeEmpty())
+		Expect(reachableEndpoint("127.0.0.1:50051")).To(BeEmpty())
+		Expect(reachableEndpoint("[::1]:50051")).To(BeEmpty())
+		Expect(reachableEndpoint("0.0.0.0:50051")).To(BeEmpty())
+		Expect(reachableEndpoint("192.168.1.2")).To(BeEmpty())
+		Expect(reachableEndpoint("192.168.1.2:50051")).To(Equal("192.168.1.2:50051"))
+		Expect(reachableEndpoint("dpu-1.example.com:50051")).To(Equal("dpu-1.example.com:50051"))
+	})
+})
@@ -2,25 +2,29 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"reflect"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
 	configv1 "github.com/openshift/dpu-operator/api/v1"
+	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	"github.com/openshift/dpu-operator/internal/images"
 	"github.com/openshift/dpu-operator/internal/platform"
 	"github.com/openshift/dpu-operator/internal/scheme"
 	"github.com/openshift/dpu-operator/internal/utils"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	"github.com/openshift/dpu-operator/pkgs/drain"
 	"github.com/openshift/dpu-operator/pkgs/vars"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -28,6 +32,7 @@ import (
 	"github.com/spf13/afero"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -35,6 +40,10 @@ var ()
 
 const DpuSideLabelKey = "dpu.config.openshift.io/dpuside"
 
+// dpuResyncInterval is how often the DPUs are detected while PCI uevents are
+// watched, in case a uevent was missed.
+const dpuResyncInterval = time.Minute
+
 type SideManager interface {
 	StartVsp(ctx context.Context) error
 	SetupDevices() error
@@ -43,6 +52,12 @@ type SideManager interface {
 	CheckPing() bool
 }
 
+// physicalFunctionReporter is implemented by side managers that know which
+// host VFs have a bridge port.
+type physicalFunctionReporter interface {
+	PhysicalFunctions() []configv1.PhysicalFunctionStatus
+}
+
 // ManagedDpu represents a DPU with all its runtime state and management components
 type ManagedDpu struct {
 	DpuCR          *configv1.DataProcessingUnit
@@ -51,6 +66,34 @@ type ManagedDpu struct {
 	Cancel         context.CancelFunc
 	Done           chan struct{}
 	AppliedVfCount *int32
+	// Supervisor runs Manager and replaces it with a new one when it fails.
+	Supervisor *sideManagerSupervisor
+	// InventoryRefreshed is when the inventory was last read from the plugin.
+	InventoryRefreshed time.Time
+	// InventoryRead reads the inventory in the background, nil while no
+	// read is in progress.
+	InventoryRead *inventoryRead
+	// ConditionsChecked is when the conditions that need a call to the API
+	// server or to the registry plugin were last refreshed.
+	ConditionsChecked time.Time
+	// VfCountFailure is the last VF count that failed to apply on the drained node.
+	VfCountFailure *vfCountFailure
+	// FirmwareVersions are the firmware versions last read from the plugin.
+	FirmwareVersions []pkgplugin.FirmwareVersion
+	// FirmwareRefreshed is when the firmware versions were last read.
+	FirmwareRefreshed time.Time
+	// FirmwareReadErr is the error of the last read of the firmware versions.
+	FirmwareReadErr error
+	// FirmwareFailure is the last firmware version that failed to install.
+	FirmwareFailure *firmwareFailure
+	// Missing is set while a host-side DPU is not detected anymore, but kept
+	// managed until it is back from its reset.
+	Missing bool
+	// Reboot follows the DPU through a reset, nil while it is not resetting.
+	Reboot *dpuReboot
+	// RebootFailure is the last reboot of the DPU, if it failed. The DPU is
+	// not followed through another reset until its heartbeat is back.
+	RebootFailure *configv1.DpuRebootState
 }
 
 type Daemon struct {
@@ -60,11 +103,36 @@ type Daemon struct {
 	config            *rest.Config
 	managers          []SideManager
 	client            client.Client
+	dpuCache          cache.Cache
 	fs                afero.Fs
 	p                 platform.Platform
 	dpuDetectorManger *platform.DpuDetectorManager
 	managedDpus       map[string]*ManagedDpu
 	nodeName          string
+	// drainer and vfCountRollout roll VF count changes out on the node.
+	drainer        nodeDrainer
+	vfCountRollout *vfCountRollout
+	// firmwareRollout installs firmware on the DPUs of the node. A node that
+	// must reboot for the firmware to run is recorded on the node, and checked
+	// once per daemon start.
+	firmwareRollout       *firmwareRollout
+	firmwareRebootChecked bool
+	firmwareRebootPending bool
+	firmwareRebootSince   time.Time
+	// The DPUs that reset are followed by the dpuReboot of their ManagedDpu,
+	// according to the DpuResetPolicy of the DpuOperatorConfig. The DPUs the
+	// node is cordoned for are recorded on the node, and checked once per
+	// daemon start.
+	resetCordon           bool
+	resetTimeout          time.Duration
+	rebootHoldMutex       sync.Mutex
+	rebootHoldsChecked    bool
+	rebootRequestsChecked time.Time
+	// dpuRemovalGracePeriod is how long a DPU is not detected before its CR
+	// is deleted.
+	dpuRemovalGracePeriod time.Duration
+	// detection schedules the detection of the DPUs of the node on PCI uevents.
+	detection detectionSchedule
 	// Readiness state tracking
 	readyMutex sync.RWMutex
 	isReady    bool
@@ -72,6 +140,7 @@ type Daemon struct {
 
 func NewDaemon(fs afero.Fs, p platform.Platform, config *rest.Config, imageManager images.ImageManager, pathManager *utils.PathManager, nodeName string) Daemon {
 	log := ctrl.Log.WithName("Daemon")
+	resetCordon, resetTimeout := resetPolicyFromEnv()
 	return Daemon{
 		fs:                fs,
 		pm:                pathManager,
@@ -83,6 +152,10 @@ func NewDaemon(fs afero.Fs, p platform.Platform, config *rest.Config, imageManag
 		managers:          make([]SideManager, 0),
 		managedDpus:       make(map[string]*ManagedDpu),
 		nodeName:          nodeName,
+		resetCordon:       resetCordon,
+		resetTimeout:      resetTimeout,
+
+		dpuRemovalGracePeriod: dpuRemovalGracePeriodFromEnv(),
 	}
 }
 
@@ -176,6 +249,11 @@ func (d *Daemon) Prepare() error {
 		return fmt.Errorf("Failed to create client: %v", err)
 	}
 
+	d.dpuCache, err = newDpuCache(d.config, d.nodeName)
+	if err != nil {
+		return err
+	}
+
 	err = d.prepareCni()
 	if err != nil {
 		return err
@@ -204,19 +282,51 @@ func (d *Daemon) Serve(ctx context.Context) error {
 		}
 	}()
 
+	// Read the DPUs of the node from a cache rather than listing all the DPUs
+	// of the cluster on every iteration.
+	if d.dpuCache != nil {
+		cacheDone := make(chan struct{})
+		routineDone = append(routineDone, cacheDone)
+		if err := d.startDpuCache(routineCtx, cacheDone, errChan); err != nil {
+			cancelRoutines()
+			for _, done := range routineDone {
+				<-done
+			}
+			return err
+		}
+	}
+
+	// Detect the DPUs when the PCI devices change, and every
+	// dpuResyncInterval in case a change was missed.
+	pciEvents, err := d.p.WatchPciDevices(routineCtx)
+	if err != nil {
+		d.log.Error(err, "Failed to watch PCI uevents, detecting DPUs every second")
+	}
+	d.detection = detectionSchedule{watching: err == nil}
+
 	d.log.Info("Entering main daemon loop")
 
 	for {
 		select {
-		case <-ticker.C:
-			detectedDpusList, err := d.dpuDetectorManger.DetectAll(d.imageManager, d.client, *d.pm, d.nodeName)
-			if err != nil {
-				d.log.Error(err, "Got error while detecting DPUs")
-				return err
+		case _, ok := <-pciEvents:
+			if !ok {
+				d.log.Info("PCI uevent watch stopped, detecting DPUs every second")
+				pciEvents = nil
+				d.detection.watching = false
 			}
+			d.detection.pending = true
+		case <-ticker.C:
+			if detectNow := time.Now(); d.detection.due(detectNow) {
+				d.detection.done(detectNow)
+				detectedDpusList, err := d.dpuDetectorManger.DetectAll(d.imageManager, d.client, *d.pm, d.nodeName)
+				if err != nil {
+					d.log.Error(err, "Got error while detecting DPUs")
+					return err
+				}
 
-			// Update managed DPUs with newly detected ones
-			d.updateManagedDpus(detectedDpusList)
+				// Update managed DPUs with newly detected ones
+				d.updateManagedDpus(detectedDpusList)
+			}
 
 			// Log DPU count for observability
 			if len(d.managedDpus) > 1 {
@@ -236,54 +346,52 @@ func (d *Daemon) Serve(ctx context.Context) error {
 					managedDpu.Manager = sideManager
 					d.managers = append(d.managers, sideManager)
 
+					// A failing side manager is restarted on its own, only
+					// failing to create a new one stops the daemon.
+					dpuCR, dpuPlugin := managedDpu.DpuCR, managedDpu.Plugin
+					supervisor := newSideManagerSupervisor(d.log.WithValues("dpu", identifier), sideManager,
+						func() (SideManager, error) {
+							return d.createSideManager(dpuCR, dpuPlugin)
+						},
+						func(ctx context.Context, mgr SideManager) error {
+							return d.runSideManager(mgr, identifier, ctx)
+						})
+					managedDpu.Supervisor = supervisor
+
 					managerCtx, cancel := context.WithCancel(routineCtx)
 					done := make(chan struct{})
 					routineDone = append(routineDone, done)
 					managedDpu.Cancel = cancel
 					managedDpu.Done = done
-					go func(mgr SideManager, identifier string, doneChannel chan struct{}) {
+					go func(doneChannel chan struct{}) {
 						defer close(doneChannel)
-						err := d.runSideManager(mgr, identifier, managerCtx)
+						err := supervisor.supervise(managerCtx)
 						if err != nil {
 							errChan <- err
 						}
-					}(sideManager, identifier, done)
+					}(done)
 				}
 			}
 
+			now := time.Now()
+			// Follow the DPUs that reset or that DpuReboots request to reboot.
+			d.handleDpuReboots(routineCtx, now)
+
 			for _, managedDpu := range d.managedDpus {
-				var newCondition metav1.Condition
-				if managedDpu.Plugin.IsInitialized() {
-					if managedDpu.Manager.CheckPing() {
-						newCondition = metav1.Condition{
-							Type:    plugin.ReadyConditionType,
-							Status:  metav1.ConditionTrue,
-							Reason:  "Initialized",
-							Message: "DPU plugin is initialized and ping successful.",
-						}
-					} else {
-						newCondition = metav1.Condition{
-							Type:    plugin.ReadyConditionType,
-							Status:  metav1.ConditionFalse,
-							Reason:  "PingFailed",
-							Message: "DPU plugin is initialized but ping failed.",
-						}
-					}
-				} else {
-					newCondition = metav1.Condition{
-						Type:    plugin.ReadyConditionType,
-						Status:  metav1.ConditionFalse,
-						Reason:  "NotInitialized",
-						Message: "DPU plugin is not yet initialized.",
-					}
-				}
-				// Always set a transition time
-				newCondition.LastTransitionTime = metav1.Now()
+				observeSideManager(managedDpu)
+				d.updateConditions(routineCtx, managedDpu, now)
+				updateDeviceHealth(managedDpu)
 
-				// Use the helper to add or update the condition
-				meta.SetStatusCondition(&managedDpu.DpuCR.Status.Conditions, newCondition)
+				if reporter, ok := managedDpu.Manager.(physicalFunctionReporter); ok {
+					managedDpu.DpuCR.Status.PhysicalFunctions = reporter.PhysicalFunctions()
+				}
+				if managedDpu.Plugin != nil {
+					managedDpu.DpuCR.Status.OpiEndpoint = reachableEndpoint(managedDpu.Plugin.RegistryEndpoint())
+				}
 			}
 
+			d.refreshInventories(routineCtx, now)
+
 			// Sync DPU CRs with the current state
 			changed, err := d.SyncDpuCRs()
 			if err != nil {
@@ -298,8 +406,11 @@ func (d *Daemon) Serve(ctx context.Context) error {
 				return err
 			}
 
-			// Apply any desired VF count overrides from DataProcessingUnitConfig annotations.
-			d.applyDesiredVfCounts(context.Background())
+			// Apply the VF counts of the effective configs of the DPUs.
+			d.applyDesiredVfCounts(context.Background(), now)
+
+			// Install the firmware the DpuFirmwarePolicies set on the DPUs.
+			d.applyDesiredFirmware(context.Background(), now)
 
 			// Mark daemon as ready only when DPU CRs are fully in sync
 			// This ensures CRs are queryable before signaling readiness
@@ -318,13 +429,36 @@ func (d *Daemon) Serve(ctx context.Context) error {
 	}
 }
 
+// detectionSchedule decides when the daemon loop detects the DPUs of the
+// node. Detecting enumerates all the PCI devices, so while PCI uevents are
+// watched it only runs on the first iteration, after a uevent, and every
+// dpuResyncInterval in case a uevent was missed. Without the watch, it runs
+// on every iteration.
+type detectionSchedule struct {
+	watching bool
+	pending  bool
+	last     time.Time
+}
+
+func (s *detectionSchedule) due(now time.Time) bool {
+	return !s.watching || s.pending || s.last.IsZero() || now.Sub(s.last) >= dpuResyncInterval
+}
+
+func (s *detectionSchedule) done(now time.Time) {
+	s.pending = false
+	s.last = now
+}
+
 func (d *Daemon) shutdown(cancelRoutines context.CancelFunc, routineDone []chan struct{}) {
-	// Clean up all DPU CRs by clearing managed DPUs and syncing
-	d.log.Info("Cleaning up DPU CRs before shutdown")
-	d.managedDpus = make(map[string]*ManagedDpu)
+	// Keep the DPU CRs, so that the daemon picks them up with their status
+	// once it is back, and only report that they are not watched meanwhile.
+	d.log.Info("Marking DPU CRs stale before shutdown")
+	for _, managedDpu := range d.managedDpus {
+		markDpuStale(managedDpu.DpuCR)
+	}
 	_, err := d.SyncDpuCRs()
 	if err != nil {
-		d.log.Error(err, "Failed to clean up DPU CRs during shutdown")
+		d.log.Error(err, "Failed to mark DPU CRs stale during shutdown")
 	}
 
 	// Stop all routines
@@ -355,21 +489,16 @@ func (d *Daemon) createSideManager(dpuCR *configv1.DataProcessingUnit, dpuPlugin
 // SyncDpuCRs synchronizes DPU CRs with Kubernetes.
 // Returns (true, nil) if any changes were made, (false, nil) if everything was in sync.
 func (d *Daemon) SyncDpuCRs() (bool, error) {
-	// Get all existing DPU CRs from K8s
-	existingCRs := &configv1.DataProcessingUnitList{}
-	err := d.client.List(context.TODO(), existingCRs)
+	// Get the existing DPU CRs of this node
+	existingCRs, err := d.listNodeDpus(context.TODO())
 	if err != nil {
 		return false, fmt.Errorf("Failed to list existing DPU CRs: %v", err)
 	}
 
-	// Create a map of existing CRs by name for quick lookup, filtered by node name
+	// Create a map of existing CRs by name for quick lookup
 	existingCRMap := make(map[string]*configv1.DataProcessingUnit)
-	for i := range existingCRs.Items {
-		cr := &existingCRs.Items[i]
-		// Only include CRs that belong to this node
-		if cr.Spec.NodeName == d.nodeName {
-			existingCRMap[cr.Name] = cr
-		}
+	for i := range existingCRs {
+		existingCRMap[existingCRs[i].Name] = &existingCRs[i]
 	}
 
 	changed := false
@@ -385,16 +514,16 @@ func (d *Daemon) SyncDpuCRs() (bool, error) {
 		}
 	}
 
-	// Check for orphaned CRs that no longer have corresponding in-memory DPUs
-	// Only consider CRs that belong to this node
+	// Check for orphaned CRs that no longer have corresponding in-memory DPUs,
+	// and delete them once they were not detected for the grace period
+	now := time.Now()
 	for crName, orphanedCR := range existingCRMap {
 		if _, exists := d.managedDpus[crName]; !exists {
-			d.log.Info("Found orphaned DPU CR for this node, removing it", "crName", crName, "nodeName", d.nodeName)
-			err := d.client.Delete(context.TODO(), orphanedCR)
+			updated, err := d.syncUndetectedDpuCR(orphanedCR, now)
 			if err != nil {
-				d.log.Error(err, "Failed to delete orphaned DPU CR", "crName", crName)
-			} else {
-				d.log.Info("Successfully deleted orphaned DPU CR", "crName", crName)
+				d.log.Error(err, "Failed to sync orphaned DPU CR", "crName", crName)
+			}
+			if updated {
 				changed = true
 			}
 		}
@@ -408,32 +537,26 @@ func (d *Daemon) SyncDpuCRs() (bool, error) {
 func (d *Daemon) syncSingleDpuCR(dpuCR *configv1.DataProcessingUnit, existingCRMap map[string]*configv1.DataProcessingUnit) (bool, error) {
 	identifier := dpuCR.Name
 
-	// Check if CR exists in the cluster
-	currentDpuCR := &configv1.DataProcessingUnit{}
-	err := d.client.Get(context.TODO(), client.ObjectKey{
-		Name:      identifier,
-		Namespace: dpuCR.Namespace,
-	}, currentDpuCR)
-
-	if err != nil {
-		if client.IgnoreNotFound(err) == nil {
-			// CR doesn't exist, create it
-			// TODO FIXME: client Create/Update will update the dpuCR with metafields such as resourceVersion. Meaning that the dpuCR pointer cannot be
-			// reused to create a new DPU CR. For now, we do a deep copy of the dpuCR and use that to update the status.
-			dpuCRCopy := dpuCR.DeepCopy()
-
-			// Set owner reference to DpuOperatorConfig
-			if err := d.setOwnerReference(dpuCRCopy); err != nil {
-				return false, fmt.Errorf("Failed to set owner reference for DPU CR %s: %v", identifier, err)
-			}
-
-			err := d.client.Create(context.TODO(), dpuCRCopy)
-			if err != nil {
-				return false, fmt.Errorf("Failed to create DPU CR %s: %v", identifier, err)
-			}
+	// Check if CR exists in the cluster. The existing CRs come from the DPU
+	// cache, which may not hold a CR created by a previous iteration yet.
+	currentDpuCR, exists := existingCRMap[identifier]
+	if !exists {
+		// CR doesn't exist, create it
+		// TODO FIXME: client Create/Update will update the dpuCR with metafields such as resourceVersion. Meaning that the dpuCR pointer cannot be
+		// reused to create a new DPU CR. For now, we do a deep copy of the dpuCR and use that to update the status.
+		dpuCRCopy := dpuCR.DeepCopy()
+
+		// Set owner reference to DpuOperatorConfig
+		if err := d.setOwnerReference(dpuCRCopy); err != nil {
+			return false, fmt.Errorf("Failed to set owner reference for DPU CR %s: %v", identifier, err)
+		}
 
-			// Update status after creation
-			err = d.client.Status().Update(context.TODO(), dpuCRCopy)
+		err := d.client.Create(context.TODO(), dpuCRCopy)
+		if err == nil {
+			// Set the status after creation, which drops it
+			patch := client.MergeFrom(dpuCRCopy.DeepCopy())
+			dpuCRCopy.Status = dpuCR.Status
+			err = d.client.Status().Patch(context.TODO(), dpuCRCopy, patch)
 			if err != nil {
 				return false, fmt.Errorf("Failed to update DPU CR status %s: %v", identifier, err)
 			}
@@ -441,27 +564,56 @@ func (d *Daemon) syncSingleDpuCR(dpuCR *configv1.DataProcessingUnit, existingCRM
 			d.log.Info("Created DPU CR", "name", identifier, "dpuProductName", dpuCRCopy.Spec.DpuProductName, "isDpuSide", dpuCRCopy.Spec.IsDpuSide)
 			return true, nil
 		}
-		return false, fmt.Errorf("Failed to get DPU CR %s: %v", identifier, err)
+		if !apierrors.IsAlreadyExists(err) {
+			return false, fmt.Errorf("Failed to create DPU CR %s: %v", identifier, err)
+		}
+
+		currentDpuCR = &configv1.DataProcessingUnit{}
+		err = d.client.Get(context.TODO(), client.ObjectKey{
+			Name:      identifier,
+			Namespace: dpuCR.Namespace,
+		}, currentDpuCR)
+		if err != nil {
+			return false, fmt.Errorf("Failed to get DPU CR %s: %v", identifier, err)
+		}
 	}
+	// The cached CR is shared, only patch a copy of it.
+	currentDpuCR = currentDpuCR.DeepCopy()
+	patch := client.MergeFrom(currentDpuCR.DeepCopy())
 
 	// CR exists, update it to reflect all fields
-	needsSpecUpdate := !reflect.DeepEqual(currentDpuCR.Spec, dpuCR.Spec)
+	// The effective config is owned by the DataProcessingUnitConfig controller.
+	desiredSpec := dpuCR.Spec
+	desiredSpec.EffectiveConfig = currentDpuCR.Spec.EffectiveConfig
+	// The firmware target is owned by the DpuFirmwarePolicy controller.
+	desiredSpec.Firmware = currentDpuCR.Spec.Firmware
+	needsSpecUpdate := !reflect.DeepEqual(currentDpuCR.Spec, desiredSpec)
 	needsMetadataUpdate := mergeLabels(currentDpuCR, dpuCR.Labels)
 	// For status, compare conditions  rather than using reflect.DeepEqual
 	// which fails due to LastTransitionTime and other Kubernetes metadata differences
-	needsStatusUpdate := d.conditionsNeedUpdate(currentDpuCR.Status.Conditions, dpuCR.Status.Conditions)
-
+	needsStatusUpdate := d.conditionsNeedUpdate(currentDpuCR.Status.Conditions, dpuCR.Status.Conditions) ||
+		currentDpuCR.Status.OpiEndpoint != dpuCR.Status.OpiEndpoint ||
+		!reflect.DeepEqual(currentDpuCR.Status.PhysicalFunctions, dpuCR.Status.PhysicalFunctions) ||
+		inventoryNeedsUpdate(currentDpuCR.Status.Inventory, dpuCR.Status.Inventory) ||
+		!reflect.DeepEqual(currentDpuCR.Status.VfCount, dpuCR.Status.VfCount) ||
+		!reflect.DeepEqual(currentDpuCR.Status.Firmware, dpuCR.Status.Firmware) ||
+		rebootNeedsUpdate(currentDpuCR.Status.Reboot, dpuCR.Status.Reboot) ||
+		managerNeedsUpdate(currentDpuCR.Status.Manager, dpuCR.Status.Manager)
+
+	// Patch rather than update, so that the fields other controllers own and
+	// the cache has not seen yet are not overwritten.
 	if needsSpecUpdate || needsMetadataUpdate {
-		currentDpuCR.Spec = dpuCR.Spec
-		err := d.client.Update(context.TODO(), currentDpuCR)
+		currentDpuCR.Spec = desiredSpec
+		err := d.client.Patch(context.TODO(), currentDpuCR, patch)
 		if err != nil {
 			return false, fmt.Errorf("Failed to update DPU CR spec %s: %v", identifier, err)
 		}
 	}
 
 	if needsStatusUpdate {
+		statusPatch := client.MergeFrom(currentDpuCR.DeepCopy())
 		currentDpuCR.Status = dpuCR.Status
-		err := d.client.Status().Update(context.TODO(), currentDpuCR)
+		err := d.client.Status().Patch(context.TODO(), currentDpuCR, statusPatch)
 		if err != nil {
 			return false, fmt.Errorf("Failed to update DPU CR status %s: %v", identifier, err)
 		}
@@ -475,6 +627,19 @@ func (d *Daemon) syncSingleDpuCR(dpuCR *configv1.DataProcessingUnit, existingCRM
 	return false, nil
 }
 
+// reachableEndpoint returns endpoint if the operator can reach it from
+// another node, or "" if it is empty or on loopback.
+func reachableEndpoint(endpoint string) string {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil || host == "" || host == "localhost" {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsUnspecified()) {
+		return ""
+	}
+	return endpoint
+}
+
 func mergeLabels(target *configv1.DataProcessingUnit, desired map[string]string) bool {
 	if len(desired) == 0 {
 		return false
@@ -493,32 +658,50 @@ func mergeLabels(target *configv1.DataProcessingUnit, desired map[string]string)
 	return changed
 }
 
-// conditionsNeedUpdate compares the latest condition and returns true if it differs.
+// rebootNeedsUpdate compares the reboots reported on a DPU. The start time
+// is compared as an instant, as it loses its precision on the API server.
+func rebootNeedsUpdate(current, desired *configv1.DpuRebootState) bool {
+	if current == nil || desired == nil {
+		return current != desired
+	}
+	return current.Trigger != desired.Trigger ||
+		current.Phase != desired.Phase ||
+		current.Request != desired.Request ||
+		current.LastError != desired.LastError ||
+		!current.StartTime.Equal(&metav1.Time{Time: desired.StartTime.Truncate(time.Second)})
+}
+
+// conditionsNeedUpdate compares all conditions by type and returns true if any differs.
 // This comparison ignores lastTransitionTime differences that don't reflect actual condition changes.
 func (d *Daemon) conditionsNeedUpdate(current, desired []metav1.Condition) bool {
-	// Get the latest desired condition (assuming it's the last one or find by type)
-	// In this case, we're looking for the conditions which is what the daemon sets
-	latestDesired := meta.FindStatusCondition(desired, plugin.ReadyConditionType)
-	if latestDesired == nil {
-		// No Ready condition in desired, but we might have one in current. If we do, then update.
-		return meta.FindStatusCondition(current, plugin.ReadyConditionType) != nil
-	}
-
-	// Find the corresponding current condition
-	latestCurrent := meta.FindStatusCondition(current, plugin.ReadyConditionType)
-	if latestCurrent == nil {
-		// Condition doesn't exist in current, update is needed
+	// Condition types are unique, so a different length means a condition
+	// was added or removed.
+	if len(current) != len(desired) {
 		return true
 	}
 
-	// Compare the fields (ignoring lastTransitionTime)
-	return latestCurrent.Status != latestDesired.Status ||
-		latestCurrent.Reason != latestDesired.Reason ||
-		latestCurrent.Message != latestDesired.Message ||
-		latestCurrent.ObservedGeneration != latestDesired.ObservedGeneration
+	for _, desiredCondition := range desired {
+		currentCondition := meta.FindStatusCondition(current, desiredCondition.Type)
+		if currentCondition == nil {
+			// Condition doesn't exist in current, update is needed
+			return true
+		}
+
+		// Compare the fields (ignoring lastTransitionTime)
+		if currentCondition.Status != desiredCondition.Status ||
+			currentCondition.Reason != desiredCondition.Reason ||
+			currentCondition.Message != desiredCondition.Message ||
+			currentCondition.ObservedGeneration != desiredCondition.ObservedGeneration {
+			return true
+		}
+	}
+	return false
 }
 
-func (d *Daemon) applyDesiredVfCounts(ctx context.Context) {
+func (d *Daemon) applyDesiredVfCounts(ctx context.Context, now time.Time) {
+	d.collectVfCountRollout(now)
+
+	var drainChanges []*vfCountChange
 	for name, managed := range d.managedDpus {
 		if managed == nil || managed.Plugin == nil || managed.DpuCR == nil {
 			continue
@@ -528,70 +711,213 @@ func (d *Daemon) applyDesiredVfCounts(ctx context.Context) {
 		}
 
 		current := &configv1.DataProcessingUnit{}
-		if err := d.client.Get(ctx, client.ObjectKey{Name: name}, current); err != nil {
+		if err := d.dpuReader().Get(ctx, client.ObjectKey{Name: name}, current); err != nil {
 			d.log.V(1).Info("Failed to fetch DPU CR for VF count evaluation", "dpu", name, "error", err)
 			continue
 		}
 
-		desired, ok, err := desiredVfCountFromAnnotations(current.Annotations)
+		conditions := &managed.DpuCR.Status.Conditions
+		desired, source, ok, err := desiredVfCount(current.Spec.EffectiveConfig)
 		if err != nil {
-			d.log.Info("Skipping VF count update due to annotation conflict", "dpu", name, "error", err)
+			d.log.Info("Skipping invalid VF count", "dpu", name, "error", err)
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse, "Invalid", err.Error())
+			managed.DpuCR.Status.VfCount = &configv1.VfCountStatus{
+				Applied:   managed.AppliedVfCount,
+				Sources:   []string{source},
+				State:     configv1.VfCountStateFailed,
+				LastError: err.Error(),
+			}
 			continue
 		}
 		if !ok {
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionTrue,
+				"NoOverride", "No DataProcessingUnitConfig requests a VF count.")
+			managed.DpuCR.Status.VfCount = nil
 			continue
 		}
 
+		d.seedAppliedVfCount(name, managed, managed.Plugin, current)
+		status := &configv1.VfCountStatus{
+			Desired: &desired,
+			Applied: managed.AppliedVfCount,
+			Sources: []string{source},
+			State:   configv1.VfCountStatePending,
+		}
+		managed.DpuCR.Status.VfCount = status
+
 		if managed.AppliedVfCount != nil && *managed.AppliedVfCount == desired {
+			status.State = configv1.VfCountStateApplied
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionTrue,
+				"Applied", fmt.Sprintf("VF count %d is applied.", desired))
+			continue
+		}
+
+		// Changes wait for the rollout in progress on the node.
+		if rollout := d.vfCountRollout; rollout != nil {
+			if phase, _ := rollout.state(); rollout.includes(name) {
+				status.Phase = phase
+			}
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse,
+				"RollingOut", fmt.Sprintf("VF count %d is rolling out on the node.", desired))
+			continue
+		}
+
+		// Changes wait for the firmware update in progress on the node.
+		if d.firmwareBusy() {
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse,
+				"Waiting", fmt.Sprintf("VF count %d waits for the firmware update on the node.", desired))
+			continue
+		}
+
+		// Changes wait for the DPUs of the node that reboot.
+		if d.rebootBusy() {
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse,
+				"Waiting", fmt.Sprintf("VF count %d waits for the DPU reboot on the node.", desired))
+			continue
+		}
+
+		if current.Spec.EffectiveConfig.Drain {
+			if failure := managed.VfCountFailure; failure != nil && failure.vfCount == desired &&
+				now.Sub(failure.at) < vfCountRetryInterval {
+				status.State = configv1.VfCountStateFailed
+				status.LastError = failure.err
+				setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse, "ApplyFailed", failure.err)
+				continue
+			}
+			drainChanges = append(drainChanges, &vfCountChange{dpu: name, setter: managed.Plugin, vfCount: desired})
+			status.Phase = configv1.VfCountPhaseDraining
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse,
+				"RollingOut", fmt.Sprintf("VF count %d is rolling out on the node.", desired))
 			continue
 		}
 
 		if _, err := managed.Plugin.SetNumVfs(desired); err != nil {
 			d.log.Info("Failed to apply VF count override", "dpu", name, "vfCount", desired, "error", err)
+			status.State = configv1.VfCountStateFailed
+			status.LastError = fmt.Sprintf("Failed to apply VF count %d: %v", desired, err)
+			setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionFalse, "ApplyFailed", status.LastError)
 			continue
 		}
 
 		managed.AppliedVfCount = &desired
+		status.Applied = &desired
+		status.State = configv1.VfCountStateApplied
+		setDpuCondition(conditions, configv1.DpuConditionVfCountApplied, metav1.ConditionTrue,
+			"Applied", fmt.Sprintf("VF count %d is applied.", desired))
 		d.log.Info("Applied VF count override", "dpu", name, "vfCount", desired)
 	}
+
+	if len(drainChanges) > 0 {
+		d.startVfCountRollout(drainChanges)
+	}
 }
 
-func desiredVfCountFromAnnotations(annotations map[string]string) (int32, bool, error) {
-	if len(annotations) == 0 {
-		return 0, false, nil
+// seedAppliedVfCount sets the applied VF count of a DPU the daemon has not
+// applied a VF count to since it started or since the DPU was reset, so that
+// a VF count that is already in place is not applied again with a drain of
+// the node. The count the plugin reports is preferred over the one a previous
+// run recorded in the DPU status.
+func (d *Daemon) seedAppliedVfCount(name string, managed *ManagedDpu, reader vfCountReader, current *configv1.DataProcessingUnit) {
+	if managed.AppliedVfCount != nil {
+		return
 	}
+	vfCount, err := reader.GetNumVfs()
+	if err == nil {
+		d.log.Info("Read the applied VF count from the plugin", "dpu", name, "vfCount", vfCount)
+		managed.AppliedVfCount = &vfCount
+		return
+	}
+	d.log.V(1).Info("Failed to read the VF count from the plugin", "dpu", name, "error", err)
+	if status := current.Status.VfCount; status != nil && status.Applied != nil {
+		vfCount := *status.Applied
+		d.log.Info("Using the applied VF count recorded in the DPU status", "dpu", name, "vfCount", vfCount)
+		managed.AppliedVfCount = &vfCount
+	}
+}
 
-	values := map[int32][]string{}
-	for key, value := range annotations {
-		if !strings.HasPrefix(key, vars.DpuConfigVFCountAnnotationPrefix) {
-			continue
+// startVfCountRollout drains the node in the background and applies the VF
+// count changes while it is drained.
+func (d *Daemon) startVfCountRollout(changes []*vfCountChange) {
+	rollout := newVfCountRollout(changes)
+	d.vfCountRollout = rollout
+
+	drainer, err := d.nodeDrainer()
+	if err != nil {
+		rollout.fail(err)
+		rollout.finish()
+		return
+	}
+
+	d.log.Info("Draining the node to apply VF counts", "node", d.nodeName, "dpus", len(changes))
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), vfCountRolloutTimeout)
+		defer cancel()
+
+		node := &corev1.Node{}
+		if err := d.client.Get(ctx, client.ObjectKey{Name: d.nodeName}, node); err != nil {
+			rollout.fail(fmt.Errorf("failed to get node %s: %v", d.nodeName, err))
+			rollout.finish()
+			return
 		}
-		parsed, err := strconv.Atoi(value)
+		rollout.run(ctx, drainer, node)
+	}()
+}
+
+// nodeDrainer returns the drainer of the node, creating it on first use.
+func (d *Daemon) nodeDrainer() (nodeDrainer, error) {
+	if d.drainer == nil {
+		drainer, err := drain.NewDrainer(d.config, drain.WithResourcePrefix(deviceplugin.DpuResourceName))
 		if err != nil {
-			return 0, false, fmt.Errorf("invalid vfCount %q in %s", value, key)
+			return nil, fmt.Errorf("failed to create the node drainer: %v", err)
 		}
-		if parsed <= 0 {
-			return 0, false, fmt.Errorf("vfCount must be > 0 in %s", key)
-		}
-		values[int32(parsed)] = append(values[int32(parsed)], key)
+		d.drainer = drainer
 	}
+	return d.drainer, nil
+}
 
-	if len(values) == 0 {
-		return 0, false, nil
+// collectVfCountRollout records the results of a finished rollout on the
+// managed DPUs. A failed change is retried after vfCountRetryInterval.
+func (d *Daemon) collectVfCountRollout(now time.Time) {
+	rollout := d.vfCountRollout
+	if rollout == nil {
+		return
 	}
+	if _, done := rollout.state(); !done {
+		return
+	}
+	d.vfCountRollout = nil
 
-	if len(values) > 1 {
-		conflicts := make([]string, 0, len(values))
-		for value, sources := range values {
-			conflicts = append(conflicts, fmt.Sprintf("%d=%v", value, sources))
+	for _, change := range rollout.changes {
+		managed, ok := d.managedDpus[change.dpu]
+		if !ok {
+			continue
+		}
+		if change.err != nil {
+			d.log.Info("Failed to apply VF count on the drained node", "dpu", change.dpu, "vfCount", change.vfCount, "error", change.err)
+			managed.VfCountFailure = &vfCountFailure{
+				vfCount: change.vfCount,
+				err:     fmt.Sprintf("VF count rollout on the node failed: %v", change.err),
+				at:      now,
+			}
+			continue
 		}
-		return 0, false, fmt.Errorf("conflicting vfCount annotations: %s", strings.Join(conflicts, "; "))
+		vfCount := change.vfCount
+		managed.AppliedVfCount = &vfCount
+		managed.VfCountFailure = nil
+		d.log.Info("Applied VF count override", "dpu", change.dpu, "vfCount", vfCount)
 	}
+}
 
-	for value := range values {
-		return value, true, nil
+// desiredVfCount returns the VF count of the effective config of a DPU and
+// the DataProcessingUnitConfig it comes from.
+func desiredVfCount(effective *configv1.DpuEffectiveConfig) (int32, string, bool, error) {
+	if effective == nil || effective.VfCount == nil {
+		return 0, "", false, nil
+	}
+	if *effective.VfCount <= 0 {
+		return 0, effective.VfCountSource, false, fmt.Errorf("vfCount must be > 0 in %s", effective.VfCountSource)
 	}
-	return 0, false, nil
+	return *effective.VfCount, effective.VfCountSource, true, nil
 }
 
 func (d *Daemon) updateManagedDpus(detectedDpusList []*platform.DetectedDpuWithPlugin) {
@@ -616,29 +942,50 @@ func (d *Daemon) updateManagedDpus(detectedDpusList []*platform.DetectedDpuWithP
 		}
 	}
 
-	// Remove managed DPUs that are no longer detected
-	for identifier := range d.managedDpus {
-		if _, stillDetected := currentlyDetected[identifier]; !stillDetected {
-			d.log.Info("Removing no longer detected DPU", "identifier", identifier)
-			managed := d.managedDpus[identifier]
-			if managed != nil {
-				if managed.Cancel != nil {
-					managed.Cancel()
-				}
-				if managed.Plugin != nil {
-					managed.Plugin.Close()
-				}
-				if managed.Done != nil {
-					select {
-					case <-managed.Done:
-					case <-time.After(5 * time.Second):
-						d.log.Info("Timed out waiting for side manager shutdown", "identifier", identifier)
-					}
-				}
+	// Remove managed DPUs that are no longer detected. A host-side DPU with a
+	// side manager is kept until it is back from its reset, or its reboot failed.
+	for identifier, managed := range d.managedDpus {
+		if _, stillDetected := currentlyDetected[identifier]; stillDetected {
+			if managed.Missing {
+				d.log.Info("DPU is detected again", "identifier", identifier)
+				managed.Missing = false
+			}
+			continue
+		}
+		if _, ok := managed.Manager.(rebootTarget); ok && !managed.DpuCR.Spec.IsDpuSide && managed.RebootFailure == nil {
+			if !managed.Missing {
+				d.log.Info("DPU is not detected anymore, waiting for it to come back", "identifier", identifier)
+				managed.Missing = true
+			}
+			continue
+		}
+		d.removeManagedDpu(identifier)
+	}
+}
+
+// removeManagedDpu stops the side manager of a DPU and stops managing it.
+func (d *Daemon) removeManagedDpu(identifier string) {
+	d.log.Info("Removing no longer detected DPU", "identifier", identifier)
+	managed := d.managedDpus[identifier]
+	if managed != nil {
+		if managed.Cancel != nil {
+			managed.Cancel()
+		}
+		if managed.Plugin != nil {
+			managed.Plugin.Close()
+		}
+		if managed.DpuCR != nil {
+			recordInventoryMetrics(managed.DpuCR.Status.Inventory, nil)
+		}
+		if managed.Done != nil {
+			select {
+			case <-managed.Done:
+			case <-time.After(5 * time.Second):
+				d.log.Info("Timed out waiting for side manager shutdown", "identifier", identifier)
 			}
-			delete(d.managedDpus, identifier)
 		}
 	}
+	delete(d.managedDpus, identifier)
 }
 
 func (d *Daemon) prepareCni() error {
@@ -657,6 +1004,22 @@ func (d *Daemon) prepareCni() error {
 	return nil
 }
 
+// startVsp initializes the VSP and returns the address of the DPU daemon.
+// The VSP of a restarted side manager was initialized by the one before and
+// refuses Init, the DPU daemon keeps the address the VSP reported then. A VSP
+// initialized before the daemon started cannot tell the address anymore,
+// which restarting the side manager does not fix.
+func startVsp(ctx context.Context, vsp plugin.VendorPlugin) (string, int32, error) {
+	addr, port, err := vsp.Start(ctx)
+	if errors.Is(err, plugin.ErrVspAlreadyInitialized) {
+		if addr == "" {
+			return "", 0, unrecoverable(err)
+		}
+		return addr, port, nil
+	}
+	return addr, port, err
+}
+
 func (d *Daemon) runSideManager(mgr SideManager, identifier string, managerCtx context.Context) error {
 	err := mgr.StartVsp(managerCtx)
 	if err != nil {
@@ -687,7 +1050,7 @@ func (d *Daemon) runSideManager(mgr SideManager, identifier string, managerCtx c
 func (d *Daemon) updateNodeLabels() error {
 	// Get the current node
 	node := &corev1.Node{}
-	err := d.client.Get(context.TODO(), client.ObjectKey{Name: d.nodeName}, node)
+	err := d.nodeReader().Get(context.TODO(), client.ObjectKey{Name: d.nodeName}, node)
 	if err != nil {
 		return fmt.Errorf("Failed to get node %s: %v", d.nodeName, err)
 	}
@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaypipes/ghw"
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/dpu-operator/internal/platform"
+)
+
+var _ = g.Describe("DPU detection schedule", func() {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	g.It("detects on the first iteration, after a uevent and on resync while watching", func() {
+		s := detectionSchedule{watching: true}
+		Expect(s.due(now)).To(BeTrue())
+		s.done(now)
+		Expect(s.due(now.Add(time.Second))).To(BeFalse())
+
+		s.pending = true
+		Expect(s.due(now.Add(time.Second))).To(BeTrue())
+		s.done(now.Add(time.Second))
+		Expect(s.due(now.Add(2 * time.Second))).To(BeFalse())
+
+		Expect(s.due(now.Add(time.Second + dpuResyncInterval))).To(BeTrue())
+	})
+
+	g.It("detects on every iteration without a watch", func() {
+		s := detectionSchedule{}
+		s.done(now)
+		Expect(s.due(now.Add(time.Second))).To(BeTrue())
+	})
+
+	g.It("is notified when fake PCI devices change", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		fakePlatform := platform.NewFakePlatform("")
+		events, err := fakePlatform.WatchPciDevices(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		fakePlatform.AddPciDevice(&ghw.PCIDevice{Address: "0000:3b:00.0"})
+		Eventually(events).Should(Receive())
+		fakePlatform.RemoveAllPciDevices()
+		Eventually(events).Should(Receive())
+		Consistently(events, 50*time.Millisecond).ShouldNot(Receive())
+
+		cancel()
+		Eventually(events).Should(BeClosed())
+	})
+})
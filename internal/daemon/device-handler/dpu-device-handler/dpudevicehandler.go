@@ -2,6 +2,9 @@ package dpudevicehandler
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
@@ -69,12 +72,31 @@ func (d *dpuDeviceHandler) GetDevices() (*dh.DeviceList, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Error in deviceHandler: device %s from GetDevice request: %v", device.ID, err)
 		}
-		devices[devPciId] = pluginapi.Device{ID: devPciId, Health: pluginapi.Healthy}
+		devices[devPciId] = pluginapi.Device{ID: devPciId, Health: linkHealth(devPciId)}
 	}
 
 	return &devices, nil
 }
 
+// linkHealth returns the health of a host VF from the link state of its PF: a
+// VF is unhealthy while the link of its PF is down. A VF whose PF or link
+// state cannot be read is healthy.
+func linkHealth(vfPci string) string {
+	pfName, err := sriovutils.GetPfName(vfPci)
+	if err != nil {
+		return pluginapi.Healthy
+	}
+	operState, err := os.ReadFile(filepath.Join(sriovutils.NetDirectory, pfName, "operstate"))
+	if err != nil {
+		return pluginapi.Healthy
+	}
+	switch strings.TrimSpace(string(operState)) {
+	case "down", "lowerlayerdown":
+		return pluginapi.Unhealthy
+	}
+	return pluginapi.Healthy
+}
+
 // TODO: When changing the SRIOV numVfs, we should do the following:
 // 1) Drain all pods running on the node with a drain controller running
 // on the control plane. The nodes will be marked for draining and read by
@@ -0,0 +1,53 @@
+package dpudevicehandler
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+var _ = Describe("linkHealth", func() {
+	const vfPci = "0000:3b:02.0"
+
+	BeforeEach(func() {
+		origSysBusPci, origNetDirectory := sriovutils.SysBusPci, sriovutils.NetDirectory
+		DeferCleanup(func() {
+			sriovutils.SysBusPci, sriovutils.NetDirectory = origSysBusPci, origNetDirectory
+		})
+		root := GinkgoT().TempDir()
+		sriovutils.SysBusPci = filepath.Join(root, "bus/pci/devices")
+		sriovutils.NetDirectory = filepath.Join(root, "class/net")
+		Expect(os.MkdirAll(filepath.Join(sriovutils.SysBusPci, vfPci, "physfn", "net", "ens1f0"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(sriovutils.NetDirectory, "ens1f0"), 0755)).To(Succeed())
+	})
+
+	setOperState := func(state string) {
+		Expect(os.WriteFile(filepath.Join(sriovutils.NetDirectory, "ens1f0", "operstate"), []byte(state+"\n"), 0644)).To(Succeed())
+	}
+
+	It("is healthy while the link of the PF is up", func() {
+		setOperState("up")
+		Expect(linkHealth(vfPci)).To(Equal(pluginapi.Healthy))
+	})
+
+	It("is unhealthy while the link of the PF is down", func() {
+		setOperState("down")
+		Expect(linkHealth(vfPci)).To(Equal(pluginapi.Unhealthy))
+
+		setOperState("lowerlayerdown")
+		Expect(linkHealth(vfPci)).To(Equal(pluginapi.Unhealthy))
+	})
+
+	It("is healthy if the link state of the PF cannot be read", func() {
+		Expect(linkHealth(vfPci)).To(Equal(pluginapi.Healthy))
+	})
+
+	It("is healthy if the PF of the VF cannot be found", func() {
+		Expect(linkHealth("0000:3b:02.1")).To(Equal(pluginapi.Healthy))
+	})
+})
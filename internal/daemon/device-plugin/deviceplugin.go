@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -39,14 +40,35 @@ type dpServer struct {
 	deviceHandler dh.DeviceHandler
 	startedWg     sync.WaitGroup
 	vsp           plugin.VendorPlugin
+	registered    atomic.Bool
+	// unhealthy holds why the DPU is unhealthy, by the source that reported
+	// it. All the devices are reported unhealthy to the kubelet while it is
+	// not empty. healthChanged wakes up ListAndWatch when it changes.
+	healthMutex   sync.Mutex
+	unhealthy     map[string]string
+	healthChanged chan struct{}
 }
 
+// Sources of the health of the DPU, see SetHealth.
+const (
+	// HealthSourceReboot is unhealthy while the DPU resets.
+	HealthSourceReboot = "Reboot"
+	// HealthSourceHeartbeat is unhealthy while the heartbeat between the
+	// host and the DPU fails.
+	HealthSourceHeartbeat = "Heartbeat"
+	// HealthSourceHealthCheck is unhealthy while the health check of the
+	// vendor plugin fails.
+	HealthSourceHealthCheck = "HealthCheck"
+)
+
 type DevicePlugin interface {
 	SetupDevices() error
 	ListenAndServe() error
 	Serve(lis net.Listener) error
 	Listen() (net.Listener, error)
 	Stop() error
+	Registered() bool
+	SetHealth(source string, err error)
 }
 
 func (dp *dpServer) sendDevices(stream pluginapi.DevicePlugin_ListAndWatchServer, devices *dh.DeviceList) error {
@@ -96,10 +118,18 @@ func (dp *dpServer) checkCachedDeviceHealth(id string) (bool, error) {
 func (dp *dpServer) ListAndWatch(empty *pluginapi.Empty, stream pluginapi.DevicePlugin_ListAndWatchServer) error {
 	oldDevices := make(dh.DeviceList)
 	for {
-		newDevices, err := dp.deviceHandler.GetDevices()
-		if err != nil {
-			dp.log.Error(err, "Failed to get Devices")
-			return err
+		var newDevices *dh.DeviceList
+		if !dp.healthy() {
+			// The devices may not be listed while the DPU is unhealthy, so
+			// the last known ones are reported unhealthy.
+			newDevices = unhealthyDevices(oldDevices)
+		} else {
+			devices, err := dp.deviceHandler.GetDevices()
+			if err != nil {
+				dp.log.Error(err, "Failed to get Devices")
+				return err
+			}
+			newDevices = devices
 		}
 		if !dp.devicesEqual(&oldDevices, newDevices) {
 			err := dp.sendDevices(stream, newDevices)
@@ -110,8 +140,60 @@ func (dp *dpServer) ListAndWatch(empty *pluginapi.Empty, stream pluginapi.Device
 			oldDevices = *newDevices
 			dp.setDeviceCache(newDevices)
 		}
-		time.Sleep(5 * time.Second)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-dp.healthChanged:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// unhealthyDevices returns a copy of devices with all of them unhealthy.
+func unhealthyDevices(devices dh.DeviceList) *dh.DeviceList {
+	unhealthy := make(dh.DeviceList, len(devices))
+	for id, dev := range devices {
+		dev.Health = pluginapi.Unhealthy
+		unhealthy[id] = dev
+	}
+	return &unhealthy
+}
+
+// SetHealth records the health of the DPU reported by source: healthy when
+// err is nil, unhealthy for the reason err gives otherwise. All the devices
+// are reported unhealthy to the kubelet while any source is unhealthy, so that
+// no pod is scheduled on them, and allocations of the devices are refused.
+// The devices are reported healthy again once all the sources recovered.
+func (dp *dpServer) SetHealth(source string, err error) {
+	dp.healthMutex.Lock()
+	_, wasUnhealthy := dp.unhealthy[source]
+	if err != nil {
+		dp.unhealthy[source] = err.Error()
+	} else {
+		delete(dp.unhealthy, source)
+	}
+	healthy := len(dp.unhealthy) == 0
+	dp.healthMutex.Unlock()
+
+	if wasUnhealthy == (err != nil) {
+		return
+	}
+	if err != nil {
+		dp.log.Info("DPU is unhealthy, reporting its devices unhealthy", "source", source, "reason", err.Error())
+	} else {
+		dp.log.Info("DPU recovered", "source", source, "devicesHealthy", healthy)
 	}
+	select {
+	case dp.healthChanged <- struct{}{}:
+	default:
+	}
+}
+
+// healthy returns whether no source reports the DPU unhealthy.
+func (dp *dpServer) healthy() bool {
+	dp.healthMutex.Lock()
+	defer dp.healthMutex.Unlock()
+	return len(dp.unhealthy) == 0
 }
 
 // Allocate passes the dev name as an env variable to the requesting container
@@ -196,6 +278,8 @@ func (dp *dpServer) Serve(lis net.Listener) error {
 	if err != nil {
 		return fmt.Errorf("failed to register the Device Plugin server with Kubelet: %v", err)
 	}
+	dp.registered.Store(true)
+	defer dp.registered.Store(false)
 
 	err = <-done
 	// The "serve" design paradigm must be a blocking call. Thus we wait here.
@@ -300,6 +384,12 @@ func (dp *dpServer) connectWithRetry(endpoint string) (*grpc.ClientConn, error)
 	return conn, nil
 }
 
+// Registered returns true while the device plugin is registered with the
+// kubelet and serving.
+func (dp *dpServer) Registered() bool {
+	return dp.registered.Load()
+}
+
 func (dp *dpServer) Stop() error {
 	dp.log.Info("Stopping Device Plugin...")
 	if dp.grpcServer == nil {
@@ -347,6 +437,8 @@ func NewDevicePlugin(vsp plugin.VendorPlugin, dpuMode bool, pm utils.PathManager
 		pathManager:   pm,
 		deviceHandler: dh,
 		vsp:           vsp,
+		unhealthy:     make(map[string]string),
+		healthChanged: make(chan struct{}, 1),
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,143 @@
+package deviceplugin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dh "github.com/openshift/dpu-operator/internal/daemon/device-handler"
+	"github.com/openshift/dpu-operator/internal/utils"
+	"google.golang.org/grpc"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+)
+
+// fakeDeviceHandler lists the same healthy devices every time.
+type fakeDeviceHandler struct {
+	devices dh.DeviceList
+}
+
+func (h *fakeDeviceHandler) SetupDevices() error {
+	return nil
+}
+
+func (h *fakeDeviceHandler) GetDevices() (*dh.DeviceList, error) {
+	devices := make(dh.DeviceList, len(h.devices))
+	for id, dev := range h.devices {
+		devices[id] = dev
+	}
+	return &devices, nil
+}
+
+// fakeListAndWatchStream records the device lists sent to the kubelet.
+type fakeListAndWatchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pluginapi.ListAndWatchResponse
+}
+
+func (s *fakeListAndWatchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeListAndWatchStream) Send(resp *pluginapi.ListAndWatchResponse) error {
+	s.sent <- resp
+	return nil
+}
+
+var _ = Describe("Device health", func() {
+	var dp *dpServer
+
+	allocate := func(id string) error {
+		_, err := dp.Allocate(context.Background(), &pluginapi.AllocateRequest{
+			ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIDs: []string{id}}},
+		})
+		return err
+	}
+
+	BeforeEach(func() {
+		dp = NewDevicePlugin(nil, true, *utils.NewPathManager(GinkgoT().TempDir()))
+		dp.deviceHandler = &fakeDeviceHandler{devices: dh.DeviceList{
+			"dev0": {ID: "dev0", Health: pluginapi.Healthy},
+			"dev1": {ID: "dev1", Health: pluginapi.Healthy},
+		}}
+	})
+
+	It("is unhealthy until all the sources recovered", func() {
+		Expect(dp.healthy()).To(BeTrue())
+
+		dp.SetHealth(HealthSourceHeartbeat, errors.New("heartbeat lost"))
+		dp.SetHealth(HealthSourceReboot, errors.New("DPU resetting"))
+		Expect(dp.healthy()).To(BeFalse())
+
+		dp.SetHealth(HealthSourceHeartbeat, nil)
+		Expect(dp.healthy()).To(BeFalse(), "the reboot still makes the DPU unhealthy")
+
+		dp.SetHealth(HealthSourceHealthCheck, nil)
+		Expect(dp.healthy()).To(BeFalse(), "a source that was never unhealthy does not recover the others")
+
+		dp.SetHealth(HealthSourceReboot, nil)
+		Expect(dp.healthy()).To(BeTrue())
+	})
+
+	It("wakes up ListAndWatch only when the health of a source changes", func() {
+		dp.SetHealth(HealthSourceHeartbeat, errors.New("heartbeat lost"))
+		Expect(dp.healthChanged).To(Receive())
+
+		dp.SetHealth(HealthSourceHeartbeat, errors.New("heartbeat still lost"))
+		Expect(dp.healthChanged).NotTo(Receive())
+
+		dp.SetHealth(HealthSourceHeartbeat, nil)
+		Expect(dp.healthChanged).To(Receive())
+
+		dp.SetHealth(HealthSourceHeartbeat, nil)
+		Expect(dp.healthChanged).NotTo(Receive())
+	})
+
+	It("re-sends the devices to the kubelet as the health changes", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream := &fakeListAndWatchStream{ctx: ctx, sent: make(chan *pluginapi.ListAndWatchResponse, 10)}
+		done := make(chan error, 1)
+		go func() {
+			done <- dp.ListAndWatch(&pluginapi.Empty{}, stream)
+		}()
+		DeferCleanup(func() {
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+		})
+
+		expectSent := func(health string) {
+			var resp *pluginapi.ListAndWatchResponse
+			Eventually(stream.sent).Should(Receive(&resp))
+			Expect(resp.Devices).To(HaveLen(2))
+			for _, dev := range resp.Devices {
+				Expect(dev.Health).To(Equal(health), "device %s", dev.ID)
+			}
+		}
+
+		expectSent(pluginapi.Healthy)
+
+		dp.SetHealth(HealthSourceHeartbeat, errors.New("heartbeat lost"))
+		expectSent(pluginapi.Unhealthy)
+
+		dp.SetHealth(HealthSourceReboot, errors.New("DPU resetting"))
+		dp.SetHealth(HealthSourceHeartbeat, nil)
+		Consistently(stream.sent, 200*time.Millisecond).ShouldNot(Receive(), "the devices stay unhealthy")
+
+		dp.SetHealth(HealthSourceReboot, nil)
+		expectSent(pluginapi.Healthy)
+	})
+
+	It("refuses to allocate the devices last reported unhealthy", func() {
+		devices, err := dp.deviceHandler.GetDevices()
+		Expect(err).NotTo(HaveOccurred())
+		dp.setDeviceCache(devices)
+		Expect(allocate("dev0")).To(Succeed())
+
+		dp.setDeviceCache(unhealthyDevices(*devices))
+		Expect(allocate("dev0")).To(MatchError(ContainSubstring("unhealthy device")))
+		Expect(allocate("dev2")).To(MatchError(ContainSubstring("non-existing device")))
+	})
+})
@@ -0,0 +1,118 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/platform"
+	"github.com/openshift/dpu-operator/internal/scheme"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dpuNodeNameField indexes the DataProcessingUnits of the DPU cache by node.
+const dpuNodeNameField = "spec.nodeName"
+
+// newDpuCache returns a cache of the DataProcessingUnits of a node and of
+// the Node itself. It only watches the DPUs labeled with the node, so that
+// the daemons of a large cluster neither list nor watch the DPUs of the other
+// nodes, and indexes them by dpuNodeNameField. Reading any other type from it
+// fails.
+func newDpuCache(config *rest.Config, nodeName string) (cache.Cache, error) {
+	dpuCache, err := cache.New(config, cache.Options{
+		Scheme: scheme.Scheme,
+		ByObject: map[client.Object]cache.ByObject{
+			&configv1.DataProcessingUnit{}: {
+				Label: labels.SelectorFromSet(labels.Set{vars.DpuNodeLabel: platform.DpuNodeLabelValue(nodeName)}),
+			},
+			&corev1.Node{}: {
+				Field: fields.OneTermEqualSelector("metadata.name", nodeName),
+			},
+		},
+		ReaderFailOnMissingInformer: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the DPU cache: %v", err)
+	}
+	if err := dpuCache.IndexField(context.Background(), &configv1.DataProcessingUnit{}, dpuNodeNameField, dpuNodeName); err != nil {
+		return nil, fmt.Errorf("failed to index DPUs by node: %v", err)
+	}
+	if _, err := dpuCache.GetInformer(context.Background(), &corev1.Node{}); err != nil {
+		return nil, fmt.Errorf("failed to watch node %s: %v", nodeName, err)
+	}
+	return dpuCache, nil
+}
+
+func dpuNodeName(obj client.Object) []string {
+	dpu, ok := obj.(*configv1.DataProcessingUnit)
+	if !ok || dpu.Spec.NodeName == "" {
+		return nil
+	}
+	return []string{dpu.Spec.NodeName}
+}
+
+// dpuReader returns the reader of the DataProcessingUnits of the node: the
+// DPU cache once the daemon is prepared, the API server otherwise.
+func (d *Daemon) dpuReader() client.Reader {
+	if d.dpuCache != nil {
+		return d.dpuCache
+	}
+	return d.client
+}
+
+// nodeReader returns the reader of the Node of the daemon, which it reads on
+// every iteration of its loop: the DPU cache once the daemon is prepared, the
+// API server otherwise.
+func (d *Daemon) nodeReader() client.Reader {
+	if d.dpuCache != nil {
+		return d.dpuCache
+	}
+	return d.client
+}
+
+// listNodeDpus lists the DataProcessingUnits of the node.
+func (d *Daemon) listNodeDpus(ctx context.Context) ([]configv1.DataProcessingUnit, error) {
+	dpus := &configv1.DataProcessingUnitList{}
+	if d.dpuCache != nil {
+		if err := d.dpuCache.List(ctx, dpus, client.MatchingFields{dpuNodeNameField: d.nodeName}); err != nil {
+			return nil, err
+		}
+		return dpus.Items, nil
+	}
+
+	if err := d.client.List(ctx, dpus); err != nil {
+		return nil, err
+	}
+	var items []configv1.DataProcessingUnit
+	for _, dpu := range dpus.Items {
+		if dpu.Spec.NodeName == d.nodeName {
+			items = append(items, dpu)
+		}
+	}
+	return items, nil
+}
+
+// startDpuCache starts the DPU cache and waits until it is synced. done is
+// closed once the cache stopped.
+func (d *Daemon) startDpuCache(ctx context.Context, done chan struct{}, errChan chan<- error) error {
+	go func() {
+		defer close(done)
+		if err := d.dpuCache.Start(ctx); err != nil {
+			select {
+			case errChan <- fmt.Errorf("DPU cache failed: %v", err):
+			default:
+			}
+		}
+	}()
+	if !d.dpuCache.WaitForCacheSync(ctx) {
+		return fmt.Errorf("failed to sync the DPU cache of node %s", d.nodeName)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package daemon
+
+import (
+	"strings"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/platform"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var _ = g.Describe("DPU cache", func() {
+	g.It("indexes the DPUs by node", func() {
+		dpu := &configv1.DataProcessingUnit{Spec: configv1.DataProcessingUnitSpec{NodeName: "worker-0"}}
+		Expect(dpuNodeName(dpu)).To(Equal([]string{"worker-0"}))
+
+		dpu.Spec.NodeName = ""
+		Expect(dpuNodeName(dpu)).To(BeEmpty())
+		Expect(dpuNodeName(&corev1.Node{})).To(BeEmpty())
+	})
+
+	g.It("labels the DPUs with a valid value for long node names", func() {
+		Expect(platform.DpuNodeLabelValue("worker-0")).To(Equal("worker-0"))
+
+		long := strings.Repeat("worker.example.com.", 5)
+		value := platform.DpuNodeLabelValue(long + "0")
+		Expect(validation.IsValidLabelValue(value)).To(BeEmpty())
+		Expect(platform.DpuNodeLabelValue(long + "0")).To(Equal(value))
+		Expect(platform.DpuNodeLabelValue(long + "1")).NotTo(Equal(value))
+	})
+})
@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dpuRemovalGracePeriodFromEnv reads the DPU removal grace period of the
+// DpuOperatorConfig, which the operator passes to the daemon through its
+// environment.
+func dpuRemovalGracePeriodFromEnv() time.Duration {
+	gracePeriod, err := time.ParseDuration(os.Getenv("DPU_REMOVAL_GRACE_PERIOD"))
+	if err != nil || gracePeriod < 0 {
+		return configv1.DefaultDpuRemovalGracePeriod
+	}
+	return gracePeriod
+}
+
+// markDpuStale reports that the daemon stopped detecting a DPU because it
+// shuts down. The DPU is kept, so that the daemon picks it up with its status
+// once it is back.
+func markDpuStale(dpuCR *configv1.DataProcessingUnit) {
+	message := fmt.Sprintf("The daemon of node %s stopped, the DPU is not watched until it is back.", dpuCR.Spec.NodeName)
+	setDpuCondition(&dpuCR.Status.Conditions, configv1.DpuConditionDetected, metav1.ConditionUnknown, "DaemonStopped", message)
+	setDpuCondition(&dpuCR.Status.Conditions, plugin.ReadyConditionType, metav1.ConditionUnknown, "DaemonStopped", message)
+}
+
+// syncUndetectedDpuCR handles the DPU CR of this node the daemon does not
+// detect anymore. The DPU is reported as not detected, and only deleted once
+// it was not detected for the removal grace period, so that a DPU that comes
+// back keeps its CR.
+// Returns (true, nil) if changes were made, (false, nil) if no changes needed.
+func (d *Daemon) syncUndetectedDpuCR(dpuCR *configv1.DataProcessingUnit, now time.Time) (bool, error) {
+	detected := meta.FindStatusCondition(dpuCR.Status.Conditions, configv1.DpuConditionDetected)
+	if detected == nil || detected.Status != metav1.ConditionFalse {
+		// The cached CR is shared, only patch a copy of it.
+		current := dpuCR.DeepCopy()
+		patch := client.MergeFrom(current.DeepCopy())
+		message := fmt.Sprintf("DPU is not detected on node %s, removing it after %s.", d.nodeName, d.dpuRemovalGracePeriod)
+		setDpuCondition(&current.Status.Conditions, configv1.DpuConditionDetected, metav1.ConditionFalse, "NotDetected", message)
+		setDpuCondition(&current.Status.Conditions, plugin.ReadyConditionType, metav1.ConditionFalse, "NotDetected", message)
+		if err := d.client.Status().Patch(context.TODO(), current, patch); err != nil {
+			return false, fmt.Errorf("Failed to mark DPU CR %s not detected: %v", dpuCR.Name, err)
+		}
+		d.log.Info("DPU is not detected anymore, keeping its CR for the grace period", "crName", dpuCR.Name, "gracePeriod", d.dpuRemovalGracePeriod)
+		return true, nil
+	}
+
+	if now.Sub(detected.LastTransitionTime.Time) < d.dpuRemovalGracePeriod {
+		return false, nil
+	}
+
+	d.log.Info("DPU was not detected for the grace period, removing its CR", "crName", dpuCR.Name, "nodeName", d.nodeName)
+	// The cache may still hold a CR deleted by a previous iteration.
+	if err := client.IgnoreNotFound(d.client.Delete(context.TODO(), dpuCR)); err != nil {
+		return false, fmt.Errorf("Failed to delete DPU CR %s: %v", dpuCR.Name, err)
+	}
+	return true, nil
+}
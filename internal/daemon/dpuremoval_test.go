@@ -0,0 +1,60 @@
+package daemon
+
+import (
+	"os"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ = g.Describe("DPU removal", func() {
+	g.It("marks the DPUs stale when the daemon stops", func() {
+		dpuCR := &configv1.DataProcessingUnit{Spec: configv1.DataProcessingUnitSpec{NodeName: "worker-0"}}
+		setDpuCondition(&dpuCR.Status.Conditions, configv1.DpuConditionDetected, metav1.ConditionTrue, "Detected", "DPU detected on node worker-0.")
+
+		markDpuStale(dpuCR)
+
+		for _, conditionType := range []string{configv1.DpuConditionDetected, plugin.ReadyConditionType} {
+			condition := meta.FindStatusCondition(dpuCR.Status.Conditions, conditionType)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(condition.Reason).To(Equal("DaemonStopped"))
+		}
+	})
+
+	g.It("keeps a DPU that is not detected for less than the grace period", func() {
+		now := time.Now()
+		d := &Daemon{log: ctrl.Log.WithName("Daemon"), nodeName: "worker-0", dpuRemovalGracePeriod: time.Minute}
+		dpuCR := &configv1.DataProcessingUnit{}
+		meta.SetStatusCondition(&dpuCR.Status.Conditions, metav1.Condition{
+			Type:               configv1.DpuConditionDetected,
+			Status:             metav1.ConditionFalse,
+			Reason:             "NotDetected",
+			LastTransitionTime: metav1.NewTime(now.Add(-30 * time.Second)),
+		})
+
+		changed, err := d.syncUndetectedDpuCR(dpuCR, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	g.It("reads the grace period from the environment", func() {
+		g.DeferCleanup(os.Unsetenv, "DPU_REMOVAL_GRACE_PERIOD")
+
+		Expect(os.Unsetenv("DPU_REMOVAL_GRACE_PERIOD")).To(Succeed())
+		Expect(dpuRemovalGracePeriodFromEnv()).To(Equal(configv1.DefaultDpuRemovalGracePeriod))
+
+		Expect(os.Setenv("DPU_REMOVAL_GRACE_PERIOD", "30m0s")).To(Succeed())
+		Expect(dpuRemovalGracePeriodFromEnv()).To(Equal(30 * time.Minute))
+
+		Expect(os.Setenv("DPU_REMOVAL_GRACE_PERIOD", "0s")).To(Succeed())
+		Expect(dpuRemovalGracePeriodFromEnv()).To(BeZero())
+	})
+})
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"slices"
 	"sync"
 	"time"
 
 	cni100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/networkfn"
@@ -35,15 +37,23 @@ type DpuSideManager struct {
 	lifecycleapi.UnimplementedDeviceServiceServer
 	lifecycleapi.UnimplementedHeartbeatServiceServer
 
-	vsp          plugin.VendorPlugin
-	dp           deviceplugin.DevicePlugin
-	addr         string
-	port         int32
-	log          logr.Logger
-	server       *grpc.Server
-	cniserver    *cniserver.Server
-	manager      ctrl.Manager
-	macStore     map[string][]string
+	vsp       plugin.VendorPlugin
+	dp        deviceplugin.DevicePlugin
+	addr      string
+	port      int32
+	log       logr.Logger
+	server    *grpc.Server
+	cniserver *cniserver.Server
+	manager   ctrl.Manager
+	macStore  map[string][]string
+	podNetns  map[string]string
+	chains    map[string][]configv1.ServiceFunctionChainHop
+	chainPods map[string]string
+	// chainMutex guards the maps above. wiringMutex serializes the changes
+	// to the applied hops and is held during the VSP calls making them, so
+	// that chainMutex is never held while waiting on the VSP.
+	chainMutex   sync.Mutex
+	wiringMutex  sync.Mutex
 	startedWg    sync.WaitGroup
 	config       *rest.Config
 	pathManager  utils.PathManager
@@ -53,7 +63,7 @@ type DpuSideManager struct {
 
 func (s *DpuSideManager) CreateBridgePort(context context.Context, bpr *pb.CreateBridgePortRequest) (*pb.BridgePort, error) {
 	s.log.Info("Passing CreateBridgePort", "name", bpr.BridgePort.Name)
-	return s.vsp.CreateBridgePort(bpr)
+	return s.vsp.CreateBridgePort(context, bpr)
 }
 
 func (s *DpuSideManager) DeleteBridgePort(context context.Context, bpr *pb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
@@ -62,6 +72,16 @@ func (s *DpuSideManager) DeleteBridgePort(context context.Context, bpr *pb.Delet
 	return &emptypb.Empty{}, err
 }
 
+func (s *DpuSideManager) GetBridgePort(context context.Context, bpr *pb.GetBridgePortRequest) (*pb.BridgePort, error) {
+	s.log.V(1).Info("Passing GetBridgePort", "name", bpr.Name)
+	return s.vsp.GetBridgePort(bpr)
+}
+
+func (s *DpuSideManager) ListBridgePorts(context context.Context, bpr *pb.ListBridgePortsRequest) (*pb.ListBridgePortsResponse, error) {
+	s.log.V(1).Info("Passing ListBridgePorts")
+	return s.vsp.ListBridgePorts(bpr)
+}
+
 func (s *DpuSideManager) setPing(ping time.Time) {
 	s.pingMutex.Lock()
 	s.lastPingTime = ping
@@ -87,6 +107,17 @@ func (s *DpuSideManager) Ping(ctx context.Context, req *lifecycleapi.PingRequest
 	}, nil
 }
 
+// LastPing returns when the last ping from the host was received.
+func (s *DpuSideManager) LastPing() time.Time {
+	return s.getPing()
+}
+
+// DevicePluginRegistered returns true while the device plugin is registered
+// with the kubelet.
+func (s *DpuSideManager) DevicePluginRegistered() bool {
+	return s.dp != nil && s.dp.Registered()
+}
+
 func (s *DpuSideManager) CheckPing() bool {
 	// Check if we received a ping from the host within the last minute
 	lastPing := s.getPing()
@@ -106,6 +137,9 @@ func NewDpuSideManager(vsp plugin.VendorPlugin, config *rest.Config, opts ...fun
 		pathManager: *utils.NewPathManager("/"),
 		log:         ctrl.Log.WithName("DpuSideManager"),
 		macStore:    make(map[string][]string),
+		podNetns:    make(map[string]string),
+		chains:      make(map[string][]configv1.ServiceFunctionChainHop),
+		chainPods:   make(map[string]string),
 		config:      config,
 	}
 
@@ -125,9 +159,9 @@ func WithPathManager(pathManager utils.PathManager) func(*DpuSideManager) {
 }
 
 func (d *DpuSideManager) StartVsp(ctx context.Context) error {
-	addr, port, err := d.vsp.Start(ctx)
+	addr, port, err := startVsp(ctx, d.vsp)
 	if err != nil {
-		return fmt.Errorf("failed calling VSP Start() from DpuSideManager: %v", err)
+		return fmt.Errorf("failed calling VSP Start() from DpuSideManager: %w", err)
 	}
 	d.addr = addr
 	d.port = port
@@ -149,12 +183,16 @@ func (d *DpuSideManager) cniCmdNfAddHandler(req *cnitypes.PodRequest) (*cni100.R
 		return nil, fmt.Errorf("SRIOV manager failed in add handler: %v", err)
 	}
 
-	d.macStore[req.Netns] = append(d.macStore[req.Netns], req.CNIConf.MAC)
-	if len(d.macStore[req.Netns]) == 2 {
-		d.log.Info("cniCmdNfAddHandler", "req.Netns", req.Netns)
-		macs := d.macStore[req.Netns]
-		d.vsp.CreateNetworkFunction(macs[0], macs[1])
-	}
+	// A pod of a chain is stitched into it by the ServiceFunctionChain
+	// reconciler once all network functions of the chain have their ports.
+	key := podKey(req.PodNamespace, req.PodName)
+	d.chainMutex.Lock()
+	d.macStore[key] = append(d.macStore[key], req.CNIConf.MAC)
+	d.podNetns[key] = req.Netns
+	d.saveState()
+	d.chainMutex.Unlock()
+	d.wireStandalone(key)
+
 	d.log.Info("cniCmdNfAddHandler CmdAdd succeeded")
 	return res, nil
 }
@@ -166,30 +204,314 @@ func (d *DpuSideManager) cniCmdNfDelHandler(req *cnitypes.PodRequest) (*cni100.R
 		return nil, errors.New("SRIOV manager failed in del handler")
 	}
 
-	macs := d.macStore[req.Netns]
+	key := podKey(req.PodNamespace, req.PodName)
+	d.wiringMutex.Lock()
+	defer d.wiringMutex.Unlock()
+	d.chainMutex.Lock()
+	macs := append([]string(nil), d.macStore[key]...)
+	d.chainMutex.Unlock()
+	d.unwirePorts(macs)
+
+	d.chainMutex.Lock()
+	if len(macs) > 0 {
+		macs = macs[:len(macs)-1]
+	}
+	if len(macs) == 0 {
+		delete(d.macStore, key)
+		delete(d.podNetns, key)
+	} else {
+		d.macStore[key] = macs
+	}
+	d.saveState()
+	d.chainMutex.Unlock()
 
-	if len(macs) == 2 {
-		d.log.Info("cniCmdNfDelHandler", "req.Netns", req.Netns)
-		d.vsp.DeleteNetworkFunction(macs[0], macs[1])
+	d.log.Info("cniCmdNfDelHandler CmdDel succeeded")
+	return nil, nil
+}
+
+func (d *DpuSideManager) cniCmdNfCheckHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	d.log.Info("cniCmdNfCheckHandler")
+	err := networkfn.CmdCheck(req)
+	if err != nil {
+		return nil, fmt.Errorf("network function check failed: %v", err)
 	}
 
-	d.macStore[req.Netns] = macs[:len(macs)-1]
+	key := podKey(req.PodNamespace, req.PodName)
+	d.chainMutex.Lock()
+	macs := d.macStore[key]
+	d.chainMutex.Unlock()
+	if !slices.Contains(macs, req.CNIConf.MAC) {
+		return nil, fmt.Errorf("port %s of pod %s is not known to the DPU daemon", req.CNIConf.MAC, key)
+	}
 
-	d.log.Info("cniCmdNfDelHandler CmdDel succeeded")
+	d.log.Info("cniCmdNfCheckHandler CmdCheck succeeded")
 	return nil, nil
 }
 
+func podKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// standaloneChain returns the chain key under which the hop of a network
+// function pod that is not part of a chain is applied.
+func standaloneChain(key string) string {
+	return "pod:" + key
+}
+
+// wireStandalone wires a network function pod that is not part of any chain
+// from its ingress to its egress port, once both are attached.
+func (d *DpuSideManager) wireStandalone(key string) {
+	d.wiringMutex.Lock()
+	defer d.wiringMutex.Unlock()
+
+	d.chainMutex.Lock()
+	ports := append([]string(nil), d.macStore[key]...)
+	_, member := d.chainPods[key]
+	d.chainMutex.Unlock()
+	if member || len(ports) < 2 {
+		return
+	}
+
+	hops := sfcreconciler.ChainHops([]sfcreconciler.FunctionPorts{{Name: key, Ingress: ports[0], Egress: ports[1]}})
+	if failures := d.applyChainHops(standaloneChain(key), hops); failures[key] != "" {
+		d.log.Info("Failed to wire network function", "pod", key, "error", failures[key])
+	}
+	d.chainMutex.Lock()
+	d.saveState()
+	d.chainMutex.Unlock()
+}
+
+// claimPods records the candidate pods of the members of a chain, so that
+// they are not wired on their own, and returns those of them that are. It
+// must be called with chainMutex held.
+func (d *DpuSideManager) claimPods(chain string, members []sfcreconciler.ChainMember) []string {
+	for key, owner := range d.chainPods {
+		if owner == chain {
+			delete(d.chainPods, key)
+		}
+	}
+	var standalone []string
+	for _, member := range members {
+		for _, pod := range member.Pods {
+			key := podKey(pod.Namespace, pod.Name)
+			d.chainPods[key] = chain
+			if _, ok := d.chains[standaloneChain(key)]; ok {
+				standalone = append(standalone, key)
+			}
+		}
+	}
+	return standalone
+}
+
+// unwirePorts removes every applied hop that uses one of the given ports, in
+// any chain. It must be called with wiringMutex held.
+func (d *DpuSideManager) unwirePorts(ports []string) {
+	uses := func(hop configv1.ServiceFunctionChainHop) bool {
+		for _, port := range ports {
+			if hop.Input == port || hop.Output == port {
+				return true
+			}
+		}
+		return false
+	}
+	remaining := make(map[string][]configv1.ServiceFunctionChainHop)
+	for chain, hops := range d.chains {
+		var kept []configv1.ServiceFunctionChainHop
+		for _, hop := range hops {
+			if uses(hop) && d.deleteHop(chain, hop) == nil {
+				continue
+			}
+			kept = append(kept, hop)
+		}
+		remaining[chain] = kept
+	}
+
+	d.chainMutex.Lock()
+	defer d.chainMutex.Unlock()
+	for chain, hops := range remaining {
+		d.setChainHops(chain, hops)
+	}
+}
+
+func (d *DpuSideManager) deleteHop(chain string, hop configv1.ServiceFunctionChainHop) error {
+	d.log.Info("Deleting chain hop", "chain", chain, "input", hop.Input, "output", hop.Output)
+	if err := d.vsp.DeleteNetworkFunction(hop.Input, hop.Output); err != nil {
+		d.log.Error(err, "Failed to delete chain hop", "chain", chain, "input", hop.Input, "output", hop.Output)
+		return err
+	}
+	return nil
+}
+
+// setChainHops records the applied hops of a chain. It must be called with
+// both wiringMutex and chainMutex held.
+func (d *DpuSideManager) setChainHops(chain string, hops []configv1.ServiceFunctionChainHop) {
+	if len(hops) == 0 {
+		delete(d.chains, chain)
+		return
+	}
+	d.chains[chain] = hops
+}
+
+// memberPorts returns the ports of the first candidate pod of a chain member
+// that has interfaces attached on this node. It must be called with
+// chainMutex held.
+func (d *DpuSideManager) memberPorts(member sfcreconciler.ChainMember) ([]string, bool) {
+	for _, pod := range member.Pods {
+		if macs, ok := d.macStore[podKey(pod.Namespace, pod.Name)]; ok {
+			return append([]string(nil), macs...), true
+		}
+	}
+	return nil, false
+}
+
+// WireChain implements sfcreconciler.ChainWirer. The chain is only wired once
+// every member has both of its ports attached on this node; until then any
+// previously applied hops are removed so that traffic never skips a network
+// function. Chains of several network functions are only wired on VSPs that
+// can chain them. The members of a chain are not wired on their own.
+func (d *DpuSideManager) WireChain(chain string, members []sfcreconciler.ChainMember) sfcreconciler.ChainWiring {
+	d.wiringMutex.Lock()
+	defer d.wiringMutex.Unlock()
+
+	d.chainMutex.Lock()
+	standalone := d.claimPods(chain, members)
+	result := sfcreconciler.ChainWiring{Members: make(map[string]sfcreconciler.NetworkFunctionWiring)}
+	var functions []sfcreconciler.FunctionPorts
+	complete := true
+	for _, member := range members {
+		ports, ok := d.memberPorts(member)
+		if !ok {
+			complete = false
+			continue
+		}
+		wiring := sfcreconciler.NetworkFunctionWiring{Ports: ports, Result: configv1.WiringPending}
+		if len(ports) < 2 {
+			complete = false
+			wiring.Message = "Waiting for both network function interfaces"
+		} else {
+			functions = append(functions, sfcreconciler.FunctionPorts{Name: member.Name, Ingress: ports[0], Egress: ports[1]})
+		}
+		result.Members[member.Name] = wiring
+	}
+	d.chainMutex.Unlock()
+
+	for _, key := range standalone {
+		d.applyChainHops(standaloneChain(key), nil)
+	}
+	var chainErr string
+	if complete && len(functions) > 1 {
+		chainErr = d.chainingError()
+	}
+	var desired []configv1.ServiceFunctionChainHop
+	if complete && chainErr == "" {
+		desired = sfcreconciler.ChainHops(functions)
+	}
+	failures := d.applyChainHops(chain, desired)
+
+	d.chainMutex.Lock()
+	d.saveState()
+	result.Hops = append([]configv1.ServiceFunctionChainHop(nil), d.chains[chain]...)
+	d.chainMutex.Unlock()
+
+	for name, wiring := range result.Members {
+		if len(wiring.Ports) < 2 {
+			continue
+		}
+		switch {
+		case !complete:
+			wiring.Message = "Waiting for the other network functions of the chain"
+		case chainErr != "":
+			wiring.Result = configv1.WiringFailed
+			wiring.Message = chainErr
+		case failures[name] != "":
+			wiring.Result = configv1.WiringFailed
+			wiring.Message = failures[name]
+		default:
+			wiring.Result = configv1.WiringSucceeded
+		}
+		result.Members[name] = wiring
+	}
+	return result
+}
+
+// chainingError returns why the network functions of a chain cannot be
+// chained on the VSP, or an empty string if they can.
+func (d *DpuSideManager) chainingError() string {
+	chaining, err := d.vsp.ChainsNetworkFunctions()
+	if err != nil {
+		d.log.Error(err, "Failed to get the network function capabilities of the VSP")
+		return fmt.Sprintf("failed to get the network function capabilities of the VSP: %v", err)
+	}
+	if !chaining {
+		return "the VSP cannot chain network functions, so a chain can hold only one"
+	}
+	return ""
+}
+
+// applyChainHops deletes the applied hops of a chain that are not desired
+// anymore and creates the missing ones. It returns the VSP errors by network
+// function name. It must be called with wiringMutex held, and makes the VSP
+// calls without holding chainMutex.
+func (d *DpuSideManager) applyChainHops(chain string, desired []configv1.ServiceFunctionChainHop) map[string]string {
+	sameHop := func(a, b configv1.ServiceFunctionChainHop) bool {
+		return a.Input == b.Input && a.Output == b.Output
+	}
+	contains := func(hops []configv1.ServiceFunctionChainHop, hop configv1.ServiceFunctionChainHop) bool {
+		for _, h := range hops {
+			if sameHop(h, hop) {
+				return true
+			}
+		}
+		return false
+	}
+
+	applied := d.chains[chain]
+	var stale []configv1.ServiceFunctionChainHop
+	for _, hop := range applied {
+		if contains(desired, hop) {
+			continue
+		}
+		if err := d.deleteHop(chain, hop); err != nil {
+			stale = append(stale, hop)
+		}
+	}
+
+	failures := make(map[string]string)
+	var hops []configv1.ServiceFunctionChainHop
+	for _, hop := range desired {
+		if !contains(applied, hop) {
+			d.log.Info("Creating chain hop", "chain", chain, "input", hop.Input, "output", hop.Output)
+			if err := d.vsp.CreateNetworkFunction(hop.Input, hop.Output); err != nil {
+				d.log.Error(err, "Failed to create chain hop", "chain", chain, "input", hop.Input, "output", hop.Output)
+				msg := fmt.Sprintf("failed to wire %s to %s: %v", hop.Input, hop.Output, err)
+				failures[hop.InputFunction] = msg
+				failures[hop.OutputFunction] = msg
+				continue
+			}
+		}
+		hops = append(hops, hop)
+	}
+	d.chainMutex.Lock()
+	d.setChainHops(chain, append(hops, stale...))
+	d.chainMutex.Unlock()
+	return failures
+}
+
 func (d *DpuSideManager) Listen() (net.Listener, error) {
 	d.startedWg.Add(1)
 	d.log.Info("Starting DpuDaemon")
 	d.server = grpc.NewServer()
 	if err := d.setupReconcilers(); err != nil {
-		return nil, fmt.Errorf("failed to setup reconcilers: %v", err)
+		return nil, fmt.Errorf("failed to setup reconcilers: %w", err)
 	}
 
 	pb.RegisterBridgePortServiceServer(d.server, d)
 	lifecycleapi.RegisterHeartbeatServiceServer(d.server, d)
 
+	// Recover the network functions wired before a restart before any CNI
+	// request or chain reconcile can change them.
+	d.restoreState()
+
 	lis, err := net.Listen("tcp", fmt.Sprintf("%s:%d", d.addr, d.port))
 	if err != nil {
 		return lis, fmt.Errorf("Failed to start listening on %v:%v: %v", d.addr, d.port, err)
@@ -203,7 +525,13 @@ func (d *DpuSideManager) Listen() (net.Listener, error) {
 		return d.cniCmdNfDelHandler(r)
 	}
 
-	d.cniserver = cniserver.NewCNIServer(add, del, cniserver.WithPathManager(d.pathManager))
+	check := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdNfCheckHandler(r)
+	}
+
+	d.cniserver = cniserver.NewCNIServer(add, del,
+		cniserver.WithPathManager(d.pathManager),
+		cniserver.WithCheckHandler(check))
 
 	return lis, err
 }
@@ -221,6 +549,10 @@ func (d *DpuSideManager) Serve(ctx context.Context, listener net.Listener) error
 	d.log.Info("Serve")
 	var wg sync.WaitGroup
 	done := make(chan error, 4)
+	// Shut all the servers down when one of them stops, so that Serve
+	// returns and the side manager can be restarted.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	go func() {
 		<-ctx.Done()
@@ -287,6 +619,7 @@ func (d *DpuSideManager) Serve(ctx context.Context, listener net.Listener) error
 		if err != nil {
 			d.log.Error(err, "one of the go-routines failed")
 		}
+		cancel()
 		wg.Wait()
 		d.startedWg.Done()
 		return err
@@ -320,14 +653,15 @@ func (d *DpuSideManager) setupReconcilers() error {
 		})
 		if err != nil {
 			d.log.Error(err, "unable to start manager")
-			return fmt.Errorf("failed to create controller manager: %v", err)
+			return unrecoverable(fmt.Errorf("failed to create controller manager: %v", err))
 		}
 
-		sfcReconciler := sfcreconciler.NewSfcReconciler(mgr.GetClient(), mgr.GetScheme())
+		sfcReconciler := sfcreconciler.NewSfcReconciler(mgr.GetClient(), mgr.GetScheme(),
+			sfcreconciler.WithChainWirer(d))
 
 		if err = sfcReconciler.SetupWithManager(mgr); err != nil {
 			d.log.Error(err, "unable to create controller", "controller", "ServiceFunctionChain")
-			return fmt.Errorf("failed to setup ServiceFunctionChain controller: %v", err)
+			return unrecoverable(fmt.Errorf("failed to setup ServiceFunctionChain controller: %v", err))
 		}
 		d.manager = mgr
 	}
@@ -2,7 +2,10 @@ package daemon
 
 import (
 	"context"
+	"net"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	g "github.com/onsi/ginkgo/v2"
@@ -10,12 +13,20 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
 	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	sfcreconciler "github.com/openshift/dpu-operator/internal/daemon/sfc-reconciler"
 	mockvsp "github.com/openshift/dpu-operator/internal/daemon/vendor-specific-plugins/mock-vsp"
 	"github.com/openshift/dpu-operator/internal/testutils"
 	"github.com/openshift/dpu-operator/internal/utils"
+	opi "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -112,3 +123,329 @@ var _ = g.Describe("DPU side manager", Ordered, func() {
 		})
 	})
 })
+
+// nfRecordingPlugin records the network functions created and deleted on the
+// VSP. It lists the network functions in flows, or none if cannotList is set,
+// and chains them if chaining is set.
+type nfRecordingPlugin struct {
+	DummyPlugin
+	bridgePortMacs []string
+	flows          [][2]string
+	cannotList     bool
+	chaining       bool
+	created        [][2]string
+	deleted        [][2]string
+}
+
+func (p *nfRecordingPlugin) ListBridgePorts(listRequest *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	resp := &opi.ListBridgePortsResponse{}
+	for _, mac := range p.bridgePortMacs {
+		hw, _ := net.ParseMAC(mac)
+		resp.BridgePorts = append(resp.BridgePorts, &opi.BridgePort{Spec: &opi.BridgePortSpec{MacAddress: hw}})
+	}
+	return resp, nil
+}
+
+func (p *nfRecordingPlugin) CreateNetworkFunction(input string, output string) error {
+	p.created = append(p.created, [2]string{input, output})
+	return nil
+}
+
+func (p *nfRecordingPlugin) DeleteNetworkFunction(input string, output string) error {
+	p.deleted = append(p.deleted, [2]string{input, output})
+	return nil
+}
+
+func (p *nfRecordingPlugin) ListNetworkFunctions() ([]*nfapi.NFRequest, error) {
+	if p.cannotList {
+		return nil, status.Error(codes.Unimplemented, "method ListNetworkFunctions not implemented")
+	}
+	var nfs []*nfapi.NFRequest
+	for _, flow := range p.flows {
+		nfs = append(nfs, &nfapi.NFRequest{Input: flow[0], Output: flow[1]})
+	}
+	return nfs, nil
+}
+
+func (p *nfRecordingPlugin) ChainsNetworkFunctions() (bool, error) {
+	return p.chaining, nil
+}
+
+// singleNfVsp is a VSP that keeps a single network function, as the Marvell
+// VSP does: creating another one replaces it. It reports capabilities only
+// if they are set.
+type singleNfVsp struct {
+	nfapi.UnimplementedNetworkFunctionServiceServer
+	capabilities *nfapi.NFCapabilities
+	mu           sync.Mutex
+	nf           *nfapi.NFRequest
+}
+
+func (v *singleNfVsp) CreateNetworkFunction(ctx context.Context, in *nfapi.NFRequest) (*nfapi.Empty, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.nf = &nfapi.NFRequest{Input: in.Input, Output: in.Output}
+	return &nfapi.Empty{}, nil
+}
+
+func (v *singleNfVsp) DeleteNetworkFunction(ctx context.Context, in *nfapi.NFRequest) (*nfapi.Empty, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.nf = nil
+	return &nfapi.Empty{}, nil
+}
+
+func (v *singleNfVsp) GetCapabilities(ctx context.Context, in *nfapi.Empty) (*nfapi.NFCapabilities, error) {
+	if v.capabilities == nil {
+		return nil, status.Error(codes.Unimplemented, "method GetCapabilities not implemented")
+	}
+	return v.capabilities, nil
+}
+
+func (v *singleNfVsp) networkFunction() [2]string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.nf == nil {
+		return [2]string{}
+	}
+	return [2]string{v.nf.Input, v.nf.Output}
+}
+
+// serveSingleNfVsp serves vsp on the vendor plugin socket of pathManager
+// until the spec ends.
+func serveSingleNfVsp(vsp *singleNfVsp, pathManager *utils.PathManager) {
+	Expect(pathManager.EnsureSocketDirExists(pathManager.VendorPluginSocket())).To(Succeed())
+	listener, err := net.Listen("unix", pathManager.VendorPluginSocket())
+	Expect(err).NotTo(HaveOccurred())
+	server := grpc.NewServer()
+	nfapi.RegisterNetworkFunctionServiceServer(server, vsp)
+	go server.Serve(listener)
+	g.DeferCleanup(server.Stop)
+}
+
+var _ = g.Describe("Network function state", func() {
+	var (
+		pathManager utils.PathManager
+		liveNetns   string
+	)
+
+	g.BeforeEach(func() {
+		root := g.GinkgoT().TempDir()
+		pathManager = *utils.NewPathManager(root)
+		liveNetns = filepath.Join(root, "netns-live")
+		Expect(os.WriteFile(liveNetns, nil, 0o600)).To(Succeed())
+	})
+
+	persist := func(vsp *nfRecordingPlugin) {
+		d, err := NewDpuSideManager(vsp, nil, WithPathManager(pathManager))
+		Expect(err).NotTo(HaveOccurred())
+
+		d.chainMutex.Lock()
+		d.macStore["default/fw"] = []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}
+		d.podNetns["default/fw"] = liveNetns
+		d.macStore["default/lb"] = []string{"aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04"}
+		d.podNetns["default/lb"] = filepath.Join(filepath.Dir(liveNetns), "netns-gone")
+		d.chains["default/live"] = []configv1.ServiceFunctionChainHop{
+			{Input: "aa:bb:cc:dd:ee:01", Output: "aa:bb:cc:dd:ee:02"},
+			{Input: "aa:bb:cc:dd:ee:02", Output: "aa:bb:cc:dd:ee:10"},
+		}
+		d.chains["default/stale"] = []configv1.ServiceFunctionChainHop{
+			{Input: "aa:bb:cc:dd:ee:03", Output: "aa:bb:cc:dd:ee:04"},
+		}
+		d.saveState()
+		d.chainMutex.Unlock()
+	}
+
+	restore := func(vsp plugin.VendorPlugin) *DpuSideManager {
+		restarted, err := NewDpuSideManager(vsp, nil, WithPathManager(pathManager))
+		Expect(err).NotTo(HaveOccurred())
+		restarted.restoreState()
+
+		Expect(restarted.macStore).To(Equal(map[string][]string{"default/fw": {"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}}))
+		Expect(restarted.chains).To(HaveKey("default/live"))
+		Expect(restarted.chains).NotTo(HaveKey("default/stale"))
+
+		state, err := loadNfState(pathManager.NetworkFunctionStatePath())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Pods).To(HaveKey("default/fw"))
+		Expect(state.Pods).NotTo(HaveKey("default/lb"))
+		return restarted
+	}
+
+	g.It("restores the wiring and reconciles it with the VSP network functions", func() {
+		vsp := &nfRecordingPlugin{bridgePortMacs: []string{"aa:bb:cc:dd:ee:10"}}
+		persist(vsp)
+		vsp.flows = [][2]string{
+			{"AA:BB:CC:DD:EE:01", "AA:BB:CC:DD:EE:02"},
+			{"aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04"},
+			{"aa:bb:cc:dd:ee:05", "aa:bb:cc:dd:ee:06"},
+		}
+
+		restarted := restore(vsp)
+		Expect(restarted.chains["default/live"]).To(HaveLen(2))
+		Expect(vsp.created).To(Equal([][2]string{{"aa:bb:cc:dd:ee:02", "aa:bb:cc:dd:ee:10"}}))
+		Expect(vsp.deleted).To(ConsistOf(
+			[2]string{"aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04"},
+			[2]string{"aa:bb:cc:dd:ee:05", "aa:bb:cc:dd:ee:06"}))
+	})
+
+	g.It("re-applies the wiring on VSPs that cannot list their network functions", func() {
+		vsp := &nfRecordingPlugin{bridgePortMacs: []string{"aa:bb:cc:dd:ee:10"}, cannotList: true}
+		persist(vsp)
+
+		restore(vsp)
+		Expect(vsp.created).To(ConsistOf(
+			[2]string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"},
+			[2]string{"aa:bb:cc:dd:ee:02", "aa:bb:cc:dd:ee:10"}))
+		Expect(vsp.deleted).To(Equal([][2]string{{"aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04"}}))
+	})
+
+	g.It("reconciles the wiring with the network functions the mock VSP lists", func() {
+		persist(&nfRecordingPlugin{})
+		ctx, cancel := context.WithCancel(context.Background())
+		mockVsp := mockvsp.NewMockVsp(mockvsp.WithPathManager(pathManager))
+		listener, err := mockVsp.Listen()
+		Expect(err).NotTo(HaveOccurred())
+		go mockVsp.Serve(ctx, listener)
+		g.DeferCleanup(cancel)
+		vsp, err := plugin.NewGrpcPlugin(true, "dpu-0", nil, plugin.WithPathManager(pathManager))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vsp.CreateNetworkFunction("aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02")).To(Succeed())
+		Expect(vsp.CreateNetworkFunction("aa:bb:cc:dd:ee:05", "aa:bb:cc:dd:ee:06")).To(Succeed())
+
+		restarted := restore(vsp)
+		Expect(restarted.chains["default/live"]).To(Equal([]configv1.ServiceFunctionChainHop{
+			{Input: "aa:bb:cc:dd:ee:01", Output: "aa:bb:cc:dd:ee:02"},
+		}), "the hop to a port the mock VSP does not know is dropped")
+		nfs, err := vsp.ListNetworkFunctions()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nfs).To(HaveLen(1), "the leaked network function is deleted")
+		Expect(nfs[0].GetInput()).To(Equal("aa:bb:cc:dd:ee:01"))
+		Expect(nfs[0].GetOutput()).To(Equal("aa:bb:cc:dd:ee:02"))
+	})
+
+	g.It("ignores state files of another version", func() {
+		path := pathManager.NetworkFunctionStatePath()
+		Expect(os.MkdirAll(filepath.Dir(path), 0o700)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(`{"version":0,"pods":{"default/fw":{"netns":"/","ports":["aa:bb:cc:dd:ee:01"]}}}`), 0o600)).To(Succeed())
+
+		d, err := NewDpuSideManager(&nfRecordingPlugin{}, nil, WithPathManager(pathManager))
+		Expect(err).NotTo(HaveOccurred())
+		d.restoreState()
+		Expect(d.macStore).To(BeEmpty())
+	})
+})
+
+var _ = g.Describe("Network function wiring", func() {
+	var (
+		vsp *nfRecordingPlugin
+		d   *DpuSideManager
+	)
+
+	g.BeforeEach(func() {
+		var err error
+		vsp = &nfRecordingPlugin{}
+		d, err = NewDpuSideManager(vsp, nil, WithPathManager(*utils.NewPathManager(g.GinkgoT().TempDir())))
+		Expect(err).NotTo(HaveOccurred())
+		d.macStore["default/fw"] = []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}
+	})
+
+	g.It("wires a network function pod outside any chain on its own", func() {
+		d.wireStandalone("default/fw")
+
+		Expect(vsp.created).To(Equal([][2]string{{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}}))
+		Expect(d.chains).To(HaveKey(standaloneChain("default/fw")))
+	})
+
+	g.It("moves a network function pod into its chain", func() {
+		d.wireStandalone("default/fw")
+		members := []sfcreconciler.ChainMember{{
+			Name: "fw",
+			Pods: []types.NamespacedName{{Namespace: "default", Name: "fw"}},
+		}}
+
+		wiring := d.WireChain("default/chain", members)
+
+		Expect(wiring.Members["fw"].Result).To(Equal(configv1.WiringSucceeded))
+		Expect(vsp.deleted).To(Equal([][2]string{{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}}))
+		Expect(d.chains).NotTo(HaveKey(standaloneChain("default/fw")))
+		Expect(d.chains).To(HaveKey("default/chain"))
+
+		d.wireStandalone("default/fw")
+		Expect(vsp.created).To(HaveLen(2))
+	})
+})
+
+var _ = g.Describe("Network function chaining", func() {
+	fw := sfcreconciler.ChainMember{Name: "fw", Pods: []types.NamespacedName{{Namespace: "default", Name: "fw"}}}
+	lb := sfcreconciler.ChainMember{Name: "lb", Pods: []types.NamespacedName{{Namespace: "default", Name: "lb"}}}
+
+	newManager := func(vsp plugin.VendorPlugin, pathManager *utils.PathManager) *DpuSideManager {
+		d, err := NewDpuSideManager(vsp, nil, WithPathManager(*pathManager))
+		Expect(err).NotTo(HaveOccurred())
+		d.macStore["default/fw"] = []string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}
+		d.macStore["default/lb"] = []string{"aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04"}
+		return d
+	}
+
+	g.Context("on a VSP keeping a single network function", func() {
+		var (
+			vsp *singleNfVsp
+			d   *DpuSideManager
+		)
+
+		g.BeforeEach(func() {
+			pathManager := utils.NewPathManager(g.GinkgoT().TempDir())
+			vsp = &singleNfVsp{capabilities: &nfapi.NFCapabilities{Chaining: false}}
+			serveSingleNfVsp(vsp, pathManager)
+			grpcPlugin, err := plugin.NewGrpcPlugin(true, "dpu-0", nil, plugin.WithPathManager(*pathManager))
+			Expect(err).NotTo(HaveOccurred())
+			d = newManager(grpcPlugin, pathManager)
+		})
+
+		expectRejected := func(wiring sfcreconciler.ChainWiring) {
+			for _, name := range []string{"fw", "lb"} {
+				Expect(wiring.Members[name].Result).To(Equal(configv1.WiringFailed))
+				Expect(wiring.Members[name].Message).To(ContainSubstring("cannot chain network functions"))
+			}
+			Expect(wiring.Hops).To(BeEmpty())
+			Expect(vsp.networkFunction()).To(Equal([2]string{}), "no network function is left on the VSP")
+			Expect(d.chains).NotTo(HaveKey("default/chain"))
+		}
+
+		g.It("wires a chain of one network function", func() {
+			wiring := d.WireChain("default/chain", []sfcreconciler.ChainMember{fw})
+
+			Expect(wiring.Members["fw"].Result).To(Equal(configv1.WiringSucceeded))
+			Expect(vsp.networkFunction()).To(Equal([2]string{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"}))
+		})
+
+		g.It("rejects a chain of several network functions", func() {
+			expectRejected(d.WireChain("default/chain", []sfcreconciler.ChainMember{fw, lb}))
+		})
+
+		g.It("unwires a chain that grows beyond one network function", func() {
+			d.WireChain("default/chain", []sfcreconciler.ChainMember{fw})
+			expectRejected(d.WireChain("default/chain", []sfcreconciler.ChainMember{fw, lb}))
+		})
+
+		g.It("rejects a chain of several network functions if the VSP does not report its capabilities", func() {
+			vsp.capabilities = nil
+			expectRejected(d.WireChain("default/chain", []sfcreconciler.ChainMember{fw, lb}))
+		})
+	})
+
+	g.It("stitches the network functions of a chain on VSPs that can chain them", func() {
+		vsp := &nfRecordingPlugin{chaining: true}
+		d := newManager(vsp, utils.NewPathManager(g.GinkgoT().TempDir()))
+
+		wiring := d.WireChain("default/chain", []sfcreconciler.ChainMember{fw, lb})
+
+		Expect(wiring.Members["fw"].Result).To(Equal(configv1.WiringSucceeded))
+		Expect(wiring.Members["lb"].Result).To(Equal(configv1.WiringSucceeded))
+		Expect(vsp.created).To(Equal([][2]string{
+			{"aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:04"},
+			{"aa:bb:cc:dd:ee:02", "aa:bb:cc:dd:ee:03"},
+		}))
+	})
+})
@@ -0,0 +1,541 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// firmwareRefreshInterval is how often the firmware versions of a DPU are
+	// read from its plugin while a DpuFirmwarePolicy sets its firmware.
+	firmwareRefreshInterval = 5 * time.Minute
+	// firmwareReadTimeout bounds a single read of the firmware versions.
+	firmwareReadTimeout = 30 * time.Second
+	// firmwareUpdateTimeout bounds the staging, the drain, the activation and
+	// the reset of the firmware updates on a node.
+	firmwareUpdateTimeout = time.Hour
+	// firmwareResetTimeout is how long the daemon waits for a DPU to come
+	// back after a reset or a reboot of the node.
+	firmwareResetTimeout = 10 * time.Minute
+	// firmwareResetPollInterval is how often a DPU that resets is polled.
+	firmwareResetPollInterval = 10 * time.Second
+	// firmwareRetryInterval is how long the daemon waits before it tries a
+	// failed firmware update again.
+	firmwareRetryInterval = 30 * time.Minute
+)
+
+// firmwareUpdater updates the firmware of a DPU. It is implemented by plugin.GrpcPlugin.
+type firmwareUpdater interface {
+	GetFirmwareVersions(ctx context.Context) ([]pkgplugin.FirmwareVersion, error)
+	StageFirmware(ctx context.Context, image *pkgplugin.FirmwareImage) error
+	ActivateFirmware(ctx context.Context) (*pkgplugin.FirmwareActivation, error)
+}
+
+// firmwareUpdate is a firmware image to install on a DPU of the node.
+type firmwareUpdate struct {
+	dpu     string
+	updater firmwareUpdater
+	image   pkgplugin.FirmwareImage
+	reset   pkgplugin.FirmwareReset
+	err     error
+}
+
+// firmwareFailure is a firmware version that failed to install.
+type firmwareFailure struct {
+	version string
+	err     string
+	at      time.Time
+}
+
+// firmwareRebootRecord is the value of the FirmwareRebootPendingAnnotation of a node.
+type firmwareRebootRecord struct {
+	// BootID is the boot ID of the node when the firmware was activated.
+	BootID string `json:"bootID"`
+}
+
+// firmwareRollout installs firmware on the DPUs of the node of the daemon. It
+// stages the images while the node still runs workloads, then drains the
+// node, activates the firmware, waits for the DPUs that reset, verifies the
+// versions the plugins report and uncordons the node. It runs in the
+// background while the daemon loop reports its phase on the DPUs. Firmware
+// that runs after the node reboots is recorded on the node, which is
+// uncordoned as well: the daemon does not reboot nodes, the administrator
+// does when it suits the workloads.
+type firmwareRollout struct {
+	updates []*firmwareUpdate
+	drain   bool
+
+	mu    sync.Mutex
+	phase configv1.FirmwarePhase
+	done  bool
+	// rebootPending is set when firmware runs after the node reboots. No
+	// other firmware is installed on the node until the daemon restarts on
+	// the new boot.
+	rebootPending bool
+}
+
+func newFirmwareRollout(updates []*firmwareUpdate, drain bool) *firmwareRollout {
+	return &firmwareRollout{updates: updates, drain: drain, phase: configv1.FirmwarePhaseStaging}
+}
+
+func (r *firmwareRollout) setPhase(phase configv1.FirmwarePhase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = phase
+}
+
+// state returns the current phase and whether the rollout is done. The
+// updates must only be read once it is done.
+func (r *firmwareRollout) state() (configv1.FirmwarePhase, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.phase, r.done
+}
+
+func (r *firmwareRollout) includes(dpu string) bool {
+	for _, update := range r.updates {
+		if update.dpu == dpu {
+			return true
+		}
+	}
+	return false
+}
+
+// run installs the firmware. markRebootPending records on the node that it
+// must reboot for the firmware to run. The node is uncordoned once done,
+// whether the updates succeeded, failed or wait for a reboot.
+func (r *firmwareRollout) run(ctx context.Context, drainer nodeDrainer, node *corev1.Node, markRebootPending func(context.Context, *corev1.Node, firmwareRebootRecord) error) {
+	defer r.finish()
+
+	r.setPhase(configv1.FirmwarePhaseStaging)
+	var staged []*firmwareUpdate
+	for _, update := range r.updates {
+		if err := update.updater.StageFirmware(ctx, &update.image); err != nil {
+			update.err = fmt.Errorf("failed to stage firmware %s: %v", update.image.Version, err)
+			continue
+		}
+		staged = append(staged, update)
+	}
+	if len(staged) == 0 {
+		return
+	}
+
+	if r.drain {
+		r.setPhase(configv1.FirmwarePhaseDraining)
+		if err := drainNode(ctx, drainer, node); err != nil {
+			r.fail(err)
+			r.uncordon(ctx, drainer, node)
+			return
+		}
+	}
+
+	r.setPhase(configv1.FirmwarePhaseActivating)
+	var rebooting, resetting []*firmwareUpdate
+	for _, update := range staged {
+		activation, err := update.updater.ActivateFirmware(ctx)
+		if err != nil {
+			update.err = fmt.Errorf("failed to activate firmware %s: %v", update.image.Version, err)
+			continue
+		}
+		update.reset = activation.Reset
+		switch update.reset {
+		case pkgplugin.FirmwareResetHost:
+			rebooting = append(rebooting, update)
+		case pkgplugin.FirmwareResetDevice:
+			resetting = append(resetting, update)
+		}
+	}
+
+	if len(rebooting) > 0 {
+		record := firmwareRebootRecord{BootID: node.Status.NodeInfo.BootID}
+		if err := markRebootPending(ctx, node, record); err != nil {
+			for _, update := range rebooting {
+				update.err = fmt.Errorf("failed to record the pending reboot of node %s: %v", node.Name, err)
+			}
+		} else {
+			r.mu.Lock()
+			r.rebootPending = true
+			r.mu.Unlock()
+		}
+	}
+
+	if len(resetting) > 0 {
+		r.setPhase(configv1.FirmwarePhaseResetting)
+		for _, update := range resetting {
+			if err := waitForFirmwareReset(ctx, update.updater); err != nil {
+				update.err = fmt.Errorf("DPU did not come back after activating firmware %s: %v", update.image.Version, err)
+			}
+		}
+	}
+
+	r.setPhase(configv1.FirmwarePhaseVerifying)
+	for _, update := range staged {
+		if update.err != nil || update.reset == pkgplugin.FirmwareResetHost {
+			continue
+		}
+		update.err = verifyFirmware(ctx, update.updater, update.image)
+	}
+
+	if r.drain {
+		r.uncordon(ctx, drainer, node)
+	}
+}
+
+func (r *firmwareRollout) uncordon(ctx context.Context, drainer nodeDrainer, node *corev1.Node) {
+	r.setPhase(configv1.FirmwarePhaseUncordoning)
+	uncordonCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), vfCountUncordonTimeout)
+	defer cancel()
+	if _, err := drainer.CompleteDrainNode(uncordonCtx, node); err != nil {
+		r.fail(fmt.Errorf("failed to uncordon node %s: %v", node.Name, err))
+	}
+}
+
+func (r *firmwareRollout) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = ""
+	r.done = true
+}
+
+// fail records the error on the updates that did not fail yet.
+func (r *firmwareRollout) fail(err error) {
+	for _, update := range r.updates {
+		if update.err == nil {
+			update.err = err
+		}
+	}
+}
+
+// waitForFirmwareReset polls a DPU that resets until its plugin reports its
+// firmware again.
+func waitForFirmwareReset(ctx context.Context, updater firmwareUpdater) error {
+	ctx, cancel := context.WithTimeout(ctx, firmwareResetTimeout)
+	defer cancel()
+	for {
+		_, err := updater.GetFirmwareVersions(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%v, last error: %v", ctx.Err(), err)
+		case <-time.After(firmwareResetPollInterval):
+		}
+	}
+}
+
+// verifyFirmware checks that the plugin reports the version of the image as
+// running.
+func verifyFirmware(ctx context.Context, updater firmwareUpdater, image pkgplugin.FirmwareImage) error {
+	versions, err := updater.GetFirmwareVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the firmware versions: %v", err)
+	}
+	if running := runningFirmware(versions, image.Component); running != image.Version {
+		return fmt.Errorf("plugin reports firmware %q after activating firmware %s", running, image.Version)
+	}
+	return nil
+}
+
+// runningFirmware returns the running version of a firmware component.
+func runningFirmware(versions []pkgplugin.FirmwareVersion, component string) string {
+	for _, version := range versions {
+		if version.Component == component {
+			return version.Running
+		}
+	}
+	return ""
+}
+
+// firmwareImage returns the image of the firmware target of a DPU.
+func firmwareImage(target *configv1.DpuFirmwareTarget) pkgplugin.FirmwareImage {
+	return pkgplugin.FirmwareImage{
+		Component: target.Component,
+		Version:   target.Version,
+		URL:       target.URL,
+		Checksum:  target.Checksum,
+	}
+}
+
+// firmwareBusy returns whether a firmware update holds the node, so that no
+// other change drains it or changes its DPUs. A node that waits for a reboot
+// is not held, it runs workloads until it is rebooted.
+func (d *Daemon) firmwareBusy() bool {
+	return d.firmwareRollout != nil
+}
+
+// applyDesiredFirmware installs the firmware targets of the DPUs of the node
+// that do not run them yet, all in one rollout, and reports their firmware.
+func (d *Daemon) applyDesiredFirmware(ctx context.Context, now time.Time) {
+	d.collectFirmwareRollout(now)
+
+	targets := make(map[string]*configv1.DpuFirmwareTarget)
+	for name, managed := range d.managedDpus {
+		if managed == nil || managed.Plugin == nil || managed.DpuCR == nil || managed.DpuCR.Spec.IsDpuSide {
+			continue
+		}
+
+		current := &configv1.DataProcessingUnit{}
+		if err := d.dpuReader().Get(ctx, client.ObjectKey{Name: name}, current); err != nil {
+			d.log.V(1).Info("Failed to fetch DPU CR for firmware evaluation", "dpu", name, "error", err)
+			continue
+		}
+		if current.Spec.Firmware == nil {
+			meta.RemoveStatusCondition(&managed.DpuCR.Status.Conditions, configv1.DpuConditionFirmwareUpdated)
+			managed.DpuCR.Status.Firmware = nil
+			continue
+		}
+		targets[name] = current.Spec.Firmware
+	}
+
+	if !d.firmwareRebootChecked {
+		d.checkFirmwareReboot(ctx, now, targets)
+	}
+
+	var updates []*firmwareUpdate
+	drain := false
+	for name, target := range targets {
+		managed := d.managedDpus[name]
+		if d.firmwareRollout == nil && firmwareRefreshDue(managed.FirmwareRefreshed, now) {
+			d.refreshFirmware(ctx, managed, now)
+		}
+
+		running := runningFirmware(managed.FirmwareVersions, target.Component)
+		status := &configv1.DpuFirmwareStatus{
+			Component: target.Component,
+			Desired:   target.Version,
+			Running:   running,
+			State:     configv1.FirmwareStatePending,
+		}
+		for _, version := range managed.FirmwareVersions {
+			if version.Component == target.Component {
+				status.Staged = version.Staged
+			}
+		}
+		managed.DpuCR.Status.Firmware = status
+		conditions := &managed.DpuCR.Status.Conditions
+
+		if rollout := d.firmwareRollout; rollout != nil {
+			if phase, _ := rollout.state(); rollout.includes(name) {
+				status.Phase = phase
+			}
+			setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionFalse,
+				"Updating", fmt.Sprintf("Firmware %s is being installed on the node.", target.Version))
+			continue
+		}
+
+		if running == target.Version {
+			status.State = configv1.FirmwareStateUpdated
+			managed.FirmwareFailure = nil
+			setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionTrue,
+				"Updated", fmt.Sprintf("Firmware %s is running.", target.Version))
+			continue
+		}
+
+		if d.firmwareRebootPending {
+			status.Phase = configv1.FirmwarePhaseRebootRequired
+			setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionFalse,
+				"RebootRequired", fmt.Sprintf("Firmware %s runs once node %s reboots. Drain and reboot the node to finish the update.", target.Version, d.nodeName))
+			continue
+		}
+
+		if err := managed.FirmwareReadErr; err != nil && errors.Is(err, pkgplugin.ErrCapabilityNotSupported) {
+			status.State = configv1.FirmwareStateFailed
+			status.LastError = "The vendor plugin of the DPU does not support firmware updates."
+			setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionFalse, "NotSupported", status.LastError)
+			continue
+		}
+
+		if failure := managed.FirmwareFailure; failure != nil && failure.version == target.Version &&
+			now.Sub(failure.at) < firmwareRetryInterval {
+			status.State = configv1.FirmwareStateFailed
+			status.LastError = failure.err
+			setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionFalse, "UpdateFailed", failure.err)
+			continue
+		}
+
+		if !d.firmwareRebootChecked || d.vfCountRollout != nil || d.rebootBusy() {
+			setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionFalse,
+				"Waiting", fmt.Sprintf("Firmware %s waits for the changes in progress on the node.", target.Version))
+			continue
+		}
+
+		updates = append(updates, &firmwareUpdate{dpu: name, updater: managed.Plugin, image: firmwareImage(target)})
+		drain = drain || target.Drain
+		status.Phase = configv1.FirmwarePhaseStaging
+		setDpuCondition(conditions, configv1.DpuConditionFirmwareUpdated, metav1.ConditionFalse,
+			"Updating", fmt.Sprintf("Firmware %s is being installed on the node.", target.Version))
+	}
+
+	if len(updates) > 0 {
+		d.startFirmwareRollout(updates, drain)
+	}
+}
+
+func firmwareRefreshDue(lastRefresh, now time.Time) bool {
+	return lastRefresh.IsZero() || now.Sub(lastRefresh) >= firmwareRefreshInterval
+}
+
+// refreshFirmware reads the firmware versions of a DPU from its plugin. A
+// failed read keeps the last known versions.
+func (d *Daemon) refreshFirmware(ctx context.Context, managed *ManagedDpu, now time.Time) {
+	managed.FirmwareRefreshed = now
+	readCtx, cancel := context.WithTimeout(ctx, firmwareReadTimeout)
+	defer cancel()
+	versions, err := managed.Plugin.GetFirmwareVersions(readCtx)
+	managed.FirmwareReadErr = err
+	if err != nil {
+		d.log.Info("Failed to read DPU firmware versions", "dpu", managed.DpuCR.Name, "error", err)
+		return
+	}
+	managed.FirmwareVersions = versions
+}
+
+// startFirmwareRollout installs the firmware in the background.
+func (d *Daemon) startFirmwareRollout(updates []*firmwareUpdate, drain bool) {
+	rollout := newFirmwareRollout(updates, drain)
+	d.firmwareRollout = rollout
+
+	drainer, err := d.nodeDrainer()
+	if err != nil {
+		rollout.fail(err)
+		rollout.finish()
+		return
+	}
+
+	d.log.Info("Installing DPU firmware", "node", d.nodeName, "dpus", len(updates), "drain", drain)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), firmwareUpdateTimeout)
+		defer cancel()
+
+		node := &corev1.Node{}
+		if err := d.client.Get(ctx, client.ObjectKey{Name: d.nodeName}, node); err != nil {
+			rollout.fail(fmt.Errorf("failed to get node %s: %v", d.nodeName, err))
+			rollout.finish()
+			return
+		}
+		rollout.run(ctx, drainer, node, d.markFirmwareRebootPending)
+	}()
+}
+
+// collectFirmwareRollout records the results of a finished rollout on the
+// managed DPUs. A failed update is retried after firmwareRetryInterval.
+func (d *Daemon) collectFirmwareRollout(now time.Time) {
+	rollout := d.firmwareRollout
+	if rollout == nil {
+		return
+	}
+	if _, done := rollout.state(); !done {
+		return
+	}
+	d.firmwareRollout = nil
+	d.firmwareRebootPending = rollout.rebootPending
+
+	for _, update := range rollout.updates {
+		managed, ok := d.managedDpus[update.dpu]
+		if !ok {
+			continue
+		}
+		// Read the versions the update left on the next evaluation.
+		managed.FirmwareRefreshed = time.Time{}
+		if update.err != nil {
+			d.log.Info("Failed to install DPU firmware", "dpu", update.dpu, "version", update.image.Version, "error", update.err)
+			managed.FirmwareFailure = &firmwareFailure{
+				version: update.image.Version,
+				err:     fmt.Sprintf("Firmware update on the node failed: %v", update.err),
+				at:      now,
+			}
+			continue
+		}
+		managed.FirmwareFailure = nil
+		if update.reset == pkgplugin.FirmwareResetHost {
+			d.log.Info("DPU firmware runs after the node reboots", "dpu", update.dpu, "version", update.image.Version)
+			continue
+		}
+		d.log.Info("Installed DPU firmware", "dpu", update.dpu, "version", update.image.Version)
+	}
+}
+
+// markFirmwareRebootPending records on the node that it must reboot for the
+// activated firmware to run.
+func (d *Daemon) markFirmwareRebootPending(ctx context.Context, node *corev1.Node, record firmwareRebootRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[vars.FirmwareRebootPendingAnnotation] = string(value)
+	return d.client.Patch(ctx, node, patch)
+}
+
+// checkFirmwareReboot finishes a firmware update that waits for the node to
+// reboot, once per daemon start. Until the node rebooted, no other firmware
+// update starts on it. Once it rebooted and the DPUs are back, the DPUs that
+// do not run their firmware target are marked failed.
+func (d *Daemon) checkFirmwareReboot(ctx context.Context, now time.Time, targets map[string]*configv1.DpuFirmwareTarget) {
+	node := &corev1.Node{}
+	if err := d.client.Get(ctx, client.ObjectKey{Name: d.nodeName}, node); err != nil {
+		d.log.V(1).Info("Failed to fetch node for the firmware reboot check", "node", d.nodeName, "error", err)
+		return
+	}
+	value, ok := node.Annotations[vars.FirmwareRebootPendingAnnotation]
+	if !ok {
+		d.firmwareRebootChecked = true
+		return
+	}
+	var record firmwareRebootRecord
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		d.log.Info("Ignoring invalid firmware reboot annotation", "node", d.nodeName, "value", value, "error", err)
+	}
+	if record.BootID != "" && record.BootID == node.Status.NodeInfo.BootID {
+		d.firmwareRebootPending = true
+		d.firmwareRebootChecked = true
+		return
+	}
+
+	if d.firmwareRebootSince.IsZero() {
+		d.firmwareRebootSince = now
+	}
+	waited := now.Sub(d.firmwareRebootSince) >= firmwareResetTimeout
+	for name, target := range targets {
+		managed := d.managedDpus[name]
+		d.refreshFirmware(ctx, managed, now)
+		if managed.FirmwareReadErr != nil && !waited {
+			// Wait for the DPUs to come back after the reboot.
+			return
+		}
+		if running := runningFirmware(managed.FirmwareVersions, target.Component); running != target.Version {
+			managed.FirmwareFailure = &firmwareFailure{
+				version: target.Version,
+				err:     fmt.Sprintf("Firmware %s is not running after node %s rebooted, the DPU runs %q.", target.Version, d.nodeName, running),
+				at:      now,
+			}
+		}
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	delete(node.Annotations, vars.FirmwareRebootPendingAnnotation)
+	if err := d.client.Patch(ctx, node, patch); err != nil {
+		d.log.Info("Failed to remove the firmware reboot annotation", "node", d.nodeName, "error", err)
+		return
+	}
+	d.log.Info("Finished the firmware update after the node rebooted", "node", d.nodeName)
+	d.firmwareRebootChecked = true
+	d.firmwareRebootSince = time.Time{}
+}
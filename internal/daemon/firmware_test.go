@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	"github.com/openshift/dpu-operator/pkg/plugin/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const testFirmwareChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func newFirmwareUpdate(firmwarePlugin *fake.FirmwarePlugin, version string) *firmwareUpdate {
+	grpcPlugin, err := plugin.NewGrpcPlugin(false, "bf3-0", nil)
+	Expect(err).NotTo(HaveOccurred())
+	grpcPlugin.AttachRegistryPlugin(firmwarePlugin, pkgplugin.PluginConfig{})
+	return &firmwareUpdate{
+		dpu:     "bf3-0",
+		updater: grpcPlugin,
+		image: pkgplugin.FirmwareImage{
+			Component: pkgplugin.FirmwareComponentNIC,
+			Version:   version,
+			URL:       "https://firmware.example.com/bf3-32.43.1014.bin",
+			Checksum:  testFirmwareChecksum,
+		},
+	}
+}
+
+var _ = g.Describe("Firmware rollout", func() {
+	var (
+		drainer        *fakeDrainer
+		node           *corev1.Node
+		firmwarePlugin *fake.FirmwarePlugin
+		records        []firmwareRebootRecord
+		markReboot     func(context.Context, *corev1.Node, firmwareRebootRecord) error
+	)
+
+	g.BeforeEach(func() {
+		drainer = &fakeDrainer{}
+		node = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker-1"},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{BootID: "boot-1"}},
+		}
+		firmwarePlugin = fake.NewFirmwarePlugin(pkgplugin.Device{ID: "bf3-0", FirmwareVersion: "32.41.1000"})
+		records = nil
+		markReboot = func(_ context.Context, _ *corev1.Node, record firmwareRebootRecord) error {
+			records = append(records, record)
+			return nil
+		}
+	})
+
+	g.It("stages the firmware before the drain, activates and verifies it and uncordons the node", func() {
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+		rollout := newFirmwareRollout([]*firmwareUpdate{update}, true)
+
+		rollout.run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).NotTo(HaveOccurred())
+		Expect(firmwarePlugin.Calls()).To(Equal([]string{
+			"StageFirmware bf3-0 nic 32.43.1014",
+			"ActivateFirmware bf3-0",
+		}))
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+		Expect(records).To(BeEmpty())
+		phase, done := rollout.state()
+		Expect(done).To(BeTrue())
+		Expect(phase).To(BeEmpty())
+	})
+
+	g.It("does not drain the node when the strategy disables the drain", func() {
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+
+		newFirmwareRollout([]*firmwareUpdate{update}, false).run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).NotTo(HaveOccurred())
+		Expect(drainer.steps).To(BeEmpty())
+	})
+
+	g.It("waits for a DPU that resets before verifying its firmware", func() {
+		firmwarePlugin.SetReset(pkgplugin.FirmwareResetDevice, 0)
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+
+		newFirmwareRollout([]*firmwareUpdate{update}, true).run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).NotTo(HaveOccurred())
+		Expect(update.reset).To(Equal(pkgplugin.FirmwareResetDevice))
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+	})
+
+	g.It("records the boot and uncordons the node when the firmware runs after a reboot", func() {
+		firmwarePlugin.SetReset(pkgplugin.FirmwareResetHost, 0)
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+		rollout := newFirmwareRollout([]*firmwareUpdate{update}, true)
+
+		rollout.run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).NotTo(HaveOccurred())
+		Expect(rollout.rebootPending).To(BeTrue())
+		Expect(records).To(Equal([]firmwareRebootRecord{{BootID: "boot-1"}}))
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+	})
+
+	g.It("uncordons the node when the pending reboot cannot be recorded", func() {
+		firmwarePlugin.SetReset(pkgplugin.FirmwareResetHost, 0)
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+		rollout := newFirmwareRollout([]*firmwareUpdate{update}, true)
+
+		rollout.run(context.Background(), drainer, node, func(context.Context, *corev1.Node, firmwareRebootRecord) error {
+			return errors.New("nodes \"worker-1\" is forbidden")
+		})
+
+		Expect(update.err).To(MatchError(ContainSubstring("failed to record the pending reboot of node worker-1")))
+		Expect(rollout.rebootPending).To(BeFalse())
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+	})
+
+	g.It("does not drain the node when no firmware could be staged", func() {
+		firmwarePlugin.FailStage(errors.New("download failed"))
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+
+		newFirmwareRollout([]*firmwareUpdate{update}, true).run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).To(MatchError(ContainSubstring("failed to stage firmware 32.43.1014")))
+		Expect(update.err).To(MatchError(ContainSubstring("download failed")))
+		Expect(drainer.steps).To(BeEmpty())
+	})
+
+	g.It("uncordons the node when the drain fails", func() {
+		drainer.drainErr = errors.New("eviction blocked by PodDisruptionBudget")
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+
+		newFirmwareRollout([]*firmwareUpdate{update}, true).run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).To(MatchError(ContainSubstring("failed to drain node worker-1")))
+		Expect(firmwarePlugin.Calls()).NotTo(ContainElement("ActivateFirmware bf3-0"))
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+	})
+
+	g.It("fails an activation and still uncordons the node", func() {
+		firmwarePlugin.FailActivate(errors.New("image signature rejected"))
+		update := newFirmwareUpdate(firmwarePlugin, "32.43.1014")
+
+		newFirmwareRollout([]*firmwareUpdate{update}, true).run(context.Background(), drainer, node, markReboot)
+
+		Expect(update.err).To(MatchError(ContainSubstring("failed to activate firmware 32.43.1014")))
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+	})
+
+	g.It("records the results of a finished rollout on the managed DPUs", func() {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		installed := &firmwareUpdate{dpu: "bf3-0", image: pkgplugin.FirmwareImage{Version: "32.43.1014"}}
+		failed := &firmwareUpdate{dpu: "bf3-1", image: pkgplugin.FirmwareImage{Version: "32.43.1014"}, err: errors.New("failed to drain node worker-1")}
+		rebooting := &firmwareUpdate{dpu: "bf3-2", image: pkgplugin.FirmwareImage{Version: "32.43.1014"}, reset: pkgplugin.FirmwareResetHost}
+		rollout := newFirmwareRollout([]*firmwareUpdate{installed, failed, rebooting}, true)
+		rollout.rebootPending = true
+
+		d := &Daemon{
+			log: ctrl.Log.WithName("Daemon"),
+			managedDpus: map[string]*ManagedDpu{
+				"bf3-0": {DpuCR: &configv1.DataProcessingUnit{}, FirmwareRefreshed: now},
+				"bf3-1": {DpuCR: &configv1.DataProcessingUnit{}, FirmwareRefreshed: now},
+				"bf3-2": {DpuCR: &configv1.DataProcessingUnit{}, FirmwareRefreshed: now},
+			},
+			firmwareRollout: rollout,
+		}
+		Expect(d.firmwareBusy()).To(BeTrue())
+
+		d.collectFirmwareRollout(now)
+		Expect(d.firmwareRollout).To(Equal(rollout), "the rollout is still running")
+
+		rollout.finish()
+		d.collectFirmwareRollout(now)
+		Expect(d.firmwareRollout).To(BeNil())
+		Expect(d.firmwareRebootPending).To(BeTrue())
+		Expect(d.firmwareBusy()).To(BeFalse(), "the node runs workloads until it is rebooted")
+		Expect(d.managedDpus["bf3-0"].FirmwareFailure).To(BeNil())
+		Expect(d.managedDpus["bf3-0"].FirmwareRefreshed.IsZero()).To(BeTrue())
+		Expect(d.managedDpus["bf3-1"].FirmwareFailure.version).To(Equal("32.43.1014"))
+		Expect(d.managedDpus["bf3-1"].FirmwareFailure.err).To(ContainSubstring("failed to drain node worker-1"))
+		Expect(d.managedDpus["bf3-1"].FirmwareFailure.at).To(Equal(now))
+		Expect(d.managedDpus["bf3-2"].FirmwareFailure).To(BeNil())
+	})
+})
@@ -5,14 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	cni100 "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cniserver"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriov"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
 	deviceplugin "github.com/openshift/dpu-operator/internal/daemon/device-plugin"
 	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	sfcreconciler "github.com/openshift/dpu-operator/internal/daemon/sfc-reconciler"
@@ -22,7 +28,9 @@ import (
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	lifecycleapi "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -49,9 +57,40 @@ type HostSideManager struct {
 	pathManager        utils.PathManager
 	stopRequested      bool
 	dpListener         net.Listener
+	vlanPolicy         configv1.VlanPolicy
+	bridgePorts        map[string]hostBridgePort
+	bridgePortsMutex   sync.RWMutex
 }
 
-func (d *HostSideManager) CreateBridgePort(pf int, vf int, vlan int, mac string) (*pb.BridgePort, error) {
+// hostFunction is the host PF and VF allocated to a pod.
+type hostFunction struct {
+	pf     int
+	pfName string
+	pfPci  string
+	vf     int
+	vfPci  string
+}
+
+// hostBridgePort is a bridge port created on the DPU for a host VF.
+type hostBridgePort struct {
+	function hostFunction
+	mac      string
+}
+
+func bridgePortName(pf int, vf int) string {
+	return "host" + fmt.Sprintf("%d-%d", pf, vf)
+}
+
+// parseBridgePortName returns the PF and VF of a bridge port named by
+// bridgePortName. ok is false for bridge ports not created for a host VF.
+func parseBridgePortName(name string) (pf int, vf int, ok bool) {
+	if _, err := fmt.Sscanf(name, "host%d-%d", &pf, &vf); err != nil || bridgePortName(pf, vf) != name {
+		return 0, 0, false
+	}
+	return pf, vf, true
+}
+
+func (d *HostSideManager) CreateBridgePort(pf int, vf int, vlan plugin.BridgePortVlan, mac string) (*pb.BridgePort, error) {
 	err := d.connectWithRetry()
 	if err != nil {
 		return nil, fmt.Errorf("Failed to connect with retry: %v", err)
@@ -64,29 +103,59 @@ func (d *HostSideManager) CreateBridgePort(pf int, vf int, vlan int, mac string)
 
 	createRequest := &pb.CreateBridgePortRequest{
 		BridgePort: &pb.BridgePort{
-			Name: "host" + fmt.Sprintf("%d-%d", pf, vf),
+			Name: bridgePortName(pf, vf),
 			Spec: &pb.BridgePortSpec{
-				Ptype:      1,
-				MacAddress: m,
-				LogicalBridges: []string{
-					// TODO: Remove +2
-					fmt.Sprintf("%d", vf+2),
-				},
+				Ptype:          pb.BridgePortType_BRIDGE_PORT_TYPE_ACCESS,
+				MacAddress:     m,
+				LogicalBridges: []string{vlan.LogicalBridge()},
 			},
 		},
 	}
 
-	return d.client.CreateBridgePort(context.TODO(), createRequest)
+	ctx := plugin.BridgePortFunction{PF: pf, VF: vf}.OutgoingContext(context.TODO())
+	return d.client.CreateBridgePort(ctx, createRequest)
 }
 
-func (d *HostSideManager) DeleteBridgePort(pf int, vf int, vlan int, mac string) error {
+func (d *HostSideManager) DeleteBridgePort(pf int, vf int, vlan plugin.BridgePortVlan, mac string) error {
 	d.connectWithRetry()
-	req := &pb.DeleteBridgePortRequest{Name: "host" + fmt.Sprintf("%d-%d", pf, vf)}
+	req := &pb.DeleteBridgePortRequest{Name: bridgePortName(pf, vf)}
 
 	_, err := d.client.DeleteBridgePort(context.TODO(), req)
 	return err
 }
 
+func (d *HostSideManager) GetBridgePort(pf int, vf int) (*pb.BridgePort, error) {
+	err := d.connectWithRetry()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect with retry: %v", err)
+	}
+
+	req := &pb.GetBridgePortRequest{Name: bridgePortName(pf, vf)}
+	return d.client.GetBridgePort(context.TODO(), req)
+}
+
+// listBridgePorts returns all bridge ports on the DPU, following pagination.
+func (d *HostSideManager) listBridgePorts() ([]*pb.BridgePort, error) {
+	err := d.connectWithRetry()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect with retry: %v", err)
+	}
+
+	var ports []*pb.BridgePort
+	req := &pb.ListBridgePortsRequest{}
+	for {
+		resp, err := d.client.ListBridgePorts(context.TODO(), req)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, resp.BridgePorts...)
+		if resp.NextPageToken == "" {
+			return ports, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
 func NewHostSideManager(vsp plugin.VendorPlugin, opts ...func(*HostSideManager)) (*HostSideManager, error) {
 	h := &HostSideManager{
 		vsp:           vsp,
@@ -94,6 +163,8 @@ func NewHostSideManager(vsp plugin.VendorPlugin, opts ...func(*HostSideManager))
 		sm:            sriov.NewSriovManager(),
 		pathManager:   *utils.NewPathManager("/"),
 		stopRequested: false,
+		vlanPolicy:    vlanPolicyFromEnv(),
+		bridgePorts:   make(map[string]hostBridgePort),
 	}
 
 	for _, opt := range opts {
@@ -125,16 +196,116 @@ func WithClient(client *rest.Config) func(*HostSideManager) {
 	}
 }
 
+func WithVlanPolicy(policy configv1.VlanPolicy) func(*HostSideManager) {
+	return func(d *HostSideManager) {
+		d.vlanPolicy = policy
+	}
+}
+
+// vlanPolicyFromEnv reads the VLAN policy of the DpuOperatorConfig, which the
+// operator passes to the daemon through its environment.
+func vlanPolicyFromEnv() configv1.VlanPolicy {
+	policy := configv1.VlanPolicy{
+		Mode:         configv1.VlanDefaultMode(os.Getenv("DPU_VLAN_MODE")),
+		DefaultProto: os.Getenv("DPU_VLAN_PROTO"),
+	}
+	policy.DefaultVlan, _ = strconv.Atoi(os.Getenv("DPU_VLAN_ID"))
+	policy.DefaultQoS, _ = strconv.Atoi(os.Getenv("DPU_VLAN_QOS"))
+	return policy
+}
+
+// networkVlan holds the VLAN settings requested by a network configuration,
+// nil when not set.
+type networkVlan struct {
+	id    *int
+	qos   *int
+	proto *string
+}
+
+func networkVlanOf(conf *cnitypes.NetConf) networkVlan {
+	return networkVlan{id: conf.Vlan, qos: conf.VlanQoS, proto: conf.VlanProto}
+}
+
+// bridgePortVlan returns the VLAN of the bridge port of a VF. Settings of the
+// network configuration take precedence over the VLAN policy. A VLAN ID of 0
+// (untagged) cannot be used for a bridge port, so it falls back to the policy.
+func (d *HostSideManager) bridgePortVlan(requested networkVlan, vf int) (plugin.BridgePortVlan, error) {
+	vlan := plugin.BridgePortVlan{
+		ID:    vf + configv1.VlanPerVFOffset,
+		QoS:   d.vlanPolicy.DefaultQoS,
+		Proto: d.vlanPolicy.DefaultProto,
+	}
+	if d.vlanPolicy.Mode == configv1.VlanDefaultFixed {
+		vlan.ID = d.vlanPolicy.DefaultVlan
+	}
+	if vlan.Proto == "" {
+		vlan.Proto = configv1.VlanProto8021Q
+	}
+
+	if requested.id != nil && *requested.id != 0 {
+		vlan.ID = *requested.id
+	}
+	if requested.qos != nil {
+		vlan.QoS = *requested.qos
+	}
+	if requested.proto != nil {
+		vlan.Proto = strings.ToLower(*requested.proto)
+	}
+
+	if err := vlan.Validate(); err != nil {
+		return vlan, fmt.Errorf("invalid vlan configuration for VF %d: %v", vf, err)
+	}
+	return vlan, nil
+}
+
 func (d *HostSideManager) StartVsp(ctx context.Context) error {
-	addr, port, err := d.vsp.Start(ctx)
+	addr, port, err := startVsp(ctx, d.vsp)
 	if err != nil {
-		return fmt.Errorf("failed calling VSP Start() from HostSideManager: %v", err)
+		return fmt.Errorf("failed calling VSP Start() from HostSideManager: %w", err)
 	}
 	d.addr = addr
 	d.port = port
 	return nil
 }
 
+// Reinitialize initializes the VSP and sets the devices up again once the DPU
+// is back from a reset. The DPU daemon is dialed again if the VSP reports
+// another address for it.
+func (d *HostSideManager) Reinitialize(ctx context.Context) error {
+	addr, port, err := d.vsp.Start(ctx)
+	if err != nil && !errors.Is(err, plugin.ErrVspAlreadyInitialized) {
+		return fmt.Errorf("failed calling VSP Start() from HostSideManager: %v", err)
+	}
+	if err == nil && (addr != d.addr || port != d.port) {
+		d.clientMutex.Lock()
+		if d.conn != nil {
+			d.conn.Close()
+			d.conn = nil
+		}
+		d.addr = addr
+		d.port = port
+		d.clientMutex.Unlock()
+		d.log.Info("VSP reported a new address for the DPU daemon", "addr", addr, "port", port)
+	}
+	return d.SetupDevices()
+}
+
+// SetDevicesHealthy reports the devices of the device plugin unhealthy to
+// the kubelet while the DPU resets.
+func (d *HostSideManager) SetDevicesHealthy(healthy bool) {
+	var err error
+	if !healthy {
+		err = errors.New("the DPU is resetting")
+	}
+	d.dp.SetHealth(deviceplugin.HealthSourceReboot, err)
+}
+
+// SetDeviceHealth folds the health of the DPU reported by source into the
+// health of the devices of the device plugin.
+func (d *HostSideManager) SetDeviceHealth(source string, err error) {
+	d.dp.SetHealth(source, err)
+}
+
 func (d *HostSideManager) SetupDevices() error {
 	err := d.dp.SetupDevices()
 	if err != nil {
@@ -185,22 +356,30 @@ func (d *HostSideManager) connectWithRetry() error {
 
 func (d *HostSideManager) cniCmdAddHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
 	d.log.Info("addHandler")
+	// CmdAdd fills in defaults for the VLAN settings, so keep track of what
+	// the network configuration actually requested.
+	requested := networkVlanOf(req.CNIConf)
 	res, err := d.sm.CmdAdd(req)
 	if err != nil {
 		return nil, fmt.Errorf("SRIOV manager failed in add handler: %v", err)
 	}
 	d.log.Info("addHandler d.sm.CmdAdd succeeded")
-	pf := 0
-	vf := req.CNIConf.VFID
+	fn, err := lookupHostFunction(req.CNIConf)
+	if err != nil {
+		return nil, err
+	}
 	mac := req.CNIConf.OrigVfState.EffectiveMAC
 	d.log.Info("addHandler", "CNIConf", req.CNIConf)
-	// TODO: fix setting Vlan based on network definition in CR
-	vlan := 2 // *req.CNIConf.Vlan
-	d.log.Info("addHandler", "pf", pf, "vf", vf, "mac", mac, "vlan", vlan)
-	_, err = d.CreateBridgePort(pf, vf, vlan, mac)
+	vlan, err := d.bridgePortVlan(requested, fn.vf)
+	if err != nil {
+		return nil, err
+	}
+	d.log.Info("addHandler", "pf", fn.pf, "pfName", fn.pfName, "vf", fn.vf, "mac", mac, "vlan", vlan.ID, "vlanQoS", vlan.QoS, "vlanProto", vlan.Proto)
+	_, err = d.CreateBridgePort(fn.pf, fn.vf, vlan, mac)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to call CreateBridgePort: %v", err)
 	}
+	d.setBridgePort(bridgePortName(fn.pf, fn.vf), &hostBridgePort{function: fn, mac: mac})
 	d.log.Info("addHandler CreateBridgePort succeeded")
 
 	return res, nil
@@ -212,17 +391,323 @@ func (d *HostSideManager) cniCmdDelHandler(req *cnitypes.PodRequest) (*cni100.Re
 		return nil, errors.New("SRIOV manager failed in del handler")
 	}
 	if vfReleased {
-		pf := 0
-		vf := req.CNIConf.VFID
+		// The VF is released, so DEL succeeds even if its bridge port cannot
+		// be deleted. A bridge port left behind is deleted by the next GC.
 		mac := req.CNIConf.OrigVfState.EffectiveMAC
-		// TODO: fix setting Vlan based on network definition in CR
-		vlan := 2 // *req.CNIConf.Vlan
-		d.log.Info("cniCmdDelHandler", "pf", pf, "vf", vf, "mac", mac, "vlan", vlan)
-		d.DeleteBridgePort(pf, vf, vlan, mac)
+		fn, err := lookupHostFunction(req.CNIConf)
+		if errors.Is(err, errNoDeviceID) {
+			d.log.Info("cniCmdDelHandler has no device ID, no bridge port to delete")
+			return nil, nil
+		}
+		if err != nil {
+			// The VF may be gone already, such as after a VF count change.
+			known, ok := d.recordedHostFunction(req.CNIConf, mac)
+			if !ok {
+				d.log.Error(err, "cniCmdDelHandler cannot find the host function of the released VF, leaving its bridge port to GC")
+				return nil, nil
+			}
+			fn = known
+		}
+		vlan, err := d.bridgePortVlan(networkVlanOf(req.CNIConf), fn.vf)
+		if err != nil {
+			d.log.Error(err, "cniCmdDelHandler using an invalid vlan configuration", "vf", fn.vf)
+		}
+		d.log.Info("cniCmdDelHandler", "pf", fn.pf, "pfName", fn.pfName, "vf", fn.vf, "mac", mac, "vlan", vlan.ID)
+		d.DeleteBridgePort(fn.pf, fn.vf, vlan, mac)
+		d.setBridgePort(bridgePortName(fn.pf, fn.vf), nil)
+	}
+	return nil, nil
+}
+
+func (d *HostSideManager) cniCmdCheckHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	requested := networkVlanOf(req.CNIConf)
+	netConf, err := d.sm.CmdCheck(req)
+	if err != nil {
+		return nil, fmt.Errorf("SRIOV manager failed in check handler: %v", err)
+	}
+	fn, err := lookupHostFunction(netConf)
+	if err != nil {
+		return nil, err
+	}
+	vlan, err := d.bridgePortVlan(requested, fn.vf)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.checkBridgePort(fn, vlan, netConf.OrigVfState.EffectiveMAC); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// checkBridgePort verifies that the bridge port of a VF still exists on the
+// DPU with the expected MAC address and VLAN. Fields the VSP does not report
+// are not checked, and VSPs that cannot look up bridge ports are trusted.
+func (d *HostSideManager) checkBridgePort(fn hostFunction, vlan plugin.BridgePortVlan, mac string) error {
+	name := bridgePortName(fn.pf, fn.vf)
+	port, err := d.GetBridgePort(fn.pf, fn.vf)
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unimplemented:
+		d.log.Info("checkBridgePort: VSP cannot look up bridge ports, skipping", "name", name)
+		return nil
+	case codes.NotFound:
+		return fmt.Errorf("bridge port %s of VF %d on PF %d does not exist anymore", name, fn.vf, fn.pf)
+	default:
+		return fmt.Errorf("failed to get bridge port %s: %v", name, err)
+	}
+
+	if got := port.GetSpec().GetMacAddress(); len(got) > 0 && !strings.EqualFold(net.HardwareAddr(got).String(), mac) {
+		return fmt.Errorf("bridge port %s has MAC address %s, expected %s", name, net.HardwareAddr(got), mac)
+	}
+	if got := port.GetSpec().GetLogicalBridges(); len(got) > 0 && (len(got) != 1 || got[0] != vlan.LogicalBridge()) {
+		return fmt.Errorf("bridge port %s is on logical bridges %v, expected vlan %d", name, got, vlan.ID)
+	}
+	return nil
+}
+
+func (d *HostSideManager) cniCmdGCHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	var errs []error
+	// Bridge ports of the VFs that were released still need to be deleted,
+	// even if releasing others failed.
+	released, err := d.sm.CmdGC(req)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("SRIOV manager failed in GC handler: %v", err))
+	}
+	for _, vfPci := range released {
+		fn, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: vfPci, DeviceIDType: cnitypes.DeviceIDTypePCI})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		d.log.Info("cniCmdGCHandler deleting bridge port of released VF", "pf", fn.pf, "vf", fn.vf, "vfPci", vfPci)
+		err = d.DeleteBridgePort(fn.pf, fn.vf, plugin.BridgePortVlan{}, "")
+		if err != nil && status.Code(err) != codes.NotFound {
+			errs = append(errs, fmt.Errorf("failed to delete bridge port %s: %v", bridgePortName(fn.pf, fn.vf), err))
+			continue
+		}
+		d.setBridgePort(bridgePortName(fn.pf, fn.vf), nil)
+	}
+
+	if err := d.deleteOrphanedBridgePorts(); err != nil {
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// deleteOrphanedBridgePorts deletes the bridge ports of host VFs that are not
+// allocated to a pod anymore, such as the ones left behind on the DPU when the
+// node crashed.
+func (d *HostSideManager) deleteOrphanedBridgePorts() error {
+	allocated, err := d.sm.AllocatedVFs()
+	if err != nil {
+		return fmt.Errorf("failed to list allocated VFs: %v", err)
+	}
+
+	inUse := make(map[string]bool)
+	for _, vfPci := range allocated {
+		fn, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: vfPci, DeviceIDType: cnitypes.DeviceIDTypePCI})
+		if err != nil {
+			// The bridge port of this VF cannot be told apart from an
+			// orphaned one, so don't delete anything.
+			return fmt.Errorf("failed to look up allocated VF %s: %v", vfPci, err)
+		}
+		inUse[bridgePortName(fn.pf, fn.vf)] = true
+	}
+	d.bridgePortsMutex.RLock()
+	for name := range d.bridgePorts {
+		inUse[name] = true
+	}
+	d.bridgePortsMutex.RUnlock()
+
+	ports, err := d.listBridgePorts()
+	if status.Code(err) == codes.Unimplemented {
+		d.log.Info("deleteOrphanedBridgePorts: VSP cannot list bridge ports, skipping")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list bridge ports: %v", err)
+	}
+
+	var errs []error
+	for _, port := range ports {
+		pf, vf, ok := parseBridgePortName(port.Name)
+		if !ok || inUse[port.Name] {
+			continue
+		}
+		d.log.Info("deleteOrphanedBridgePorts deleting bridge port", "name", port.Name, "pf", pf, "vf", vf)
+		err := d.DeleteBridgePort(pf, vf, plugin.BridgePortVlan{}, "")
+		if err != nil && status.Code(err) != codes.NotFound {
+			errs = append(errs, fmt.Errorf("failed to delete orphaned bridge port %s: %v", port.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cniCmdStatusHandler reports the plugin as not available while the DPU
+// daemon does not answer pings, since bridge ports cannot be created then.
+func (d *HostSideManager) cniCmdStatusHandler(req *cnitypes.PodRequest) (*cni100.Result, error) {
+	if !d.CheckPing() {
+		return nil, errors.New("DPU daemon is not reachable")
 	}
 	return nil, nil
 }
 
+// errNoDeviceID is returned for a network configuration without the device
+// ID of its VF, as the PF of the VF cannot be told then.
+var errNoDeviceID = errors.New("no device ID in the network configuration, the VF must be allocated by the device plugin")
+
+// lookupHostFunction derives the PF of the VF allocated to a pod from the
+// PCI parent of the VF.
+func lookupHostFunction(conf *cnitypes.NetConf) (hostFunction, error) {
+	if conf.DeviceID == "" {
+		return hostFunction{}, errNoDeviceID
+	}
+
+	vfPci := conf.DeviceID
+	if conf.DeviceIDType == cnitypes.DeviceIDTypeNetdev {
+		pci, err := sriovutils.GetPciFromNetDev(conf.DeviceID)
+		if err != nil {
+			return hostFunction{}, fmt.Errorf("failed to resolve PCI address of VF %s: %v", conf.DeviceID, err)
+		}
+		vfPci = pci
+	}
+
+	pfName, err := sriovutils.GetPfName(vfPci)
+	if err != nil {
+		return hostFunction{}, fmt.Errorf("failed to get PF of VF %s: %v", vfPci, err)
+	}
+	vf, err := sriovutils.GetVfid(vfPci, pfName)
+	if err != nil {
+		return hostFunction{}, fmt.Errorf("failed to get VF ID of VF %s: %v", vfPci, err)
+	}
+	pfPci, err := sriovutils.GetPciFromNetDev(pfName)
+	if err != nil {
+		return hostFunction{}, fmt.Errorf("failed to get PCI address of PF %s: %v", pfName, err)
+	}
+	pf, err := sriovutils.GetPciFunction(pfPci)
+	if err != nil {
+		return hostFunction{}, fmt.Errorf("failed to get index of PF %s: %v", pfName, err)
+	}
+
+	return hostFunction{pf: pf, pfName: pfName, pfPci: pfPci, vf: vf, vfPci: vfPci}, nil
+}
+
+// recordedHostFunction returns the host function of the bridge port recorded
+// for a VF, found by the PCI address of the VF or its MAC address. It is used
+// once the VF cannot be looked up anymore.
+func (d *HostSideManager) recordedHostFunction(conf *cnitypes.NetConf, mac string) (hostFunction, bool) {
+	vfPci := ""
+	if conf.DeviceIDType != cnitypes.DeviceIDTypeNetdev {
+		vfPci = conf.DeviceID
+	}
+	d.bridgePortsMutex.RLock()
+	defer d.bridgePortsMutex.RUnlock()
+	for _, port := range d.bridgePorts {
+		if (vfPci != "" && port.function.vfPci == vfPci) || (mac != "" && strings.EqualFold(port.mac, mac)) {
+			return port.function, true
+		}
+	}
+	return hostFunction{}, false
+}
+
+// setBridgePort records a bridge port created for a host VF. A nil port
+// forgets it.
+func (d *HostSideManager) setBridgePort(name string, port *hostBridgePort) {
+	d.bridgePortsMutex.Lock()
+	defer d.bridgePortsMutex.Unlock()
+	if port == nil {
+		delete(d.bridgePorts, name)
+		return
+	}
+	d.bridgePorts[name] = *port
+}
+
+// restoreBridgePorts records again the bridge ports of the host VFs allocated
+// to pods, as the records only live in memory and are lost when the daemon
+// restarts. The bridge ports are listed from the DPU and matched by name with
+// the VFs the SR-IOV manager still has allocated. Bridge ports already
+// recorded are kept, and the ones of other VFs are left to GC.
+func (d *HostSideManager) restoreBridgePorts() error {
+	allocated, err := d.sm.AllocatedVFs()
+	if err != nil {
+		return fmt.Errorf("failed to list allocated VFs: %v", err)
+	}
+	if len(allocated) == 0 {
+		return nil
+	}
+
+	ports, err := d.listBridgePorts()
+	if status.Code(err) == codes.Unimplemented {
+		d.log.Info("restoreBridgePorts: VSP cannot list bridge ports, skipping")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list bridge ports: %v", err)
+	}
+	macs := make(map[string]string, len(ports))
+	for _, port := range ports {
+		mac := ""
+		if hw := port.GetSpec().GetMacAddress(); len(hw) > 0 {
+			mac = net.HardwareAddr(hw).String()
+		}
+		macs[port.GetName()] = mac
+	}
+
+	d.bridgePortsMutex.Lock()
+	defer d.bridgePortsMutex.Unlock()
+	for _, vfPci := range allocated {
+		fn, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: vfPci, DeviceIDType: cnitypes.DeviceIDTypePCI})
+		if err != nil {
+			d.log.Error(err, "restoreBridgePorts cannot look up allocated VF", "vfPci", vfPci)
+			continue
+		}
+		name := bridgePortName(fn.pf, fn.vf)
+		mac, ok := macs[name]
+		if _, recorded := d.bridgePorts[name]; !ok || recorded {
+			continue
+		}
+		d.log.Info("restoreBridgePorts recording bridge port", "name", name, "pf", fn.pf, "vf", fn.vf, "vfPci", vfPci)
+		d.bridgePorts[name] = hostBridgePort{function: fn, mac: mac}
+	}
+	return nil
+}
+
+// PhysicalFunctions returns the host PF to VF mapping of the bridge ports
+// created by this manager, sorted by PF index and VF ID.
+func (d *HostSideManager) PhysicalFunctions() []configv1.PhysicalFunctionStatus {
+	d.bridgePortsMutex.RLock()
+	defer d.bridgePortsMutex.RUnlock()
+
+	byIndex := make(map[int]*configv1.PhysicalFunctionStatus)
+	for name, port := range d.bridgePorts {
+		pf, ok := byIndex[port.function.pf]
+		if !ok {
+			pf = &configv1.PhysicalFunctionStatus{
+				Index:      port.function.pf,
+				Name:       port.function.pfName,
+				PCIAddress: port.function.pfPci,
+			}
+			byIndex[port.function.pf] = pf
+		}
+		pf.VirtualFunctions = append(pf.VirtualFunctions, configv1.VirtualFunctionStatus{
+			ID:         port.function.vf,
+			PCIAddress: port.function.vfPci,
+			BridgePort: name,
+			MACAddress: port.mac,
+		})
+	}
+
+	var pfs []configv1.PhysicalFunctionStatus
+	for _, pf := range byIndex {
+		sort.Slice(pf.VirtualFunctions, func(i, j int) bool {
+			return pf.VirtualFunctions[i].ID < pf.VirtualFunctions[j].ID
+		})
+		pfs = append(pfs, *pf)
+	}
+	sort.Slice(pfs, func(i, j int) bool { return pfs[i].Index < pfs[j].Index })
+	return pfs
+}
+
 func (d *HostSideManager) setPing(ping time.Time) {
 	d.pingMutex.Lock()
 	d.lastSuccessfulPing = ping
@@ -235,6 +720,17 @@ func (d *HostSideManager) getPing() time.Time {
 	return d.lastSuccessfulPing
 }
 
+// LastPing returns when the last ping to the DPU succeeded.
+func (d *HostSideManager) LastPing() time.Time {
+	return d.getPing()
+}
+
+// DevicePluginRegistered returns true while the device plugin is registered
+// with the kubelet.
+func (d *HostSideManager) DevicePluginRegistered() bool {
+	return d.dp != nil && d.dp.Registered()
+}
+
 func (d *HostSideManager) Ping() bool {
 	err := d.connectWithRetry()
 	if err != nil {
@@ -273,10 +769,18 @@ func (d *HostSideManager) StartPing(ctx context.Context) error {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
+	// The bridge ports are restored once the DPU daemon answers.
+	restored := false
 	for {
 		select {
 		case <-ticker.C:
-			d.Ping()
+			if d.Ping() && !restored {
+				if err := d.restoreBridgePorts(); err != nil {
+					d.log.Error(err, "Failed to restore the bridge ports of the host VFs")
+				} else {
+					restored = true
+				}
+			}
 		case <-ctx.Done():
 			d.log.Info("Stopped ping client")
 			return ctx.Err()
@@ -302,7 +806,13 @@ func (d *HostSideManager) Listen() (net.Listener, error) {
 	d.startedWg.Add(1)
 	d.log.Info("Starting HostDaemon", "devflag", d.dev, "cniServerPath", d.pathManager.CNIServerPath())
 
-	d.setupReconcilers()
+	// The VLAN policy of the node only changes with the daemon environment.
+	if _, err := d.bridgePortVlan(networkVlan{}, 0); err != nil {
+		return nil, unrecoverable(fmt.Errorf("invalid VLAN policy: %v", err))
+	}
+	if err := d.setupReconcilers(); err != nil {
+		return nil, err
+	}
 
 	add := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
 		return d.cniCmdAddHandler(r)
@@ -311,7 +821,21 @@ func (d *HostSideManager) Listen() (net.Listener, error) {
 		return d.cniCmdDelHandler(r)
 	}
 
-	d.cniserver = cniserver.NewCNIServer(add, del, cniserver.WithPathManager(d.pathManager))
+	check := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdCheckHandler(r)
+	}
+	gc := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdGCHandler(r)
+	}
+	cniStatus := func(r *cnitypes.PodRequest) (*cni100.Result, error) {
+		return d.cniCmdStatusHandler(r)
+	}
+
+	d.cniserver = cniserver.NewCNIServer(add, del,
+		cniserver.WithPathManager(d.pathManager),
+		cniserver.WithCheckHandler(check),
+		cniserver.WithGCHandler(gc),
+		cniserver.WithStatusHandler(cniStatus))
 	d.dpListener, err = d.dp.Listen()
 	if err != nil {
 		return nil, fmt.Errorf("HostSideManager Failed to Listen while calling device plugin listen: %v", err)
@@ -335,6 +859,10 @@ func (d *HostSideManager) Serve(ctx context.Context, listener net.Listener) erro
 	var wg sync.WaitGroup
 	var err error
 	done := make(chan error, 4)
+	// Shut all the servers down when one of them stops, so that Serve
+	// returns and the side manager can be restarted.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	// Context for graceful shutdown
 	go func() {
@@ -397,6 +925,7 @@ func (d *HostSideManager) Serve(ctx context.Context, listener net.Listener) erro
 	// are forced to exit, or context cancellation
 	select {
 	case err = <-done:
+		cancel()
 		wg.Wait()
 		d.startedWg.Done()
 		if d.stopRequested {
@@ -414,7 +943,7 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
-func (d *HostSideManager) setupReconcilers() {
+func (d *HostSideManager) setupReconcilers() error {
 	d.log.Info("HostSideManager.setupReconcilers()")
 	if d.manager == nil {
 		mgr, err := ctrl.NewManager(d.config, ctrl.Options{
@@ -428,13 +957,16 @@ func (d *HostSideManager) setupReconcilers() {
 		})
 		if err != nil {
 			d.log.Error(err, "unable to start manager")
+			return unrecoverable(fmt.Errorf("failed to create controller manager: %v", err))
 		}
 
 		sfcReconciler := sfcreconciler.NewSfcReconciler(mgr.GetClient(), mgr.GetScheme())
 
 		if err = sfcReconciler.SetupWithManager(mgr); err != nil {
 			d.log.Error(err, "unable to create controller", "controller", "ServiceFunctionChain")
+			return unrecoverable(fmt.Errorf("failed to setup ServiceFunctionChain controller: %v", err))
 		}
 		d.manager = mgr
 	}
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 
 	g "github.com/onsi/ginkgo/v2"
 	"go.uber.org/zap/zapcore"
@@ -16,14 +17,21 @@ import (
 	"github.com/containernetworking/cni/pkg/skel"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ns"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cni"
 	"github.com/openshift/dpu-operator/dpu-cni/pkgs/cnitypes"
+	"github.com/openshift/dpu-operator/dpu-cni/pkgs/sriovutils"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
 	"github.com/openshift/dpu-operator/internal/testutils"
 	"github.com/openshift/dpu-operator/internal/utils"
 	opi "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	lifecyclev1alpha1 "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -55,7 +63,7 @@ func (v *DummyPlugin) Close() {
 
 }
 
-func (v *DummyPlugin) CreateBridgePort(createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
+func (v *DummyPlugin) CreateBridgePort(ctx context.Context, createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
 	return &opi.BridgePort{}, nil
 }
 
@@ -63,6 +71,14 @@ func (v *DummyPlugin) DeleteBridgePort(deleteRequest *opi.DeleteBridgePortReques
 	return nil
 }
 
+func (v *DummyPlugin) GetBridgePort(getRequest *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	return &opi.BridgePort{Name: getRequest.Name}, nil
+}
+
+func (v *DummyPlugin) ListBridgePorts(listRequest *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	return &opi.ListBridgePortsResponse{}, nil
+}
+
 func (g *DummyPlugin) CreateNetworkFunction(input string, output string) error {
 	return nil
 }
@@ -71,6 +87,14 @@ func (g *DummyPlugin) DeleteNetworkFunction(input string, output string) error {
 	return nil
 }
 
+func (g *DummyPlugin) ListNetworkFunctions() ([]*nfapi.NFRequest, error) {
+	return nil, nil
+}
+
+func (g *DummyPlugin) ChainsNetworkFunctions() (bool, error) {
+	return false, nil
+}
+
 type SriovManagerStub struct{}
 
 func (m SriovManagerStub) SetupVF(conf *cnitypes.NetConf, podifName string, netns ns.NetNS) error {
@@ -103,22 +127,63 @@ func (m SriovManagerStub) CmdDel(req *cnitypes.PodRequest) (bool, error) {
 	return false, nil
 }
 
+func (m SriovManagerStub) CmdCheck(req *cnitypes.PodRequest) (*cnitypes.NetConf, error) {
+	return req.CNIConf, nil
+}
+
+func (m SriovManagerStub) CmdGC(req *cnitypes.PodRequest) ([]string, error) {
+	return nil, nil
+}
+
+func (m SriovManagerStub) AllocatedVFs() ([]string, error) {
+	return nil, nil
+}
+
+// allocatedVFsStub is a SriovManagerStub with VFs allocated to pods.
+type allocatedVFsStub struct {
+	SriovManagerStub
+	allocated []string
+}
+
+func (m allocatedVFsStub) AllocatedVFs() ([]string, error) {
+	return m.allocated, nil
+}
+
 type DummyDpuDaemon struct {
 	pb.UnimplementedBridgePortServiceServer
 	server      *grpc.Server
 	bridgePorts int
+	ports       map[string]*pb.BridgePort
 }
 
 func (s *DummyDpuDaemon) CreateBridgePort(context context.Context, bpr *pb.CreateBridgePortRequest) (*pb.BridgePort, error) {
 	s.bridgePorts += 1
+	s.ports[bpr.BridgePort.Name] = bpr.BridgePort
 	return &pb.BridgePort{}, nil
 }
 
 func (s *DummyDpuDaemon) DeleteBridgePort(context context.Context, bpr *pb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
 	s.bridgePorts -= 1
+	delete(s.ports, bpr.Name)
 	return &emptypb.Empty{}, nil
 }
 
+func (s *DummyDpuDaemon) GetBridgePort(context context.Context, bpr *pb.GetBridgePortRequest) (*pb.BridgePort, error) {
+	port, ok := s.ports[bpr.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s not found", bpr.Name)
+	}
+	return port, nil
+}
+
+func (s *DummyDpuDaemon) ListBridgePorts(context context.Context, bpr *pb.ListBridgePortsRequest) (*pb.ListBridgePortsResponse, error) {
+	resp := &pb.ListBridgePortsResponse{}
+	for _, port := range s.ports {
+		resp.BridgePorts = append(resp.BridgePorts, port)
+	}
+	return resp, nil
+}
+
 func (d *DummyDpuDaemon) Listen() (net.Listener, error) {
 	addr := "127.0.0.1"
 	port := 50051
@@ -183,6 +248,18 @@ func cmdAdd(cniVersion string, serverSocketPath string) (*cnitypes.Response, str
 	return p.PostRequest(PrepArgs(cniVersion, cnitypes.CNIAdd))
 }
 
+func cmdCheck(cniVersion string, serverSocketPath string) error {
+	p := &cni.Plugin{SocketPath: serverSocketPath}
+	_, _, err := p.PostRequest(PrepArgs(cniVersion, cnitypes.CNICheck))
+	return err
+}
+
+func cmdGC(cniVersion string, serverSocketPath string) error {
+	p := &cni.Plugin{SocketPath: serverSocketPath}
+	_, _, err := p.PostRequest(PrepArgs(cniVersion, cnitypes.CNIGC))
+	return err
+}
+
 var _ = g.BeforeSuite(func() {
 	opts := zap.Options{
 		Development: true,
@@ -210,7 +287,7 @@ var _ = g.Describe("Host Daemon", func() {
 		client := testCluster.EnsureExists()
 		pathManager = utils.NewPathManager(testCluster.TempDirPath())
 		Expect(err).NotTo(HaveOccurred())
-		fakeDpuDaemon = &DummyDpuDaemon{}
+		fakeDpuDaemon = &DummyDpuDaemon{ports: make(map[string]*pb.BridgePort)}
 		dummyPluginHost := NewDummyPlugin()
 		m := SriovManagerStub{}
 		hostDaemon, err = NewHostSideManager(dummyPluginHost, WithPathManager2(pathManager), WithSriovManager(m), WithClient(client))
@@ -260,5 +337,212 @@ var _ = g.Describe("Host Daemon", func() {
 
 			Expect(fakeDpuDaemon.bridgePorts).To(Equal(1))
 		})
+
+		g.It("should check bridge ports and delete orphaned ones on GC", func() {
+			dpuListen, err := fakeDpuDaemon.Listen()
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				err = fakeDpuDaemon.Serve(ctx, dpuListen)
+				fakeDpuDaemonDone <- err
+			}()
+
+			hostListen, err := hostDaemon.Listen()
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				err := hostDaemon.Serve(ctx, hostListen)
+				hostDaemonDone <- err
+			}()
+
+			cniVersion := "1.1.0"
+			_, _, err = cmdAdd(cniVersion, pathManager.CNIServerPath())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cmdCheck(cniVersion, pathManager.CNIServerPath())).To(Succeed())
+
+			// A bridge port left behind by a crash is reclaimed, the one in use is kept.
+			fakeDpuDaemon.ports["host0-5"] = &pb.BridgePort{Name: "host0-5"}
+			Expect(cmdGC(cniVersion, pathManager.CNIServerPath())).To(Succeed())
+			Expect(fakeDpuDaemon.ports).To(HaveKey("host0-0"))
+			Expect(fakeDpuDaemon.ports).NotTo(HaveKey("host0-5"))
+
+			delete(fakeDpuDaemon.ports, "host0-0")
+			Expect(cmdCheck(cniVersion, pathManager.CNIServerPath())).NotTo(Succeed())
+		})
+	})
+})
+
+// fakeSriovSysfs lays out the sysfs entries of a PF with SR-IOV VFs.
+func fakeSriovSysfs(root string, pfName string, pfPci string, vfPcis []string) {
+	sriovutils.SysBusPci = filepath.Join(root, "bus/pci/devices")
+	sriovutils.NetDirectory = filepath.Join(root, "class/net")
+
+	pfDir := filepath.Join(sriovutils.SysBusPci, pfPci)
+	Expect(os.MkdirAll(pfDir, 0755)).To(Succeed())
+	Expect(os.WriteFile(filepath.Join(pfDir, "sriov_numvfs"), []byte(fmt.Sprintf("%d", len(vfPcis))), 0644)).To(Succeed())
+	Expect(os.MkdirAll(filepath.Join(sriovutils.NetDirectory, pfName), 0755)).To(Succeed())
+	Expect(os.Symlink(pfDir, filepath.Join(sriovutils.NetDirectory, pfName, "device"))).To(Succeed())
+
+	for i, vfPci := range vfPcis {
+		vfDir := filepath.Join(sriovutils.SysBusPci, vfPci)
+		Expect(os.MkdirAll(filepath.Join(vfDir, "physfn", "net", pfName), 0755)).To(Succeed())
+		Expect(os.Symlink(vfDir, filepath.Join(pfDir, fmt.Sprintf("virtfn%d", i)))).To(Succeed())
+	}
+}
+
+var _ = g.Describe("Host function lookup", func() {
+	var origSysBusPci, origNetDirectory string
+
+	g.BeforeEach(func() {
+		origSysBusPci = sriovutils.SysBusPci
+		origNetDirectory = sriovutils.NetDirectory
+		root := g.GinkgoT().TempDir()
+		fakeSriovSysfs(root, "ens1f0", "0000:3b:00.0", []string{"0000:3b:02.0", "0000:3b:02.1"})
+		fakeSriovSysfs(root, "ens1f1", "0000:3b:00.1", []string{"0000:3b:0a.0", "0000:3b:0a.1"})
+	})
+
+	g.AfterEach(func() {
+		sriovutils.SysBusPci = origSysBusPci
+		sriovutils.NetDirectory = origNetDirectory
+	})
+
+	g.It("derives the PF from the PCI parent of the VF", func() {
+		fn, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: "0000:3b:0a.1", DeviceIDType: cnitypes.DeviceIDTypePCI})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fn).To(Equal(hostFunction{pf: 1, pfName: "ens1f1", pfPci: "0000:3b:00.1", vf: 1, vfPci: "0000:3b:0a.1"}))
+	})
+
+	g.It("requires a device ID", func() {
+		_, err := lookupHostFunction(&cnitypes.NetConf{VFID: 3})
+		Expect(err).To(MatchError(errNoDeviceID))
+	})
+
+	g.It("finds the recorded host function of a VF that is gone", func() {
+		fn := hostFunction{pf: 1, pfName: "ens1f1", pfPci: "0000:3b:00.1", vf: 1, vfPci: "0000:3b:0a.1"}
+		h := &HostSideManager{bridgePorts: make(map[string]hostBridgePort)}
+		h.setBridgePort(bridgePortName(fn.pf, fn.vf), &hostBridgePort{function: fn, mac: "aa:bb:cc:dd:ee:01"})
+
+		_, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: "0000:3b:0a.3", DeviceIDType: cnitypes.DeviceIDTypePCI})
+		Expect(err).To(HaveOccurred())
+
+		found, ok := h.recordedHostFunction(&cnitypes.NetConf{DeviceID: "0000:3b:0a.1", DeviceIDType: cnitypes.DeviceIDTypePCI}, "")
+		Expect(ok).To(BeTrue())
+		Expect(found).To(Equal(fn))
+
+		found, ok = h.recordedHostFunction(&cnitypes.NetConf{DeviceID: "ens1f1v1", DeviceIDType: cnitypes.DeviceIDTypeNetdev}, "AA:BB:CC:DD:EE:01")
+		Expect(ok).To(BeTrue())
+		Expect(found).To(Equal(fn))
+
+		_, ok = h.recordedHostFunction(&cnitypes.NetConf{DeviceID: "0000:3b:0a.0", DeviceIDType: cnitypes.DeviceIDTypePCI}, "aa:bb:cc:dd:ee:02")
+		Expect(ok).To(BeFalse())
+	})
+
+	g.It("parses the names of host bridge ports", func() {
+		pf, vf, ok := parseBridgePortName(bridgePortName(1, 12))
+		Expect(ok).To(BeTrue())
+		Expect([]int{pf, vf}).To(Equal([]int{1, 12}))
+
+		for _, name := range []string{"host1", "host1-2-3", "nf-1-2", "host01-2"} {
+			_, _, ok := parseBridgePortName(name)
+			Expect(ok).To(BeFalse(), name)
+		}
+	})
+
+	g.It("reports the PF to VF mapping of the bridge ports", func() {
+		h := &HostSideManager{bridgePorts: make(map[string]hostBridgePort)}
+		for _, vfPci := range []string{"0000:3b:0a.1", "0000:3b:02.0", "0000:3b:0a.0"} {
+			fn, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: vfPci, DeviceIDType: cnitypes.DeviceIDTypePCI})
+			Expect(err).NotTo(HaveOccurred())
+			h.setBridgePort(bridgePortName(fn.pf, fn.vf), &hostBridgePort{function: fn})
+		}
+		h.setBridgePort("host1-0", nil)
+
+		Expect(h.PhysicalFunctions()).To(Equal([]configv1.PhysicalFunctionStatus{
+			{
+				Index: 0, Name: "ens1f0", PCIAddress: "0000:3b:00.0",
+				VirtualFunctions: []configv1.VirtualFunctionStatus{{ID: 0, PCIAddress: "0000:3b:02.0", BridgePort: "host0-0"}},
+			},
+			{
+				Index: 1, Name: "ens1f1", PCIAddress: "0000:3b:00.1",
+				VirtualFunctions: []configv1.VirtualFunctionStatus{{ID: 1, PCIAddress: "0000:3b:0a.1", BridgePort: "host1-1"}},
+			},
+		}))
+	})
+
+	g.It("restores the bridge ports of the allocated VFs from the DPU", func() {
+		mac := func(s string) []byte {
+			hw, err := net.ParseMAC(s)
+			Expect(err).NotTo(HaveOccurred())
+			return hw
+		}
+		dpuDaemon := &DummyDpuDaemon{ports: map[string]*pb.BridgePort{
+			"host1-1": {Name: "host1-1", Spec: &pb.BridgePortSpec{MacAddress: mac("aa:bb:cc:dd:ee:01")}},
+			"host0-0": {Name: "host0-0", Spec: &pb.BridgePortSpec{MacAddress: mac("aa:bb:cc:dd:ee:02")}},
+			"host0-1": {Name: "host0-1", Spec: &pb.BridgePortSpec{MacAddress: mac("aa:bb:cc:dd:ee:03")}},
+		}}
+		socket := filepath.Join(g.GinkgoT().TempDir(), "dpu-daemon.sock")
+		listener, err := net.Listen("unix", socket)
+		Expect(err).NotTo(HaveOccurred())
+		server := grpc.NewServer()
+		pb.RegisterBridgePortServiceServer(server, dpuDaemon)
+		go server.Serve(listener)
+		g.DeferCleanup(server.Stop)
+		conn, err := grpc.Dial("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).NotTo(HaveOccurred())
+		g.DeferCleanup(conn.Close)
+
+		h := &HostSideManager{
+			log:         ctrl.Log.WithName("HostDaemon"),
+			conn:        conn,
+			client:      pb.NewBridgePortServiceClient(conn),
+			sm:          allocatedVFsStub{allocated: []string{"0000:3b:0a.1", "0000:3b:0a.0", "0000:3b:02.1"}},
+			bridgePorts: make(map[string]hostBridgePort),
+		}
+		recorded, err := lookupHostFunction(&cnitypes.NetConf{DeviceID: "0000:3b:02.1", DeviceIDType: cnitypes.DeviceIDTypePCI})
+		Expect(err).NotTo(HaveOccurred())
+		h.setBridgePort("host0-1", &hostBridgePort{function: recorded, mac: "aa:bb:cc:dd:ee:04"})
+
+		Expect(h.restoreBridgePorts()).To(Succeed())
+		Expect(h.PhysicalFunctions()).To(Equal([]configv1.PhysicalFunctionStatus{
+			{
+				Index: 0, Name: "ens1f0", PCIAddress: "0000:3b:00.0",
+				VirtualFunctions: []configv1.VirtualFunctionStatus{
+					{ID: 1, PCIAddress: "0000:3b:02.1", BridgePort: "host0-1", MACAddress: "aa:bb:cc:dd:ee:04"},
+				},
+			},
+			{
+				Index: 1, Name: "ens1f1", PCIAddress: "0000:3b:00.1",
+				VirtualFunctions: []configv1.VirtualFunctionStatus{
+					{ID: 1, PCIAddress: "0000:3b:0a.1", BridgePort: "host1-1", MACAddress: "aa:bb:cc:dd:ee:01"},
+				},
+			},
+		}), "only the bridge ports of allocated VFs are restored, and recorded ones are kept")
+	})
+})
+
+var _ = g.Describe("Bridge port VLAN", func() {
+	g.It("applies the network VLAN over the VLAN policy", func() {
+		h := &HostSideManager{vlanPolicy: configv1.VlanPolicy{Mode: configv1.VlanDefaultFixed, DefaultVlan: 100}}
+		vlan, err := h.bridgePortVlan(networkVlan{}, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vlan).To(Equal(plugin.BridgePortVlan{ID: 100, Proto: configv1.VlanProto8021Q}))
+
+		id := 200
+		vlan, err = h.bridgePortVlan(networkVlan{id: &id}, 2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vlan.ID).To(Equal(200))
+	})
+
+	g.It("rejects the VLAN QoS and protocols bridge ports cannot apply", func() {
+		h := &HostSideManager{}
+		qos := 3
+		_, err := h.bridgePortVlan(networkVlan{qos: &qos}, 2)
+		Expect(err).To(MatchError(ContainSubstring("QoS 3 not supported")))
+
+		proto := "802.1AD"
+		_, err = h.bridgePortVlan(networkVlan{proto: &proto}, 2)
+		Expect(err).To(MatchError(ContainSubstring(`proto "802.1ad" not supported`)))
+
+		h.vlanPolicy.DefaultQoS = 5
+		_, err = h.bridgePortVlan(networkVlan{}, 2)
+		Expect(err).To(HaveOccurred())
 	})
 })
@@ -0,0 +1,205 @@
+package daemon
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkg/metrics"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// inventoryRefreshInterval is how often the hardware inventory of a DPU
+	// is read from its plugin. The inventory rarely changes, so this is kept
+	// well below the cadence of the main daemon loop.
+	inventoryRefreshInterval = 5 * time.Minute
+	// inventoryTimeout bounds a single inventory read.
+	inventoryTimeout = 30 * time.Second
+)
+
+// inventoryReader reads the inventory of a DPU. It is implemented by
+// plugin.GrpcPlugin.
+type inventoryReader interface {
+	GetInventory(ctx context.Context) (*pkgplugin.Device, *pkgplugin.InventoryResponse, error)
+}
+
+// inventoryRead reads the inventory of a DPU from its plugin in the
+// background, so that a slow plugin does not hold up the daemon loop.
+type inventoryRead struct {
+	startTime time.Time
+	done      chan struct{}
+	device    *pkgplugin.Device
+	inventory *pkgplugin.InventoryResponse
+	err       error
+}
+
+func startInventoryRead(ctx context.Context, reader inventoryReader, now time.Time) *inventoryRead {
+	read := &inventoryRead{startTime: now, done: make(chan struct{})}
+	go func() {
+		defer close(read.done)
+		inventoryCtx, cancel := context.WithTimeout(ctx, inventoryTimeout)
+		defer cancel()
+		read.device, read.inventory, read.err = reader.GetInventory(inventoryCtx)
+	}()
+	return read
+}
+
+// finished returns whether the read is done, without waiting for it.
+func (r *inventoryRead) finished() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// refreshInventories starts reading the inventory of the managed DPUs that
+// are due for a refresh, and writes the reads that finished into their DPU CR
+// status and the inventory metric. A failed read keeps the last known
+// inventory and is retried on the next refresh.
+func (d *Daemon) refreshInventories(ctx context.Context, now time.Time) {
+	for name, managed := range d.managedDpus {
+		if managed == nil || managed.Plugin == nil || managed.DpuCR == nil {
+			continue
+		}
+		if read := managed.InventoryRead; read != nil {
+			if !read.finished() {
+				continue
+			}
+			managed.InventoryRead = nil
+			d.collectInventory(name, managed, read)
+		}
+		if !inventoryRefreshDue(managed.InventoryRefreshed, now) {
+			continue
+		}
+		managed.InventoryRefreshed = now
+		managed.InventoryRead = startInventoryRead(ctx, managed.Plugin, now)
+	}
+}
+
+// collectInventory writes a finished read into the DPU CR status. An
+// inventory that did not change is kept as it is, along with when it was
+// last updated.
+func (d *Daemon) collectInventory(name string, managed *ManagedDpu, read *inventoryRead) {
+	if read.err != nil {
+		if !pkgplugin.IsNotImplemented(read.err) {
+			d.log.Info("Failed to read DPU inventory", "dpu", name, "error", read.err)
+		}
+		return
+	}
+
+	previous := managed.DpuCR.Status.Inventory
+	current := dpuInventory(read.device, read.inventory, metav1.NewTime(read.startTime).Rfc3339Copy())
+	if previous != nil && !inventoryChanged(previous, current) {
+		return
+	}
+	managed.DpuCR.Status.Inventory = current
+	recordInventoryMetrics(previous, current)
+}
+
+func inventoryRefreshDue(lastRefresh, now time.Time) bool {
+	return lastRefresh.IsZero() || now.Sub(lastRefresh) >= inventoryRefreshInterval
+}
+
+// dpuInventory converts the device and inventory reported by a plugin to the
+// DPU CR status. Empty lists are left nil so the inventory compares equal to
+// the one read back from the API server.
+func dpuInventory(device *pkgplugin.Device, inventory *pkgplugin.InventoryResponse, lastUpdated metav1.Time) *configv1.DpuInventory {
+	out := &configv1.DpuInventory{
+		LastUpdated:     lastUpdated,
+		DeviceID:        device.ID,
+		PCIAddress:      device.PCIAddress,
+		Vendor:          device.Vendor,
+		Model:           device.Model,
+		SerialNumber:    device.SerialNumber,
+		FirmwareVersion: device.FirmwareVersion,
+	}
+	if out.DeviceID == "" {
+		out.DeviceID = device.PCIAddress
+	}
+	if inventory == nil {
+		return out
+	}
+
+	if inventory.DeviceID != "" {
+		out.DeviceID = inventory.DeviceID
+	}
+	out.BIOSVersion = inventory.BIOSVersion
+	out.BMCVersion = inventory.BMCVersion
+	if inventory.Chassis != nil {
+		out.Chassis = &configv1.ChassisInfo{
+			Manufacturer: inventory.Chassis.Manufacturer,
+			Model:        inventory.Chassis.Model,
+			SerialNumber: inventory.Chassis.SerialNumber,
+		}
+	}
+	if inventory.CPU != nil {
+		out.CPU = &configv1.CPUInfo{
+			Model:        inventory.CPU.Model,
+			CoreCount:    int32(inventory.CPU.CoreCount),
+			ThreadCount:  int32(inventory.CPU.ThreadCount),
+			FrequencyMHz: int32(inventory.CPU.FrequencyMHz),
+		}
+	}
+	if inventory.Memory != nil {
+		out.Memory = &configv1.MemoryInfo{
+			TotalBytes: int64(inventory.Memory.TotalBytes),
+			Type:       inventory.Memory.Type,
+		}
+	}
+	for _, nic := range inventory.NetworkInterfaces {
+		out.NetworkInterfaces = append(out.NetworkInterfaces, configv1.NetworkInterfaceInfo{
+			Name:       nic.Name,
+			MACAddress: nic.MACAddress,
+			SpeedMbps:  int32(nic.SpeedMbps),
+			LinkUp:     nic.LinkUp,
+		})
+	}
+	for _, disk := range inventory.StorageDevices {
+		out.StorageDevices = append(out.StorageDevices, configv1.StorageDeviceInfo{
+			Name:          disk.Name,
+			Model:         disk.Model,
+			CapacityBytes: int64(disk.CapacityBytes),
+			Type:          disk.Type,
+		})
+	}
+	return out
+}
+
+// recordInventoryMetrics replaces the inventory series of the previous
+// inventory with the current one.
+func recordInventoryMetrics(previous, current *configv1.DpuInventory) {
+	if previous != nil {
+		metrics.DeleteDeviceInventory(previous.DeviceID)
+	}
+	if current != nil {
+		metrics.DeleteDeviceInventory(current.DeviceID)
+		metrics.RecordDeviceInventory(current.Vendor, current.Model, current.DeviceID,
+			current.FirmwareVersion, current.SerialNumber, current.PCIAddress)
+	}
+}
+
+// inventoryNeedsUpdate returns true if the inventory differs from the one in
+// the cluster. LastUpdated is compared with Equal since the copy read back from
+// the API server differs in location and monotonic clock reading.
+func inventoryNeedsUpdate(current, desired *configv1.DpuInventory) bool {
+	if current == nil || desired == nil {
+		return current != desired
+	}
+	if !current.LastUpdated.Equal(&desired.LastUpdated) {
+		return true
+	}
+	return inventoryChanged(current, desired)
+}
+
+// inventoryChanged returns whether the hardware described by two inventories
+// differs, whenever they were read.
+func inventoryChanged(previous, current *configv1.DpuInventory) bool {
+	previousCopy, currentCopy := *previous, *current
+	previousCopy.LastUpdated, currentCopy.LastUpdated = metav1.Time{}, metav1.Time{}
+	return !reflect.DeepEqual(previousCopy, currentCopy)
+}
@@ -0,0 +1,198 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// inventoryPlugin is a registry plugin reporting a single device.
+type inventoryPlugin struct {
+	inventory    *pkgplugin.InventoryResponse
+	inventoryErr error
+	// release holds GetInventory until it is closed, if set.
+	release chan struct{}
+}
+
+func (p *inventoryPlugin) Info() pkgplugin.PluginInfo {
+	return pkgplugin.PluginInfo{Name: "inventory", Vendor: "test"}
+}
+func (p *inventoryPlugin) Initialize(context.Context, pkgplugin.PluginConfig) error { return nil }
+func (p *inventoryPlugin) Shutdown(context.Context) error                           { return nil }
+func (p *inventoryPlugin) HealthCheck(context.Context) error                        { return nil }
+
+func (p *inventoryPlugin) DiscoverDevices(context.Context) ([]pkgplugin.Device, error) {
+	return []pkgplugin.Device{{
+		ID:              "bf3-0",
+		PCIAddress:      "0000:03:00.0",
+		Vendor:          "NVIDIA",
+		Model:           "BlueField-3",
+		SerialNumber:    "MT2328X00001",
+		FirmwareVersion: "32.39.1002",
+	}}, nil
+}
+
+func (p *inventoryPlugin) GetInventory(context.Context, string) (*pkgplugin.InventoryResponse, error) {
+	if p.release != nil {
+		<-p.release
+	}
+	return p.inventory, p.inventoryErr
+}
+
+func testInventoryResponse() *pkgplugin.InventoryResponse {
+	return &pkgplugin.InventoryResponse{
+		DeviceID:    "bf3-0",
+		BIOSVersion: "4.7.0",
+		Chassis:     &pkgplugin.ChassisInfo{Manufacturer: "NVIDIA", Model: "900-9D3B6", SerialNumber: "MT2328X00001"},
+		CPU:         &pkgplugin.CPUInfo{Model: "Cortex-A78AE", CoreCount: 16, ThreadCount: 16, FrequencyMHz: 2000},
+		Memory:      &pkgplugin.MemoryInfo{TotalBytes: 32 << 30, Type: "DDR5"},
+		NetworkInterfaces: []pkgplugin.NetworkInterface{
+			{Name: "p0", MACAddress: "b8:3f:d2:00:00:01", SpeedMbps: 200000, LinkUp: true},
+		},
+		StorageDevices: []pkgplugin.StorageDevice{
+			{Name: "nvme0n1", Model: "eMMC", CapacityBytes: 128 << 30, Type: "NVMe"},
+		},
+	}
+}
+
+func newInventoryDaemon(registryPlugin pkgplugin.Plugin) (*Daemon, *ManagedDpu) {
+	grpcPlugin, err := plugin.NewGrpcPlugin(false, "bf3-0", nil)
+	Expect(err).NotTo(HaveOccurred())
+	grpcPlugin.AttachRegistryPlugin(registryPlugin, pkgplugin.PluginConfig{})
+
+	managed := &ManagedDpu{
+		DpuCR:  &configv1.DataProcessingUnit{ObjectMeta: metav1.ObjectMeta{Name: "bf3-0"}},
+		Plugin: grpcPlugin,
+	}
+	return &Daemon{
+		log:         ctrl.Log.WithName("Daemon"),
+		managedDpus: map[string]*ManagedDpu{"bf3-0": managed},
+	}, managed
+}
+
+// refreshInventoriesNow starts the inventory reads that are due and collects
+// them once they finished.
+func refreshInventoriesNow(d *Daemon, now time.Time) {
+	d.refreshInventories(context.Background(), now)
+	for _, managed := range d.managedDpus {
+		if read := managed.InventoryRead; read != nil {
+			Eventually(read.finished).Should(BeTrue())
+		}
+	}
+	d.refreshInventories(context.Background(), now)
+}
+
+var _ = g.Describe("DPU inventory", func() {
+	var now time.Time
+
+	g.BeforeEach(func() {
+		now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	g.It("writes the device and its inventory into the DPU status", func() {
+		d, managed := newInventoryDaemon(&inventoryPlugin{inventory: testInventoryResponse()})
+
+		refreshInventoriesNow(d, now)
+
+		inventory := managed.DpuCR.Status.Inventory
+		Expect(inventory).NotTo(BeNil())
+		Expect(inventory.LastUpdated.Time).To(BeTemporally("==", now))
+		Expect(inventory.DeviceID).To(Equal("bf3-0"))
+		Expect(inventory.PCIAddress).To(Equal("0000:03:00.0"))
+		Expect(inventory.FirmwareVersion).To(Equal("32.39.1002"))
+		Expect(inventory.BIOSVersion).To(Equal("4.7.0"))
+		Expect(inventory.CPU.CoreCount).To(Equal(int32(16)))
+		Expect(inventory.Memory.TotalBytes).To(Equal(int64(32 << 30)))
+		Expect(inventory.NetworkInterfaces).To(ConsistOf(configv1.NetworkInterfaceInfo{
+			Name: "p0", MACAddress: "b8:3f:d2:00:00:01", SpeedMbps: 200000, LinkUp: true,
+		}))
+		Expect(inventory.StorageDevices).To(HaveLen(1))
+	})
+
+	g.It("reports the device when the plugin has no inventory", func() {
+		d, managed := newInventoryDaemon(&inventoryPlugin{inventoryErr: pkgplugin.ErrNotImplemented})
+
+		refreshInventoriesNow(d, now)
+
+		inventory := managed.DpuCR.Status.Inventory
+		Expect(inventory).NotTo(BeNil())
+		Expect(inventory.Model).To(Equal("BlueField-3"))
+		Expect(inventory.CPU).To(BeNil())
+		Expect(inventory.NetworkInterfaces).To(BeNil())
+	})
+
+	g.It("keeps the last inventory when a refresh fails", func() {
+		registryPlugin := &inventoryPlugin{inventory: testInventoryResponse()}
+		d, managed := newInventoryDaemon(registryPlugin)
+		refreshInventoriesNow(d, now)
+
+		registryPlugin.inventoryErr = pkgplugin.ErrConnectionFailed
+		refreshInventoriesNow(d, now.Add(inventoryRefreshInterval))
+
+		Expect(managed.DpuCR.Status.Inventory.LastUpdated.Time).To(BeTemporally("==", now))
+		Expect(managed.InventoryRefreshed).To(Equal(now.Add(inventoryRefreshInterval)))
+	})
+
+	g.It("keeps when the inventory was last updated while it does not change", func() {
+		registryPlugin := &inventoryPlugin{inventory: testInventoryResponse()}
+		d, managed := newInventoryDaemon(registryPlugin)
+		refreshInventoriesNow(d, now)
+		first := managed.DpuCR.Status.Inventory
+
+		refreshInventoriesNow(d, now.Add(inventoryRefreshInterval))
+		Expect(managed.DpuCR.Status.Inventory).To(BeIdenticalTo(first))
+
+		registryPlugin.inventory.BIOSVersion = "4.8.0"
+		refreshInventoriesNow(d, now.Add(2*inventoryRefreshInterval))
+		Expect(managed.DpuCR.Status.Inventory.BIOSVersion).To(Equal("4.8.0"))
+		Expect(managed.DpuCR.Status.Inventory.LastUpdated.Time).To(BeTemporally("==", now.Add(2*inventoryRefreshInterval)))
+	})
+
+	g.It("does not wait for a slow plugin", func() {
+		registryPlugin := &inventoryPlugin{inventory: testInventoryResponse(), release: make(chan struct{})}
+		d, managed := newInventoryDaemon(registryPlugin)
+
+		d.refreshInventories(context.Background(), now)
+		d.refreshInventories(context.Background(), now.Add(time.Second))
+		Expect(managed.InventoryRead).NotTo(BeNil())
+		Expect(managed.DpuCR.Status.Inventory).To(BeNil())
+
+		close(registryPlugin.release)
+		Eventually(managed.InventoryRead.finished).Should(BeTrue())
+		d.refreshInventories(context.Background(), now.Add(2*time.Second))
+		Expect(managed.InventoryRead).To(BeNil())
+		Expect(managed.DpuCR.Status.Inventory.LastUpdated.Time).To(BeTemporally("==", now))
+	})
+
+	g.It("only refreshes at the inventory interval", func() {
+		Expect(inventoryRefreshDue(time.Time{}, now)).To(BeTrue())
+		Expect(inventoryRefreshDue(now, now.Add(inventoryRefreshInterval-time.Second))).To(BeFalse())
+		Expect(inventoryRefreshDue(now, now.Add(inventoryRefreshInterval))).To(BeTrue())
+	})
+
+	g.It("compares equal to the inventory read back from the API server", func() {
+		device := &pkgplugin.Device{ID: "bf3-0", PCIAddress: "0000:03:00.0"}
+		desired := dpuInventory(device, &pkgplugin.InventoryResponse{}, metav1.NewTime(now).Rfc3339Copy())
+
+		raw, err := json.Marshal(desired)
+		Expect(err).NotTo(HaveOccurred())
+		current := &configv1.DpuInventory{}
+		Expect(json.Unmarshal(raw, current)).To(Succeed())
+
+		Expect(inventoryNeedsUpdate(current, desired)).To(BeFalse())
+		Expect(inventoryNeedsUpdate(nil, desired)).To(BeTrue())
+		Expect(inventoryNeedsUpdate(nil, nil)).To(BeFalse())
+
+		desired.FirmwareVersion = "32.40.1000"
+		Expect(inventoryNeedsUpdate(current, desired)).To(BeTrue())
+	})
+})
@@ -0,0 +1,225 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	pb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nfStateVersion is bumped whenever the layout of nfState changes in an
+// incompatible way. State files with another version are ignored.
+const nfStateVersion = 1
+
+// nfState is the network function wiring of the DPU daemon as persisted on disk.
+type nfState struct {
+	Version int `json:"version"`
+	// Pods holds the attached network function pods by "namespace/name".
+	Pods map[string]nfPodState `json:"pods,omitempty"`
+	// Chains holds the VSP network functions applied for each chain.
+	Chains map[string][]configv1.ServiceFunctionChainHop `json:"chains,omitempty"`
+}
+
+type nfPodState struct {
+	Netns string   `json:"netns"`
+	Ports []string `json:"ports"`
+}
+
+// loadNfState reads the state file at path. A missing file results in an
+// empty state.
+func loadNfState(path string) (*nfState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &nfState{Version: nfStateVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read network function state %s: %w", path, err)
+	}
+	state := &nfState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse network function state %s: %w", path, err)
+	}
+	if state.Version != nfStateVersion {
+		return nil, fmt.Errorf("network function state %s has version %d, expected %d", path, state.Version, nfStateVersion)
+	}
+	return state, nil
+}
+
+// saveNfState atomically replaces the state file at path.
+func saveNfState(path string, state *nfState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode network function state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory for network function state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write network function state %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace network function state %s: %w", path, err)
+	}
+	return nil
+}
+
+// saveState persists the network function wiring. It must be called with
+// chainMutex held.
+func (d *DpuSideManager) saveState() {
+	state := &nfState{
+		Version: nfStateVersion,
+		Pods:    make(map[string]nfPodState, len(d.macStore)),
+		Chains:  d.chains,
+	}
+	for key, macs := range d.macStore {
+		state.Pods[key] = nfPodState{Netns: d.podNetns[key], Ports: macs}
+	}
+	if err := saveNfState(d.pathManager.NetworkFunctionStatePath(), state); err != nil {
+		d.log.Error(err, "Failed to persist network function state")
+	}
+}
+
+// restoreState loads the network function wiring persisted by a previous run
+// of the daemon and reconciles it with the network functions the VSP lists.
+// Pods whose network namespace is gone are forgotten, and hops of ports that
+// are gone or that the VSP does not know anymore are dropped. Hops missing on
+// the VSP are created again and the network functions the VSP has that no
+// remaining hop accounts for are deleted. VSPs that cannot list their network
+// functions get every remaining hop re-applied instead.
+func (d *DpuSideManager) restoreState() {
+	d.wiringMutex.Lock()
+	defer d.wiringMutex.Unlock()
+
+	state, err := loadNfState(d.pathManager.NetworkFunctionStatePath())
+	if err != nil {
+		d.log.Error(err, "Ignoring persisted network function state")
+		return
+	}
+
+	live := d.vspPortMacs()
+	pods := make(map[string]nfPodState)
+	for key, pod := range state.Pods {
+		if _, err := os.Stat(pod.Netns); err != nil {
+			d.log.Info("Forgetting network function pod without network namespace", "pod", key, "netns", pod.Netns)
+			continue
+		}
+		pods[key] = pod
+		for _, port := range pod.Ports {
+			live[port] = true
+		}
+	}
+
+	existing, listed := d.vspNetworkFunctions()
+	chains := make(map[string][]configv1.ServiceFunctionChainHop)
+	wanted := make(map[string]bool)
+	for chain, hops := range state.Chains {
+		for _, hop := range hops {
+			key := hopKey(hop.Input, hop.Output)
+			_, exists := existing[key]
+			if !live[hop.Input] || !live[hop.Output] {
+				if listed && !exists {
+					continue
+				}
+				if d.deleteHop(chain, hop) != nil {
+					// Keeping the hop lets the next WireChain delete it again.
+					chains[chain] = append(chains[chain], hop)
+					wanted[key] = true
+				}
+				delete(existing, key)
+				continue
+			}
+			wanted[key] = true
+			if listed && exists {
+				chains[chain] = append(chains[chain], hop)
+				continue
+			}
+			d.log.Info("Re-applying chain hop", "chain", chain, "input", hop.Input, "output", hop.Output)
+			if err := d.vsp.CreateNetworkFunction(hop.Input, hop.Output); err != nil {
+				// Not recording the hop lets the next WireChain create it again.
+				d.log.Error(err, "Failed to re-apply chain hop", "chain", chain, "input", hop.Input, "output", hop.Output)
+				continue
+			}
+			chains[chain] = append(chains[chain], hop)
+		}
+	}
+
+	for key, nf := range existing {
+		if wanted[key] {
+			continue
+		}
+		d.log.Info("Deleting leaked network function", "input", nf.Input, "output", nf.Output)
+		if err := d.vsp.DeleteNetworkFunction(nf.Input, nf.Output); err != nil {
+			d.log.Error(err, "Failed to delete leaked network function", "input", nf.Input, "output", nf.Output)
+		}
+	}
+
+	d.chainMutex.Lock()
+	defer d.chainMutex.Unlock()
+	for key, pod := range pods {
+		d.macStore[key] = pod.Ports
+		d.podNetns[key] = pod.Netns
+	}
+	for chain, hops := range chains {
+		d.setChainHops(chain, hops)
+	}
+	d.log.Info("Restored network function state", "pods", len(d.macStore), "chains", len(d.chains))
+	d.saveState()
+}
+
+// hopKey identifies a network function by its ports, regardless of how the
+// VSP spells their MAC addresses.
+func hopKey(input, output string) string {
+	return strings.ToLower(input) + "->" + strings.ToLower(output)
+}
+
+// vspNetworkFunctions returns the network functions set up on the VSP by
+// hopKey. The second result is false if the VSP could not list them.
+func (d *DpuSideManager) vspNetworkFunctions() (map[string]configv1.ServiceFunctionChainHop, bool) {
+	nfs, err := d.vsp.ListNetworkFunctions()
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented || pkgplugin.IsNotImplemented(err) {
+			d.log.Info("VSP cannot list its network functions, re-applying all chain hops")
+		} else {
+			d.log.Error(err, "Failed to list VSP network functions, re-applying all chain hops")
+		}
+		return nil, false
+	}
+	existing := make(map[string]configv1.ServiceFunctionChainHop, len(nfs))
+	for _, nf := range nfs {
+		existing[hopKey(nf.GetInput(), nf.GetOutput())] = configv1.ServiceFunctionChainHop{Input: nf.GetInput(), Output: nf.GetOutput()}
+	}
+	return existing, true
+}
+
+// vspPortMacs returns the MAC addresses of the bridge ports known to the VSP.
+// VSPs that cannot list their bridge ports result in an empty set.
+func (d *DpuSideManager) vspPortMacs() map[string]bool {
+	macs := make(map[string]bool)
+	req := &pb.ListBridgePortsRequest{}
+	for {
+		resp, err := d.vsp.ListBridgePorts(req)
+		if err != nil {
+			d.log.Info("Unable to list VSP bridge ports", "error", err)
+			return macs
+		}
+		for _, port := range resp.GetBridgePorts() {
+			if mac := port.GetSpec().GetMacAddress(); len(mac) > 0 {
+				macs[net.HardwareAddr(mac).String()] = true
+			}
+		}
+		if resp.GetNextPageToken() == "" {
+			return macs
+		}
+		req = &pb.ListBridgePortsRequest{PageToken: resp.GetNextPageToken()}
+	}
+}
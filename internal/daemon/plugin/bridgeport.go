@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// The OPI BridgePortSpec has no field for the host function a port belongs
+// to, so it is sent along the CreateBridgePort call as gRPC metadata.
+const (
+	pfMetadataKey = "dpu-pf"
+	vfMetadataKey = "dpu-vf"
+)
+
+// BridgePortFunction identifies the host PF and VF a bridge port is created for.
+type BridgePortFunction struct {
+	PF int
+	VF int
+}
+
+// OutgoingContext attaches the host PF and VF to a CreateBridgePort call.
+func (f BridgePortFunction) OutgoingContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx,
+		pfMetadataKey, strconv.Itoa(f.PF),
+		vfMetadataKey, strconv.Itoa(f.VF))
+}
+
+// BridgePortFunctionFromContext returns the host PF and VF attached to an
+// incoming CreateBridgePort call. ok is false if the caller did not attach them.
+func BridgePortFunctionFromContext(ctx context.Context) (f BridgePortFunction, ok bool, err error) {
+	md, found := metadata.FromIncomingContext(ctx)
+	if !found || len(md.Get(pfMetadataKey)) == 0 || len(md.Get(vfMetadataKey)) == 0 {
+		return BridgePortFunction{}, false, nil
+	}
+	f.PF, err = strconv.Atoi(md.Get(pfMetadataKey)[0])
+	if err != nil || f.PF < 0 {
+		return BridgePortFunction{}, true, fmt.Errorf("pf %q invalid", md.Get(pfMetadataKey)[0])
+	}
+	f.VF, err = strconv.Atoi(md.Get(vfMetadataKey)[0])
+	if err != nil || f.VF < 0 {
+		return BridgePortFunction{}, true, fmt.Errorf("vf %q invalid", md.Get(vfMetadataKey)[0])
+	}
+	return f, true, nil
+}
+
+// BridgePortVlan is the VLAN configuration of a host-side bridge port.
+type BridgePortVlan struct {
+	ID    int
+	QoS   int
+	Proto string
+}
+
+// Validate rejects out-of-range VLAN configurations, and those bridge ports
+// cannot apply. The OPI BridgePortSpec carries the VLAN ID as the logical
+// bridge of the port but has no field for the VLAN QoS and protocol, so only
+// their defaults can be used.
+func (v BridgePortVlan) Validate() error {
+	if v.ID < 1 || v.ID > 4094 {
+		return fmt.Errorf("vlan id %d invalid: value must be in the range 1-4094", v.ID)
+	}
+	if v.QoS < 0 || v.QoS > 7 {
+		return fmt.Errorf("vlan QoS %d invalid: value must be in the range 0-7", v.QoS)
+	}
+	if v.Proto != configv1.VlanProto8021Q && v.Proto != configv1.VlanProto8021AD {
+		return fmt.Errorf("vlan proto %q invalid: value must be %s or %s", v.Proto, configv1.VlanProto8021Q, configv1.VlanProto8021AD)
+	}
+	if v.QoS != 0 {
+		return fmt.Errorf("vlan QoS %d not supported: bridge ports only support QoS 0", v.QoS)
+	}
+	if v.Proto != configv1.VlanProto8021Q {
+		return fmt.Errorf("vlan proto %q not supported: bridge ports only support %s", v.Proto, configv1.VlanProto8021Q)
+	}
+	return nil
+}
+
+// LogicalBridge returns the name of the OPI logical bridge of the VLAN.
+func (v BridgePortVlan) LogicalBridge() string {
+	return strconv.Itoa(v.ID)
+}
@@ -17,8 +17,10 @@ import (
 	opi "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	pb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -26,15 +28,29 @@ import (
 
 const ReadyConditionType = "Ready"
 
+// ErrVspAlreadyInitialized is returned by Start when the VSP was initialized
+// before, for instance by a previous daemon or before a DPU reset the VSP did
+// not notice.
+var ErrVspAlreadyInitialized = errors.New("VSP already initialized")
+
 type DpuIdentifier string
 
 type VendorPlugin interface {
+	// Start initializes the VSP and returns the address of the DPU daemon it
+	// reports. A VSP initialized before returns ErrVspAlreadyInitialized,
+	// together with the address it reported last if it is known.
 	Start(ctx context.Context) (string, int32, error)
 	Close()
-	CreateBridgePort(bpr *opi.CreateBridgePortRequest) (*opi.BridgePort, error)
+	CreateBridgePort(ctx context.Context, bpr *opi.CreateBridgePortRequest) (*opi.BridgePort, error)
 	DeleteBridgePort(bpr *opi.DeleteBridgePortRequest) error
+	GetBridgePort(bpr *opi.GetBridgePortRequest) (*opi.BridgePort, error)
+	ListBridgePorts(bpr *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error)
 	CreateNetworkFunction(input string, output string) error
 	DeleteNetworkFunction(input string, output string) error
+	ListNetworkFunctions() ([]*nfapi.NFRequest, error)
+	// ChainsNetworkFunctions reports whether the output of a network function
+	// can be wired to the input of another one through CreateNetworkFunction.
+	ChainsNetworkFunctions() (bool, error)
 	GetDevices() (*pb.DeviceListResponse, error)
 	SetNumVfs(vfCount int32) (*pb.VfCount, error)
 }
@@ -52,6 +68,10 @@ type GrpcPlugin struct {
 	pathManager   utils.PathManager
 	initialized   bool
 	initMutex     sync.RWMutex
+	// daemonAddr and daemonPort are the address of the DPU daemon the VSP
+	// reported on the last successful Init.
+	daemonAddr string
+	daemonPort int32
 
 	registryPlugin      pkgplugin.Plugin
 	registryConfig      pkgplugin.PluginConfig
@@ -87,10 +107,12 @@ func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 
 		ipPort, err := g.client.Init(ctx, &pb.InitRequest{DpuMode: g.dpuMode, DpuIdentifier: string(g.dpuIdentifier)})
 		if err != nil {
-			if strings.Contains(err.Error(), "already initialized") {
+			if vspAlreadyInitialized(err) {
 				// VSP was already initialized, mark as initialized and return the error
+				// with the address it reported before, if this plugin initialized it.
 				g.SetInitDone(true)
-				return "", 0, err
+				addr, port := g.daemonAddress()
+				return addr, port, fmt.Errorf("%w: %v", ErrVspAlreadyInitialized, err)
 			}
 			select {
 			case <-ctx.Done():
@@ -102,6 +124,7 @@ func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 
 		// Init succeeded, mark as initialized
 		g.SetInitDone(true)
+		g.setDaemonAddress(ipPort.Ip, ipPort.Port)
 
 		g.log.Info("GrpcPlugin Start() succeeded", "duration", time.Since(start), "ip", ipPort.Ip, "port", ipPort.Port, "dpuMode",
 			g.dpuMode, "dpuIdentifier", g.dpuIdentifier)
@@ -109,6 +132,19 @@ func (g *GrpcPlugin) Start(ctx context.Context) (string, int32, error) {
 	}
 }
 
+// vspAlreadyInitialized returns whether the VSP refused Init because it was
+// initialized before. VSPs answer AlreadyExists, older ones only say so in
+// the message of an unknown error.
+func vspAlreadyInitialized(err error) bool {
+	switch status.Code(err) {
+	case codes.AlreadyExists:
+		return true
+	case codes.Unknown:
+		return strings.Contains(status.Convert(err).Message(), "already initialized")
+	}
+	return false
+}
+
 func (g *GrpcPlugin) Close() {
 	if g.registryPlugin != nil {
 		g.registryInitMutex.Lock()
@@ -208,6 +244,45 @@ func (g *GrpcPlugin) ensureRegistryInitialized(ctx context.Context) bool {
 	return true
 }
 
+// RegistryEndpointConfigured reports whether an OPI endpoint is configured for
+// the attached registry plugin. Without one the plugin falls back to the
+// default endpoint, which the DPU may not serve.
+func (g *GrpcPlugin) RegistryEndpointConfigured() bool {
+	g.registryInitMutex.Lock()
+	defer g.registryInitMutex.Unlock()
+	return g.registryPlugin != nil && g.registryConfig.OPIEndpoint != ""
+}
+
+// RegistryEndpoint returns the OPI endpoint configured for the attached
+// registry plugin, or "" if there is none.
+func (g *GrpcPlugin) RegistryEndpoint() string {
+	g.registryInitMutex.Lock()
+	defer g.registryInitMutex.Unlock()
+	if g.registryPlugin == nil {
+		return ""
+	}
+	return g.registryConfig.OPIEndpoint
+}
+
+// RegistryHealthCheck checks the health of the attached registry plugin,
+// initializing it first if needed. It returns ErrPluginNotFound if no
+// registry plugin is attached.
+func (g *GrpcPlugin) RegistryHealthCheck(ctx context.Context) error {
+	if g.registryPlugin == nil {
+		return pkgplugin.ErrPluginNotFound
+	}
+	if !g.ensureRegistryInitialized(ctx) {
+		g.registryInitMutex.Lock()
+		err := g.registryInitErr
+		g.registryInitMutex.Unlock()
+		if err == nil {
+			err = pkgplugin.ErrNotInitialized
+		}
+		return fmt.Errorf("registry plugin %s is not initialized: %w", g.registryPlugin.Info().Name, err)
+	}
+	return g.registryPlugin.HealthCheck(ctx)
+}
+
 func (g *GrpcPlugin) registryNetworkPlugin() (pkgplugin.NetworkPlugin, bool) {
 	if g.registryPlugin == nil {
 		return nil, false
@@ -255,15 +330,24 @@ func (g *GrpcPlugin) ensureConnected() error {
 	return nil
 }
 
-func (g *GrpcPlugin) CreateBridgePort(createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
+func (g *GrpcPlugin) CreateBridgePort(ctx context.Context, createRequest *opi.CreateBridgePortRequest) (*opi.BridgePort, error) {
 	if createRequest == nil {
 		return nil, fmt.Errorf("CreateBridgePort request is nil")
 	}
 
+	function, hasFunction, err := BridgePortFunctionFromContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("CreateBridgePort got an invalid host function: %v", err)
+	}
+
 	if g.ensureRegistryInitialized(context.Background()) {
 		if networkPlugin, ok := g.registryNetworkPlugin(); ok {
 			bridgeReq := bridgePortRequestFromOPI(createRequest)
-			port, err := networkPlugin.CreateBridgePort(context.Background(), bridgeReq)
+			if hasFunction {
+				bridgeReq.PF = &function.PF
+				bridgeReq.VF = &function.VF
+			}
+			port, err := networkPlugin.CreateBridgePort(ctx, bridgeReq)
 			if err == nil {
 				return bridgePortToOPI(port, bridgeReq.Name), nil
 			}
@@ -275,11 +359,15 @@ func (g *GrpcPlugin) CreateBridgePort(createRequest *opi.CreateBridgePortRequest
 		}
 	}
 
-	err := g.ensureConnected()
+	err = g.ensureConnected()
 	if err != nil {
 		return nil, fmt.Errorf("CreateBridgePort failed to ensure GRPC connection: %v", err)
 	}
-	return g.opiClient.CreateBridgePort(context.TODO(), createRequest)
+	vspCtx := context.TODO()
+	if hasFunction {
+		vspCtx = function.OutgoingContext(vspCtx)
+	}
+	return g.opiClient.CreateBridgePort(vspCtx, createRequest)
 }
 
 func (g *GrpcPlugin) DeleteBridgePort(deleteRequest *opi.DeleteBridgePortRequest) error {
@@ -309,6 +397,62 @@ func (g *GrpcPlugin) DeleteBridgePort(deleteRequest *opi.DeleteBridgePortRequest
 	return err
 }
 
+func (g *GrpcPlugin) GetBridgePort(getRequest *opi.GetBridgePortRequest) (*opi.BridgePort, error) {
+	if getRequest == nil {
+		return nil, fmt.Errorf("GetBridgePort request is nil")
+	}
+
+	if g.ensureRegistryInitialized(context.Background()) {
+		if networkPlugin, ok := g.registryNetworkPlugin(); ok {
+			port, err := networkPlugin.GetBridgePort(context.Background(), getRequest.Name)
+			if err == nil {
+				return bridgePortToOPI(port, getRequest.Name), nil
+			}
+			if pkgplugin.IsNotImplemented(err) || pkgplugin.IsCapabilityNotSupported(err) {
+				g.log.Info("Registry plugin GetBridgePort not implemented; falling back to VSP", "error", err)
+			} else {
+				return nil, err
+			}
+		}
+	}
+
+	err := g.ensureConnected()
+	if err != nil {
+		return nil, fmt.Errorf("GetBridgePort failed to ensure GRPC connection: %v", err)
+	}
+	return g.opiClient.GetBridgePort(context.TODO(), getRequest)
+}
+
+func (g *GrpcPlugin) ListBridgePorts(listRequest *opi.ListBridgePortsRequest) (*opi.ListBridgePortsResponse, error) {
+	if listRequest == nil {
+		return nil, fmt.Errorf("ListBridgePorts request is nil")
+	}
+
+	if g.ensureRegistryInitialized(context.Background()) {
+		if networkPlugin, ok := g.registryNetworkPlugin(); ok {
+			ports, err := networkPlugin.ListBridgePorts(context.Background())
+			if err == nil {
+				resp := &opi.ListBridgePortsResponse{}
+				for _, port := range ports {
+					resp.BridgePorts = append(resp.BridgePorts, bridgePortToOPI(port, ""))
+				}
+				return resp, nil
+			}
+			if pkgplugin.IsNotImplemented(err) || pkgplugin.IsCapabilityNotSupported(err) {
+				g.log.Info("Registry plugin ListBridgePorts not implemented; falling back to VSP", "error", err)
+			} else {
+				return nil, err
+			}
+		}
+	}
+
+	err := g.ensureConnected()
+	if err != nil {
+		return nil, fmt.Errorf("ListBridgePorts failed to ensure GRPC connection: %v", err)
+	}
+	return g.opiClient.ListBridgePorts(context.TODO(), listRequest)
+}
+
 func (g *GrpcPlugin) CreateNetworkFunction(input string, output string) error {
 	g.log.Info("CreateNetworkFunction", "input", input, "output", output)
 
@@ -357,6 +501,60 @@ func (g *GrpcPlugin) DeleteNetworkFunction(input string, output string) error {
 	return err
 }
 
+func (g *GrpcPlugin) ListNetworkFunctions() ([]*nfapi.NFRequest, error) {
+	if g.ensureRegistryInitialized(context.Background()) {
+		if networkPlugin, ok := g.registryNetworkPlugin(); ok {
+			nfs, err := networkPlugin.ListNetworkFunctions(context.Background())
+			if err == nil {
+				var result []*nfapi.NFRequest
+				for _, nf := range nfs {
+					result = append(result, &nfapi.NFRequest{Input: nf.Input, Output: nf.Output})
+				}
+				return result, nil
+			}
+			if pkgplugin.IsNotImplemented(err) || pkgplugin.IsCapabilityNotSupported(err) {
+				g.log.Info("Registry plugin ListNetworkFunctions not implemented; falling back to VSP", "error", err)
+			} else {
+				return nil, err
+			}
+		}
+	}
+
+	err := g.ensureConnected()
+	if err != nil {
+		return nil, fmt.Errorf("ListNetworkFunctions failed to ensure GRPC connection: %v", err)
+	}
+	resp, err := g.nfclient.ListNetworkFunctions(context.TODO(), &nfapi.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetNetworkFunctions(), nil
+}
+
+// ChainsNetworkFunctions asks the registry plugin, or else the VSP, whether
+// network functions can be chained. VSPs that do not report their
+// capabilities are assumed not to.
+func (g *GrpcPlugin) ChainsNetworkFunctions() (bool, error) {
+	if g.ensureRegistryInitialized(context.Background()) {
+		if chainPlugin, ok := g.registryPlugin.(pkgplugin.ChainPlugin); ok {
+			return chainPlugin.ChainsNetworkFunctions(), nil
+		}
+	}
+
+	err := g.ensureConnected()
+	if err != nil {
+		return false, fmt.Errorf("GetCapabilities failed to ensure GRPC connection: %v", err)
+	}
+	caps, err := g.nfclient.GetCapabilities(context.TODO(), &nfapi.Empty{})
+	if status.Code(err) == codes.Unimplemented {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return caps.GetChaining(), nil
+}
+
 func (g *GrpcPlugin) GetDevices() (*pb.DeviceListResponse, error) {
 	if g.ensureRegistryInitialized(context.Background()) {
 		devices, err := g.registryPlugin.DiscoverDevices(context.Background())
@@ -378,6 +576,135 @@ func (g *GrpcPlugin) GetDevices() (*pb.DeviceListResponse, error) {
 	return g.dsClient.GetDevices(context.Background(), &emptypb.Empty{})
 }
 
+// GetInventory returns the device matching the DPU identifier and its
+// inventory from the registry plugin. The inventory is nil if the plugin
+// discovers the device but does not implement GetInventory. There is no VSP
+// fallback, the VSP does not report an inventory.
+func (g *GrpcPlugin) GetInventory(ctx context.Context) (*pkgplugin.Device, *pkgplugin.InventoryResponse, error) {
+	if !g.ensureRegistryInitialized(ctx) {
+		return nil, nil, pkgplugin.ErrNotImplemented
+	}
+
+	devices, err := g.registryPlugin.DiscoverDevices(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover devices: %w", err)
+	}
+	if len(devices) == 0 {
+		return nil, nil, pkgplugin.ErrDeviceNotFound
+	}
+
+	device := selectDeviceForIdentifier(string(g.dpuIdentifier), devices)
+	inventory, err := g.registryPlugin.GetInventory(ctx, resolveDeviceID(device))
+	if err != nil {
+		if pkgplugin.IsNotImplemented(err) || pkgplugin.IsCapabilityNotSupported(err) {
+			return &device, nil, nil
+		}
+		return &device, nil, fmt.Errorf("failed to get inventory of device %s: %w", resolveDeviceID(device), err)
+	}
+	return &device, inventory, nil
+}
+
+// registryDeviceID returns the ID of the device matching the DPU identifier
+// in the devices of the registry plugin.
+func (g *GrpcPlugin) registryDeviceID(ctx context.Context) (string, error) {
+	if !g.ensureRegistryInitialized(ctx) {
+		return "", fmt.Errorf("registry plugin %s is not initialized: %w", g.registryPlugin.Info().Name, pkgplugin.ErrNotInitialized)
+	}
+
+	devices, err := g.registryPlugin.DiscoverDevices(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to discover devices: %w", err)
+	}
+	if len(devices) == 0 {
+		return "", pkgplugin.ErrDeviceNotFound
+	}
+	return resolveDeviceID(selectDeviceForIdentifier(string(g.dpuIdentifier), devices)), nil
+}
+
+// registryFirmwarePlugin returns the registry plugin as a FirmwarePlugin and
+// the ID of the device matching the DPU identifier. There is no VSP fallback,
+// the VSP cannot update firmware.
+func (g *GrpcPlugin) registryFirmwarePlugin(ctx context.Context) (pkgplugin.FirmwarePlugin, string, error) {
+	firmwarePlugin, ok := g.registryPlugin.(pkgplugin.FirmwarePlugin)
+	if !ok {
+		return nil, "", pkgplugin.ErrCapabilityNotSupported
+	}
+	deviceID, err := g.registryDeviceID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return firmwarePlugin, deviceID, nil
+}
+
+// GetFirmwareVersions returns the firmware of the device matching the DPU
+// identifier from the registry plugin.
+func (g *GrpcPlugin) GetFirmwareVersions(ctx context.Context) ([]pkgplugin.FirmwareVersion, error) {
+	firmwarePlugin, deviceID, err := g.registryFirmwarePlugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return firmwarePlugin.GetFirmwareVersions(ctx, deviceID)
+}
+
+// StageFirmware stages a firmware image on the device matching the DPU identifier.
+func (g *GrpcPlugin) StageFirmware(ctx context.Context, image *pkgplugin.FirmwareImage) error {
+	firmwarePlugin, deviceID, err := g.registryFirmwarePlugin(ctx)
+	if err != nil {
+		return err
+	}
+	return firmwarePlugin.StageFirmware(ctx, deviceID, image)
+}
+
+// ActivateFirmware activates the firmware staged on the device matching the
+// DPU identifier.
+func (g *GrpcPlugin) ActivateFirmware(ctx context.Context) (*pkgplugin.FirmwareActivation, error) {
+	firmwarePlugin, deviceID, err := g.registryFirmwarePlugin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return firmwarePlugin.ActivateFirmware(ctx, deviceID)
+}
+
+// RebootDevice starts a reboot of the device matching the DPU identifier.
+// There is no VSP fallback, the VSP cannot reboot the DPU.
+func (g *GrpcPlugin) RebootDevice(ctx context.Context) error {
+	rebootPlugin, ok := g.registryPlugin.(pkgplugin.RebootPlugin)
+	if !ok {
+		return pkgplugin.ErrCapabilityNotSupported
+	}
+	deviceID, err := g.registryDeviceID(ctx)
+	if err != nil {
+		return err
+	}
+	return rebootPlugin.RebootDevice(ctx, deviceID)
+}
+
+// DeviceResetting returns whether the registry plugin reports the device of
+// the DPU resetting, that is no longer discovered or discovered unhealthy.
+// There is no VSP fallback, a VSP that does not answer cannot tell a reset of
+// the DPU from a failure of its own.
+func (g *GrpcPlugin) DeviceResetting(ctx context.Context) (bool, error) {
+	if !g.ensureRegistryInitialized(ctx) {
+		return false, pkgplugin.ErrNotImplemented
+	}
+
+	devices, err := g.registryPlugin.DiscoverDevices(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to discover devices: %w", err)
+	}
+	if len(devices) == 0 {
+		return true, nil
+	}
+	device := devices[0]
+	if g.dpuIdentifier != "" {
+		var ok bool
+		if device, ok = findDeviceForIdentifier(string(g.dpuIdentifier), devices); !ok {
+			return true, nil
+		}
+	}
+	return !device.Healthy, nil
+}
+
 func (g *GrpcPlugin) SetNumVfs(count int32) (*pb.VfCount, error) {
 	if g.ensureRegistryInitialized(context.Background()) {
 		if networkPlugin, ok := g.registryNetworkPlugin(); ok {
@@ -412,6 +739,25 @@ func (g *GrpcPlugin) SetNumVfs(count int32) (*pb.VfCount, error) {
 	return g.dsClient.SetNumVfs(context.Background(), c)
 }
 
+// GetNumVfs returns the VF count the registry plugin reports for the device
+// matching the DPU identifier. There is no VSP fallback, the VSP cannot
+// report its VF count.
+func (g *GrpcPlugin) GetNumVfs() (int32, error) {
+	networkPlugin, ok := g.registryNetworkPlugin()
+	if !ok {
+		return 0, pkgplugin.ErrCapabilityNotSupported
+	}
+	deviceID, err := g.registryDeviceID(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	count, err := networkPlugin.GetVFCount(context.Background(), deviceID)
+	if err != nil {
+		return 0, err
+	}
+	return int32(count), nil
+}
+
 func resolveDeviceID(device pkgplugin.Device) string {
 	if device.ID != "" {
 		return device.ID
@@ -432,6 +778,15 @@ func selectDeviceForIdentifier(identifier string, devices []pkgplugin.Device) pk
 	if identifier == "" {
 		return devices[0]
 	}
+	if device, ok := findDeviceForIdentifier(identifier, devices); ok {
+		return device
+	}
+	return devices[0]
+}
+
+// findDeviceForIdentifier returns the device matching the DPU identifier,
+// and whether one matches.
+func findDeviceForIdentifier(identifier string, devices []pkgplugin.Device) (pkgplugin.Device, bool) {
 
 	candidates := identifierCandidates(identifier)
 
@@ -439,13 +794,13 @@ func selectDeviceForIdentifier(identifier string, devices []pkgplugin.Device) pk
 	for _, candidate := range candidates {
 		for _, device := range devices {
 			if device.ID != "" && candidate == normalizeIdentifier(device.ID) {
-				return device
+				return device, true
 			}
 			if device.SerialNumber != "" && candidate == normalizeIdentifier(device.SerialNumber) {
-				return device
+				return device, true
 			}
 			if device.PCIAddress != "" && candidate == normalizeIdentifier(device.PCIAddress) {
-				return device
+				return device, true
 			}
 		}
 	}
@@ -454,18 +809,18 @@ func selectDeviceForIdentifier(identifier string, devices []pkgplugin.Device) pk
 	for _, candidate := range candidates {
 		for _, device := range devices {
 			if device.ID != "" && strings.Contains(candidate, normalizeIdentifier(device.ID)) {
-				return device
+				return device, true
 			}
 			if device.SerialNumber != "" && strings.Contains(candidate, normalizeIdentifier(device.SerialNumber)) {
-				return device
+				return device, true
 			}
 			if device.PCIAddress != "" && strings.Contains(candidate, normalizeIdentifier(device.PCIAddress)) {
-				return device
+				return device, true
 			}
 		}
 	}
 
-	return devices[0]
+	return pkgplugin.Device{}, false
 }
 
 func normalizeIdentifier(value string) string {
@@ -616,3 +971,18 @@ func (g *GrpcPlugin) SetInitDone(initialized bool) {
 	defer g.initMutex.Unlock()
 	g.initialized = initialized
 }
+
+func (g *GrpcPlugin) setDaemonAddress(addr string, port int32) {
+	g.initMutex.Lock()
+	defer g.initMutex.Unlock()
+	g.daemonAddr = addr
+	g.daemonPort = port
+}
+
+// daemonAddress returns the address of the DPU daemon the VSP reported on
+// the last successful Init, or an empty address if Init never succeeded.
+func (g *GrpcPlugin) daemonAddress() (string, int32) {
+	g.initMutex.RLock()
+	defer g.initMutex.RUnlock()
+	return g.daemonAddr, g.daemonPort
+}
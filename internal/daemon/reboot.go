@@ -0,0 +1,656 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/pkgs/vars"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// rebootHeartbeatTimeout is how long the heartbeat of a DPU that answered
+	// before may fail until its plugin is asked whether the DPU resets.
+	rebootHeartbeatTimeout = 15 * time.Second
+	// resetCheckTimeout bounds asking the plugin whether a DPU resets.
+	resetCheckTimeout = 5 * time.Second
+	// rebootPollInterval is how often a reboot checks whether the DPU is back.
+	rebootPollInterval = 5 * time.Second
+	// rebootDownTimeout bounds the wait for a DPU to go down once its plugin
+	// started the reboot. A DPU that resets quickly may not be seen down.
+	rebootDownTimeout = 2 * time.Minute
+	// rebootRequestCheckInterval is how often the DpuReboots are listed.
+	rebootRequestCheckInterval = 10 * time.Second
+)
+
+// dpuRebooter starts the reboot of a DPU. It is implemented by plugin.GrpcPlugin.
+type dpuRebooter interface {
+	RebootDevice(ctx context.Context) error
+}
+
+// resetReporter tells whether a DPU resets, as its plugin sees it. It is
+// implemented by plugin.GrpcPlugin.
+type resetReporter interface {
+	DeviceResetting(ctx context.Context) (bool, error)
+}
+
+// rebootTarget is implemented by side managers that follow their DPU through
+// a reset.
+type rebootTarget interface {
+	SetDevicesHealthy(healthy bool)
+	Reinitialize(ctx context.Context) error
+}
+
+// nodeHolder keeps the node cordoned while DPUs reset. It is implemented by
+// the daemon.
+type nodeHolder interface {
+	holdNode(ctx context.Context, dpu string, drain bool) error
+	releaseNode(ctx context.Context, dpu string) error
+}
+
+// dpuReboot follows a DPU of the node through a reset, whether it was
+// requested by a DpuReboot or noticed by the daemon. The devices of the DPU
+// are unhealthy, and the node optionally cordoned, until the DPU is detected
+// again, its VSP is initialized again and its heartbeat is back. It runs in
+// the background while the daemon loop reports what the DPU is seen doing.
+type dpuReboot struct {
+	dpu     string
+	trigger configv1.DpuRebootTrigger
+	// request is the DpuReboot that requested the reboot, if any.
+	request string
+	// hold cordons the node for the reboot, drain drains it as well.
+	hold  bool
+	drain bool
+	// resumed is set for reboots a previous daemon started. The node is
+	// already held and the DPU already rebooting.
+	resumed   bool
+	startTime time.Time
+	poll      time.Duration
+
+	mu      sync.Mutex
+	phase   configv1.DpuRebootPhase
+	present bool
+	pingOk  bool
+	err     error
+	done    bool
+	// reported is the phase last reported on the DpuReboot.
+	reported configv1.DpuRebootPhase
+}
+
+func newDpuReboot(dpu string, trigger configv1.DpuRebootTrigger, now time.Time) *dpuReboot {
+	return &dpuReboot{
+		dpu:       dpu,
+		trigger:   trigger,
+		startTime: now,
+		poll:      rebootPollInterval,
+		phase:     configv1.DpuRebootPhaseWaitingForDpu,
+	}
+}
+
+func (r *dpuReboot) setPhase(phase configv1.DpuRebootPhase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = phase
+}
+
+// state returns the current phase, the error of a failed reboot and whether
+// the reboot is done.
+func (r *dpuReboot) state() (configv1.DpuRebootPhase, error, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.phase, r.err, r.done
+}
+
+// observe records what the daemon loop sees of the DPU: whether it is
+// detected and whether its heartbeat is healthy.
+func (r *dpuReboot) observe(present bool, pingOk bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.present = present
+	r.pingOk = pingOk
+}
+
+func (r *dpuReboot) observed() (bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.present, r.pingOk
+}
+
+// status returns the reboot as reported on the DataProcessingUnit.
+func (r *dpuReboot) status() *configv1.DpuRebootState {
+	phase, err, _ := r.state()
+	state := &configv1.DpuRebootState{
+		Trigger:   r.trigger,
+		Phase:     phase,
+		Request:   r.request,
+		StartTime: metav1.NewTime(r.startTime),
+	}
+	if err != nil {
+		state.LastError = err.Error()
+	}
+	return state
+}
+
+// run follows the DPU through the reboot. The node is released once the
+// reboot completes or fails. The devices of a DPU that failed to come back
+// stay unhealthy.
+func (r *dpuReboot) run(ctx context.Context, target rebootTarget, rebooter dpuRebooter, holder nodeHolder) {
+	defer r.finish()
+	defer r.release(ctx, holder)
+
+	target.SetDevicesHealthy(false)
+
+	if r.hold && !r.resumed {
+		if r.drain {
+			r.setPhase(configv1.DpuRebootPhaseDraining)
+		}
+		if err := holder.holdNode(ctx, r.dpu, r.drain); err != nil {
+			r.fail(err)
+			return
+		}
+	}
+
+	if r.trigger == configv1.DpuRebootTriggerRequested && !r.resumed {
+		r.setPhase(configv1.DpuRebootPhaseRebooting)
+		if err := rebooter.RebootDevice(ctx); err != nil {
+			r.fail(fmt.Errorf("failed to reboot DPU %s: %v", r.dpu, err))
+			return
+		}
+		// The DPU may still answer right after its plugin started the reboot.
+		downCtx, cancel := context.WithTimeout(ctx, rebootDownTimeout)
+		r.waitFor(downCtx, func(present bool, pingOk bool) bool { return !present || !pingOk })
+		cancel()
+	}
+
+	r.setPhase(configv1.DpuRebootPhaseWaitingForDpu)
+	if err := r.waitFor(ctx, func(present bool, _ bool) bool { return present }); err != nil {
+		r.fail(fmt.Errorf("DPU %s was not detected again: %v", r.dpu, err))
+		return
+	}
+
+	r.setPhase(configv1.DpuRebootPhaseReinitializing)
+	if err := target.Reinitialize(ctx); err != nil {
+		r.fail(fmt.Errorf("failed to initialize DPU %s again: %v", r.dpu, err))
+		return
+	}
+	if err := r.waitFor(ctx, func(_ bool, pingOk bool) bool { return pingOk }); err != nil {
+		r.fail(fmt.Errorf("heartbeat of DPU %s did not come back: %v", r.dpu, err))
+		return
+	}
+	target.SetDevicesHealthy(true)
+}
+
+// waitFor polls what the daemon loop sees of the DPU until done returns true.
+func (r *dpuReboot) waitFor(ctx context.Context, done func(present bool, pingOk bool) bool) error {
+	for {
+		if done(r.observed()) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.poll):
+		}
+	}
+}
+
+func (r *dpuReboot) release(ctx context.Context, holder nodeHolder) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), vfCountUncordonTimeout)
+	defer cancel()
+	if err := holder.releaseNode(releaseCtx, r.dpu); err != nil {
+		r.fail(err)
+	}
+}
+
+func (r *dpuReboot) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *dpuReboot) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = configv1.DpuRebootPhaseCompleted
+	if r.err != nil {
+		r.phase = configv1.DpuRebootPhaseFailed
+	}
+	r.done = true
+}
+
+// resetPolicyFromEnv reads the DpuResetPolicy of the DpuOperatorConfig, which
+// the operator passes to the daemon through its environment.
+func resetPolicyFromEnv() (bool, time.Duration) {
+	cordon, _ := strconv.ParseBool(os.Getenv("DPU_RESET_CORDON_NODE"))
+	timeout, err := time.ParseDuration(os.Getenv("DPU_RESET_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = configv1.DefaultDpuResetTimeout
+	}
+	return cordon, timeout
+}
+
+// rebootBusy returns whether a DPU of the node is rebooting, so that no other
+// change drains the node or changes its DPUs.
+func (d *Daemon) rebootBusy() bool {
+	for _, managed := range d.managedDpus {
+		if managed.Reboot != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// handleDpuReboots follows the host-side DPUs through their resets. A DPU
+// is followed when it disappears from the PCI bus, when its heartbeat is lost
+// and its plugin reports it resetting, or when a DpuReboot requests it. A DPU whose reboot failed is not followed
+// again until its heartbeat is back.
+func (d *Daemon) handleDpuReboots(ctx context.Context, now time.Time) {
+	d.handleRebootRequests(ctx, now)
+	if !d.rebootHoldsChecked {
+		d.resumeRebootHolds(ctx, now)
+	}
+
+	for name, managed := range d.managedDpus {
+		if managed.DpuCR.Spec.IsDpuSide {
+			continue
+		}
+		target, ok := managed.Manager.(rebootTarget)
+		if !ok {
+			continue
+		}
+		pingOk := managed.Manager.CheckPing()
+
+		if reboot := managed.Reboot; reboot != nil {
+			reboot.observe(!managed.Missing, pingOk)
+			d.reportRebootRequest(ctx, reboot)
+			managed.DpuCR.Status.Reboot = reboot.status()
+			d.collectDpuReboot(name, managed)
+			continue
+		}
+
+		if managed.RebootFailure != nil {
+			if !pingOk {
+				managed.DpuCR.Status.Reboot = managed.RebootFailure
+				continue
+			}
+			d.log.Info("DPU heartbeat is back after a failed reboot", "dpu", name)
+			target.SetDevicesHealthy(true)
+			managed.RebootFailure = nil
+		}
+		managed.DpuCR.Status.Reboot = nil
+
+		switch {
+		case managed.Missing:
+			d.startDpuReboot(managed, target, d.detectedReboot(name, configv1.DpuRebootTriggerDeviceLost, now))
+		case heartbeatLost(managed.Manager, now) && managed.Plugin != nil && d.resetReported(ctx, name, managed.Plugin):
+			d.startDpuReboot(managed, target, d.detectedReboot(name, configv1.DpuRebootTriggerHeartbeatLost, now))
+		}
+	}
+}
+
+// heartbeatLost returns whether the heartbeat of a DPU that answered before
+// failed for longer than rebootHeartbeatTimeout.
+func heartbeatLost(manager SideManager, now time.Time) bool {
+	reporter, ok := manager.(heartbeatReporter)
+	if !ok {
+		return false
+	}
+	lastPing := reporter.LastPing()
+	return !lastPing.IsZero() && now.Sub(lastPing) > rebootHeartbeatTimeout
+}
+
+// resetReported returns whether the plugin of a DPU whose heartbeat is lost
+// reports the DPU resetting. A lost heartbeat alone may as well be a failure
+// of the daemon on the DPU or of the network in between, which the devices
+// health and the heartbeat condition already report.
+func (d *Daemon) resetReported(ctx context.Context, dpu string, reporter resetReporter) bool {
+	checkCtx, cancel := context.WithTimeout(ctx, resetCheckTimeout)
+	defer cancel()
+	resetting, err := reporter.DeviceResetting(checkCtx)
+	if err != nil {
+		d.log.V(1).Info("Heartbeat of DPU is lost, but its plugin cannot tell whether it resets", "dpu", dpu, "error", err)
+		return false
+	}
+	if !resetting {
+		d.log.V(1).Info("Heartbeat of DPU is lost, but its plugin does not report it resetting", "dpu", dpu)
+	}
+	return resetting
+}
+
+// detectedReboot returns a reboot the daemon noticed, which holds the node
+// according to the reset policy.
+func (d *Daemon) detectedReboot(dpu string, trigger configv1.DpuRebootTrigger, now time.Time) *dpuReboot {
+	reboot := newDpuReboot(dpu, trigger, now)
+	reboot.hold = d.resetCordon
+	return reboot
+}
+
+// startDpuReboot follows a DPU through its reboot in the background.
+func (d *Daemon) startDpuReboot(managed *ManagedDpu, target rebootTarget, reboot *dpuReboot) {
+	managed.Reboot = reboot
+	managed.RebootFailure = nil
+	managed.DpuCR.Status.Reboot = reboot.status()
+	if reboot.drain || reboot.resumed {
+		// The drainer is created here, as the daemon loop owns it.
+		if _, err := d.nodeDrainer(); err != nil && reboot.drain {
+			reboot.fail(err)
+			reboot.finish()
+			return
+		}
+	}
+
+	timeout := d.resetTimeout
+	if timeout <= 0 {
+		timeout = configv1.DefaultDpuResetTimeout
+	}
+	d.log.Info("Following DPU through a reboot", "dpu", reboot.dpu, "trigger", reboot.trigger,
+		"request", reboot.request, "cordon", reboot.hold, "drain", reboot.drain, "timeout", timeout)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		reboot.run(ctx, target, managed.Plugin, d)
+	}()
+}
+
+// collectDpuReboot records the result of a finished reboot on the managed
+// DPU, once it is reported on its DpuReboot. A DPU that failed to come back
+// and is still not detected is not managed anymore.
+func (d *Daemon) collectDpuReboot(name string, managed *ManagedDpu) {
+	reboot := managed.Reboot
+	phase, err, done := reboot.state()
+	if !done || (reboot.request != "" && reboot.reported != phase) {
+		return
+	}
+	managed.Reboot = nil
+
+	if err == nil {
+		d.log.Info("DPU is back from its reboot", "dpu", name, "trigger", reboot.trigger)
+		managed.DpuCR.Status.Reboot = nil
+		// Read what the reset may have changed on the next evaluation,
+		// starting with the VF count the plugin reports.
+		managed.AppliedVfCount = nil
+		managed.InventoryRefreshed = time.Time{}
+		managed.FirmwareRefreshed = time.Time{}
+		return
+	}
+
+	d.log.Info("DPU reboot failed", "dpu", name, "trigger", reboot.trigger, "error", err)
+	managed.RebootFailure = reboot.status()
+	managed.DpuCR.Status.Reboot = managed.RebootFailure
+	if managed.Missing {
+		d.removeManagedDpu(name)
+	}
+}
+
+// handleRebootRequests starts the reboots requested by DpuReboots for the
+// DPUs of the node. A request waits for the changes in progress on the node.
+// A request a previous daemon started is followed until the DPU is back.
+func (d *Daemon) handleRebootRequests(ctx context.Context, now time.Time) {
+	if !d.rebootRequestsChecked.IsZero() && now.Sub(d.rebootRequestsChecked) < rebootRequestCheckInterval {
+		return
+	}
+	d.rebootRequestsChecked = now
+
+	requests := &configv1.DpuRebootList{}
+	if err := d.client.List(ctx, requests); err != nil {
+		d.log.V(1).Info("Failed to list DpuReboots", "error", err)
+		return
+	}
+	for i := range requests.Items {
+		request := &requests.Items[i]
+		phase := request.Status.Phase
+		if phase == configv1.DpuRebootPhaseCompleted || phase == configv1.DpuRebootPhaseFailed {
+			continue
+		}
+		managed, ok := d.managedDpus[request.Spec.DpuName]
+		if !ok {
+			continue
+		}
+		if managed.DpuCR.Spec.IsDpuSide {
+			d.updateRebootRequest(ctx, request, configv1.DpuRebootPhaseFailed,
+				fmt.Sprintf("DPU %s is the DPU side, only host-side DPUs can be rebooted.", request.Spec.DpuName), nil, true)
+			continue
+		}
+		target, ok := managed.Manager.(rebootTarget)
+		if !ok {
+			continue
+		}
+		if reboot := managed.Reboot; reboot != nil {
+			if reboot.request != request.Name {
+				d.updateRebootRequest(ctx, request, configv1.DpuRebootPhasePending,
+					fmt.Sprintf("Waiting for the reboot in progress of DPU %s.", request.Spec.DpuName), nil, false)
+			}
+			continue
+		}
+
+		resumed := phase != "" && phase != configv1.DpuRebootPhasePending
+		if !resumed && (d.vfCountRollout != nil || d.firmwareBusy()) {
+			d.updateRebootRequest(ctx, request, configv1.DpuRebootPhasePending,
+				fmt.Sprintf("Waiting for the changes in progress on node %s.", d.nodeName), nil, false)
+			continue
+		}
+
+		reboot := newDpuReboot(request.Spec.DpuName, configv1.DpuRebootTriggerRequested, now)
+		reboot.request = request.Name
+		reboot.hold = !resumed
+		reboot.drain = request.Spec.Drain == nil || *request.Spec.Drain
+		reboot.resumed = resumed
+		if resumed && request.Status.StartTime != nil {
+			reboot.startTime = request.Status.StartTime.Time
+		}
+		d.startDpuReboot(managed, target, reboot)
+		d.reportRebootRequest(ctx, reboot)
+	}
+}
+
+// reportRebootRequest reports the phase of a requested reboot on its
+// DpuReboot when it changed.
+func (d *Daemon) reportRebootRequest(ctx context.Context, reboot *dpuReboot) {
+	phase, err, done := reboot.state()
+	if reboot.request == "" || reboot.reported == phase {
+		return
+	}
+	request := &configv1.DpuReboot{}
+	if getErr := d.client.Get(ctx, client.ObjectKey{Name: reboot.request}, request); getErr != nil {
+		if client.IgnoreNotFound(getErr) == nil {
+			// The request was deleted, there is nothing to report on.
+			reboot.reported = phase
+			return
+		}
+		d.log.V(1).Info("Failed to fetch DpuReboot", "name", reboot.request, "error", getErr)
+		return
+	}
+
+	var message string
+	switch phase {
+	case configv1.DpuRebootPhaseDraining:
+		message = fmt.Sprintf("Draining node %s.", d.nodeName)
+	case configv1.DpuRebootPhaseRebooting:
+		message = fmt.Sprintf("Rebooting DPU %s.", reboot.dpu)
+	case configv1.DpuRebootPhaseWaitingForDpu:
+		message = fmt.Sprintf("Waiting for DPU %s to come back.", reboot.dpu)
+	case configv1.DpuRebootPhaseReinitializing:
+		message = fmt.Sprintf("Initializing DPU %s again.", reboot.dpu)
+	case configv1.DpuRebootPhaseCompleted:
+		message = fmt.Sprintf("DPU %s is back.", reboot.dpu)
+	case configv1.DpuRebootPhaseFailed:
+		message = err.Error()
+	}
+	startTime := metav1.NewTime(reboot.startTime)
+	if d.updateRebootRequest(ctx, request, phase, message, &startTime, done) {
+		reboot.reported = phase
+	}
+}
+
+// updateRebootRequest sets the status of a DpuReboot. It returns whether the
+// status is up to date.
+func (d *Daemon) updateRebootRequest(ctx context.Context, request *configv1.DpuReboot, phase configv1.DpuRebootPhase, message string, startTime *metav1.Time, done bool) bool {
+	status := request.Status.DeepCopy()
+	status.Phase = phase
+	status.NodeName = d.nodeName
+	status.Message = message
+	if startTime != nil && status.StartTime == nil {
+		status.StartTime = startTime
+	}
+	if done && status.CompletionTime == nil {
+		now := metav1.Now()
+		status.CompletionTime = &now
+	}
+	if status.Phase == request.Status.Phase && status.Message == request.Status.Message &&
+		status.NodeName == request.Status.NodeName && (status.StartTime == nil) == (request.Status.StartTime == nil) &&
+		(status.CompletionTime == nil) == (request.Status.CompletionTime == nil) {
+		return true
+	}
+
+	request.Status = *status
+	if err := d.client.Status().Update(ctx, request); err != nil {
+		d.log.Info("Failed to update DpuReboot status", "name", request.Name, "phase", phase, "error", err)
+		return false
+	}
+	return true
+}
+
+// resumeRebootHolds follows the DPUs the node was cordoned for by a previous
+// daemon through their reset, once per daemon start. The node is released
+// for the DPUs that are not detected anymore.
+func (d *Daemon) resumeRebootHolds(ctx context.Context, now time.Time) {
+	node := &corev1.Node{}
+	if err := d.client.Get(ctx, client.ObjectKey{Name: d.nodeName}, node); err != nil {
+		d.log.V(1).Info("Failed to fetch node for the DPU reboot check", "node", d.nodeName, "error", err)
+		return
+	}
+	d.rebootHoldsChecked = true
+
+	for dpu := range rebootHolds(node) {
+		managed, ok := d.managedDpus[dpu]
+		if ok && managed.Reboot != nil {
+			continue
+		}
+		var target rebootTarget
+		if ok {
+			target, ok = managed.Manager.(rebootTarget)
+		}
+		if !ok {
+			d.log.Info("Releasing the node held for a DPU that is not detected anymore", "node", d.nodeName, "dpu", dpu)
+			if err := d.releaseNode(ctx, dpu); err != nil {
+				d.log.Info("Failed to release the node", "node", d.nodeName, "dpu", dpu, "error", err)
+			}
+			continue
+		}
+		reboot := newDpuReboot(dpu, configv1.DpuRebootTriggerHeartbeatLost, now)
+		reboot.resumed = true
+		d.startDpuReboot(managed, target, reboot)
+	}
+}
+
+// rebootHolds returns the DPUs the node is cordoned for, and whether it was
+// drained for them.
+func rebootHolds(node *corev1.Node) map[string]bool {
+	holds := map[string]bool{}
+	if value, ok := node.Annotations[vars.DpuRebootHoldAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &holds); err != nil {
+			return map[string]bool{}
+		}
+	}
+	return holds
+}
+
+func setRebootHolds(node *corev1.Node, holds map[string]bool) error {
+	value, err := json.Marshal(holds)
+	if err != nil {
+		return err
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[vars.DpuRebootHoldAnnotation] = string(value)
+	return nil
+}
+
+// holdNode cordons the node while a DPU resets, and drains it if requested.
+// The DPU is recorded on the node first, so that the node is released even
+// if the daemon restarts meanwhile. A node that is unschedulable for another
+// reason is neither drained nor released by the daemon.
+func (d *Daemon) holdNode(ctx context.Context, dpu string, drain bool) error {
+	d.rebootHoldMutex.Lock()
+	defer d.rebootHoldMutex.Unlock()
+
+	node := &corev1.Node{}
+	if err := d.client.Get(ctx, client.ObjectKey{Name: d.nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node %s: %v", d.nodeName, err)
+	}
+	holds := rebootHolds(node)
+	if node.Spec.Unschedulable && len(holds) == 0 {
+		d.log.Info("Node is already unschedulable, leaving it as it is for the DPU reboot", "node", d.nodeName, "dpu", dpu)
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	holds[dpu] = holds[dpu] || drain
+	if err := setRebootHolds(node, holds); err != nil {
+		return err
+	}
+	if !drain {
+		node.Spec.Unschedulable = true
+	}
+	if err := d.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", d.nodeName, err)
+	}
+	if drain {
+		return drainNode(ctx, d.drainer, node)
+	}
+	return nil
+}
+
+// releaseNode removes a DPU from the ones the node is held for, and
+// uncordons the node once no DPU holds it anymore.
+func (d *Daemon) releaseNode(ctx context.Context, dpu string) error {
+	d.rebootHoldMutex.Lock()
+	defer d.rebootHoldMutex.Unlock()
+
+	node := &corev1.Node{}
+	if err := d.client.Get(ctx, client.ObjectKey{Name: d.nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node %s: %v", d.nodeName, err)
+	}
+	holds := rebootHolds(node)
+	drained, ok := holds[dpu]
+	if !ok {
+		return nil
+	}
+	delete(holds, dpu)
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if len(holds) > 0 {
+		// The node stays cordoned for the other DPUs, and is uncordoned as a
+		// drained node if it was drained for any of them.
+		for other := range holds {
+			holds[other] = holds[other] || drained
+		}
+		if err := setRebootHolds(node, holds); err != nil {
+			return err
+		}
+	} else {
+		if drained && d.drainer != nil {
+			if _, err := d.drainer.CompleteDrainNode(ctx, node); err != nil {
+				return fmt.Errorf("failed to uncordon node %s: %v", d.nodeName, err)
+			}
+		}
+		node.Spec.Unschedulable = false
+		delete(node.Annotations, vars.DpuRebootHoldAnnotation)
+	}
+	if err := d.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to release node %s: %v", d.nodeName, err)
+	}
+	return nil
+}
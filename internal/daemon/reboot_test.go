@@ -0,0 +1,261 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// fakeRebootSteps records the steps of a reboot on the side manager, the
+// plugin and the node.
+type fakeRebootSteps struct {
+	mu             sync.Mutex
+	steps          []string
+	rebootErr      error
+	onReboot       func()
+	onReinitialize func()
+}
+
+func (f *fakeRebootSteps) record(step string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.steps = append(f.steps, step)
+}
+
+func (f *fakeRebootSteps) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.steps...)
+}
+
+func (f *fakeRebootSteps) SetDevicesHealthy(healthy bool) {
+	if healthy {
+		f.record("healthy")
+	} else {
+		f.record("unhealthy")
+	}
+}
+
+func (f *fakeRebootSteps) Reinitialize(_ context.Context) error {
+	f.record("reinitialize")
+	if f.onReinitialize != nil {
+		f.onReinitialize()
+	}
+	return nil
+}
+
+func (f *fakeRebootSteps) RebootDevice(_ context.Context) error {
+	f.record("reboot")
+	if f.onReboot != nil {
+		f.onReboot()
+	}
+	return f.rebootErr
+}
+
+func (f *fakeRebootSteps) holdNode(_ context.Context, dpu string, drain bool) error {
+	if drain {
+		f.record("drain " + dpu)
+	} else {
+		f.record("cordon " + dpu)
+	}
+	return nil
+}
+
+func (f *fakeRebootSteps) releaseNode(_ context.Context, dpu string) error {
+	f.record("release " + dpu)
+	return nil
+}
+
+// resetPlugin is a registry plugin discovering the given devices.
+type resetPlugin struct {
+	devices     []pkgplugin.Device
+	discoverErr error
+}
+
+func (p *resetPlugin) Info() pkgplugin.PluginInfo {
+	return pkgplugin.PluginInfo{Name: "reset", Vendor: "test"}
+}
+func (p *resetPlugin) Initialize(context.Context, pkgplugin.PluginConfig) error { return nil }
+func (p *resetPlugin) Shutdown(context.Context) error                           { return nil }
+func (p *resetPlugin) HealthCheck(context.Context) error                        { return nil }
+func (p *resetPlugin) GetInventory(context.Context, string) (*pkgplugin.InventoryResponse, error) {
+	return nil, pkgplugin.ErrNotImplemented
+}
+
+func (p *resetPlugin) DiscoverDevices(context.Context) ([]pkgplugin.Device, error) {
+	return p.devices, p.discoverErr
+}
+
+func newTestDpuReboot(trigger configv1.DpuRebootTrigger) *dpuReboot {
+	reboot := newDpuReboot("bf3-0", trigger, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	reboot.poll = 10 * time.Millisecond
+	return reboot
+}
+
+var _ = g.Describe("DPU reboot", func() {
+	var steps *fakeRebootSteps
+
+	g.BeforeEach(func() {
+		steps = &fakeRebootSteps{}
+	})
+
+	g.It("follows a DPU that lost its heartbeat until it is back", func() {
+		reboot := newTestDpuReboot(configv1.DpuRebootTriggerHeartbeatLost)
+		reboot.hold = true
+		reboot.observe(true, false)
+		steps.onReinitialize = func() { reboot.observe(true, true) }
+
+		reboot.run(context.Background(), steps, steps, steps)
+
+		phase, err, done := reboot.state()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(done).To(BeTrue())
+		Expect(phase).To(Equal(configv1.DpuRebootPhaseCompleted))
+		Expect(steps.recorded()).To(Equal([]string{"unhealthy", "cordon bf3-0", "reinitialize", "healthy", "release bf3-0"}))
+	})
+
+	g.It("drains the node, reboots the DPU and waits for it to go down and come back", func() {
+		reboot := newTestDpuReboot(configv1.DpuRebootTriggerRequested)
+		reboot.hold = true
+		reboot.drain = true
+		reboot.observe(true, true)
+		steps.onReboot = func() { reboot.observe(false, false) }
+
+		finished := make(chan struct{})
+		go func() {
+			defer close(finished)
+			reboot.run(context.Background(), steps, steps, steps)
+		}()
+
+		Eventually(steps.recorded).Should(ContainElement("reboot"))
+		reboot.observe(true, false)
+		Eventually(func() configv1.DpuRebootPhase {
+			phase, _, _ := reboot.state()
+			return phase
+		}).Should(Equal(configv1.DpuRebootPhaseReinitializing))
+		reboot.observe(true, true)
+		Eventually(finished).Should(BeClosed())
+
+		_, err, _ := reboot.state()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(steps.recorded()).To(Equal([]string{
+			"unhealthy", "drain bf3-0", "reboot", "reinitialize", "healthy", "release bf3-0",
+		}))
+	})
+
+	g.It("does not hold the node or reboot the DPU again for a resumed reboot", func() {
+		reboot := newTestDpuReboot(configv1.DpuRebootTriggerRequested)
+		reboot.drain = true
+		reboot.resumed = true
+		reboot.observe(true, true)
+
+		reboot.run(context.Background(), steps, steps, steps)
+
+		_, err, _ := reboot.state()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(steps.recorded()).To(Equal([]string{"unhealthy", "reinitialize", "healthy", "release bf3-0"}))
+	})
+
+	g.It("fails a reboot the plugin cannot start and releases the node", func() {
+		steps.rebootErr = errors.New("capability not supported by plugin")
+		reboot := newTestDpuReboot(configv1.DpuRebootTriggerRequested)
+		reboot.hold = true
+
+		reboot.run(context.Background(), steps, steps, steps)
+
+		phase, err, done := reboot.state()
+		Expect(done).To(BeTrue())
+		Expect(phase).To(Equal(configv1.DpuRebootPhaseFailed))
+		Expect(err).To(MatchError(ContainSubstring("failed to reboot DPU bf3-0")))
+		Expect(steps.recorded()).To(Equal([]string{"unhealthy", "cordon bf3-0", "reboot", "release bf3-0"}))
+		Expect(reboot.status().LastError).To(ContainSubstring("capability not supported by plugin"))
+	})
+
+	g.It("fails a DPU that is not detected again in time and keeps its devices unhealthy", func() {
+		reboot := newTestDpuReboot(configv1.DpuRebootTriggerDeviceLost)
+		reboot.observe(false, false)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		reboot.run(ctx, steps, steps, steps)
+
+		phase, err, _ := reboot.state()
+		Expect(phase).To(Equal(configv1.DpuRebootPhaseFailed))
+		Expect(err).To(MatchError(ContainSubstring("DPU bf3-0 was not detected again")))
+		Expect(steps.recorded()).To(Equal([]string{"unhealthy", "release bf3-0"}))
+	})
+
+	g.It("records the result of a finished reboot on the managed DPU", func() {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		count := int32(8)
+		d := &Daemon{
+			log: ctrl.Log.WithName("Daemon"),
+			managedDpus: map[string]*ManagedDpu{
+				"bf3-0": {DpuCR: &configv1.DataProcessingUnit{}, AppliedVfCount: &count, FirmwareRefreshed: now},
+				"bf3-1": {DpuCR: &configv1.DataProcessingUnit{}, Missing: true},
+			},
+		}
+		succeeded := newTestDpuReboot(configv1.DpuRebootTriggerHeartbeatLost)
+		failed := newTestDpuReboot(configv1.DpuRebootTriggerDeviceLost)
+		d.managedDpus["bf3-0"].Reboot = succeeded
+		d.managedDpus["bf3-1"].Reboot = failed
+		Expect(d.rebootBusy()).To(BeTrue())
+
+		d.collectDpuReboot("bf3-0", d.managedDpus["bf3-0"])
+		Expect(d.managedDpus["bf3-0"].Reboot).To(Equal(succeeded), "the reboot is still running")
+
+		succeeded.finish()
+		d.collectDpuReboot("bf3-0", d.managedDpus["bf3-0"])
+		Expect(d.managedDpus["bf3-0"].Reboot).To(BeNil())
+		Expect(d.managedDpus["bf3-0"].AppliedVfCount).To(BeNil())
+		Expect(d.managedDpus["bf3-0"].FirmwareRefreshed.IsZero()).To(BeTrue())
+		Expect(d.managedDpus["bf3-0"].DpuCR.Status.Reboot).To(BeNil())
+
+		failed.fail(errors.New("DPU bf3-1 was not detected again: context deadline exceeded"))
+		failed.finish()
+		d.collectDpuReboot("bf3-1", d.managedDpus["bf3-1"])
+		Expect(d.managedDpus).NotTo(HaveKey("bf3-1"), "a DPU that did not come back is not managed anymore")
+		Expect(d.rebootBusy()).To(BeFalse())
+	})
+
+	g.Context("noticing a DPU reset", func() {
+		var d *Daemon
+
+		g.BeforeEach(func() {
+			d = &Daemon{log: ctrl.Log.WithName("Daemon")}
+		})
+
+		resetReported := func(registryPlugin pkgplugin.Plugin) bool {
+			grpcPlugin, err := plugin.NewGrpcPlugin(false, "bf3-0", nil)
+			Expect(err).NotTo(HaveOccurred())
+			if registryPlugin != nil {
+				grpcPlugin.AttachRegistryPlugin(registryPlugin, pkgplugin.PluginConfig{})
+			}
+			return d.resetReported(context.Background(), "bf3-0", grpcPlugin)
+		}
+
+		g.It("does not follow a DPU whose plugin still discovers it healthy", func() {
+			Expect(resetReported(&resetPlugin{devices: []pkgplugin.Device{{ID: "bf3-0", Healthy: true}}})).To(BeFalse())
+		})
+
+		g.It("follows a DPU its plugin discovers unhealthy or not at all", func() {
+			Expect(resetReported(&resetPlugin{devices: []pkgplugin.Device{{ID: "bf3-0"}}})).To(BeTrue())
+			Expect(resetReported(&resetPlugin{devices: []pkgplugin.Device{{ID: "bf3-1", Healthy: true}}})).To(BeTrue())
+			Expect(resetReported(&resetPlugin{})).To(BeTrue())
+		})
+
+		g.It("does not follow a DPU on a lost heartbeat alone", func() {
+			Expect(resetReported(nil)).To(BeFalse(), "the VSP cannot report a reset")
+			Expect(resetReported(&resetPlugin{discoverErr: errors.New("connection refused")})).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package sfcreconciler
+
+import (
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NetworkFunctionWiring describes how a network function pod was wired on the DPU.
+type NetworkFunctionWiring struct {
+	// Ports are the MAC addresses of the pod interfaces attached so far. The
+	// first one is the ingress of the network function, the second one the egress.
+	Ports []string
+	// Result is the outcome of the VSP calls wiring this network function into the chain.
+	Result configv1.NetworkFunctionWiringResult
+	// Message gives details about the result, such as the VSP error.
+	Message string
+}
+
+// ChainMember is a network function of a chain.
+type ChainMember struct {
+	// Name is the name of the network function in the ServiceFunctionChain spec.
+	Name string
+	// Pods are the candidate pods running the network function. The first one
+	// with interfaces attached on this node is used.
+	Pods []types.NamespacedName
+}
+
+// ChainWiring is the result of wiring a chain on this node.
+type ChainWiring struct {
+	// Members holds the wiring of the network functions found on this node, by name.
+	Members map[string]NetworkFunctionWiring
+	// Hops are the VSP network functions currently applied for the chain.
+	Hops []configv1.ServiceFunctionChainHop
+}
+
+// ChainWirer wires service function chains on this node.
+type ChainWirer interface {
+	// WireChain stitches the members of a chain together in the given order
+	// and returns the resulting wiring. Hops that are no longer needed are
+	// removed, so calling it without members tears the chain down.
+	WireChain(chain string, members []ChainMember) ChainWiring
+}
+
+// FunctionPorts are the ingress and egress DPU ports of a network function.
+type FunctionPorts struct {
+	Name    string
+	Ingress string
+	Egress  string
+}
+
+// ChainHops returns the VSP network functions needed to chain the given
+// network functions in order. The ingress of the first and the egress of the
+// last network function are the chain endpoints, and the egress of every
+// network function is stitched to the ingress of the next one. A chain with a
+// single network function results in a single hop between its own ports.
+func ChainHops(functions []FunctionPorts) []configv1.ServiceFunctionChainHop {
+	if len(functions) == 0 {
+		return nil
+	}
+
+	first := functions[0]
+	last := functions[len(functions)-1]
+	hops := []configv1.ServiceFunctionChainHop{{
+		Input:          first.Ingress,
+		Output:         last.Egress,
+		InputFunction:  first.Name,
+		OutputFunction: last.Name,
+	}}
+	for i := 0; i+1 < len(functions); i++ {
+		hops = append(hops, configv1.ServiceFunctionChainHop{
+			Input:          functions[i].Egress,
+			Output:         functions[i+1].Ingress,
+			InputFunction:  functions[i].Name,
+			OutputFunction: functions[i+1].Name,
+		})
+	}
+	return hops
+}
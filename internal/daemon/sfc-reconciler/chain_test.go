@@ -0,0 +1,41 @@
+package sfcreconciler_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	sfcreconciler "github.com/openshift/dpu-operator/internal/daemon/sfc-reconciler"
+)
+
+var _ = Describe("ChainHops", func() {
+	fw := sfcreconciler.FunctionPorts{Name: "fw", Ingress: "fw-in", Egress: "fw-out"}
+	lb := sfcreconciler.FunctionPorts{Name: "lb", Ingress: "lb-in", Egress: "lb-out"}
+	ids := sfcreconciler.FunctionPorts{Name: "ids", Ingress: "ids-in", Egress: "ids-out"}
+
+	It("returns no hops for an empty chain", func() {
+		Expect(sfcreconciler.ChainHops(nil)).To(BeEmpty())
+	})
+
+	It("wires a single network function to itself", func() {
+		Expect(sfcreconciler.ChainHops([]sfcreconciler.FunctionPorts{fw})).To(Equal([]configv1.ServiceFunctionChainHop{
+			{Input: "fw-in", Output: "fw-out", InputFunction: "fw", OutputFunction: "fw"},
+		}))
+	})
+
+	It("stitches the egress of each network function to the ingress of the next", func() {
+		Expect(sfcreconciler.ChainHops([]sfcreconciler.FunctionPorts{fw, ids, lb})).To(Equal([]configv1.ServiceFunctionChainHop{
+			{Input: "fw-in", Output: "lb-out", InputFunction: "fw", OutputFunction: "lb"},
+			{Input: "fw-out", Output: "ids-in", InputFunction: "fw", OutputFunction: "ids"},
+			{Input: "ids-out", Output: "lb-in", InputFunction: "ids", OutputFunction: "lb"},
+		}))
+	})
+
+	It("rewires the chain when the order changes", func() {
+		hops := sfcreconciler.ChainHops([]sfcreconciler.FunctionPorts{lb, fw})
+		Expect(hops).To(ContainElement(configv1.ServiceFunctionChainHop{
+			Input: "lb-out", Output: "fw-in", InputFunction: "lb", OutputFunction: "fw",
+		}))
+		Expect(hops).NotTo(ContainElement(HaveField("Input", "fw-out")))
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"sync/atomic"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -25,12 +27,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
+// wiringRequeueInterval is how often a chain with pending wiring is revisited.
+const wiringRequeueInterval = 5 * time.Second
+
 // SfcReconciler reconciles a Service Function Chain object
 type SfcReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	log      logr.Logger
 	nodeName string
+	wirer    ChainWirer
 }
 
 func networkFunctionPod(name string, image string, nodeSelector map[string]string) *corev1.Pod {
@@ -117,8 +123,17 @@ func (r *SfcReconciler) ensureNetworkFunctionExists(ctx context.Context, sfc *co
 	return nil
 }
 
+// WithChainWirer makes the reconciler wire the network functions of each chain
+// in order through the given wirer, and publish the result into the
+// ServiceFunctionChain status.
+func WithChainWirer(wirer ChainWirer) func(*SfcReconciler) {
+	return func(r *SfcReconciler) {
+		r.wirer = wirer
+	}
+}
+
 // NewSfcReconciler creates a new SfcReconciler with the current node name
-func NewSfcReconciler(client client.Client, scheme *runtime.Scheme) *SfcReconciler {
+func NewSfcReconciler(client client.Client, scheme *runtime.Scheme, opts ...func(*SfcReconciler)) *SfcReconciler {
 	nodeName := os.Getenv("K8S_NODE")
 	if nodeName == "" {
 		// Fallback to hostname if K8S_NODE is not set
@@ -130,11 +145,15 @@ func NewSfcReconciler(client client.Client, scheme *runtime.Scheme) *SfcReconcil
 		}
 	}
 
-	return &SfcReconciler{
+	r := &SfcReconciler{
 		Client:   client,
 		Scheme:   scheme,
 		nodeName: nodeName,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // matchesNodeSelector checks if the current node matches the ServiceFunctionChain's nodeSelector
@@ -173,6 +192,11 @@ func (r *SfcReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	err := r.Get(ctx, req.NamespacedName, sfc)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			if r.wirer != nil {
+				r.log.Info("ServiceFunctionChain CR not found, tearing down its wiring")
+				r.wirer.WireChain(req.NamespacedName.String(), nil)
+				return ctrl.Result{}, nil
+			}
 			r.log.Info("ServiceFunctionChain CR not found, ignoring")
 			return ctrl.Result{Requeue: true}, nil
 		}
@@ -190,6 +214,9 @@ func (r *SfcReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	if !matches {
 		r.log.Info("ServiceFunctionChain node selector does not match current node, skipping creation of any network function pods",
 			"nodeSelector", sfc.Spec.NodeSelector, "currentNode", r.nodeName)
+		if r.wirer != nil {
+			r.wirer.WireChain(req.NamespacedName.String(), nil)
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -204,9 +231,126 @@ func (r *SfcReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		}
 	}
 
+	if r.wirer != nil {
+		wiring := r.wirer.WireChain(req.NamespacedName.String(), chainMembers(sfc))
+		if err := r.updateWiringStatus(ctx, req.NamespacedName, wiring); err != nil {
+			r.log.Error(err, "Failed to update ServiceFunctionChain wiring status")
+			return ctrl.Result{RequeueAfter: wiringRequeueInterval}, nil
+		}
+		if r.wiringPending(ctx, sfc, wiring) {
+			return ctrl.Result{RequeueAfter: wiringRequeueInterval}, nil
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// wiringPending returns whether the wiring of a chain waits for the
+// interfaces of its network functions to be attached on this node. A chain
+// with network functions scheduled on other nodes cannot be wired here, and
+// is only revisited when its pods change.
+func (r *SfcReconciler) wiringPending(ctx context.Context, sfc *configv1.ServiceFunctionChain, wiring ChainWiring) bool {
+	pending := false
+	for _, nf := range sfc.Spec.NetworkFunctions {
+		if !r.isLocal(ctx, sfc, nf) {
+			return false
+		}
+		nfWiring, ok := wiring.Members[nf.Name]
+		if !ok || nfWiring.Result == configv1.WiringPending {
+			pending = true
+		}
+	}
+	return pending
+}
+
+// isLocal returns whether a network function runs, or may still be scheduled,
+// on this node. The pod created by this daemon is looked up directly, the
+// node of the pod created by the operator is taken from the chain status.
+func (r *SfcReconciler) isLocal(ctx context.Context, sfc *configv1.ServiceFunctionChain, nf configv1.NetworkFunction) bool {
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: vars.Namespace, Name: nf.Name}, pod); err == nil {
+		return pod.Spec.NodeName == "" || pod.Spec.NodeName == r.nodeName
+	}
+	for _, nfStatus := range sfc.Status.NetworkFunctions {
+		if nfStatus.Name == nf.Name && nfStatus.NodeName != "" {
+			return nfStatus.NodeName == r.nodeName
+		}
+	}
+	return true
+}
+
+// chainMembers lists the network functions of a chain in traffic order. A
+// network function runs either in the pod created by this daemon or in the
+// pod created by the operator.
+func chainMembers(sfc *configv1.ServiceFunctionChain) []ChainMember {
+	members := make([]ChainMember, 0, len(sfc.Spec.NetworkFunctions))
+	for _, nf := range sfc.Spec.NetworkFunctions {
+		members = append(members, ChainMember{
+			Name: nf.Name,
+			Pods: []types.NamespacedName{
+				{Namespace: vars.Namespace, Name: nf.Name},
+				{Namespace: sfc.Namespace, Name: fmt.Sprintf("%s-%s", sfc.Name, nf.Name)},
+			},
+		})
+	}
+	return members
+}
+
+// updateWiringStatus publishes the chain wiring done on this node into the
+// ServiceFunctionChain status.
+func (r *SfcReconciler) updateWiringStatus(ctx context.Context, key types.NamespacedName, wiring ChainWiring) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		sfc := &configv1.ServiceFunctionChain{}
+		if err := r.Get(ctx, key, sfc); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		changed := false
+		for _, nf := range sfc.Spec.NetworkFunctions {
+			nfWiring, ok := wiring.Members[nf.Name]
+			if !ok {
+				continue
+			}
+			if mergeWiringStatus(&sfc.Status, nf.Name, nfWiring) {
+				changed = true
+			}
+		}
+		if !reflect.DeepEqual(sfc.Status.Hops, wiring.Hops) {
+			sfc.Status.Hops = wiring.Hops
+			changed = true
+		}
+		if !changed {
+			return nil
+		}
+		return r.Status().Update(ctx, sfc)
+	})
+}
+
+func mergeWiringStatus(status *configv1.ServiceFunctionChainStatus, name string, wiring NetworkFunctionWiring) bool {
+	for i := range status.NetworkFunctions {
+		nfStatus := &status.NetworkFunctions[i]
+		if nfStatus.Name != name {
+			continue
+		}
+		if nfStatus.Wiring == wiring.Result &&
+			nfStatus.WiringMessage == wiring.Message &&
+			reflect.DeepEqual(nfStatus.BridgePorts, wiring.Ports) {
+			return false
+		}
+		nfStatus.Wiring = wiring.Result
+		nfStatus.WiringMessage = wiring.Message
+		nfStatus.BridgePorts = wiring.Ports
+		return true
+	}
+	status.NetworkFunctions = append(status.NetworkFunctions, configv1.NetworkFunctionStatus{
+		Name:          name,
+		BridgePorts:   wiring.Ports,
+		Wiring:        wiring.Result,
+		WiringMessage: wiring.Message,
+	})
+	return true
+}
+
 var uniqueCounter int64 = 0
 
 func (r *SfcReconciler) uniqueName() string {
@@ -218,6 +362,7 @@ func (r *SfcReconciler) uniqueName() string {
 func (r *SfcReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&configv1.ServiceFunctionChain{}).
+		Owns(&corev1.Pod{}).
 		Named(r.uniqueName()).
 		Complete(r)
 }
@@ -0,0 +1,13 @@
+package sfcreconciler_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSfcReconciler(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SfcReconciler Suite")
+}
@@ -0,0 +1,188 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// sideManagerRestartDelay is the delay before the first restart of a
+	// failed side manager. It doubles with every failure in a row.
+	sideManagerRestartDelay = time.Second
+	// sideManagerMaxRestartDelay caps the delay between two restarts.
+	sideManagerMaxRestartDelay = 5 * time.Minute
+	// sideManagerStableDuration is how long a side manager runs before its
+	// next failure is not counted in a row with the previous ones anymore.
+	sideManagerStableDuration = 10 * time.Minute
+)
+
+// errUnrecoverable marks side manager failures that restarting the side
+// manager does not fix, such as an invalid configuration. The supervisor
+// returns them to the daemon, which exits.
+var errUnrecoverable = errors.New("unrecoverable side manager failure")
+
+// unrecoverable marks err as a failure that restarting does not fix.
+func unrecoverable(err error) error {
+	return fmt.Errorf("%w: %w", errUnrecoverable, err)
+}
+
+// sideManagerSupervisor runs the side manager of a DPU and restarts it when
+// it fails, so that a failing DPU does not stop the side managers of the
+// other DPUs of the node. A side manager cannot be served again once it
+// stopped, so every restart creates a new one.
+type sideManagerSupervisor struct {
+	log    logr.Logger
+	create func() (SideManager, error)
+	run    func(ctx context.Context, mgr SideManager) error
+
+	restartDelay    time.Duration
+	maxRestartDelay time.Duration
+	stableDuration  time.Duration
+
+	mu          sync.Mutex
+	manager     SideManager
+	restarts    int32
+	lastErr     error
+	lastFailure time.Time
+}
+
+func newSideManagerSupervisor(log logr.Logger, mgr SideManager, create func() (SideManager, error), run func(context.Context, SideManager) error) *sideManagerSupervisor {
+	return &sideManagerSupervisor{
+		log:             log,
+		create:          create,
+		run:             run,
+		restartDelay:    sideManagerRestartDelay,
+		maxRestartDelay: sideManagerMaxRestartDelay,
+		stableDuration:  sideManagerStableDuration,
+		manager:         mgr,
+	}
+}
+
+// supervise runs the side manager until ctx is done. It restarts the side
+// manager with an exponential backoff when it fails, and only returns an
+// error when a new side manager cannot be created or when it fails in a way
+// restarting does not fix.
+func (s *sideManagerSupervisor) supervise(ctx context.Context) error {
+	failures := 0
+	mgr := s.current()
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		started := time.Now()
+		err := s.run(runCtx, mgr)
+		// Stop whatever the failed side manager left running.
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			err = errors.New("side manager stopped")
+		}
+		if errors.Is(err, errUnrecoverable) {
+			s.recordFailure(err)
+			return err
+		}
+
+		if time.Since(started) >= s.stableDuration {
+			failures = 0
+		}
+		failures++
+		delay := s.backoff(failures)
+		s.recordFailure(err)
+		s.log.Error(err, "Side manager failed, restarting it", "failures", failures, "delay", delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		mgr, err = s.create()
+		if err != nil {
+			return fmt.Errorf("failed to create a new side manager: %v", err)
+		}
+		s.setManager(mgr)
+	}
+}
+
+// backoff returns the delay before the restart that follows the given
+// number of failures in a row.
+func (s *sideManagerSupervisor) backoff(failures int) time.Duration {
+	delay := s.restartDelay
+	for i := 1; i < failures && delay < s.maxRestartDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, s.maxRestartDelay)
+}
+
+func (s *sideManagerSupervisor) recordFailure(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	s.lastFailure = time.Now()
+}
+
+func (s *sideManagerSupervisor) setManager(mgr SideManager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.manager = mgr
+	s.restarts++
+}
+
+// current returns the side manager that runs, or the last one that failed
+// while the next one is not created yet.
+func (s *sideManagerSupervisor) current() SideManager {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.manager
+}
+
+// status returns the failures of the side manager to report on the DPU, or
+// nil if it never failed. The failure time is truncated to the precision the
+// API server keeps.
+func (s *sideManagerSupervisor) status() *configv1.DpuManagerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastErr == nil {
+		return nil
+	}
+	lastFailure := metav1.NewTime(s.lastFailure.Truncate(time.Second))
+	return &configv1.DpuManagerStatus{
+		Restarts:        s.restarts,
+		LastError:       s.lastErr.Error(),
+		LastFailureTime: &lastFailure,
+	}
+}
+
+// observeSideManager points the managed DPU to the side manager its
+// supervisor runs, and reports the failures of the side manager.
+func observeSideManager(managed *ManagedDpu) {
+	if managed.Supervisor == nil {
+		return
+	}
+	managed.Manager = managed.Supervisor.current()
+	managed.DpuCR.Status.Manager = managed.Supervisor.status()
+}
+
+// managerNeedsUpdate compares the side manager failures reported on a DPU.
+// The failure time is compared as an instant, as it loses its location on
+// the API server.
+func managerNeedsUpdate(current, desired *configv1.DpuManagerStatus) bool {
+	if current == nil || desired == nil {
+		return current != desired
+	}
+	if current.LastFailureTime == nil || desired.LastFailureTime == nil {
+		if current.LastFailureTime != desired.LastFailureTime {
+			return true
+		}
+	} else if !current.LastFailureTime.Equal(desired.LastFailureTime) {
+		return true
+	}
+	return current.Restarts != desired.Restarts || current.LastError != desired.LastError
+}
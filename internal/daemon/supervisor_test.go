@@ -0,0 +1,251 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	"github.com/openshift/dpu-operator/internal/daemon/plugin"
+	"github.com/openshift/dpu-operator/internal/utils"
+	pb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// failingSideManagers creates side managers that fail until the given
+// number of them was run, then run until they are stopped.
+type failingSideManagers struct {
+	mu       sync.Mutex
+	failures int
+	runs     []SideManager
+}
+
+func (f *failingSideManagers) create() (SideManager, error) {
+	return &conditionsSideManager{}, nil
+}
+
+func (f *failingSideManagers) run(ctx context.Context, mgr SideManager) error {
+	f.mu.Lock()
+	f.runs = append(f.runs, mgr)
+	failing := len(f.runs) <= f.failures
+	f.mu.Unlock()
+	if failing {
+		return errors.New("VSP connection lost")
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (f *failingSideManagers) ran() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.runs)
+}
+
+// initOnceVsp is a VSP that refuses to be initialized twice, as the VSPs
+// do, and that fails to set the VFs up.
+type initOnceVsp struct {
+	pb.UnimplementedLifeCycleServiceServer
+	pb.UnimplementedDeviceServiceServer
+	mu          sync.Mutex
+	initialized bool
+	inits       int
+	setNumVfs   int
+}
+
+func (v *initOnceVsp) Init(ctx context.Context, in *pb.InitRequest) (*pb.IpPort, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.inits++
+	if v.initialized {
+		return nil, status.Error(codes.AlreadyExists, "VSP already initialized")
+	}
+	v.initialized = true
+	return &pb.IpPort{Ip: "127.0.0.1", Port: 50151}, nil
+}
+
+func (v *initOnceVsp) SetNumVfs(ctx context.Context, in *pb.VfCount) (*pb.VfCount, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.setNumVfs++
+	return nil, status.Error(codes.Unavailable, "PF link down")
+}
+
+func (v *initOnceVsp) counts() (int, int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.inits, v.setNumVfs
+}
+
+// serveInitOnceVsp serves vsp on the vendor plugin socket of pathManager
+// until the spec ends.
+func serveInitOnceVsp(vsp *initOnceVsp, pathManager *utils.PathManager) {
+	Expect(pathManager.EnsureSocketDirExists(pathManager.VendorPluginSocket())).To(Succeed())
+	listener, err := net.Listen("unix", pathManager.VendorPluginSocket())
+	Expect(err).NotTo(HaveOccurred())
+	server := grpc.NewServer()
+	pb.RegisterLifeCycleServiceServer(server, vsp)
+	pb.RegisterDeviceServiceServer(server, vsp)
+	go server.Serve(listener)
+	g.DeferCleanup(server.Stop)
+}
+
+func newTestSupervisor(managers *failingSideManagers) *sideManagerSupervisor {
+	s := newSideManagerSupervisor(ctrl.Log.WithName("Supervisor"), &conditionsSideManager{}, managers.create, managers.run)
+	s.restartDelay = time.Millisecond
+	s.maxRestartDelay = 4 * time.Millisecond
+	return s
+}
+
+var _ = g.Describe("Side manager supervisor", func() {
+	g.It("restarts a failing side manager with a new one and reports its failures", func() {
+		managers := &failingSideManagers{failures: 3}
+		s := newTestSupervisor(managers)
+		first := s.current()
+		Expect(s.status()).To(BeNil())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		finished := make(chan error)
+		go func() { finished <- s.supervise(ctx) }()
+
+		Eventually(managers.ran).Should(Equal(4))
+		Consistently(managers.ran, 20*time.Millisecond).Should(Equal(4))
+		Expect(s.current()).NotTo(BeIdenticalTo(first))
+		status := s.status()
+		Expect(status.Restarts).To(Equal(int32(3)))
+		Expect(status.LastError).To(Equal("VSP connection lost"))
+		Expect(status.LastFailureTime).NotTo(BeNil())
+
+		managed := &ManagedDpu{DpuCR: &configv1.DataProcessingUnit{}, Supervisor: s}
+		observeSideManager(managed)
+		Expect(managed.Manager).To(BeIdenticalTo(s.current()))
+		Expect(managed.DpuCR.Status.Manager).To(Equal(status))
+
+		cancel()
+		Eventually(finished).Should(Receive(BeNil()))
+	})
+
+	g.It("stops the daemon when it cannot create a new side manager", func() {
+		managers := &failingSideManagers{failures: 1}
+		s := newTestSupervisor(managers)
+		s.create = func() (SideManager, error) { return nil, errors.New("no rest config") }
+
+		err := s.supervise(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("no rest config")))
+	})
+
+	g.It("stops the daemon when the side manager fails in a way restarting does not fix", func() {
+		managers := &failingSideManagers{failures: 1}
+		s := newTestSupervisor(managers)
+		s.run = func(ctx context.Context, mgr SideManager) error {
+			return unrecoverable(managers.run(ctx, mgr))
+		}
+
+		err := s.supervise(context.Background())
+		Expect(err).To(MatchError(errUnrecoverable))
+		Expect(err).To(MatchError(ContainSubstring("VSP connection lost")))
+		Expect(managers.ran()).To(Equal(1))
+		Expect(s.status().LastError).To(ContainSubstring("VSP connection lost"))
+	})
+
+	g.Context("with the side managers of the daemon", func() {
+		var (
+			d           *Daemon
+			vsp         *initOnceVsp
+			dpuPlugin   *plugin.GrpcPlugin
+			pathManager *utils.PathManager
+		)
+
+		g.BeforeEach(func() {
+			d = &Daemon{log: ctrl.Log.WithName("Daemon")}
+			vsp = &initOnceVsp{}
+			pathManager = utils.NewPathManager(g.GinkgoT().TempDir())
+			serveInitOnceVsp(vsp, pathManager)
+			var err error
+			dpuPlugin, err = plugin.NewGrpcPlugin(false, "bf3-0", nil, plugin.WithPathManager(*pathManager))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		createHostSideManager := func() (SideManager, error) {
+			return NewHostSideManager(dpuPlugin, WithPathManager2(pathManager), WithClient(&rest.Config{}))
+		}
+
+		g.It("restarts a side manager around a VSP that refuses a second Init", func() {
+			first, err := createHostSideManager()
+			Expect(err).NotTo(HaveOccurred())
+			s := newSideManagerSupervisor(ctrl.Log.WithName("Supervisor"), first, createHostSideManager,
+				func(ctx context.Context, mgr SideManager) error {
+					return d.runSideManager(mgr, "bf3-0", ctx)
+				})
+			s.restartDelay = time.Millisecond
+			s.maxRestartDelay = 4 * time.Millisecond
+
+			ctx, cancel := context.WithCancel(context.Background())
+			finished := make(chan error)
+			go func() { finished <- s.supervise(ctx) }()
+
+			// Every run gets past the VSP start to the device setup.
+			Eventually(func() int {
+				_, setNumVfs := vsp.counts()
+				return setNumVfs
+			}).Should(BeNumerically(">=", 3))
+			cancel()
+			Eventually(finished).Should(Receive(BeNil()))
+
+			inits, _ := vsp.counts()
+			Expect(inits).To(BeNumerically(">=", 3))
+			Expect(s.status().LastError).To(ContainSubstring("PF link down"))
+			restarted := s.current().(*HostSideManager)
+			Expect(restarted).NotTo(BeIdenticalTo(first))
+			Expect(restarted.addr).To(Equal("127.0.0.1"))
+			Expect(restarted.port).To(Equal(int32(50151)))
+		})
+
+		g.It("stops the daemon when the VSP was initialized before it started", func() {
+			vsp.initialized = true
+			first, err := createHostSideManager()
+			Expect(err).NotTo(HaveOccurred())
+			s := newSideManagerSupervisor(ctrl.Log.WithName("Supervisor"), first, createHostSideManager,
+				func(ctx context.Context, mgr SideManager) error {
+					return d.runSideManager(mgr, "bf3-0", ctx)
+				})
+
+			err = s.supervise(context.Background())
+			Expect(err).To(MatchError(errUnrecoverable))
+			Expect(err).To(MatchError(plugin.ErrVspAlreadyInitialized))
+			_, setNumVfs := vsp.counts()
+			Expect(setNumVfs).To(BeZero())
+		})
+	})
+
+	g.It("doubles the restart delay up to its maximum", func() {
+		s := newSideManagerSupervisor(ctrl.Log.WithName("Supervisor"), nil, nil, nil)
+		Expect(s.backoff(1)).To(Equal(time.Second))
+		Expect(s.backoff(2)).To(Equal(2 * time.Second))
+		Expect(s.backoff(4)).To(Equal(8 * time.Second))
+		Expect(s.backoff(100)).To(Equal(sideManagerMaxRestartDelay))
+	})
+
+	g.It("compares the failure times reported on a DPU as instants", func() {
+		failed := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		current := &configv1.DpuManagerStatus{Restarts: 1, LastError: "failed", LastFailureTime: &metav1.Time{Time: failed}}
+		desired := current.DeepCopy()
+		desired.LastFailureTime = &metav1.Time{Time: failed.In(time.FixedZone("CET", 3600))}
+		Expect(managerNeedsUpdate(current, desired)).To(BeFalse())
+
+		desired.Restarts = 2
+		Expect(managerNeedsUpdate(current, desired)).To(BeTrue())
+		Expect(managerNeedsUpdate(nil, desired)).To(BeTrue())
+		Expect(managerNeedsUpdate(nil, nil)).To(BeFalse())
+	})
+})
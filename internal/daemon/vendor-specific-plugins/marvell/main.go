@@ -643,6 +643,46 @@ func (vsp *mrvlVspServer) DeleteNetworkFunctionPort(inpDpInterfaceName string, o
 	return out, nil
 }
 
+// ListNetworkFunctions function to list the network functions with the given context and Empty
+// The ports of the network function are reported as the MAC addresses they were created with
+func (vsp *mrvlVspServer) ListNetworkFunctions(ctx context.Context, in *nfapi.Empty) (*nfapi.NFList, error) {
+	klog.Info("Received ListNetworkFunctions() request")
+	out := new(nfapi.NFList)
+	network, exists := vsp.networkStore[NfName]
+	if !vsp.isNF || !exists {
+		return out, nil
+	}
+	input := vsp.deviceByDpInterfaceName(network.inpPort)
+	output := vsp.deviceByDpInterfaceName(network.outPort)
+	if input == "" || output == "" {
+		klog.Infof("Network function ports %s and %s are not in the device store", network.inpPort, network.outPort)
+		return out, nil
+	}
+	out.NetworkFunctions = append(out.NetworkFunctions, &nfapi.NFRequest{Input: input, Output: output})
+	return out, nil
+}
+
+// deviceByDpInterfaceName returns the key of the device with the given data plane interface name
+// It will return an empty string if there is no such device
+func (vsp *mrvlVspServer) deviceByDpInterfaceName(dpInterfaceName string) string {
+	if dpInterfaceName == "" {
+		return ""
+	}
+	for key, device := range vsp.deviceStore {
+		if device.dpInterfaceName == dpInterfaceName {
+			return key
+		}
+	}
+	return ""
+}
+
+// GetCapabilities function to report the network function capabilities with the given context and Empty
+// The data plane keeps a single network function, so network functions cannot be chained
+func (vsp *mrvlVspServer) GetCapabilities(ctx context.Context, in *nfapi.Empty) (*nfapi.NFCapabilities, error) {
+	klog.Info("Received GetCapabilities() request")
+	return &nfapi.NFCapabilities{Chaining: false}, nil
+}
+
 // GetDevices function to get all the devices with the given context and Empty
 // It will return the DeviceListResponse and error
 func (vsp *mrvlVspServer) GetDevices(ctx context.Context, in *emptypb.Empty) (*pb.DeviceListResponse, error) {
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
+)
+
+var _ = Describe("ListNetworkFunctions", func() {
+	var vsp *mrvlVspServer
+
+	list := func() []*nfapi.NFRequest {
+		out, err := vsp.ListNetworkFunctions(context.Background(), &nfapi.Empty{})
+		Expect(err).NotTo(HaveOccurred())
+		return out.GetNetworkFunctions()
+	}
+
+	BeforeEach(func() {
+		vsp = &mrvlVspServer{
+			deviceStore: map[string]mrvlDeviceInfo{
+				"aa:bb:cc:dd:ee:01": {secInterfaceName: "nf_interface0", dpInterfaceName: "dp_interface0"},
+				"aa:bb:cc:dd:ee:02": {secInterfaceName: "nf_interface1", dpInterfaceName: "dp_interface1"},
+			},
+			networkStore: map[string]mrvlNfPortMap{
+				NfName: {vfPort: []vfInfo{{vfName: "sdp1", mac: "aa:bb:cc:dd:ee:10"}}},
+			},
+		}
+	})
+
+	It("lists nothing before a network function is created", func() {
+		Expect(list()).To(BeEmpty())
+	})
+
+	It("lists the network function by the MAC addresses of its ports", func() {
+		vsp.isNF = true
+		vsp.networkStore[NfName] = mrvlNfPortMap{inpPort: "dp_interface0", outPort: "dp_interface1"}
+
+		nfs := list()
+		Expect(nfs).To(HaveLen(1))
+		Expect(nfs[0].GetInput()).To(Equal("aa:bb:cc:dd:ee:01"))
+		Expect(nfs[0].GetOutput()).To(Equal("aa:bb:cc:dd:ee:02"))
+
+		vsp.isNF = false
+		Expect(list()).To(BeEmpty(), "a deleted network function is not listed")
+	})
+})
@@ -27,6 +27,10 @@ type vspServer struct {
 	done        chan error
 	grpcServer  *grpc.Server
 	pathManager utils.PathManager
+	nfMutex     sync.Mutex
+	// nfs holds the network functions created and not yet deleted, by input
+	// and output port, so that they can be listed.
+	nfs map[[2]string]*nfapi.NFRequest
 }
 
 func (vsp *vspServer) Init(ctx context.Context, in *pb.InitRequest) (*pb.IpPort, error) {
@@ -62,12 +66,34 @@ func (vsp *vspServer) DeleteBridgePort(ctx context.Context, in *opi.DeleteBridge
 
 func (vsp *vspServer) CreateNetworkFunction(ctx context.Context, in *nfapi.NFRequest) (*nfapi.Empty, error) {
 	vsp.log.Info("Received CreateNetworkFunction() request", "Input", in.Input, "Output", in.Output)
-	return nil, nil
+	vsp.nfMutex.Lock()
+	defer vsp.nfMutex.Unlock()
+	vsp.nfs[[2]string{in.Input, in.Output}] = &nfapi.NFRequest{Input: in.Input, Output: in.Output}
+	return &nfapi.Empty{}, nil
 }
 
 func (vsp *vspServer) DeleteNetworkFunction(ctx context.Context, in *nfapi.NFRequest) (*nfapi.Empty, error) {
 	vsp.log.Info("Received DeleteNetworkFunction() request", "Input", in.Input, "Output", in.Output)
-	return nil, nil
+	vsp.nfMutex.Lock()
+	defer vsp.nfMutex.Unlock()
+	delete(vsp.nfs, [2]string{in.Input, in.Output})
+	return &nfapi.Empty{}, nil
+}
+
+func (vsp *vspServer) ListNetworkFunctions(ctx context.Context, in *nfapi.Empty) (*nfapi.NFList, error) {
+	vsp.log.Info("Received ListNetworkFunctions() request")
+	vsp.nfMutex.Lock()
+	defer vsp.nfMutex.Unlock()
+	out := &nfapi.NFList{}
+	for _, nf := range vsp.nfs {
+		out.NetworkFunctions = append(out.NetworkFunctions, nf)
+	}
+	return out, nil
+}
+
+func (vsp *vspServer) GetCapabilities(ctx context.Context, in *nfapi.Empty) (*nfapi.NFCapabilities, error) {
+	vsp.log.Info("Received GetCapabilities() request")
+	return &nfapi.NFCapabilities{Chaining: true}, nil
 }
 
 func (vsp *vspServer) Listen() (net.Listener, error) {
@@ -143,6 +169,7 @@ func NewMockVsp(opts ...func(*vspServer)) *vspServer {
 		log:         ctrl.Log.WithName("MockVsp"),
 		pathManager: *utils.NewPathManager("/"),
 		done:        make(chan error, 1),
+		nfs:         make(map[[2]string]*nfapi.NFRequest),
 	}
 
 	for _, opt := range opts {
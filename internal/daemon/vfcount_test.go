@@ -0,0 +1,44 @@
+package daemon
+
+import (
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+)
+
+var _ = g.Describe("desiredVfCount", func() {
+	vfCount := func(v int32) *int32 { return &v }
+
+	g.It("returns the VF count of the effective config and its source", func() {
+		desired, source, ok, err := desiredVfCount(&configv1.DpuEffectiveConfig{
+			VfCount:       vfCount(8),
+			VfCountSource: "team-a/vfs",
+			Sources:       []string{"team-a/vfs", "team-b/vfs"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(desired).To(Equal(int32(8)))
+		Expect(source).To(Equal("team-a/vfs"))
+	})
+
+	g.It("requests nothing without a VF count", func() {
+		_, _, ok, err := desiredVfCount(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		_, _, ok, err = desiredVfCount(&configv1.DpuEffectiveConfig{Sources: []string{"team-a/other"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+
+	g.It("rejects an invalid VF count", func() {
+		_, source, ok, err := desiredVfCount(&configv1.DpuEffectiveConfig{
+			VfCount:       vfCount(-1),
+			VfCountSource: "team-a/vfs",
+		})
+		Expect(ok).To(BeFalse())
+		Expect(err).To(MatchError(ContainSubstring("vfCount must be > 0")))
+		Expect(source).To(Equal("team-a/vfs"))
+	})
+})
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	pb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// vfCountRolloutTimeout bounds the drain and the VF count changes on a node.
+	vfCountRolloutTimeout = 30 * time.Minute
+	// vfCountUncordonTimeout bounds the uncordon, which runs even after the
+	// rollout timed out.
+	vfCountUncordonTimeout = time.Minute
+	// vfCountDrainPollInterval is how often a drain that did not complete is retried.
+	vfCountDrainPollInterval = 10 * time.Second
+	// vfCountRetryInterval is how long the daemon waits before it drains the
+	// node again for a VF count that failed to apply.
+	vfCountRetryInterval = 5 * time.Minute
+)
+
+// nodeDrainer cordons, drains and uncordons a node. It is implemented by drain.Drainer.
+type nodeDrainer interface {
+	DrainNode(ctx context.Context, node *corev1.Node, fullNodeDrain bool) (bool, error)
+	CompleteDrainNode(ctx context.Context, node *corev1.Node) (bool, error)
+}
+
+// vfCountSetter sets the VF count of a DPU. It is implemented by plugin.GrpcPlugin.
+type vfCountSetter interface {
+	SetNumVfs(count int32) (*pb.VfCount, error)
+}
+
+// vfCountReader reads the VF count of a DPU. It is implemented by plugin.GrpcPlugin.
+type vfCountReader interface {
+	GetNumVfs() (int32, error)
+}
+
+// vfCountChange is a VF count to set on a DPU of the node.
+type vfCountChange struct {
+	dpu     string
+	setter  vfCountSetter
+	vfCount int32
+	err     error
+}
+
+// vfCountFailure is a VF count that failed to apply on a drained node.
+type vfCountFailure struct {
+	vfCount int32
+	err     string
+	at      time.Time
+}
+
+// vfCountRollout sets VF counts on the node of the daemon between a drain and
+// an uncordon of the node. It runs in the background, as a drain takes
+// minutes, while the daemon loop reports its phase on the DPUs.
+type vfCountRollout struct {
+	changes []*vfCountChange
+
+	mu    sync.Mutex
+	phase configv1.VfCountPhase
+	done  bool
+}
+
+func newVfCountRollout(changes []*vfCountChange) *vfCountRollout {
+	return &vfCountRollout{changes: changes, phase: configv1.VfCountPhaseDraining}
+}
+
+func (r *vfCountRollout) setPhase(phase configv1.VfCountPhase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = phase
+}
+
+// state returns the current phase and whether the rollout is done. The
+// changes must only be read once it is done.
+func (r *vfCountRollout) state() (configv1.VfCountPhase, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.phase, r.done
+}
+
+func (r *vfCountRollout) includes(dpu string) bool {
+	for _, change := range r.changes {
+		if change.dpu == dpu {
+			return true
+		}
+	}
+	return false
+}
+
+// run drains the node, sets the VF counts, verifies them against the counts
+// the plugins report and uncordons the node. The node is uncordoned even when
+// the drain or a change fails.
+func (r *vfCountRollout) run(ctx context.Context, drainer nodeDrainer, node *corev1.Node) {
+	defer r.finish()
+
+	r.setPhase(configv1.VfCountPhaseDraining)
+	if err := drainNode(ctx, drainer, node); err != nil {
+		r.fail(err)
+	} else {
+		r.setPhase(configv1.VfCountPhaseApplying)
+		reported := make([]int32, len(r.changes))
+		for i, change := range r.changes {
+			vfCount, err := change.setter.SetNumVfs(change.vfCount)
+			if err != nil {
+				change.err = fmt.Errorf("failed to apply VF count %d: %v", change.vfCount, err)
+				continue
+			}
+			reported[i] = vfCount.GetVfCnt()
+		}
+
+		r.setPhase(configv1.VfCountPhaseVerifying)
+		for i, change := range r.changes {
+			if change.err == nil && reported[i] != change.vfCount {
+				change.err = fmt.Errorf("plugin reports %d VFs after applying VF count %d", reported[i], change.vfCount)
+			}
+		}
+	}
+
+	r.setPhase(configv1.VfCountPhaseUncordoning)
+	uncordonCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), vfCountUncordonTimeout)
+	defer cancel()
+	if _, err := drainer.CompleteDrainNode(uncordonCtx, node); err != nil {
+		r.fail(fmt.Errorf("failed to uncordon node %s: %v", node.Name, err))
+	}
+}
+
+func (r *vfCountRollout) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phase = ""
+	r.done = true
+}
+
+// fail records the error on the changes that did not fail yet.
+func (r *vfCountRollout) fail(err error) {
+	for _, change := range r.changes {
+		if change.err == nil {
+			change.err = err
+		}
+	}
+}
+
+// drainNode drains the node, retrying while the drainer reports the drain
+// is not complete, e.g. while the machine config pool is not paused yet.
+func drainNode(ctx context.Context, drainer nodeDrainer, node *corev1.Node) error {
+	for {
+		done, err := drainer.DrainNode(ctx, node, false)
+		if err != nil {
+			return fmt.Errorf("failed to drain node %s: %v", node.Name, err)
+		}
+		if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to drain node %s: %v", node.Name, ctx.Err())
+		case <-time.After(vfCountDrainPollInterval):
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	configv1 "github.com/openshift/dpu-operator/api/v1"
+	pb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// fakeDrainer records the drain steps of a node.
+type fakeDrainer struct {
+	steps    []string
+	drainErr error
+}
+
+func (f *fakeDrainer) DrainNode(_ context.Context, node *corev1.Node, _ bool) (bool, error) {
+	f.steps = append(f.steps, "drain "+node.Name)
+	return f.drainErr == nil, f.drainErr
+}
+
+func (f *fakeDrainer) CompleteDrainNode(_ context.Context, node *corev1.Node) (bool, error) {
+	f.steps = append(f.steps, "uncordon "+node.Name)
+	return true, nil
+}
+
+// fakeVfCountSetter reports the VF count it is set to, or a fixed count.
+type fakeVfCountSetter struct {
+	drainer  *fakeDrainer
+	reported *int32
+}
+
+func (f *fakeVfCountSetter) SetNumVfs(count int32) (*pb.VfCount, error) {
+	f.drainer.steps = append(f.drainer.steps, "apply")
+	if f.reported != nil {
+		return &pb.VfCount{VfCnt: *f.reported}, nil
+	}
+	return &pb.VfCount{VfCnt: count}, nil
+}
+
+// fakeVfCountReader reports a fixed VF count or an error.
+type fakeVfCountReader struct {
+	vfCount int32
+	err     error
+}
+
+func (f *fakeVfCountReader) GetNumVfs() (int32, error) {
+	return f.vfCount, f.err
+}
+
+var _ = g.Describe("VF count rollout", func() {
+	var (
+		drainer *fakeDrainer
+		node    *corev1.Node
+	)
+
+	g.BeforeEach(func() {
+		drainer = &fakeDrainer{}
+		node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}}
+	})
+
+	g.It("drains the node, applies and verifies the VF count and uncordons the node", func() {
+		change := &vfCountChange{dpu: "bf3-0", setter: &fakeVfCountSetter{drainer: drainer}, vfCount: 8}
+		rollout := newVfCountRollout([]*vfCountChange{change})
+
+		rollout.run(context.Background(), drainer, node)
+
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "apply", "uncordon worker-1"}))
+		Expect(change.err).NotTo(HaveOccurred())
+		phase, done := rollout.state()
+		Expect(done).To(BeTrue())
+		Expect(phase).To(BeEmpty())
+	})
+
+	g.It("uncordons the node when the drain fails", func() {
+		drainer.drainErr = errors.New("eviction blocked by PodDisruptionBudget")
+		change := &vfCountChange{dpu: "bf3-0", setter: &fakeVfCountSetter{drainer: drainer}, vfCount: 8}
+
+		newVfCountRollout([]*vfCountChange{change}).run(context.Background(), drainer, node)
+
+		Expect(drainer.steps).To(Equal([]string{"drain worker-1", "uncordon worker-1"}))
+		Expect(change.err).To(MatchError(ContainSubstring("failed to drain node worker-1")))
+	})
+
+	g.It("fails a VF count the plugin does not report back", func() {
+		reported := int32(4)
+		change := &vfCountChange{dpu: "bf3-0", setter: &fakeVfCountSetter{drainer: drainer, reported: &reported}, vfCount: 8}
+
+		newVfCountRollout([]*vfCountChange{change}).run(context.Background(), drainer, node)
+
+		Expect(change.err).To(MatchError("plugin reports 4 VFs after applying VF count 8"))
+	})
+
+	g.It("records the results of a finished rollout on the managed DPUs", func() {
+		now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+		applied := &vfCountChange{dpu: "bf3-0", vfCount: 8}
+		failed := &vfCountChange{dpu: "bf3-1", vfCount: 8, err: errors.New("failed to drain node worker-1")}
+		rollout := newVfCountRollout([]*vfCountChange{applied, failed})
+
+		d := &Daemon{
+			log: ctrl.Log.WithName("Daemon"),
+			managedDpus: map[string]*ManagedDpu{
+				"bf3-0": {DpuCR: &configv1.DataProcessingUnit{}},
+				"bf3-1": {DpuCR: &configv1.DataProcessingUnit{}},
+			},
+			vfCountRollout: rollout,
+		}
+
+		d.collectVfCountRollout(now)
+		Expect(d.vfCountRollout).To(Equal(rollout), "the rollout is still running")
+
+		rollout.finish()
+		d.collectVfCountRollout(now)
+		Expect(d.vfCountRollout).To(BeNil())
+		Expect(*d.managedDpus["bf3-0"].AppliedVfCount).To(Equal(int32(8)))
+		Expect(d.managedDpus["bf3-1"].AppliedVfCount).To(BeNil())
+		Expect(d.managedDpus["bf3-1"].VfCountFailure.err).To(ContainSubstring("failed to drain node worker-1"))
+		Expect(d.managedDpus["bf3-1"].VfCountFailure.at).To(Equal(now))
+	})
+
+	g.Context("seeding the applied VF count", func() {
+		var (
+			d       *Daemon
+			managed *ManagedDpu
+			current *configv1.DataProcessingUnit
+		)
+
+		g.BeforeEach(func() {
+			d = &Daemon{log: ctrl.Log.WithName("Daemon")}
+			managed = &ManagedDpu{DpuCR: &configv1.DataProcessingUnit{}}
+			recorded := int32(4)
+			current = &configv1.DataProcessingUnit{Status: configv1.DataProcessingUnitStatus{
+				VfCount: &configv1.VfCountStatus{Applied: &recorded},
+			}}
+		})
+
+		g.It("prefers the VF count the plugin reports", func() {
+			d.seedAppliedVfCount("bf3-0", managed, &fakeVfCountReader{vfCount: 8}, current)
+			Expect(*managed.AppliedVfCount).To(Equal(int32(8)))
+		})
+
+		g.It("falls back to the VF count recorded in the DPU status", func() {
+			d.seedAppliedVfCount("bf3-0", managed, &fakeVfCountReader{err: errors.New("unavailable")}, current)
+			Expect(*managed.AppliedVfCount).To(Equal(int32(4)))
+		})
+
+		g.It("leaves an unknown VF count unset", func() {
+			current.Status.VfCount = nil
+			d.seedAppliedVfCount("bf3-0", managed, &fakeVfCountReader{err: errors.New("unavailable")}, current)
+			Expect(managed.AppliedVfCount).To(BeNil())
+		})
+
+		g.It("keeps the VF count the daemon applied", func() {
+			applied := int32(2)
+			managed.AppliedVfCount = &applied
+			d.seedAppliedVfCount("bf3-0", managed, &fakeVfCountReader{vfCount: 8}, current)
+			Expect(*managed.AppliedVfCount).To(Equal(int32(2)))
+		})
+	})
+})
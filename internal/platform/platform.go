@@ -1,6 +1,7 @@
 package platform
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -21,6 +22,10 @@ type Platform interface {
 	GetNetDevNamesFromPCIeAddr(pcieAddress string) ([]string, error)
 	GetNetDevNameFromPCIeAddr(pcieAddress string) (string, error)
 	GetNetDevMACAddressFromPCIeAddr(pcieAddress string) (string, error)
+	// WatchPciDevices notifies the returned channel when the PCI devices or
+	// their drivers change. The channel is closed once ctx is done or the
+	// watch fails.
+	WatchPciDevices(ctx context.Context) (<-chan struct{}, error)
 }
 
 type HardwarePlatform struct{}
@@ -174,12 +179,15 @@ type FakePlatform struct {
 	devices      []*ghw.PCIDevice
 	netdevs      []*ghw.NIC
 	mu           sync.Mutex
+	// pciEvents is notified when the fake PCI devices change.
+	pciEvents chan struct{}
 }
 
 func NewFakePlatform(platformName string) *FakePlatform {
 	return &FakePlatform{
 		platformName: platformName,
 		devices:      make([]*ghw.PCIDevice, 0),
+		pciEvents:    make(chan struct{}, 1),
 	}
 }
 
@@ -219,6 +227,7 @@ func (p *FakePlatform) RemoveAllPciDevices() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.devices = make([]*ghw.PCIDevice, 0)
+	notify(p.pciEvents)
 }
 
 func (p *FakePlatform) GetNetDevNamesFromPCIeAddr(pcieAddress string) ([]string, error) {
@@ -237,4 +246,23 @@ func (p *FakePlatform) AddPciDevice(dev *ghw.PCIDevice) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.devices = append(p.devices, dev)
+	notify(p.pciEvents)
+}
+
+// WatchPciDevices notifies the returned channel when fake PCI devices are
+// added or removed. The fake supports a single watch.
+func (p *FakePlatform) WatchPciDevices(ctx context.Context) (<-chan struct{}, error) {
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-p.pciEvents:
+				notify(events)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
 }
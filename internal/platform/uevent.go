@@ -0,0 +1,138 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ueventKernelGroup is the netlink multicast group of the uevents sent by
+	// the kernel, as opposed to the ones udevd sends once it processed them.
+	ueventKernelGroup = 1
+	// ueventBufferSize is the receive buffer of the uevent socket.
+	ueventBufferSize = 1024 * 1024
+	// ueventReadTimeout bounds a single read of the uevent socket, so that
+	// the watch notices its context is done.
+	ueventReadTimeout = time.Second
+)
+
+// pciUeventActions are the uevent actions that add or remove a PCI device, or
+// bind or unbind its driver.
+var pciUeventActions = map[string]bool{
+	"add":    true,
+	"remove": true,
+	"bind":   true,
+	"unbind": true,
+}
+
+// uevent is a kernel uevent.
+type uevent struct {
+	action    string
+	devpath   string
+	subsystem string
+}
+
+// parseUevent parses a kernel uevent message: an "action@devpath" header
+// followed by NUL separated KEY=value pairs.
+func parseUevent(msg []byte) (*uevent, error) {
+	fields := bytes.Split(msg, []byte{0})
+	action, devpath, ok := strings.Cut(string(fields[0]), "@")
+	if !ok {
+		return nil, fmt.Errorf("invalid uevent header %q", fields[0])
+	}
+	event := &uevent{action: action, devpath: devpath}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ACTION":
+			event.action = value
+		case "DEVPATH":
+			event.devpath = value
+		case "SUBSYSTEM":
+			event.subsystem = value
+		}
+	}
+	return event, nil
+}
+
+// changesPciDevices returns whether the uevent adds or removes a PCI device,
+// or binds or unbinds its driver.
+func (e *uevent) changesPciDevices() bool {
+	return e.subsystem == "pci" && pciUeventActions[e.action]
+}
+
+// WatchPciDevices listens to the kernel uevents and notifies the returned
+// channel when a PCI device is added or removed, or its driver bound or
+// unbound. Notifications are coalesced: a notification may stand for several
+// uevents. The channel is closed when ctx is done or the socket fails. The
+// kernel only sends the uevents of PCI devices to the initial network
+// namespace, so the daemon must run with the network of the host.
+func (hp *HardwarePlatform) WatchPciDevices(ctx context.Context) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the uevent socket: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: ueventKernelGroup}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to bind the uevent socket: %w", err)
+	}
+	// A failure to grow the buffer only makes dropped uevents more likely,
+	// which are reported as a change anyway.
+	_ = syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, ueventBufferSize)
+	timeout := syscall.NsecToTimeval(ueventReadTimeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("failed to set the uevent socket timeout: %w", err)
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		defer syscall.Close(fd)
+
+		buf := make([]byte, 64*1024)
+		for ctx.Err() == nil {
+			n, _, err := syscall.Recvfrom(fd, buf, 0)
+			switch {
+			case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+				continue
+			case errors.Is(err, syscall.ENOBUFS):
+				// Uevents were dropped, any of them may have changed the PCI devices.
+				klog.V(2).Info("WatchPciDevices(): uevents dropped")
+				notify(events)
+				continue
+			case err != nil:
+				klog.Errorf("WatchPciDevices(): failed to read uevents: %v", err)
+				return
+			}
+			event, err := parseUevent(buf[:n])
+			if err != nil {
+				klog.V(2).Infof("WatchPciDevices(): ignoring uevent: %v", err)
+				continue
+			}
+			if event.changesPciDevices() {
+				klog.V(2).Infof("WatchPciDevices(): PCI uevent %s %s", event.action, event.devpath)
+				notify(events)
+			}
+		}
+	}()
+	return events, nil
+}
+
+// notify sends a notification unless one is already pending.
+func notify(events chan struct{}) {
+	select {
+	case events <- struct{}{}:
+	default:
+	}
+}
@@ -1,6 +1,8 @@
 package platform
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	stderrors "errors"
 	"fmt"
 	"os"
@@ -13,7 +15,9 @@ import (
 	"github.com/openshift/dpu-operator/internal/images"
 	"github.com/openshift/dpu-operator/internal/utils"
 	pkgplugin "github.com/openshift/dpu-operator/pkg/plugin"
+	"github.com/openshift/dpu-operator/pkgs/vars"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kind/pkg/errors"
 )
@@ -90,6 +94,18 @@ func SanitizeForK8sName(name string) string {
 	return out
 }
 
+// DpuNodeLabelValue returns the value of the vars.DpuNodeLabel of the DPUs of
+// a node. Node names can be longer than a label value, so longer ones are
+// shortened and kept unique with a hash of the full name.
+func DpuNodeLabelValue(nodeName string) string {
+	if len(nodeName) <= validation.LabelValueMaxLength {
+		return nodeName
+	}
+	sum := sha256.Sum256([]byte(nodeName))
+	hash := hex.EncodeToString(sum[:8])
+	return nodeName[:validation.LabelValueMaxLength-len(hash)-1] + "-" + hash
+}
+
 func NewDpuDetectorManager(platform Platform) *DpuDetectorManager {
 	return &DpuDetectorManager{
 		platform: platform,
@@ -200,7 +216,7 @@ func (d *DpuDetectorManager) defaultDpuLabels(detector VendorDetector, nodeName
 		"dpu.config.openshift.io/side":    side,
 		"dpu.config.openshift.io/vendor":  vendor,
 		"dpu.config.openshift.io/product": product,
-		"dpu.config.openshift.io/node":    nodeName,
+		vars.DpuNodeLabel:                 DpuNodeLabelValue(nodeName),
 	}
 }
 
@@ -326,7 +342,7 @@ func (d *DpuDetectorManager) attachRegistryPlugin(detector VendorDetector, vsp *
 	if registryPlugin == nil {
 		return
 	}
-	vsp.AttachRegistryPlugin(registryPlugin, pluginConfigFromEnv(vendor))
+	vsp.AttachRegistryPlugin(registryPlugin, PluginConfigFromEnv(vendor))
 }
 
 func (d *DpuDetectorManager) findRegistryPluginByVendor(vendor string) pkgplugin.Plugin {
@@ -339,7 +355,11 @@ func (d *DpuDetectorManager) findRegistryPluginByVendor(vendor string) pkgplugin
 	return nil
 }
 
-func pluginConfigFromEnv(vendor string) pkgplugin.PluginConfig {
+// PluginConfigFromEnv returns the registry plugin configuration for vendor.
+// The OPI endpoints can be overridden per vendor with the
+// DPU_PLUGIN_OPI_ENDPOINT_<VENDOR> and DPU_PLUGIN_OPI_NETWORK_ENDPOINT_<VENDOR>
+// environment variables.
+func PluginConfigFromEnv(vendor string) pkgplugin.PluginConfig {
 	endpoint := os.Getenv("DPU_PLUGIN_OPI_ENDPOINT")
 	networkEndpoint := os.Getenv("DPU_PLUGIN_OPI_NETWORK_ENDPOINT")
 
@@ -59,6 +59,12 @@ func (p *PathManager) VendorPluginSocket() string {
 	return p.wrap("/var/run/dpu-daemon/vendor-plugin/vendor-plugin.sock")
 }
 
+// NetworkFunctionStatePath is where the DPU daemon keeps the wiring of the
+// network functions across restarts.
+func (p *PathManager) NetworkFunctionStatePath() string {
+	return p.wrap("/var/run/dpu-daemon/nf-state.json")
+}
+
 func (p *PathManager) wrap(path string) string {
 	return filepath.Join(p.rootDir, path)
 }
@@ -35,6 +35,9 @@ const (
 	labelController = "controller"
 	labelResult     = "result"
 	labelCapability = "capability"
+	labelNamespace  = "namespace"
+	labelTunnel     = "tunnel"
+	labelDirection  = "direction"
 )
 
 var (
@@ -159,6 +162,46 @@ var (
 		},
 		[]string{labelVendor, "operation", labelResult},
 	)
+
+	// SecurityOperations tracks security operations performed
+	SecurityOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "security_operations_total",
+			Help:      "Total number of security operations performed",
+		},
+		[]string{labelVendor, "operation", labelResult},
+	)
+
+	// IPsecTunnelBytes mirrors the bytes processed by offloaded IPsec tunnels
+	IPsecTunnelBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ipsec_tunnel_bytes",
+			Help:      "Bytes processed by an offloaded IPsec tunnel as reported by the plugin",
+		},
+		[]string{labelNamespace, labelTunnel, labelDirection},
+	)
+
+	// IPsecTunnelPackets mirrors the packets processed by offloaded IPsec tunnels
+	IPsecTunnelPackets = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ipsec_tunnel_packets",
+			Help:      "Packets processed by an offloaded IPsec tunnel as reported by the plugin",
+		},
+		[]string{labelNamespace, labelTunnel, labelDirection},
+	)
+
+	// IPsecTunnelErrors mirrors the errors of offloaded IPsec tunnels
+	IPsecTunnelErrors = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "ipsec_tunnel_errors",
+			Help:      "Errors of an offloaded IPsec tunnel as reported by the plugin",
+		},
+		[]string{labelNamespace, labelTunnel},
+	)
 )
 
 func init() {
@@ -176,6 +219,10 @@ func init() {
 		DeviceInventoryInfo,
 		NetworkOperations,
 		StorageOperations,
+		SecurityOperations,
+		IPsecTunnelBytes,
+		IPsecTunnelPackets,
+		IPsecTunnelErrors,
 	)
 }
 
@@ -272,6 +319,12 @@ func RecordDeviceInventory(vendor, model, deviceID, firmwareVersion, serialNumbe
 	DeviceInventoryInfo.WithLabelValues(vendor, model, deviceID, firmwareVersion, serialNumber, pciAddress).Set(1)
 }
 
+// DeleteDeviceInventory removes the inventory information of a device, so a
+// firmware upgrade or a removed device does not leave a stale series behind
+func DeleteDeviceInventory(deviceID string) {
+	DeviceInventoryInfo.DeletePartialMatch(map[string]string{"device_id": deviceID})
+}
+
 // RecordNetworkOperation records a network operation
 func RecordNetworkOperation(vendor, operation string, success bool) {
 	result := "success"
@@ -289,3 +342,29 @@ func RecordStorageOperation(vendor, operation string, success bool) {
 	}
 	StorageOperations.WithLabelValues(vendor, operation, result).Inc()
 }
+
+// RecordSecurityOperation records a security operation
+func RecordSecurityOperation(vendor, operation string, success bool) {
+	result := "success"
+	if !success {
+		result = "error"
+	}
+	SecurityOperations.WithLabelValues(vendor, operation, result).Inc()
+}
+
+// RecordIPsecTunnelStats records the traffic counters of an IPsec tunnel
+func RecordIPsecTunnelStats(namespace, tunnel string, bytesEncrypted, bytesDecrypted, packetsEncrypted, packetsDecrypted, errors uint64) {
+	IPsecTunnelBytes.WithLabelValues(namespace, tunnel, "encrypted").Set(float64(bytesEncrypted))
+	IPsecTunnelBytes.WithLabelValues(namespace, tunnel, "decrypted").Set(float64(bytesDecrypted))
+	IPsecTunnelPackets.WithLabelValues(namespace, tunnel, "encrypted").Set(float64(packetsEncrypted))
+	IPsecTunnelPackets.WithLabelValues(namespace, tunnel, "decrypted").Set(float64(packetsDecrypted))
+	IPsecTunnelErrors.WithLabelValues(namespace, tunnel).Set(float64(errors))
+}
+
+// DeleteIPsecTunnelStats removes the traffic counters of a deleted IPsec tunnel
+func DeleteIPsecTunnelStats(namespace, tunnel string) {
+	labels := map[string]string{labelNamespace: namespace, labelTunnel: tunnel}
+	IPsecTunnelBytes.DeletePartialMatch(labels)
+	IPsecTunnelPackets.DeletePartialMatch(labels)
+	IPsecTunnelErrors.DeletePartialMatch(labels)
+}
@@ -23,11 +23,15 @@ import (
 	"fmt"
 	"time"
 
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
 	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	storagepb "github.com/opiproject/opi-api/storage/v1alpha1/gen/go"
 	lifecyclepb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // Client is the main OPI client that provides access to all OPI services.
@@ -37,18 +41,41 @@ type Client struct {
 	options  *clientOptions
 
 	// Service clients using actual OPI protobuf types
-	lifecycle *LifecycleClient
-	network   *NetworkClient
+	lifecycle       *LifecycleClient
+	network         *NetworkClient
+	networkFunction *NetworkFunctionClient
+	storage         *StorageClient
 }
 
 // ClientOption configures the OPI client.
 type ClientOption func(*clientOptions)
 
 type clientOptions struct {
-	dialTimeout   time.Duration
-	callTimeout   time.Duration
-	maxRetries    int
-	retryInterval time.Duration
+	dialTimeout      time.Duration
+	callTimeout      time.Duration
+	maxRetries       int
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+
+	breakerThreshold    int
+	breakerOpenDuration time.Duration
+
+	keepaliveTime    time.Duration
+	keepaliveTimeout time.Duration
+}
+
+func defaultClientOptions() *clientOptions {
+	return &clientOptions{
+		dialTimeout:         10 * time.Second,
+		callTimeout:         30 * time.Second,
+		maxRetries:          3,
+		retryInterval:       1 * time.Second,
+		maxRetryInterval:    10 * time.Second,
+		breakerThreshold:    5,
+		breakerOpenDuration: 30 * time.Second,
+		keepaliveTime:       30 * time.Second,
+		keepaliveTimeout:    10 * time.Second,
+	}
 }
 
 // WithDialTimeout sets the connection dial timeout.
@@ -65,7 +92,11 @@ func WithCallTimeout(d time.Duration) ClientOption {
 	}
 }
 
-// WithRetry configures retry behavior.
+// WithRetry configures retry behavior. The idempotent calls (Get, List,
+// Delete, GetDevices and Ping) are sent again up to maxRetries times while
+// they fail with the Unavailable, ResourceExhausted or Aborted gRPC codes.
+// The delay between two attempts starts at interval and doubles with every
+// attempt, with a random jitter. A maxRetries of 0 disables retries.
 func WithRetry(maxRetries int, interval time.Duration) ClientOption {
 	return func(o *clientOptions) {
 		o.maxRetries = maxRetries
@@ -73,21 +104,54 @@ func WithRetry(maxRetries int, interval time.Duration) ClientOption {
 	}
 }
 
+// WithMaxRetryInterval caps the delay between two attempts of a call.
+func WithMaxRetryInterval(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.maxRetryInterval = d
+	}
+}
+
+// WithCircuitBreaker configures the circuit breaker of the endpoint. Once
+// failureThreshold calls in a row failed because the endpoint is unavailable
+// or did not answer in time, calls fail with ErrCircuitOpen without being
+// sent for openDuration. A single call is then sent to probe the endpoint.
+// A failureThreshold of 0 disables the circuit breaker.
+func WithCircuitBreaker(failureThreshold int, openDuration time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.breakerThreshold = failureThreshold
+		o.breakerOpenDuration = openDuration
+	}
+}
+
+// WithKeepalive configures the keepalive pings of the connection, which is
+// closed when a ping is not answered within timeout. Pings are only sent
+// while calls are in flight, as servers reject pings on idle connections by
+// default. A time of 0 disables keepalive. It has no effect on the clients
+// created from an existing connection.
+func WithKeepalive(time, timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.keepaliveTime = time
+		o.keepaliveTimeout = timeout
+	}
+}
+
 // NewClient creates a new OPI client connected to the specified endpoint.
 func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
-	options := &clientOptions{
-		dialTimeout:   10 * time.Second,
-		callTimeout:   30 * time.Second,
-		maxRetries:    3,
-		retryInterval: 1 * time.Second,
-	}
+	options := defaultClientOptions()
 	for _, opt := range opts {
 		opt(options)
 	}
 
-	conn, err := grpc.NewClient(endpoint,
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	}
+	if options.keepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    options.keepaliveTime,
+			Timeout: options.keepaliveTimeout,
+		}))
+	}
+	conn, err := grpc.NewClient(endpoint, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OPI client for endpoint %s: %w", endpoint, err)
 	}
@@ -97,12 +161,10 @@ func NewClient(endpoint string, opts ...ClientOption) (*Client, error) {
 
 // NewClientWithConn creates a new OPI client from an existing gRPC connection.
 // Useful for testing with mock servers.
-func NewClientWithConn(conn *grpc.ClientConn) *Client {
-	options := &clientOptions{
-		dialTimeout:   10 * time.Second,
-		callTimeout:   30 * time.Second,
-		maxRetries:    3,
-		retryInterval: 1 * time.Second,
+func NewClientWithConn(conn *grpc.ClientConn, opts ...ClientOption) *Client {
+	options := defaultClientOptions()
+	for _, opt := range opts {
+		opt(options)
 	}
 	return newClientWithConn(conn, "mock", options)
 }
@@ -114,8 +176,12 @@ func newClientWithConn(conn *grpc.ClientConn, endpoint string, options *clientOp
 		options:  options,
 	}
 
-	c.lifecycle = newLifecycleClient(conn, options)
-	c.network = newNetworkClient(conn, options)
+	// The service clients share the circuit breaker of the endpoint.
+	inv := newInvoker(endpoint, options)
+	c.lifecycle = newLifecycleClient(conn, inv)
+	c.network = newNetworkClient(conn, inv)
+	c.networkFunction = newNetworkFunctionClient(conn, inv)
+	c.storage = newStorageClient(conn, inv)
 
 	return c
 }
@@ -138,6 +204,16 @@ func (c *Client) Network() *NetworkClient {
 	return c.network
 }
 
+// NetworkFunction returns the network function service client.
+func (c *Client) NetworkFunction() *NetworkFunctionClient {
+	return c.networkFunction
+}
+
+// Storage returns the storage service client.
+func (c *Client) Storage() *StorageClient {
+	return c.storage
+}
+
 // Endpoint returns the connected endpoint.
 func (c *Client) Endpoint() string {
 	return c.endpoint
@@ -154,70 +230,55 @@ func (c *Client) IsConnected() bool {
 	return state == connectivity.Ready || state == connectivity.Idle
 }
 
-// withTimeout wraps a context with the configured call timeout
-func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
-	if c.options.callTimeout > 0 {
-		return context.WithTimeout(ctx, c.options.callTimeout)
-	}
-	return ctx, func() {}
-}
-
 // LifecycleClient provides access to OPI Lifecycle APIs using actual protobuf types.
 type LifecycleClient struct {
 	lifecycleClient lifecyclepb.LifeCycleServiceClient
 	deviceClient    lifecyclepb.DeviceServiceClient
 	heartbeatClient lifecyclepb.HeartbeatServiceClient
 	conn            *grpc.ClientConn
-	options         *clientOptions
+	invoker         *invoker
 }
 
-func newLifecycleClient(conn *grpc.ClientConn, opts *clientOptions) *LifecycleClient {
+func newLifecycleClient(conn *grpc.ClientConn, inv *invoker) *LifecycleClient {
 	return &LifecycleClient{
 		lifecycleClient: lifecyclepb.NewLifeCycleServiceClient(conn),
 		deviceClient:    lifecyclepb.NewDeviceServiceClient(conn),
 		heartbeatClient: lifecyclepb.NewHeartbeatServiceClient(conn),
 		conn:            conn,
-		options:         opts,
-	}
-}
-
-func (c *LifecycleClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
-	if c.options.callTimeout > 0 {
-		return context.WithTimeout(ctx, c.options.callTimeout)
+		invoker:         inv,
 	}
-	return ctx, func() {}
 }
 
 // Init initializes the xPU (DPU/IPU).
 func (c *LifecycleClient) Init(ctx context.Context, req *lifecyclepb.InitRequest) (*lifecyclepb.IpPort, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.lifecycleClient.Init(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*lifecyclepb.IpPort, error) {
+		return c.lifecycleClient.Init(ctx, req)
+	})
 }
 
 // GetDevices retrieves available devices managed by the xPU.
 func (c *LifecycleClient) GetDevices(ctx context.Context) (*lifecyclepb.DeviceListResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.deviceClient.GetDevices(ctx, &lifecyclepb.GetDevicesRequest{})
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*lifecyclepb.DeviceListResponse, error) {
+		return c.deviceClient.GetDevices(ctx, &lifecyclepb.GetDevicesRequest{})
+	})
 }
 
 // SetNumVfs configures number of virtual functions for a device.
 func (c *LifecycleClient) SetNumVfs(ctx context.Context, count int32) (*lifecyclepb.VfCount, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &lifecyclepb.SetNumVfsRequest{
 		VfCnt: count,
 	}
-	return c.deviceClient.SetNumVfs(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*lifecyclepb.VfCount, error) {
+		return c.deviceClient.SetNumVfs(ctx, req)
+	})
 }
 
 // Ping checks xPU lifecycle health status.
 func (c *LifecycleClient) Ping(ctx context.Context) (*lifecyclepb.PingResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &lifecyclepb.PingRequest{}
-	return c.heartbeatClient.Ping(ctx, req)
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*lifecyclepb.PingResponse, error) {
+		return c.heartbeatClient.Ping(ctx, req)
+	})
 }
 
 // NetworkClient provides access to OPI Network APIs (EVPN-GW) using actual protobuf types.
@@ -227,142 +288,332 @@ type NetworkClient struct {
 	vrfClient           evpnpb.VrfServiceClient
 	sviClient           evpnpb.SviServiceClient
 	conn                *grpc.ClientConn
-	options             *clientOptions
+	invoker             *invoker
 }
 
-func newNetworkClient(conn *grpc.ClientConn, opts *clientOptions) *NetworkClient {
+func newNetworkClient(conn *grpc.ClientConn, inv *invoker) *NetworkClient {
 	return &NetworkClient{
 		bridgePortClient:    evpnpb.NewBridgePortServiceClient(conn),
 		logicalBridgeClient: evpnpb.NewLogicalBridgeServiceClient(conn),
 		vrfClient:           evpnpb.NewVrfServiceClient(conn),
 		sviClient:           evpnpb.NewSviServiceClient(conn),
 		conn:                conn,
-		options:             opts,
-	}
-}
-
-func (c *NetworkClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
-	if c.options.callTimeout > 0 {
-		return context.WithTimeout(ctx, c.options.callTimeout)
+		invoker:             inv,
 	}
-	return ctx, func() {}
 }
 
 // --- BridgePort Operations ---
 
 // CreateBridgePort creates a new bridge port.
 func (c *NetworkClient) CreateBridgePort(ctx context.Context, req *evpnpb.CreateBridgePortRequest) (*evpnpb.BridgePort, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.bridgePortClient.CreateBridgePort(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*evpnpb.BridgePort, error) {
+		return c.bridgePortClient.CreateBridgePort(ctx, req)
+	})
 }
 
 // GetBridgePort retrieves a bridge port by name.
 func (c *NetworkClient) GetBridgePort(ctx context.Context, name string) (*evpnpb.BridgePort, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.GetBridgePortRequest{Name: name}
-	return c.bridgePortClient.GetBridgePort(ctx, req)
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*evpnpb.BridgePort, error) {
+		return c.bridgePortClient.GetBridgePort(ctx, req)
+	})
 }
 
 // ListBridgePorts lists all bridge ports.
 func (c *NetworkClient) ListBridgePorts(ctx context.Context) (*evpnpb.ListBridgePortsResponse, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.ListBridgePortsRequest{}
-	return c.bridgePortClient.ListBridgePorts(ctx, req)
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*evpnpb.ListBridgePortsResponse, error) {
+		return c.bridgePortClient.ListBridgePorts(ctx, req)
+	})
 }
 
 // UpdateBridgePort updates an existing bridge port.
 func (c *NetworkClient) UpdateBridgePort(ctx context.Context, req *evpnpb.UpdateBridgePortRequest) (*evpnpb.BridgePort, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.bridgePortClient.UpdateBridgePort(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*evpnpb.BridgePort, error) {
+		return c.bridgePortClient.UpdateBridgePort(ctx, req)
+	})
 }
 
 // DeleteBridgePort deletes a bridge port.
 func (c *NetworkClient) DeleteBridgePort(ctx context.Context, name string) error {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.DeleteBridgePortRequest{Name: name}
-	_, err := c.bridgePortClient.DeleteBridgePort(ctx, req)
-	return err
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.bridgePortClient.DeleteBridgePort(ctx, req)
+	})
 }
 
 // --- LogicalBridge Operations ---
 
 // CreateLogicalBridge creates a new logical bridge.
 func (c *NetworkClient) CreateLogicalBridge(ctx context.Context, req *evpnpb.CreateLogicalBridgeRequest) (*evpnpb.LogicalBridge, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.logicalBridgeClient.CreateLogicalBridge(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*evpnpb.LogicalBridge, error) {
+		return c.logicalBridgeClient.CreateLogicalBridge(ctx, req)
+	})
 }
 
 // GetLogicalBridge retrieves a logical bridge by name.
 func (c *NetworkClient) GetLogicalBridge(ctx context.Context, name string) (*evpnpb.LogicalBridge, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.GetLogicalBridgeRequest{Name: name}
-	return c.logicalBridgeClient.GetLogicalBridge(ctx, req)
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*evpnpb.LogicalBridge, error) {
+		return c.logicalBridgeClient.GetLogicalBridge(ctx, req)
+	})
+}
+
+// ListLogicalBridges lists all logical bridges.
+func (c *NetworkClient) ListLogicalBridges(ctx context.Context) (*evpnpb.ListLogicalBridgesResponse, error) {
+	req := &evpnpb.ListLogicalBridgesRequest{}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*evpnpb.ListLogicalBridgesResponse, error) {
+		return c.logicalBridgeClient.ListLogicalBridges(ctx, req)
+	})
 }
 
 // DeleteLogicalBridge deletes a logical bridge.
 func (c *NetworkClient) DeleteLogicalBridge(ctx context.Context, name string) error {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.DeleteLogicalBridgeRequest{Name: name}
-	_, err := c.logicalBridgeClient.DeleteLogicalBridge(ctx, req)
-	return err
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.logicalBridgeClient.DeleteLogicalBridge(ctx, req)
+	})
 }
 
 // --- VRF Operations ---
 
 // CreateVrf creates a new VRF.
 func (c *NetworkClient) CreateVrf(ctx context.Context, req *evpnpb.CreateVrfRequest) (*evpnpb.Vrf, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.vrfClient.CreateVrf(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*evpnpb.Vrf, error) {
+		return c.vrfClient.CreateVrf(ctx, req)
+	})
 }
 
 // GetVrf retrieves a VRF by name.
 func (c *NetworkClient) GetVrf(ctx context.Context, name string) (*evpnpb.Vrf, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.GetVrfRequest{Name: name}
-	return c.vrfClient.GetVrf(ctx, req)
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*evpnpb.Vrf, error) {
+		return c.vrfClient.GetVrf(ctx, req)
+	})
 }
 
 // DeleteVrf deletes a VRF.
 func (c *NetworkClient) DeleteVrf(ctx context.Context, name string) error {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.DeleteVrfRequest{Name: name}
-	_, err := c.vrfClient.DeleteVrf(ctx, req)
-	return err
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.vrfClient.DeleteVrf(ctx, req)
+	})
 }
 
 // --- SVI Operations ---
 
 // CreateSvi creates a new SVI.
 func (c *NetworkClient) CreateSvi(ctx context.Context, req *evpnpb.CreateSviRequest) (*evpnpb.Svi, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
-	return c.sviClient.CreateSvi(ctx, req)
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*evpnpb.Svi, error) {
+		return c.sviClient.CreateSvi(ctx, req)
+	})
 }
 
 // GetSvi retrieves an SVI by name.
 func (c *NetworkClient) GetSvi(ctx context.Context, name string) (*evpnpb.Svi, error) {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.GetSviRequest{Name: name}
-	return c.sviClient.GetSvi(ctx, req)
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*evpnpb.Svi, error) {
+		return c.sviClient.GetSvi(ctx, req)
+	})
 }
 
 // DeleteSvi deletes an SVI.
 func (c *NetworkClient) DeleteSvi(ctx context.Context, name string) error {
-	ctx, cancel := c.withTimeout(ctx)
-	defer cancel()
 	req := &evpnpb.DeleteSviRequest{Name: name}
-	_, err := c.sviClient.DeleteSvi(ctx, req)
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.sviClient.DeleteSvi(ctx, req)
+	})
+}
+
+// NetworkFunctionClient provides access to the network function service that
+// vendor bridges use to steer traffic through a network function.
+type NetworkFunctionClient struct {
+	nfClient nfapi.NetworkFunctionServiceClient
+	conn     *grpc.ClientConn
+	invoker  *invoker
+}
+
+func newNetworkFunctionClient(conn *grpc.ClientConn, inv *invoker) *NetworkFunctionClient {
+	return &NetworkFunctionClient{
+		nfClient: nfapi.NewNetworkFunctionServiceClient(conn),
+		conn:     conn,
+		invoker:  inv,
+	}
+}
+
+// CreateNetworkFunction steers the traffic of the input port through the output port.
+func (c *NetworkFunctionClient) CreateNetworkFunction(ctx context.Context, input, output string) error {
+	_, err := callOnce(ctx, c.invoker, func(ctx context.Context) (*nfapi.Empty, error) {
+		return c.nfClient.CreateNetworkFunction(ctx, &nfapi.NFRequest{Input: input, Output: output})
+	})
 	return err
 }
+
+// DeleteNetworkFunction removes a network function.
+func (c *NetworkFunctionClient) DeleteNetworkFunction(ctx context.Context, input, output string) error {
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*nfapi.Empty, error) {
+		return c.nfClient.DeleteNetworkFunction(ctx, &nfapi.NFRequest{Input: input, Output: output})
+	})
+}
+
+// ListNetworkFunctions lists the network functions the bridge has set up.
+func (c *NetworkFunctionClient) ListNetworkFunctions(ctx context.Context) ([]*nfapi.NFRequest, error) {
+	resp, err := callWithRetry(ctx, c.invoker, func(ctx context.Context) (*nfapi.NFList, error) {
+		return c.nfClient.ListNetworkFunctions(ctx, &nfapi.Empty{})
+	})
+	return resp.GetNetworkFunctions(), err
+}
+
+// StorageClient provides access to OPI Storage APIs using actual protobuf types.
+// The frontend exposes NVMe subsystems, controllers and namespaces to the host.
+// The backend connects the DPU to the remote NVMe-oF controllers the
+// namespaces are backed by.
+type StorageClient struct {
+	frontendNvmeClient storagepb.FrontendNvmeServiceClient
+	remoteNvmeClient   storagepb.NvmeRemoteControllerServiceClient
+	conn               *grpc.ClientConn
+	invoker            *invoker
+}
+
+func newStorageClient(conn *grpc.ClientConn, inv *invoker) *StorageClient {
+	return &StorageClient{
+		frontendNvmeClient: storagepb.NewFrontendNvmeServiceClient(conn),
+		remoteNvmeClient:   storagepb.NewNvmeRemoteControllerServiceClient(conn),
+		conn:               conn,
+		invoker:            inv,
+	}
+}
+
+// --- NvmeSubsystem Operations ---
+
+// CreateNvmeSubsystem creates a new NVMe subsystem.
+func (c *StorageClient) CreateNvmeSubsystem(ctx context.Context, req *storagepb.CreateNvmeSubsystemRequest) (*storagepb.NvmeSubsystem, error) {
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeSubsystem, error) {
+		return c.frontendNvmeClient.CreateNvmeSubsystem(ctx, req)
+	})
+}
+
+// GetNvmeSubsystem retrieves an NVMe subsystem by name.
+func (c *StorageClient) GetNvmeSubsystem(ctx context.Context, name string) (*storagepb.NvmeSubsystem, error) {
+	req := &storagepb.GetNvmeSubsystemRequest{Name: name}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeSubsystem, error) {
+		return c.frontendNvmeClient.GetNvmeSubsystem(ctx, req)
+	})
+}
+
+// ListNvmeSubsystems lists all NVMe subsystems.
+func (c *StorageClient) ListNvmeSubsystems(ctx context.Context) (*storagepb.ListNvmeSubsystemsResponse, error) {
+	req := &storagepb.ListNvmeSubsystemsRequest{}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*storagepb.ListNvmeSubsystemsResponse, error) {
+		return c.frontendNvmeClient.ListNvmeSubsystems(ctx, req)
+	})
+}
+
+// DeleteNvmeSubsystem deletes an NVMe subsystem.
+func (c *StorageClient) DeleteNvmeSubsystem(ctx context.Context, name string) error {
+	req := &storagepb.DeleteNvmeSubsystemRequest{Name: name}
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.frontendNvmeClient.DeleteNvmeSubsystem(ctx, req)
+	})
+}
+
+// --- NvmeController Operations ---
+
+// CreateNvmeController creates a new NVMe controller within a subsystem.
+func (c *StorageClient) CreateNvmeController(ctx context.Context, req *storagepb.CreateNvmeControllerRequest) (*storagepb.NvmeController, error) {
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeController, error) {
+		return c.frontendNvmeClient.CreateNvmeController(ctx, req)
+	})
+}
+
+// GetNvmeController retrieves an NVMe controller by name.
+func (c *StorageClient) GetNvmeController(ctx context.Context, name string) (*storagepb.NvmeController, error) {
+	req := &storagepb.GetNvmeControllerRequest{Name: name}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeController, error) {
+		return c.frontendNvmeClient.GetNvmeController(ctx, req)
+	})
+}
+
+// DeleteNvmeController deletes an NVMe controller.
+func (c *StorageClient) DeleteNvmeController(ctx context.Context, name string) error {
+	req := &storagepb.DeleteNvmeControllerRequest{Name: name}
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.frontendNvmeClient.DeleteNvmeController(ctx, req)
+	})
+}
+
+// --- NvmeNamespace Operations ---
+
+// CreateNvmeNamespace creates a new NVMe namespace within a subsystem.
+func (c *StorageClient) CreateNvmeNamespace(ctx context.Context, req *storagepb.CreateNvmeNamespaceRequest) (*storagepb.NvmeNamespace, error) {
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeNamespace, error) {
+		return c.frontendNvmeClient.CreateNvmeNamespace(ctx, req)
+	})
+}
+
+// GetNvmeNamespace retrieves an NVMe namespace by name.
+func (c *StorageClient) GetNvmeNamespace(ctx context.Context, name string) (*storagepb.NvmeNamespace, error) {
+	req := &storagepb.GetNvmeNamespaceRequest{Name: name}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeNamespace, error) {
+		return c.frontendNvmeClient.GetNvmeNamespace(ctx, req)
+	})
+}
+
+// DeleteNvmeNamespace deletes an NVMe namespace.
+func (c *StorageClient) DeleteNvmeNamespace(ctx context.Context, name string) error {
+	req := &storagepb.DeleteNvmeNamespaceRequest{Name: name}
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.frontendNvmeClient.DeleteNvmeNamespace(ctx, req)
+	})
+}
+
+// --- NvmeRemoteController Operations ---
+
+// CreateNvmeRemoteController creates a connection to a remote NVMe-oF
+// controller. The controller is reached through the paths created in it.
+func (c *StorageClient) CreateNvmeRemoteController(ctx context.Context, req *storagepb.CreateNvmeRemoteControllerRequest) (*storagepb.NvmeRemoteController, error) {
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeRemoteController, error) {
+		return c.remoteNvmeClient.CreateNvmeRemoteController(ctx, req)
+	})
+}
+
+// GetNvmeRemoteController retrieves a remote NVMe controller by name.
+func (c *StorageClient) GetNvmeRemoteController(ctx context.Context, name string) (*storagepb.NvmeRemoteController, error) {
+	req := &storagepb.GetNvmeRemoteControllerRequest{Name: name}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmeRemoteController, error) {
+		return c.remoteNvmeClient.GetNvmeRemoteController(ctx, req)
+	})
+}
+
+// DeleteNvmeRemoteController deletes a remote NVMe controller.
+func (c *StorageClient) DeleteNvmeRemoteController(ctx context.Context, name string) error {
+	req := &storagepb.DeleteNvmeRemoteControllerRequest{Name: name}
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.remoteNvmeClient.DeleteNvmeRemoteController(ctx, req)
+	})
+}
+
+// --- NvmePath Operations ---
+
+// CreateNvmePath creates a fabrics path to a remote NVMe controller.
+func (c *StorageClient) CreateNvmePath(ctx context.Context, req *storagepb.CreateNvmePathRequest) (*storagepb.NvmePath, error) {
+	return callOnce(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmePath, error) {
+		return c.remoteNvmeClient.CreateNvmePath(ctx, req)
+	})
+}
+
+// GetNvmePath retrieves a path to a remote NVMe controller by name.
+func (c *StorageClient) GetNvmePath(ctx context.Context, name string) (*storagepb.NvmePath, error) {
+	req := &storagepb.GetNvmePathRequest{Name: name}
+	return callWithRetry(ctx, c.invoker, func(ctx context.Context) (*storagepb.NvmePath, error) {
+		return c.remoteNvmeClient.GetNvmePath(ctx, req)
+	})
+}
+
+// DeleteNvmePath deletes a path to a remote NVMe controller.
+func (c *StorageClient) DeleteNvmePath(ctx context.Context, name string) error {
+	req := &storagepb.DeleteNvmePathRequest{Name: name}
+	return deleteWithRetry(ctx, c.invoker, func(ctx context.Context) (*emptypb.Empty, error) {
+		return c.remoteNvmeClient.DeleteNvmePath(ctx, req)
+	})
+}
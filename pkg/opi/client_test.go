@@ -21,7 +21,9 @@ import (
 	"net"
 	"testing"
 
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
 	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	storagepb "github.com/opiproject/opi-api/storage/v1alpha1/gen/go"
 	lifecyclepb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -104,6 +106,10 @@ func (m *mockNetworkServer) GetLogicalBridge(ctx context.Context, req *evpnpb.Ge
 	return &evpnpb.LogicalBridge{Name: req.Name}, nil
 }
 
+func (m *mockNetworkServer) ListLogicalBridges(ctx context.Context, req *evpnpb.ListLogicalBridgesRequest) (*evpnpb.ListLogicalBridgesResponse, error) {
+	return &evpnpb.ListLogicalBridgesResponse{LogicalBridges: []*evpnpb.LogicalBridge{{Name: "logicalBridges/lb1"}}}, nil
+}
+
 func (m *mockNetworkServer) DeleteLogicalBridge(ctx context.Context, req *evpnpb.DeleteLogicalBridgeRequest) (*emptypb.Empty, error) {
 	return &emptypb.Empty{}, nil
 }
@@ -132,6 +138,99 @@ func (m *mockNetworkServer) DeleteSvi(ctx context.Context, req *evpnpb.DeleteSvi
 	return &emptypb.Empty{}, nil
 }
 
+// mockNetworkFunctionServer implements the network function service for testing
+type mockNetworkFunctionServer struct {
+	nfapi.UnimplementedNetworkFunctionServiceServer
+}
+
+func (m *mockNetworkFunctionServer) CreateNetworkFunction(ctx context.Context, req *nfapi.NFRequest) (*nfapi.Empty, error) {
+	return &nfapi.Empty{}, nil
+}
+
+func (m *mockNetworkFunctionServer) DeleteNetworkFunction(ctx context.Context, req *nfapi.NFRequest) (*nfapi.Empty, error) {
+	return &nfapi.Empty{}, nil
+}
+
+func (m *mockNetworkFunctionServer) ListNetworkFunctions(ctx context.Context, req *nfapi.Empty) (*nfapi.NFList, error) {
+	return &nfapi.NFList{NetworkFunctions: []*nfapi.NFRequest{{Input: "in", Output: "out"}}}, nil
+}
+
+// mockStorageServer implements the storage services for testing
+type mockStorageServer struct {
+	storagepb.UnimplementedFrontendNvmeServiceServer
+}
+
+func (m *mockStorageServer) CreateNvmeSubsystem(ctx context.Context, req *storagepb.CreateNvmeSubsystemRequest) (*storagepb.NvmeSubsystem, error) {
+	return &storagepb.NvmeSubsystem{Name: "nvmeSubsystems/" + req.NvmeSubsystemId, Spec: req.NvmeSubsystem.Spec}, nil
+}
+
+func (m *mockStorageServer) GetNvmeSubsystem(ctx context.Context, req *storagepb.GetNvmeSubsystemRequest) (*storagepb.NvmeSubsystem, error) {
+	return &storagepb.NvmeSubsystem{Name: req.Name}, nil
+}
+
+func (m *mockStorageServer) ListNvmeSubsystems(ctx context.Context, req *storagepb.ListNvmeSubsystemsRequest) (*storagepb.ListNvmeSubsystemsResponse, error) {
+	return &storagepb.ListNvmeSubsystemsResponse{
+		NvmeSubsystems: []*storagepb.NvmeSubsystem{{Name: "nvmeSubsystems/subsys1"}},
+	}, nil
+}
+
+func (m *mockStorageServer) DeleteNvmeSubsystem(ctx context.Context, req *storagepb.DeleteNvmeSubsystemRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (m *mockStorageServer) CreateNvmeController(ctx context.Context, req *storagepb.CreateNvmeControllerRequest) (*storagepb.NvmeController, error) {
+	return &storagepb.NvmeController{Name: req.Parent + "/nvmeControllers/" + req.NvmeControllerId}, nil
+}
+
+func (m *mockStorageServer) GetNvmeController(ctx context.Context, req *storagepb.GetNvmeControllerRequest) (*storagepb.NvmeController, error) {
+	return &storagepb.NvmeController{Name: req.Name}, nil
+}
+
+func (m *mockStorageServer) DeleteNvmeController(ctx context.Context, req *storagepb.DeleteNvmeControllerRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (m *mockStorageServer) CreateNvmeNamespace(ctx context.Context, req *storagepb.CreateNvmeNamespaceRequest) (*storagepb.NvmeNamespace, error) {
+	return &storagepb.NvmeNamespace{Name: req.Parent + "/nvmeNamespaces/" + req.NvmeNamespaceId}, nil
+}
+
+func (m *mockStorageServer) GetNvmeNamespace(ctx context.Context, req *storagepb.GetNvmeNamespaceRequest) (*storagepb.NvmeNamespace, error) {
+	return &storagepb.NvmeNamespace{Name: req.Name}, nil
+}
+
+func (m *mockStorageServer) DeleteNvmeNamespace(ctx context.Context, req *storagepb.DeleteNvmeNamespaceRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+// mockRemoteNvmeServer implements the storage backend services for testing
+type mockRemoteNvmeServer struct {
+	storagepb.UnimplementedNvmeRemoteControllerServiceServer
+}
+
+func (m *mockRemoteNvmeServer) CreateNvmeRemoteController(ctx context.Context, req *storagepb.CreateNvmeRemoteControllerRequest) (*storagepb.NvmeRemoteController, error) {
+	return &storagepb.NvmeRemoteController{Name: "nvmeRemoteControllers/" + req.NvmeRemoteControllerId, Multipath: req.NvmeRemoteController.Multipath}, nil
+}
+
+func (m *mockRemoteNvmeServer) GetNvmeRemoteController(ctx context.Context, req *storagepb.GetNvmeRemoteControllerRequest) (*storagepb.NvmeRemoteController, error) {
+	return &storagepb.NvmeRemoteController{Name: req.Name}, nil
+}
+
+func (m *mockRemoteNvmeServer) DeleteNvmeRemoteController(ctx context.Context, req *storagepb.DeleteNvmeRemoteControllerRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (m *mockRemoteNvmeServer) CreateNvmePath(ctx context.Context, req *storagepb.CreateNvmePathRequest) (*storagepb.NvmePath, error) {
+	return &storagepb.NvmePath{Name: req.Parent + "/nvmePaths/" + req.NvmePathId, Traddr: req.NvmePath.Traddr}, nil
+}
+
+func (m *mockRemoteNvmeServer) GetNvmePath(ctx context.Context, req *storagepb.GetNvmePathRequest) (*storagepb.NvmePath, error) {
+	return &storagepb.NvmePath{Name: req.Name}, nil
+}
+
+func (m *mockRemoteNvmeServer) DeleteNvmePath(ctx context.Context, req *storagepb.DeleteNvmePathRequest) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
 // startMockServer starts a mock gRPC server and returns the client connection
 func startMockServer(t *testing.T) (*grpc.ClientConn, func()) {
 	listener, err := net.Listen("tcp", "localhost:0")
@@ -142,6 +241,9 @@ func startMockServer(t *testing.T) (*grpc.ClientConn, func()) {
 	server := grpc.NewServer()
 	mockLifecycle := &mockLifecycleServer{}
 	mockNetwork := &mockNetworkServer{}
+	mockNetworkFunction := &mockNetworkFunctionServer{}
+	mockStorage := &mockStorageServer{}
+	mockRemoteNvme := &mockRemoteNvmeServer{}
 
 	lifecyclepb.RegisterLifeCycleServiceServer(server, mockLifecycle)
 	lifecyclepb.RegisterDeviceServiceServer(server, mockLifecycle)
@@ -150,6 +252,9 @@ func startMockServer(t *testing.T) (*grpc.ClientConn, func()) {
 	evpnpb.RegisterLogicalBridgeServiceServer(server, mockNetwork)
 	evpnpb.RegisterVrfServiceServer(server, mockNetwork)
 	evpnpb.RegisterSviServiceServer(server, mockNetwork)
+	nfapi.RegisterNetworkFunctionServiceServer(server, mockNetworkFunction)
+	storagepb.RegisterFrontendNvmeServiceServer(server, mockStorage)
+	storagepb.RegisterNvmeRemoteControllerServiceServer(server, mockRemoteNvme)
 
 	go func() {
 		if err := server.Serve(listener); err != nil {
@@ -336,6 +441,15 @@ func TestNetworkClient_LogicalBridge(t *testing.T) {
 		t.Errorf("Expected name 'logicalBridges/lb1', got %s", got.Name)
 	}
 
+	// List
+	list, err := client.Network().ListLogicalBridges(ctx)
+	if err != nil {
+		t.Fatalf("ListLogicalBridges failed: %v", err)
+	}
+	if len(list.LogicalBridges) != 1 {
+		t.Errorf("Expected 1 logical bridge, got %d", len(list.LogicalBridges))
+	}
+
 	// Delete
 	err = client.Network().DeleteLogicalBridge(ctx, "logicalBridges/lb1")
 	if err != nil {
@@ -415,6 +529,176 @@ func TestNetworkClient_Svi(t *testing.T) {
 	}
 }
 
+func TestNetworkFunctionClient(t *testing.T) {
+	conn, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := NewClientWithConn(conn)
+	ctx := context.Background()
+
+	if err := client.NetworkFunction().CreateNetworkFunction(ctx, "in", "out"); err != nil {
+		t.Fatalf("CreateNetworkFunction failed: %v", err)
+	}
+	nfs, err := client.NetworkFunction().ListNetworkFunctions(ctx)
+	if err != nil {
+		t.Fatalf("ListNetworkFunctions failed: %v", err)
+	}
+	if len(nfs) != 1 || nfs[0].Input != "in" || nfs[0].Output != "out" {
+		t.Errorf("Expected one network function from in to out, got %v", nfs)
+	}
+	if err := client.NetworkFunction().DeleteNetworkFunction(ctx, "in", "out"); err != nil {
+		t.Fatalf("DeleteNetworkFunction failed: %v", err)
+	}
+}
+
+func TestStorageClient_NvmeSubsystem(t *testing.T) {
+	conn, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := NewClientWithConn(conn)
+	ctx := context.Background()
+
+	// Create
+	createReq := &storagepb.CreateNvmeSubsystemRequest{
+		NvmeSubsystemId: "subsys1",
+		NvmeSubsystem: &storagepb.NvmeSubsystem{
+			Spec: &storagepb.NvmeSubsystemSpec{Nqn: "nqn.2024-01.io.openshift:subsys1"},
+		},
+	}
+	created, err := client.Storage().CreateNvmeSubsystem(ctx, createReq)
+	if err != nil {
+		t.Fatalf("CreateNvmeSubsystem failed: %v", err)
+	}
+	if created.Name != "nvmeSubsystems/subsys1" {
+		t.Errorf("Expected name 'nvmeSubsystems/subsys1', got %s", created.Name)
+	}
+
+	// Get
+	got, err := client.Storage().GetNvmeSubsystem(ctx, "nvmeSubsystems/subsys1")
+	if err != nil {
+		t.Fatalf("GetNvmeSubsystem failed: %v", err)
+	}
+	if got.Name != "nvmeSubsystems/subsys1" {
+		t.Errorf("Expected name 'nvmeSubsystems/subsys1', got %s", got.Name)
+	}
+
+	// List
+	list, err := client.Storage().ListNvmeSubsystems(ctx)
+	if err != nil {
+		t.Fatalf("ListNvmeSubsystems failed: %v", err)
+	}
+	if len(list.NvmeSubsystems) != 1 {
+		t.Errorf("Expected 1 subsystem, got %d", len(list.NvmeSubsystems))
+	}
+
+	// Delete
+	err = client.Storage().DeleteNvmeSubsystem(ctx, "nvmeSubsystems/subsys1")
+	if err != nil {
+		t.Fatalf("DeleteNvmeSubsystem failed: %v", err)
+	}
+}
+
+func TestStorageClient_NvmeControllerAndNamespace(t *testing.T) {
+	conn, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := NewClientWithConn(conn)
+	ctx := context.Background()
+
+	controller, err := client.Storage().CreateNvmeController(ctx, &storagepb.CreateNvmeControllerRequest{
+		Parent:           "nvmeSubsystems/subsys1",
+		NvmeControllerId: "ctrl1",
+		NvmeController:   &storagepb.NvmeController{},
+	})
+	if err != nil {
+		t.Fatalf("CreateNvmeController failed: %v", err)
+	}
+	if controller.Name != "nvmeSubsystems/subsys1/nvmeControllers/ctrl1" {
+		t.Errorf("Unexpected controller name %s", controller.Name)
+	}
+	if _, err := client.Storage().GetNvmeController(ctx, controller.Name); err != nil {
+		t.Fatalf("GetNvmeController failed: %v", err)
+	}
+
+	namespace, err := client.Storage().CreateNvmeNamespace(ctx, &storagepb.CreateNvmeNamespaceRequest{
+		Parent:          "nvmeSubsystems/subsys1",
+		NvmeNamespaceId: "ns1",
+		NvmeNamespace: &storagepb.NvmeNamespace{
+			Spec: &storagepb.NvmeNamespaceSpec{HostNsid: 1, VolumeNameRef: "volumes/vol1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateNvmeNamespace failed: %v", err)
+	}
+	if namespace.Name != "nvmeSubsystems/subsys1/nvmeNamespaces/ns1" {
+		t.Errorf("Unexpected namespace name %s", namespace.Name)
+	}
+	if _, err := client.Storage().GetNvmeNamespace(ctx, namespace.Name); err != nil {
+		t.Fatalf("GetNvmeNamespace failed: %v", err)
+	}
+
+	if err := client.Storage().DeleteNvmeNamespace(ctx, namespace.Name); err != nil {
+		t.Fatalf("DeleteNvmeNamespace failed: %v", err)
+	}
+	if err := client.Storage().DeleteNvmeController(ctx, controller.Name); err != nil {
+		t.Fatalf("DeleteNvmeController failed: %v", err)
+	}
+}
+
+func TestStorageClient_NvmeRemoteControllerAndPath(t *testing.T) {
+	conn, cleanup := startMockServer(t)
+	defer cleanup()
+
+	client := NewClientWithConn(conn)
+	ctx := context.Background()
+
+	controller, err := client.Storage().CreateNvmeRemoteController(ctx, &storagepb.CreateNvmeRemoteControllerRequest{
+		NvmeRemoteControllerId: "remote1",
+		NvmeRemoteController: &storagepb.NvmeRemoteController{
+			Multipath: storagepb.NvmeMultipath_NVME_MULTIPATH_DISABLE,
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateNvmeRemoteController failed: %v", err)
+	}
+	if controller.Name != "nvmeRemoteControllers/remote1" {
+		t.Errorf("Unexpected remote controller name %s", controller.Name)
+	}
+	if _, err := client.Storage().GetNvmeRemoteController(ctx, controller.Name); err != nil {
+		t.Fatalf("GetNvmeRemoteController failed: %v", err)
+	}
+
+	path, err := client.Storage().CreateNvmePath(ctx, &storagepb.CreateNvmePathRequest{
+		Parent:     controller.Name,
+		NvmePathId: "path1",
+		NvmePath: &storagepb.NvmePath{
+			Trtype: storagepb.NvmeTransportType_NVME_TRANSPORT_TYPE_TCP,
+			Traddr: "192.0.2.10",
+			Fabrics: &storagepb.FabricsPath{
+				Trsvcid: 4420,
+				Subnqn:  "nqn.2024-01.io.openshift:target",
+				Adrfam:  storagepb.NvmeAddressFamily_NVME_ADRFAM_IPV4,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateNvmePath failed: %v", err)
+	}
+	if path.Name != "nvmeRemoteControllers/remote1/nvmePaths/path1" || path.Traddr != "192.0.2.10" {
+		t.Errorf("Unexpected path %s to %s", path.Name, path.Traddr)
+	}
+	if _, err := client.Storage().GetNvmePath(ctx, path.Name); err != nil {
+		t.Fatalf("GetNvmePath failed: %v", err)
+	}
+
+	if err := client.Storage().DeleteNvmePath(ctx, path.Name); err != nil {
+		t.Fatalf("DeleteNvmePath failed: %v", err)
+	}
+	if err := client.Storage().DeleteNvmeRemoteController(ctx, controller.Name); err != nil {
+		t.Fatalf("DeleteNvmeRemoteController failed: %v", err)
+	}
+}
+
 func TestClient_Close(t *testing.T) {
 	conn, cleanup := startMockServer(t)
 	defer cleanup()
@@ -0,0 +1,272 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is returned without calling the endpoint while its circuit
+// breaker is open. The error carries the codes.Unavailable gRPC code.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// circuitOpenError is the error of a call rejected by an open circuit breaker.
+type circuitOpenError struct {
+	endpoint   string
+	retryAfter time.Duration
+}
+
+func (e *circuitOpenError) Error() string {
+	return fmt.Sprintf("%v for endpoint %s, retrying in %v", ErrCircuitOpen, e.endpoint, e.retryAfter)
+}
+
+func (e *circuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// GRPCStatus lets status.Code report the rejected call as unavailable.
+func (e *circuitOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// retryable returns whether a failed idempotent call may succeed when it is
+// sent again, such as while the OPI bridge restarts.
+func retryable(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// endpointFailure returns whether a failed call tells that the endpoint is
+// down or hung, as opposed to an error of the request itself.
+func endpointFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops calling an endpoint that failed failureThreshold
+// calls in a row for openDuration. A single call is then let through, which
+// closes the breaker when it succeeds and opens it again when it fails.
+type circuitBreaker struct {
+	endpoint         string
+	failureThreshold int
+	openDuration     time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func newCircuitBreaker(endpoint string, failureThreshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		endpoint:         endpoint,
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+// allow returns an error if the call must not be sent to the endpoint.
+func (b *circuitBreaker) allow() error {
+	if b.failureThreshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if elapsed := b.now().Sub(b.openedAt); elapsed < b.openDuration {
+			return &circuitOpenError{endpoint: b.endpoint, retryAfter: b.openDuration - elapsed}
+		}
+		// Let a single call probe the endpoint.
+		b.state = circuitHalfOpen
+		return nil
+	case circuitHalfOpen:
+		return &circuitOpenError{endpoint: b.endpoint, retryAfter: b.openDuration}
+	}
+	return nil
+}
+
+// record records the result of a call allow let through.
+func (b *circuitBreaker) record(err error) {
+	if b.failureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !endpointFailure(err) {
+		b.state = circuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.failureThreshold {
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+// abandon releases a call allow let through whose result tells nothing
+// about the endpoint. A probe of the endpoint is let through again.
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+	}
+}
+
+// invoker sends the calls of a Client to its endpoint, within the call
+// timeout and through the circuit breaker of the endpoint.
+type invoker struct {
+	options *clientOptions
+	breaker *circuitBreaker
+}
+
+func newInvoker(endpoint string, options *clientOptions) *invoker {
+	return &invoker{
+		options: options,
+		breaker: newCircuitBreaker(endpoint, options.breakerThreshold, options.breakerOpenDuration),
+	}
+}
+
+// withTimeout wraps a context with the configured call timeout
+func (i *invoker) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if i.options.callTimeout > 0 {
+		return context.WithTimeout(ctx, i.options.callTimeout)
+	}
+	return ctx, func() {}
+}
+
+// invoke sends a call once.
+func (i *invoker) invoke(ctx context.Context, call func(context.Context) error) error {
+	if err := i.breaker.allow(); err != nil {
+		return err
+	}
+	callCtx, cancel := i.withTimeout(ctx)
+	defer cancel()
+	err := call(callCtx)
+	if ctx.Err() != nil {
+		// The caller gave up, which tells nothing about the endpoint.
+		i.breaker.abandon()
+		return err
+	}
+	i.breaker.record(err)
+	return err
+}
+
+// retry sends an idempotent call, and sends it again after a backoff while
+// it fails with a retryable code, up to maxRetries times.
+func (i *invoker) retry(ctx context.Context, call func(context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := i.invoke(ctx, call)
+		if err == nil || attempt >= i.options.maxRetries || !retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(i.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the retry that follows the given attempt:
+// retryInterval doubled with every attempt up to maxRetryInterval, of which
+// a random half is taken off so that the clients of a restarted endpoint do
+// not all retry at once.
+func (i *invoker) backoff(attempt int) time.Duration {
+	delay := i.options.retryInterval
+	for n := 0; n < attempt && (i.options.maxRetryInterval <= 0 || delay < i.options.maxRetryInterval); n++ {
+		delay *= 2
+	}
+	if i.options.maxRetryInterval > 0 {
+		delay = min(delay, i.options.maxRetryInterval)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// callOnce sends a call that is not idempotent once.
+func callOnce[T any](ctx context.Context, i *invoker, call func(context.Context) (T, error)) (T, error) {
+	var resp T
+	err := i.invoke(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = call(ctx)
+		return err
+	})
+	return resp, err
+}
+
+// callWithRetry sends an idempotent call, retrying it while it fails with a
+// retryable code.
+func callWithRetry[T any](ctx context.Context, i *invoker, call func(context.Context) (T, error)) (T, error) {
+	var resp T
+	err := i.retry(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = call(ctx)
+		return err
+	})
+	return resp, err
+}
+
+// deleteWithRetry sends a delete, retrying it while it fails with a
+// retryable code. A retried delete that does not find the object anymore
+// succeeds, as a previous attempt may have deleted it before failing.
+func deleteWithRetry[T any](ctx context.Context, i *invoker, call func(context.Context) (T, error)) error {
+	attempts := 0
+	return i.retry(ctx, func(ctx context.Context) error {
+		attempts++
+		_, err := call(ctx)
+		if attempts > 1 && status.Code(err) == codes.NotFound {
+			return nil
+		}
+		return err
+	})
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// flakyBridgePortServer fails the calls with the queued errors before
+// answering them.
+type flakyBridgePortServer struct {
+	evpnpb.UnimplementedBridgePortServiceServer
+
+	mu     sync.Mutex
+	errors []error
+	calls  int
+}
+
+func (m *flakyBridgePortServer) fail(errs ...error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors = append(m.errors, errs...)
+}
+
+func (m *flakyBridgePortServer) called() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+func (m *flakyBridgePortServer) next() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if len(m.errors) == 0 {
+		return nil
+	}
+	err := m.errors[0]
+	m.errors = m.errors[1:]
+	return err
+}
+
+func (m *flakyBridgePortServer) CreateBridgePort(ctx context.Context, req *evpnpb.CreateBridgePortRequest) (*evpnpb.BridgePort, error) {
+	if err := m.next(); err != nil {
+		return nil, err
+	}
+	return &evpnpb.BridgePort{Name: "bridgePorts/" + req.BridgePortId}, nil
+}
+
+func (m *flakyBridgePortServer) GetBridgePort(ctx context.Context, req *evpnpb.GetBridgePortRequest) (*evpnpb.BridgePort, error) {
+	if err := m.next(); err != nil {
+		return nil, err
+	}
+	return &evpnpb.BridgePort{Name: req.Name}, nil
+}
+
+func (m *flakyBridgePortServer) DeleteBridgePort(ctx context.Context, req *evpnpb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
+	if err := m.next(); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// startFlakyServer starts a flaky bridge port server on an in-memory
+// connection and returns a client for it.
+func startFlakyServer(t *testing.T, opts ...ClientOption) (*Client, *flakyBridgePortServer) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	flaky := &flakyBridgePortServer{}
+	evpnpb.RegisterBridgePortServiceServer(server, flaky)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			t.Logf("Server stopped: %v", err)
+		}
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	opts = append([]ClientOption{WithRetry(3, time.Millisecond)}, opts...)
+	return NewClientWithConn(conn, opts...), flaky
+}
+
+func TestRetry_GetRetriesUnavailable(t *testing.T) {
+	client, flaky := startFlakyServer(t)
+	flaky.fail(status.Error(codes.Unavailable, "restarting"), status.Error(codes.ResourceExhausted, "busy"))
+
+	bp, err := client.Network().GetBridgePort(context.Background(), "bridgePorts/bp1")
+	if err != nil {
+		t.Fatalf("GetBridgePort failed: %v", err)
+	}
+	if bp.Name != "bridgePorts/bp1" {
+		t.Errorf("Expected name 'bridgePorts/bp1', got %s", bp.Name)
+	}
+	if flaky.called() != 3 {
+		t.Errorf("Expected 3 calls, got %d", flaky.called())
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	client, flaky := startFlakyServer(t, WithRetry(2, time.Millisecond), WithCircuitBreaker(0, 0))
+	for i := 0; i < 5; i++ {
+		flaky.fail(status.Error(codes.Unavailable, "down"))
+	}
+
+	_, err := client.Network().GetBridgePort(context.Background(), "bridgePorts/bp1")
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, got %v", err)
+	}
+	if flaky.called() != 3 {
+		t.Errorf("Expected 3 calls, got %d", flaky.called())
+	}
+}
+
+func TestRetry_RequestErrorNotRetried(t *testing.T) {
+	client, flaky := startFlakyServer(t)
+	flaky.fail(status.Error(codes.NotFound, "no such bridge port"))
+
+	_, err := client.Network().GetBridgePort(context.Background(), "bridgePorts/bp1")
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	if flaky.called() != 1 {
+		t.Errorf("Expected 1 call, got %d", flaky.called())
+	}
+}
+
+func TestRetry_CreateNotRetried(t *testing.T) {
+	client, flaky := startFlakyServer(t)
+	flaky.fail(status.Error(codes.Unavailable, "restarting"))
+
+	_, err := client.Network().CreateBridgePort(context.Background(), &evpnpb.CreateBridgePortRequest{BridgePortId: "bp1"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, got %v", err)
+	}
+	if flaky.called() != 1 {
+		t.Errorf("Expected 1 call, got %d", flaky.called())
+	}
+}
+
+func TestRetry_DeleteNotFoundAfterRetry(t *testing.T) {
+	client, flaky := startFlakyServer(t)
+	flaky.fail(status.Error(codes.Unavailable, "restarting"), status.Error(codes.NotFound, "already deleted"))
+
+	if err := client.Network().DeleteBridgePort(context.Background(), "bridgePorts/bp1"); err != nil {
+		t.Fatalf("DeleteBridgePort failed: %v", err)
+	}
+	if flaky.called() != 2 {
+		t.Errorf("Expected 2 calls, got %d", flaky.called())
+	}
+
+	flaky.fail(status.Error(codes.NotFound, "no such bridge port"))
+	if err := client.Network().DeleteBridgePort(context.Background(), "bridgePorts/bp1"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound on the first attempt, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	client, flaky := startFlakyServer(t, WithRetry(0, 0), WithCircuitBreaker(2, time.Minute))
+	breaker := client.Network().invoker.breaker
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	flaky.fail(status.Error(codes.Unavailable, "down"), status.Error(codes.DeadlineExceeded, "hung"))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Network().GetBridgePort(ctx, "bridgePorts/bp1"); err == nil {
+			t.Fatalf("Expected call %d to fail", i+1)
+		}
+	}
+
+	// The service clients share the breaker of the endpoint.
+	_, err := client.Network().CreateBridgePort(ctx, &evpnpb.CreateBridgePortRequest{BridgePortId: "bp1"})
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable, got %v", status.Code(err))
+	}
+	if flaky.called() != 2 {
+		t.Errorf("Expected the open breaker not to call the endpoint, got %d calls", flaky.called())
+	}
+
+	// A failed probe opens the breaker again.
+	now = now.Add(time.Minute)
+	flaky.fail(status.Error(codes.Unavailable, "still down"))
+	if _, err := client.Network().GetBridgePort(ctx, "bridgePorts/bp1"); errors.Is(err, ErrCircuitOpen) || err == nil {
+		t.Fatalf("Expected the probe to reach the endpoint, got %v", err)
+	}
+	if _, err := client.Network().GetBridgePort(ctx, "bridgePorts/bp1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen after a failed probe, got %v", err)
+	}
+
+	// A successful probe closes it.
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := client.Network().GetBridgePort(ctx, "bridgePorts/bp1"); err != nil {
+			t.Fatalf("Expected call %d to succeed, got %v", i+1, err)
+		}
+	}
+	if flaky.called() != 6 {
+		t.Errorf("Expected 6 calls, got %d", flaky.called())
+	}
+}
+
+func TestCircuitBreaker_RequestErrorsDoNotOpen(t *testing.T) {
+	client, flaky := startFlakyServer(t, WithCircuitBreaker(1, time.Minute))
+	flaky.fail(status.Error(codes.InvalidArgument, "bad name"), status.Error(codes.NotFound, "no such bridge port"))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := client.Network().GetBridgePort(ctx, "bridgePorts/bp1"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected call %d to reach the endpoint, got %v", i+1, err)
+		}
+	}
+	if _, err := client.Network().GetBridgePort(ctx, "bridgePorts/bp1"); err != nil {
+		t.Errorf("Expected the breaker to stay closed, got %v", err)
+	}
+}
+
+func TestInvoker_Backoff(t *testing.T) {
+	inv := newInvoker("mock", &clientOptions{retryInterval: time.Second, maxRetryInterval: 5 * time.Second})
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: time.Second},
+		{attempt: 1, max: 2 * time.Second},
+		{attempt: 2, max: 4 * time.Second},
+		{attempt: 3, max: 5 * time.Second},
+		{attempt: 100, max: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			delay := inv.backoff(tt.attempt)
+			if delay < tt.max/2 || delay > tt.max {
+				t.Errorf("Expected the backoff of attempt %d within [%v, %v], got %v", tt.attempt, tt.max/2, tt.max, delay)
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides in-memory plugin implementations, so that the flows
+// built on the plugin capabilities can be exercised without DPU hardware.
+package fake
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/openshift/dpu-operator/pkg/plugin"
+)
+
+const (
+	// PluginName is the identifier for this plugin.
+	PluginName = "fake-firmware"
+
+	// PluginVendor is the vendor name.
+	PluginVendor = "Fake"
+
+	// PluginVersion is the current version of this plugin implementation.
+	PluginVersion = "1.0.0"
+)
+
+// FirmwarePlugin implements plugin.FirmwarePlugin in memory. Staged firmware
+// runs once it is activated, after the device reset completes or after
+// RebootHost, depending on the reset the plugin is configured with.
+type FirmwarePlugin struct {
+	mu          sync.Mutex
+	initialized bool
+	devices     []plugin.Device
+	// firmware holds the firmware components of each device by component name.
+	firmware map[string]map[string]*plugin.FirmwareVersion
+
+	reset plugin.FirmwareReset
+	// resetPolls is how many GetFirmwareVersions calls fail while a device resets.
+	resetPolls int
+	resetting  map[string]int
+	// rebootPending lists the devices whose activated firmware runs after RebootHost.
+	rebootPending map[string]bool
+
+	stageErr    error
+	activateErr error
+	calls       []string
+}
+
+// NewFirmwarePlugin creates a fake firmware plugin managing the devices. The
+// NIC firmware of each device runs its Device.FirmwareVersion.
+func NewFirmwarePlugin(devices ...plugin.Device) *FirmwarePlugin {
+	p := &FirmwarePlugin{
+		firmware:      make(map[string]map[string]*plugin.FirmwareVersion),
+		reset:         plugin.FirmwareResetNone,
+		resetting:     make(map[string]int),
+		rebootPending: make(map[string]bool),
+	}
+	for _, device := range devices {
+		p.devices = append(p.devices, device)
+		p.firmware[device.ID] = map[string]*plugin.FirmwareVersion{
+			plugin.FirmwareComponentNIC: {Component: plugin.FirmwareComponentNIC, Running: device.FirmwareVersion},
+		}
+	}
+	return p
+}
+
+// SetReset sets the reset the activations report. A device reset makes the
+// next polls GetFirmwareVersions calls fail while the device is down.
+func (p *FirmwarePlugin) SetReset(reset plugin.FirmwareReset, polls int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset = reset
+	p.resetPolls = polls
+}
+
+// FailStage makes StageFirmware return err, or succeed again if err is nil.
+func (p *FirmwarePlugin) FailStage(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stageErr = err
+}
+
+// FailActivate makes ActivateFirmware return err, or succeed again if err is nil.
+func (p *FirmwarePlugin) FailActivate(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.activateErr = err
+}
+
+// RebootHost runs the firmware activated on the devices waiting for a host reboot.
+func (p *FirmwarePlugin) RebootHost() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for deviceID := range p.rebootPending {
+		p.runStaged(deviceID)
+	}
+	p.rebootPending = make(map[string]bool)
+}
+
+// Calls returns the firmware calls made to the plugin, in order.
+func (p *FirmwarePlugin) Calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.calls...)
+}
+
+// Info returns metadata about this plugin.
+func (p *FirmwarePlugin) Info() plugin.PluginInfo {
+	return plugin.PluginInfo{
+		Name:         PluginName,
+		Vendor:       PluginVendor,
+		Version:      PluginVersion,
+		Description:  "In-memory firmware plugin for testing without hardware",
+		Capabilities: []plugin.Capability{plugin.CapabilityFirmware},
+	}
+}
+
+// Initialize sets up the plugin.
+func (p *FirmwarePlugin) Initialize(ctx context.Context, config plugin.PluginConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.initialized {
+		return plugin.ErrAlreadyInitialized
+	}
+	p.initialized = true
+	return nil
+}
+
+// Shutdown stops the plugin.
+func (p *FirmwarePlugin) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.initialized = false
+	return nil
+}
+
+// HealthCheck verifies the plugin is initialized.
+func (p *FirmwarePlugin) HealthCheck(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.initialized {
+		return plugin.ErrNotInitialized
+	}
+	return nil
+}
+
+// DiscoverDevices returns the devices with the NIC firmware they run.
+func (p *FirmwarePlugin) DiscoverDevices(ctx context.Context) ([]plugin.Device, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	devices := make([]plugin.Device, 0, len(p.devices))
+	for _, device := range p.devices {
+		if nic, ok := p.firmware[device.ID][plugin.FirmwareComponentNIC]; ok {
+			device.FirmwareVersion = nic.Running
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// GetInventory is not implemented by the fake plugin.
+func (p *FirmwarePlugin) GetInventory(ctx context.Context, deviceID string) (*plugin.InventoryResponse, error) {
+	return nil, plugin.ErrNotImplemented
+}
+
+// GetFirmwareVersions returns the firmware components of a device, sorted by
+// component. It fails while the device resets.
+func (p *FirmwarePlugin) GetFirmwareVersions(ctx context.Context, deviceID string) ([]plugin.FirmwareVersion, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	components, ok := p.firmware[deviceID]
+	if !ok {
+		return nil, plugin.NewDeviceError(deviceID, "GetFirmwareVersions", plugin.ErrDeviceNotFound)
+	}
+	if polls, ok := p.resetting[deviceID]; ok {
+		if polls > 0 {
+			p.resetting[deviceID] = polls - 1
+			return nil, plugin.NewDeviceError(deviceID, "GetFirmwareVersions", fmt.Errorf("device is resetting: %w", plugin.ErrConnectionFailed))
+		}
+		delete(p.resetting, deviceID)
+		p.runStaged(deviceID)
+	}
+
+	versions := make([]plugin.FirmwareVersion, 0, len(components))
+	for _, version := range components {
+		versions = append(versions, *version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Component < versions[j].Component })
+	return versions, nil
+}
+
+// StageFirmware stages the image on a device. The image is not downloaded,
+// only its version and checksum are checked.
+func (p *FirmwarePlugin) StageFirmware(ctx context.Context, deviceID string, image *plugin.FirmwareImage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if image == nil {
+		return plugin.NewDeviceError(deviceID, "StageFirmware", fmt.Errorf("%w: image is nil", plugin.ErrInvalidConfig))
+	}
+	p.calls = append(p.calls, fmt.Sprintf("StageFirmware %s %s %s", deviceID, image.Component, image.Version))
+	if p.stageErr != nil {
+		return plugin.NewDeviceError(deviceID, "StageFirmware", p.stageErr)
+	}
+
+	components, ok := p.firmware[deviceID]
+	if !ok {
+		return plugin.NewDeviceError(deviceID, "StageFirmware", plugin.ErrDeviceNotFound)
+	}
+	if image.Version == "" {
+		return plugin.NewDeviceError(deviceID, "StageFirmware", fmt.Errorf("%w: image version is empty", plugin.ErrInvalidConfig))
+	}
+	if digest, err := hex.DecodeString(image.Checksum); err != nil || len(digest) != 32 {
+		return plugin.NewDeviceError(deviceID, "StageFirmware", fmt.Errorf("%w: checksum %q is not a SHA-256 digest", plugin.ErrInvalidConfig, image.Checksum))
+	}
+
+	component := image.Component
+	if component == "" {
+		component = plugin.FirmwareComponentNIC
+	}
+	if _, ok := components[component]; !ok {
+		components[component] = &plugin.FirmwareVersion{Component: component}
+	}
+	components[component].Staged = image.Version
+	return nil
+}
+
+// ActivateFirmware activates the firmware staged on a device with the
+// configured reset.
+func (p *FirmwarePlugin) ActivateFirmware(ctx context.Context, deviceID string) (*plugin.FirmwareActivation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, "ActivateFirmware "+deviceID)
+	if p.activateErr != nil {
+		return nil, plugin.NewDeviceError(deviceID, "ActivateFirmware", p.activateErr)
+	}
+	if _, ok := p.firmware[deviceID]; !ok {
+		return nil, plugin.NewDeviceError(deviceID, "ActivateFirmware", plugin.ErrDeviceNotFound)
+	}
+
+	switch p.reset {
+	case plugin.FirmwareResetDevice:
+		p.resetting[deviceID] = p.resetPolls
+	case plugin.FirmwareResetHost:
+		p.rebootPending[deviceID] = true
+	default:
+		p.runStaged(deviceID)
+	}
+	return &plugin.FirmwareActivation{Reset: p.reset}, nil
+}
+
+// runStaged makes the staged firmware of a device run. p.mu must be held.
+func (p *FirmwarePlugin) runStaged(deviceID string) {
+	for _, version := range p.firmware[deviceID] {
+		if version.Staged != "" {
+			version.Running = version.Staged
+			version.Staged = ""
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/openshift/dpu-operator/pkg/plugin"
+)
+
+const testChecksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func newTestPlugin(t *testing.T) *FirmwarePlugin {
+	t.Helper()
+	p := NewFirmwarePlugin(plugin.Device{ID: "dev-0", FirmwareVersion: "1.0.0"})
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return p
+}
+
+func testImage(version string) *plugin.FirmwareImage {
+	return &plugin.FirmwareImage{
+		Component: plugin.FirmwareComponentNIC,
+		Version:   version,
+		URL:       "https://firmware.example.com/dev.bin",
+		Checksum:  testChecksum,
+	}
+}
+
+func nicVersion(t *testing.T, p *FirmwarePlugin) plugin.FirmwareVersion {
+	t.Helper()
+	versions, err := p.GetFirmwareVersions(context.Background(), "dev-0")
+	if err != nil {
+		t.Fatalf("GetFirmwareVersions failed: %v", err)
+	}
+	if len(versions) != 1 || versions[0].Component != plugin.FirmwareComponentNIC {
+		t.Fatalf("expected only the NIC firmware, got %+v", versions)
+	}
+	return versions[0]
+}
+
+func TestFirmwarePlugin_Info(t *testing.T) {
+	checker := plugin.NewPluginChecker(NewFirmwarePlugin())
+	if !checker.IsFirmwarePlugin() {
+		t.Error("expected the fake plugin to implement FirmwarePlugin")
+	}
+	if !checker.SupportsCapability(plugin.CapabilityFirmware) {
+		t.Error("expected CapabilityFirmware in Capabilities")
+	}
+}
+
+func TestFirmwarePlugin_InitializeTwice(t *testing.T) {
+	p := newTestPlugin(t)
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{}); !errors.Is(err, plugin.ErrAlreadyInitialized) {
+		t.Errorf("expected ErrAlreadyInitialized, got %v", err)
+	}
+}
+
+func TestFirmwarePlugin_ActivateWithoutReset(t *testing.T) {
+	p := newTestPlugin(t)
+	ctx := context.Background()
+
+	if err := p.StageFirmware(ctx, "dev-0", testImage("2.0.0")); err != nil {
+		t.Fatalf("StageFirmware failed: %v", err)
+	}
+	if v := nicVersion(t, p); v.Running != "1.0.0" || v.Staged != "2.0.0" {
+		t.Errorf("expected 1.0.0 running and 2.0.0 staged, got %+v", v)
+	}
+
+	activation, err := p.ActivateFirmware(ctx, "dev-0")
+	if err != nil {
+		t.Fatalf("ActivateFirmware failed: %v", err)
+	}
+	if activation.Reset != plugin.FirmwareResetNone {
+		t.Errorf("expected reset %q, got %q", plugin.FirmwareResetNone, activation.Reset)
+	}
+	if v := nicVersion(t, p); v.Running != "2.0.0" || v.Staged != "" {
+		t.Errorf("expected 2.0.0 running, got %+v", v)
+	}
+
+	devices, err := p.DiscoverDevices(ctx)
+	if err != nil || len(devices) != 1 || devices[0].FirmwareVersion != "2.0.0" {
+		t.Errorf("expected the device to report firmware 2.0.0, got %+v, %v", devices, err)
+	}
+}
+
+func TestFirmwarePlugin_DeviceReset(t *testing.T) {
+	p := newTestPlugin(t)
+	ctx := context.Background()
+	p.SetReset(plugin.FirmwareResetDevice, 2)
+
+	if err := p.StageFirmware(ctx, "dev-0", testImage("2.0.0")); err != nil {
+		t.Fatalf("StageFirmware failed: %v", err)
+	}
+	if _, err := p.ActivateFirmware(ctx, "dev-0"); err != nil {
+		t.Fatalf("ActivateFirmware failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.GetFirmwareVersions(ctx, "dev-0"); !errors.Is(err, plugin.ErrConnectionFailed) {
+			t.Fatalf("expected ErrConnectionFailed while the device resets, got %v", err)
+		}
+	}
+	if v := nicVersion(t, p); v.Running != "2.0.0" {
+		t.Errorf("expected 2.0.0 running after the reset, got %+v", v)
+	}
+}
+
+func TestFirmwarePlugin_HostReset(t *testing.T) {
+	p := newTestPlugin(t)
+	ctx := context.Background()
+	p.SetReset(plugin.FirmwareResetHost, 0)
+
+	if err := p.StageFirmware(ctx, "dev-0", testImage("2.0.0")); err != nil {
+		t.Fatalf("StageFirmware failed: %v", err)
+	}
+	activation, err := p.ActivateFirmware(ctx, "dev-0")
+	if err != nil {
+		t.Fatalf("ActivateFirmware failed: %v", err)
+	}
+	if activation.Reset != plugin.FirmwareResetHost {
+		t.Errorf("expected reset %q, got %q", plugin.FirmwareResetHost, activation.Reset)
+	}
+	if v := nicVersion(t, p); v.Running != "1.0.0" || v.Staged != "2.0.0" {
+		t.Errorf("expected 1.0.0 running until the reboot, got %+v", v)
+	}
+
+	p.RebootHost()
+	if v := nicVersion(t, p); v.Running != "2.0.0" {
+		t.Errorf("expected 2.0.0 running after the reboot, got %+v", v)
+	}
+}
+
+func TestFirmwarePlugin_StageInvalidImage(t *testing.T) {
+	p := newTestPlugin(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		image *plugin.FirmwareImage
+	}{
+		{name: "nil image", image: nil},
+		{name: "empty version", image: testImage("")},
+		{name: "short checksum", image: &plugin.FirmwareImage{Version: "2.0.0", Checksum: "abcd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := p.StageFirmware(ctx, "dev-0", tt.image); !errors.Is(err, plugin.ErrInvalidConfig) {
+				t.Errorf("expected ErrInvalidConfig, got %v", err)
+			}
+		})
+	}
+
+	if err := p.StageFirmware(ctx, "dev-9", testImage("2.0.0")); !errors.Is(err, plugin.ErrDeviceNotFound) {
+		t.Errorf("expected ErrDeviceNotFound, got %v", err)
+	}
+}
+
+func TestFirmwarePlugin_Failures(t *testing.T) {
+	p := newTestPlugin(t)
+	ctx := context.Background()
+	stageErr := errors.New("download failed")
+	activateErr := errors.New("signature rejected")
+
+	p.FailStage(stageErr)
+	if err := p.StageFirmware(ctx, "dev-0", testImage("2.0.0")); !errors.Is(err, stageErr) {
+		t.Errorf("expected the stage error, got %v", err)
+	}
+	p.FailStage(nil)
+	if err := p.StageFirmware(ctx, "dev-0", testImage("2.0.0")); err != nil {
+		t.Fatalf("StageFirmware failed: %v", err)
+	}
+
+	p.FailActivate(activateErr)
+	if _, err := p.ActivateFirmware(ctx, "dev-0"); !errors.Is(err, activateErr) {
+		t.Errorf("expected the activation error, got %v", err)
+	}
+
+	want := []string{
+		"StageFirmware dev-0 nic 2.0.0",
+		"StageFirmware dev-0 nic 2.0.0",
+		"ActivateFirmware dev-0",
+	}
+	calls := p.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("expected call %d to be %q, got %q", i, want[i], calls[i])
+		}
+	}
+}
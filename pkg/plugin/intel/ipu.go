@@ -21,11 +21,16 @@ package intel
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"sync"
 
 	"github.com/go-logr/logr"
 	"github.com/openshift/dpu-operator/pkg/opi"
 	"github.com/openshift/dpu-operator/pkg/plugin"
+	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -74,6 +79,12 @@ func New() *IPUPlugin {
 	}
 }
 
+// NewInstance returns a new plugin instance, so that each DPU can be served
+// on its own endpoint.
+func (p *IPUPlugin) NewInstance() plugin.Plugin {
+	return New()
+}
+
 // Info returns metadata about this plugin.
 func (p *IPUPlugin) Info() plugin.PluginInfo {
 	return plugin.PluginInfo{
@@ -226,6 +237,75 @@ func (p *IPUPlugin) GetInventory(ctx context.Context, deviceID string) (*plugin.
 
 // --- NetworkPlugin interface implementation ---
 
+func logicalBridgeResourceName(id string) string {
+	return fmt.Sprintf("//network.opiproject.org/bridges/%s", id)
+}
+
+// opiError converts an error of the IPU bridge, logging unimplemented services.
+func (p *IPUPlugin) opiError(op string, err error) error {
+	switch status.Code(err) {
+	case codes.Unimplemented:
+		p.log.Info("IPU bridge does not implement "+op, "error", err.Error())
+		return plugin.ErrNotImplemented
+	case codes.NotFound:
+		return fmt.Errorf("OPI %s failed: %w: %v", op, plugin.ErrResourceNotFound, err)
+	default:
+		return fmt.Errorf("OPI %s failed: %w", op, err)
+	}
+}
+
+// ensureLogicalBridge makes sure the logical bridge of a VLAN exists and
+// returns the name bridge ports refer to it by. The IPU bridge derives the
+// VLAN of a bridge port from this name, so it is the VLAN ID itself. Bridges
+// that do not manage logical bridges only need the name.
+func (p *IPUPlugin) ensureLogicalBridge(ctx context.Context, vlanID int) (string, error) {
+	bridgeID := strconv.Itoa(vlanID)
+
+	_, err := p.opiClient.Network().GetLogicalBridge(ctx, logicalBridgeResourceName(bridgeID))
+	switch status.Code(err) {
+	case codes.OK:
+		return bridgeID, nil
+	case codes.Unimplemented:
+		p.log.V(1).Info("IPU bridge does not manage logical bridges", "vlan", vlanID)
+		return bridgeID, nil
+	case codes.NotFound:
+	default:
+		return "", fmt.Errorf("OPI get logical bridge failed: %w", err)
+	}
+
+	req := &evpnpb.CreateLogicalBridgeRequest{
+		LogicalBridgeId: bridgeID,
+		LogicalBridge: &evpnpb.LogicalBridge{
+			Spec: &evpnpb.LogicalBridgeSpec{
+				VlanId: uint32(vlanID),
+			},
+		},
+	}
+	if _, err := p.opiClient.Network().CreateLogicalBridge(ctx, req); err != nil && status.Code(err) != codes.AlreadyExists {
+		return "", fmt.Errorf("OPI create logical bridge failed: %w", err)
+	}
+	p.log.Info("Logical bridge created", "vlan", vlanID)
+	return bridgeID, nil
+}
+
+// bridgePortFromOPI converts a bridge port reported by the IPU bridge.
+func bridgePortFromOPI(bp *evpnpb.BridgePort) *plugin.BridgePort {
+	port := &plugin.BridgePort{
+		ID:     bp.GetName(),
+		Name:   bp.GetName(),
+		Status: "Active",
+	}
+	if mac := bp.GetSpec().GetMacAddress(); len(mac) > 0 {
+		port.MACAddress = net.HardwareAddr(mac).String()
+	}
+	if bridges := bp.GetSpec().GetLogicalBridges(); len(bridges) == 1 {
+		if vlanID, err := strconv.Atoi(bridges[0]); err == nil {
+			port.VLANID = &vlanID
+		}
+	}
+	return port
+}
+
 // CreateBridgePort creates a new bridge port for a network function.
 func (p *IPUPlugin) CreateBridgePort(ctx context.Context, request *plugin.BridgePortRequest) (*plugin.BridgePort, error) {
 	p.mu.RLock()
@@ -235,8 +315,50 @@ func (p *IPUPlugin) CreateBridgePort(ctx context.Context, request *plugin.Bridge
 		return nil, plugin.ErrNotInitialized
 	}
 
-	p.log.Info("CreateBridgePort not implemented for Intel IPU plugin", "name", request.Name)
-	return nil, plugin.ErrNotImplemented
+	p.log.Info("Creating bridge port", "name", request.Name, "mac", request.MACAddress)
+
+	mac, err := net.ParseMAC(request.MACAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address: %w", err)
+	}
+
+	spec := &evpnpb.BridgePortSpec{
+		MacAddress: mac,
+		Ptype:      evpnpb.BridgePortType_BRIDGE_PORT_TYPE_ACCESS,
+	}
+	if request.VLANID != nil && *request.VLANID > 0 {
+		logicalBridge, err := p.ensureLogicalBridge(ctx, *request.VLANID)
+		if err != nil {
+			return nil, err
+		}
+		spec.LogicalBridges = []string{logicalBridge}
+	}
+
+	resp, err := p.opiClient.Network().CreateBridgePort(ctx, &evpnpb.CreateBridgePortRequest{
+		BridgePortId: request.Name,
+		BridgePort: &evpnpb.BridgePort{
+			Name: request.Name,
+			Spec: spec,
+		},
+	})
+	if err != nil {
+		return nil, p.opiError("CreateBridgePort", err)
+	}
+
+	port := bridgePortFromOPI(resp)
+	if port.Name == "" {
+		port.ID = request.Name
+		port.Name = request.Name
+	}
+	if port.MACAddress == "" {
+		port.MACAddress = mac.String()
+	}
+	if port.VLANID == nil {
+		port.VLANID = request.VLANID
+	}
+
+	p.log.Info("Bridge port created", "portID", port.ID)
+	return port, nil
 }
 
 // DeleteBridgePort removes a bridge port.
@@ -248,8 +370,12 @@ func (p *IPUPlugin) DeleteBridgePort(ctx context.Context, portID string) error {
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("DeleteBridgePort not implemented for Intel IPU plugin", "portID", portID)
-	return plugin.ErrNotImplemented
+	p.log.Info("Deleting bridge port", "portID", portID)
+
+	if err := p.opiClient.Network().DeleteBridgePort(ctx, portID); err != nil {
+		return p.opiError("DeleteBridgePort", err)
+	}
+	return nil
 }
 
 // GetBridgePort retrieves information about a bridge port.
@@ -261,7 +387,11 @@ func (p *IPUPlugin) GetBridgePort(ctx context.Context, portID string) (*plugin.B
 		return nil, plugin.ErrNotInitialized
 	}
 
-	return nil, plugin.ErrNotImplemented
+	resp, err := p.opiClient.Network().GetBridgePort(ctx, portID)
+	if err != nil {
+		return nil, p.opiError("GetBridgePort", err)
+	}
+	return bridgePortFromOPI(resp), nil
 }
 
 // ListBridgePorts lists all bridge ports managed by this plugin.
@@ -273,7 +403,16 @@ func (p *IPUPlugin) ListBridgePorts(ctx context.Context) ([]*plugin.BridgePort,
 		return nil, plugin.ErrNotInitialized
 	}
 
-	return nil, plugin.ErrNotImplemented
+	resp, err := p.opiClient.Network().ListBridgePorts(ctx)
+	if err != nil {
+		return nil, p.opiError("ListBridgePorts", err)
+	}
+
+	var ports []*plugin.BridgePort
+	for _, bp := range resp.BridgePorts {
+		ports = append(ports, bridgePortFromOPI(bp))
+	}
+	return ports, nil
 }
 
 // SetVFCount configures the number of virtual functions.
@@ -285,11 +424,20 @@ func (p *IPUPlugin) SetVFCount(ctx context.Context, deviceID string, count int)
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("SetVFCount not implemented for Intel IPU plugin", "deviceID", deviceID, "count", count)
-	return plugin.ErrNotImplemented
+	p.log.Info("Setting VF count", "deviceID", deviceID, "count", count)
+
+	resp, err := p.opiClient.Lifecycle().SetNumVfs(ctx, int32(count))
+	if err != nil {
+		return p.opiError("SetNumVfs", err)
+	}
+	if int(resp.GetVfCnt()) != count {
+		return fmt.Errorf("IPU bridge configured %d VFs, requested %d", resp.GetVfCnt(), count)
+	}
+	return nil
 }
 
-// GetVFCount returns the current number of virtual functions.
+// GetVFCount returns the current number of virtual functions. The IPU bridge
+// reports every VF it exposes to the host as a device.
 func (p *IPUPlugin) GetVFCount(ctx context.Context, deviceID string) (int, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -298,7 +446,11 @@ func (p *IPUPlugin) GetVFCount(ctx context.Context, deviceID string) (int, error
 		return 0, plugin.ErrNotInitialized
 	}
 
-	return 0, plugin.ErrNotImplemented
+	resp, err := p.opiClient.Lifecycle().GetDevices(ctx)
+	if err != nil {
+		return 0, p.opiError("GetDevices", err)
+	}
+	return len(resp.GetDevices()), nil
 }
 
 // CreateNetworkFunction sets up a network function between input and output ports.
@@ -310,8 +462,12 @@ func (p *IPUPlugin) CreateNetworkFunction(ctx context.Context, input, output str
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("CreateNetworkFunction not implemented for Intel IPU plugin", "input", input, "output", output)
-	return plugin.ErrNotImplemented
+	p.log.Info("Creating network function", "input", input, "output", output)
+
+	if err := p.opiClient.NetworkFunction().CreateNetworkFunction(ctx, input, output); err != nil {
+		return p.opiError("CreateNetworkFunction", err)
+	}
+	return nil
 }
 
 // DeleteNetworkFunction removes a network function.
@@ -323,14 +479,39 @@ func (p *IPUPlugin) DeleteNetworkFunction(ctx context.Context, input, output str
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("DeleteNetworkFunction not implemented for Intel IPU plugin", "input", input, "output", output)
-	return plugin.ErrNotImplemented
+	p.log.Info("Deleting network function", "input", input, "output", output)
+
+	if err := p.opiClient.NetworkFunction().DeleteNetworkFunction(ctx, input, output); err != nil {
+		return p.opiError("DeleteNetworkFunction", err)
+	}
+	return nil
+}
+
+// ListNetworkFunctions lists the network functions set up on the IPU.
+func (p *IPUPlugin) ListNetworkFunctions(ctx context.Context) ([]*plugin.NetworkFunction, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	nfs, err := p.opiClient.NetworkFunction().ListNetworkFunctions(ctx)
+	if err != nil {
+		return nil, p.opiError("ListNetworkFunctions", err)
+	}
+	result := make([]*plugin.NetworkFunction, 0, len(nfs))
+	for _, nf := range nfs {
+		result = append(result, &plugin.NetworkFunction{Input: nf.GetInput(), Output: nf.GetOutput()})
+	}
+	return result, nil
 }
 
 // Ensure IPUPlugin implements the required interfaces.
 var (
-	_ plugin.Plugin        = (*IPUPlugin)(nil)
-	_ plugin.NetworkPlugin = (*IPUPlugin)(nil)
+	_ plugin.Plugin         = (*IPUPlugin)(nil)
+	_ plugin.NetworkPlugin  = (*IPUPlugin)(nil)
+	_ plugin.InstancePlugin = (*IPUPlugin)(nil)
 )
 
 // init registers the plugin with the global registry.
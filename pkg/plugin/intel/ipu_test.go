@@ -2,10 +2,13 @@ package intel
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/go-logr/logr"
 	"github.com/openshift/dpu-operator/pkg/plugin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func newTestPlugin(t *testing.T) *IPUPlugin {
@@ -95,3 +98,146 @@ func TestIPUPlugin_DiscoverDevicesWithoutInit(t *testing.T) {
 		t.Errorf("expected ErrNotInitialized, got: %v", err)
 	}
 }
+
+func newConnectedTestPlugin(t *testing.T) (*IPUPlugin, *mockIPUBridge) {
+	t.Helper()
+	bridge, address, cleanup := startMockServer(t)
+	t.Cleanup(cleanup)
+
+	p := newTestPlugin(t)
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{OPIEndpoint: address}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p, bridge
+}
+
+func TestIPUPlugin_BridgePorts(t *testing.T) {
+	p, bridge := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	vlan := 100
+	port, err := p.CreateBridgePort(ctx, &plugin.BridgePortRequest{
+		Name:       "host0-1",
+		MACAddress: "AA:BB:CC:DD:EE:01",
+		VLANID:     &vlan,
+	})
+	if err != nil {
+		t.Fatalf("CreateBridgePort failed: %v", err)
+	}
+	if port.ID != "host0-1" || port.MACAddress != "aa:bb:cc:dd:ee:01" {
+		t.Errorf("unexpected bridge port %+v", port)
+	}
+	if port.VLANID == nil || *port.VLANID != vlan {
+		t.Errorf("expected VLAN %d, got %v", vlan, port.VLANID)
+	}
+	if _, ok := bridge.logicalBridges[logicalBridgeResourceName("100")]; !ok {
+		t.Error("expected the logical bridge of VLAN 100 to be created")
+	}
+
+	// A second port on the same VLAN reuses the logical bridge
+	if _, err := p.CreateBridgePort(ctx, &plugin.BridgePortRequest{Name: "host0-2", MACAddress: "aa:bb:cc:dd:ee:02", VLANID: &vlan}); err != nil {
+		t.Fatalf("CreateBridgePort failed: %v", err)
+	}
+
+	got, err := p.GetBridgePort(ctx, "host0-2")
+	if err != nil {
+		t.Fatalf("GetBridgePort failed: %v", err)
+	}
+	if got.MACAddress != "aa:bb:cc:dd:ee:02" {
+		t.Errorf("expected MAC aa:bb:cc:dd:ee:02, got %s", got.MACAddress)
+	}
+
+	ports, err := p.ListBridgePorts(ctx)
+	if err != nil {
+		t.Fatalf("ListBridgePorts failed: %v", err)
+	}
+	if len(ports) != 2 {
+		t.Errorf("expected 2 bridge ports, got %d", len(ports))
+	}
+
+	if err := p.DeleteBridgePort(ctx, "host0-1"); err != nil {
+		t.Fatalf("DeleteBridgePort failed: %v", err)
+	}
+	if _, err := p.GetBridgePort(ctx, "host0-1"); !errors.Is(err, plugin.ErrResourceNotFound) {
+		t.Errorf("expected ErrResourceNotFound for a deleted port, got: %v", err)
+	}
+}
+
+func TestIPUPlugin_CreateBridgePortInvalidMAC(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	_, err := p.CreateBridgePort(context.Background(), &plugin.BridgePortRequest{Name: "host0-1", MACAddress: "invalid"})
+	if err == nil {
+		t.Error("expected an error for an invalid MAC address")
+	}
+}
+
+func TestIPUPlugin_VFCount(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	if err := p.SetVFCount(ctx, "ipu0", 4); err != nil {
+		t.Fatalf("SetVFCount failed: %v", err)
+	}
+	count, err := p.GetVFCount(ctx, "ipu0")
+	if err != nil {
+		t.Fatalf("GetVFCount failed: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("expected 4 VFs, got %d", count)
+	}
+
+	// The bridge caps the VF count, which must be reported as an error
+	if err := p.SetVFCount(ctx, "ipu0", 16); err == nil {
+		t.Error("expected an error when the bridge configures fewer VFs than requested")
+	}
+}
+
+func TestIPUPlugin_NetworkFunctions(t *testing.T) {
+	p, bridge := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	if err := p.CreateNetworkFunction(ctx, "in", "out"); err != nil {
+		t.Fatalf("CreateNetworkFunction failed: %v", err)
+	}
+	if !bridge.networkFunctions[[2]string{"in", "out"}] {
+		t.Error("expected the network function to be created on the bridge")
+	}
+	nfs, err := p.ListNetworkFunctions(ctx)
+	if err != nil {
+		t.Fatalf("ListNetworkFunctions failed: %v", err)
+	}
+	if len(nfs) != 1 || nfs[0].Input != "in" || nfs[0].Output != "out" {
+		t.Errorf("expected the network function to be listed, got %v", nfs)
+	}
+	if err := p.DeleteNetworkFunction(ctx, "in", "out"); err != nil {
+		t.Fatalf("DeleteNetworkFunction failed: %v", err)
+	}
+	if len(bridge.networkFunctions) != 0 {
+		t.Error("expected the network function to be deleted on the bridge")
+	}
+}
+
+func TestIPUPlugin_UnimplementedService(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	// The mock bridge does not register the LifeCycleService, like bridges
+	// that only implement a subset of OPI.
+	_, err := p.opiClient.Lifecycle().Init(context.Background(), nil)
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected Unimplemented, got: %v", err)
+	}
+	if !plugin.IsNotImplemented(p.opiError("Init", err)) {
+		t.Errorf("expected Unimplemented to map to ErrNotImplemented")
+	}
+}
+
+func TestIPUPlugin_NetworkWithoutInit(t *testing.T) {
+	p := newTestPlugin(t)
+	ctx := context.Background()
+	if _, err := p.CreateBridgePort(ctx, &plugin.BridgePortRequest{}); err != plugin.ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized from CreateBridgePort, got: %v", err)
+	}
+	if err := p.CreateNetworkFunction(ctx, "in", "out"); err != plugin.ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized from CreateNetworkFunction, got: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package intel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
+	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	lifecyclepb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// mockIPUBridge implements the services of the ipu-opi-plugins bridge for testing
+type mockIPUBridge struct {
+	lifecyclepb.UnimplementedDeviceServiceServer
+	lifecyclepb.UnimplementedHeartbeatServiceServer
+	evpnpb.UnimplementedBridgePortServiceServer
+	evpnpb.UnimplementedLogicalBridgeServiceServer
+	nfapi.UnimplementedNetworkFunctionServiceServer
+
+	mu               sync.Mutex
+	vfCount          int32
+	bridgePorts      map[string]*evpnpb.BridgePort
+	logicalBridges   map[string]*evpnpb.LogicalBridge
+	networkFunctions map[[2]string]bool
+}
+
+func newMockIPUBridge() *mockIPUBridge {
+	return &mockIPUBridge{
+		bridgePorts:      make(map[string]*evpnpb.BridgePort),
+		logicalBridges:   make(map[string]*evpnpb.LogicalBridge),
+		networkFunctions: make(map[[2]string]bool),
+	}
+}
+
+func (m *mockIPUBridge) GetDevices(ctx context.Context, req *lifecyclepb.GetDevicesRequest) (*lifecyclepb.DeviceListResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &lifecyclepb.DeviceListResponse{Devices: map[string]*lifecyclepb.Device{}}
+	for i := int32(0); i < m.vfCount; i++ {
+		id := fmt.Sprintf("vf%d", i)
+		resp.Devices[id] = &lifecyclepb.Device{Id: id, Health: "Healthy"}
+	}
+	return resp, nil
+}
+
+func (m *mockIPUBridge) SetNumVfs(ctx context.Context, req *lifecyclepb.SetNumVfsRequest) (*lifecyclepb.VfCount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The IPU exposes at most 8 VFs to the host
+	m.vfCount = min(req.VfCnt, 8)
+	return &lifecyclepb.VfCount{VfCnt: m.vfCount}, nil
+}
+
+func (m *mockIPUBridge) Ping(ctx context.Context, req *lifecyclepb.PingRequest) (*lifecyclepb.PingResponse, error) {
+	return &lifecyclepb.PingResponse{Healthy: true}, nil
+}
+
+func (m *mockIPUBridge) CreateBridgePort(ctx context.Context, req *evpnpb.CreateBridgePortRequest) (*evpnpb.BridgePort, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, lb := range req.BridgePort.Spec.LogicalBridges {
+		if _, ok := m.logicalBridges[logicalBridgeResourceName(lb)]; !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "logical bridge %s not found", lb)
+		}
+	}
+	m.bridgePorts[req.BridgePortId] = req.BridgePort
+	return req.BridgePort, nil
+}
+
+func (m *mockIPUBridge) GetBridgePort(ctx context.Context, req *evpnpb.GetBridgePortRequest) (*evpnpb.BridgePort, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bp, ok := m.bridgePorts[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s not found", req.Name)
+	}
+	return bp, nil
+}
+
+func (m *mockIPUBridge) ListBridgePorts(ctx context.Context, req *evpnpb.ListBridgePortsRequest) (*evpnpb.ListBridgePortsResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &evpnpb.ListBridgePortsResponse{}
+	for _, bp := range m.bridgePorts {
+		resp.BridgePorts = append(resp.BridgePorts, bp)
+	}
+	return resp, nil
+}
+
+func (m *mockIPUBridge) DeleteBridgePort(ctx context.Context, req *evpnpb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.bridgePorts[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s not found", req.Name)
+	}
+	delete(m.bridgePorts, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (m *mockIPUBridge) CreateLogicalBridge(ctx context.Context, req *evpnpb.CreateLogicalBridgeRequest) (*evpnpb.LogicalBridge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := logicalBridgeResourceName(req.LogicalBridgeId)
+	lb := &evpnpb.LogicalBridge{Name: name, Spec: req.LogicalBridge.Spec}
+	m.logicalBridges[name] = lb
+	return lb, nil
+}
+
+func (m *mockIPUBridge) GetLogicalBridge(ctx context.Context, req *evpnpb.GetLogicalBridgeRequest) (*evpnpb.LogicalBridge, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lb, ok := m.logicalBridges[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "logical bridge %s not found", req.Name)
+	}
+	return lb, nil
+}
+
+func (m *mockIPUBridge) CreateNetworkFunction(ctx context.Context, req *nfapi.NFRequest) (*nfapi.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.networkFunctions[[2]string{req.Input, req.Output}] = true
+	return &nfapi.Empty{}, nil
+}
+
+func (m *mockIPUBridge) DeleteNetworkFunction(ctx context.Context, req *nfapi.NFRequest) (*nfapi.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.networkFunctions, [2]string{req.Input, req.Output})
+	return &nfapi.Empty{}, nil
+}
+
+func (m *mockIPUBridge) ListNetworkFunctions(ctx context.Context, req *nfapi.Empty) (*nfapi.NFList, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &nfapi.NFList{}
+	for nf := range m.networkFunctions {
+		resp.NetworkFunctions = append(resp.NetworkFunctions, &nfapi.NFRequest{Input: nf[0], Output: nf[1]})
+	}
+	return resp, nil
+}
+
+// startMockServer starts a mock IPU bridge and returns it with its address and a cleanup function
+func startMockServer(t *testing.T) (*mockIPUBridge, string, func()) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	bridge := newMockIPUBridge()
+
+	lifecyclepb.RegisterDeviceServiceServer(server, bridge)
+	lifecyclepb.RegisterHeartbeatServiceServer(server, bridge)
+	evpnpb.RegisterBridgePortServiceServer(server, bridge)
+	evpnpb.RegisterLogicalBridgeServiceServer(server, bridge)
+	nfapi.RegisterNetworkFunctionServiceServer(server, bridge)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	return bridge, listener.Addr().String(), server.Stop
+}
@@ -18,6 +18,7 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 )
 
 // Plugin is the core interface that all vendor plugins must implement.
@@ -76,6 +77,18 @@ type NetworkPlugin interface {
 
 	// DeleteNetworkFunction removes a network function.
 	DeleteNetworkFunction(ctx context.Context, input, output string) error
+
+	// ListNetworkFunctions lists the network functions set up on the device.
+	ListNetworkFunctions(ctx context.Context) ([]*NetworkFunction, error)
+}
+
+// NetworkFunction is a network function between two ports.
+type NetworkFunction struct {
+	// Input is the port the traffic enters the network function through.
+	Input string
+
+	// Output is the port the traffic leaves the network function through.
+	Output string
 }
 
 // BridgePortRequest contains parameters for creating a bridge port.
@@ -89,6 +102,12 @@ type BridgePortRequest struct {
 	// VLANID is the optional VLAN ID for the port.
 	VLANID *int
 
+	// PF is the optional index of the host physical function the port serves.
+	PF *int
+
+	// VF is the optional index of the host virtual function the port serves.
+	VF *int
+
 	// Type specifies the port type (e.g., "trunk", "access").
 	Type string
 
@@ -197,6 +216,9 @@ type NVMeController struct {
 	// SubsystemID is the parent subsystem.
 	SubsystemID string
 
+	// PCIeAddress is the PCIe function the controller is exposed on.
+	PCIeAddress string
+
 	// Status is the current status.
 	Status string
 }
@@ -214,6 +236,36 @@ type NVMeNamespaceRequest struct {
 
 	// BlockSize is the logical block size.
 	BlockSize int
+
+	// Target is the remote NVMe-oF namespace backing the namespace.
+	Target *NVMeOFTarget
+}
+
+// NVMe-oF transports of an NVMeOFTarget.
+const (
+	NVMeOFTransportTCP  = "tcp"
+	NVMeOFTransportRDMA = "rdma"
+)
+
+// NVMeOFTarget describes a namespace of a remote NVMe-oF subsystem.
+type NVMeOFTarget struct {
+	// Transport is the fabrics transport, NVMeOFTransportTCP or NVMeOFTransportRDMA.
+	Transport string
+
+	// Address is the IP address of the target.
+	Address string
+
+	// Port is the transport service ID of the target.
+	Port int
+
+	// NQN is the NVMe Qualified Name of the remote subsystem.
+	NQN string
+
+	// HostNQN is the NQN the DPU connects to the target as. Optional.
+	HostNQN string
+
+	// NSID is the ID of the namespace in the remote subsystem.
+	NSID int
 }
 
 // NVMeNamespace represents an NVMe namespace.
@@ -297,6 +349,22 @@ type IPsecKeys struct {
 	SPI uint32
 }
 
+// String implements fmt.Stringer without revealing the key material, so that
+// IPsecKeys can never leak keys into logs or error messages.
+func (k IPsecKeys) String() string {
+	return fmt.Sprintf("{SPI:%d EncryptionKey:<redacted> AuthenticationKey:<redacted>}", k.SPI)
+}
+
+// GoString redacts the key material from %#v.
+func (k IPsecKeys) GoString() string {
+	return "plugin.IPsecKeys" + k.String()
+}
+
+// MarshalLog implements logr.Marshaler so structured loggers only see the SPI.
+func (k IPsecKeys) MarshalLog() interface{} {
+	return struct{ SPI uint32 }{SPI: k.SPI}
+}
+
 // IPsecTunnel represents an IPsec tunnel.
 type IPsecTunnel struct {
 	// ID is the unique identifier.
@@ -333,6 +401,111 @@ type IPsecStats struct {
 	Errors uint64
 }
 
+// FirmwarePlugin extends Plugin with firmware management.
+// Plugins that can update the firmware of their devices should implement this.
+type FirmwarePlugin interface {
+	Plugin
+
+	// GetFirmwareVersions returns the running and staged firmware of each
+	// firmware component of a device.
+	GetFirmwareVersions(ctx context.Context, deviceID string) ([]FirmwareVersion, error)
+
+	// StageFirmware downloads an image, verifies its checksum and writes it
+	// to the device without activating it. The device keeps running its
+	// current firmware until ActivateFirmware is called.
+	StageFirmware(ctx context.Context, deviceID string, image *FirmwareImage) error
+
+	// ActivateFirmware activates the firmware staged on a device. The result
+	// tells what must happen before the staged firmware runs. A device reset
+	// is triggered by the plugin, a host reboot is left to the caller.
+	ActivateFirmware(ctx context.Context, deviceID string) (*FirmwareActivation, error)
+}
+
+// FirmwareComponentNIC is the main firmware of a DPU, the one reported as
+// Device.FirmwareVersion.
+const FirmwareComponentNIC = "nic"
+
+// FirmwareVersion describes the firmware of a component of a device.
+type FirmwareVersion struct {
+	// Component is the firmware component (e.g., "nic", "bios", "bmc").
+	Component string
+
+	// Running is the version the component runs.
+	Running string
+
+	// Staged is the version that runs after the next activation, if any.
+	Staged string
+}
+
+// FirmwareImage contains parameters for staging a firmware image.
+type FirmwareImage struct {
+	// Component is the firmware component the image is for.
+	Component string
+
+	// Version is the firmware version of the image.
+	Version string
+
+	// URL is the location the plugin downloads the image from.
+	URL string
+
+	// Checksum is the hex encoded SHA-256 digest of the image. The plugin
+	// must refuse an image that does not match it.
+	Checksum string
+}
+
+// FirmwareReset is what must happen before activated firmware runs.
+type FirmwareReset string
+
+const (
+	// FirmwareResetNone means the firmware runs once it is activated.
+	FirmwareResetNone FirmwareReset = "None"
+	// FirmwareResetDevice means the plugin resets the device to run the
+	// firmware. The device is unreachable until it is back up.
+	FirmwareResetDevice FirmwareReset = "Device"
+	// FirmwareResetHost means the firmware runs after the host is rebooted.
+	FirmwareResetHost FirmwareReset = "Host"
+)
+
+// FirmwareActivation is the result of activating staged firmware.
+type FirmwareActivation struct {
+	// Reset is what must happen before the activated firmware runs.
+	Reset FirmwareReset
+}
+
+// RebootPlugin extends Plugin with device reboots.
+// Plugins that can reboot their devices on request should implement this.
+type RebootPlugin interface {
+	Plugin
+
+	// RebootDevice starts a reboot of a device and returns without waiting
+	// for the device to come back. The device is unreachable until it is
+	// back up, and its host side may disappear from the PCI bus meanwhile.
+	RebootDevice(ctx context.Context, deviceID string) error
+}
+
+// InstancePlugin extends Plugin with separate instances.
+// The registered plugin is a single instance shared by its users; plugins
+// that can serve several DPUs at once, each on its own endpoint, should
+// implement this.
+type InstancePlugin interface {
+	Plugin
+
+	// NewInstance returns a new, uninitialized instance of the plugin that
+	// shares no state with the registered one.
+	NewInstance() Plugin
+}
+
+// ChainPlugin extends NetworkPlugin with service function chains.
+// Plugins whose network functions are independent of each other, so that
+// the output of one can be wired to the input of the next through
+// CreateNetworkFunction, should implement this.
+type ChainPlugin interface {
+	NetworkPlugin
+
+	// ChainsNetworkFunctions reports whether network functions can be chained.
+	ChainsNetworkFunctions() bool
+}
+
 // PluginChecker provides type assertions for capability interfaces.
 // This helps determine which optional interfaces a plugin implements.
 type PluginChecker struct {
@@ -386,6 +559,62 @@ func (c *PluginChecker) AsSecurityPlugin() SecurityPlugin {
 	return nil
 }
 
+// IsFirmwarePlugin returns true if the plugin implements FirmwarePlugin.
+func (c *PluginChecker) IsFirmwarePlugin() bool {
+	_, ok := c.plugin.(FirmwarePlugin)
+	return ok
+}
+
+// AsFirmwarePlugin returns the plugin as FirmwarePlugin, or nil if not supported.
+func (c *PluginChecker) AsFirmwarePlugin() FirmwarePlugin {
+	if fp, ok := c.plugin.(FirmwarePlugin); ok {
+		return fp
+	}
+	return nil
+}
+
+// IsRebootPlugin returns true if the plugin implements RebootPlugin.
+func (c *PluginChecker) IsRebootPlugin() bool {
+	_, ok := c.plugin.(RebootPlugin)
+	return ok
+}
+
+// AsRebootPlugin returns the plugin as RebootPlugin, or nil if not supported.
+func (c *PluginChecker) AsRebootPlugin() RebootPlugin {
+	if rp, ok := c.plugin.(RebootPlugin); ok {
+		return rp
+	}
+	return nil
+}
+
+// IsInstancePlugin returns true if the plugin implements InstancePlugin.
+func (c *PluginChecker) IsInstancePlugin() bool {
+	_, ok := c.plugin.(InstancePlugin)
+	return ok
+}
+
+// AsInstancePlugin returns the plugin as InstancePlugin, or nil if not supported.
+func (c *PluginChecker) AsInstancePlugin() InstancePlugin {
+	if ip, ok := c.plugin.(InstancePlugin); ok {
+		return ip
+	}
+	return nil
+}
+
+// IsChainPlugin returns true if the plugin implements ChainPlugin.
+func (c *PluginChecker) IsChainPlugin() bool {
+	_, ok := c.plugin.(ChainPlugin)
+	return ok
+}
+
+// AsChainPlugin returns the plugin as ChainPlugin, or nil if not supported.
+func (c *PluginChecker) AsChainPlugin() ChainPlugin {
+	if cp, ok := c.plugin.(ChainPlugin); ok {
+		return cp
+	}
+	return nil
+}
+
 // SupportsCapability checks if the plugin supports a given capability.
 func (c *PluginChecker) SupportsCapability(cap Capability) bool {
 	info := c.plugin.Info()
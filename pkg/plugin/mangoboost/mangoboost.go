@@ -68,6 +68,12 @@ func New() *MangoBoostPlugin {
 	}
 }
 
+// NewInstance returns a new plugin instance, so that each DPU can be served
+// on its own endpoint.
+func (p *MangoBoostPlugin) NewInstance() plugin.Plugin {
+	return New()
+}
+
 // Info returns metadata about this plugin.
 func (p *MangoBoostPlugin) Info() plugin.PluginInfo {
 	return plugin.PluginInfo{
@@ -293,10 +299,23 @@ func (p *MangoBoostPlugin) DeleteNetworkFunction(ctx context.Context, input, out
 	return plugin.ErrNotImplemented
 }
 
+// ListNetworkFunctions lists the network functions set up on the device.
+func (p *MangoBoostPlugin) ListNetworkFunctions(ctx context.Context) ([]*plugin.NetworkFunction, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	return nil, plugin.ErrNotImplemented
+}
+
 // Ensure MangoBoostPlugin implements the required interfaces.
 var (
-	_ plugin.Plugin        = (*MangoBoostPlugin)(nil)
-	_ plugin.NetworkPlugin = (*MangoBoostPlugin)(nil)
+	_ plugin.Plugin         = (*MangoBoostPlugin)(nil)
+	_ plugin.NetworkPlugin  = (*MangoBoostPlugin)(nil)
+	_ plugin.InstancePlugin = (*MangoBoostPlugin)(nil)
 )
 
 // init registers the plugin with the global registry.
@@ -0,0 +1,138 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package marvell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	nfapi "github.com/openshift/dpu-operator/dpu-api/gen"
+	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	lifecyclepb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// mockVsp mimics the Marvell VSP: it serves bridge ports, network functions
+// and devices, but neither gets nor lists bridge ports.
+type mockVsp struct {
+	lifecyclepb.UnimplementedDeviceServiceServer
+	evpnpb.UnimplementedBridgePortServiceServer
+	nfapi.UnimplementedNetworkFunctionServiceServer
+
+	mu               sync.Mutex
+	vfCount          int32
+	bridgePorts      map[string]*evpnpb.BridgePort
+	networkFunctions map[[2]string]bool
+}
+
+func (m *mockVsp) GetDevices(ctx context.Context, req *lifecyclepb.GetDevicesRequest) (*lifecyclepb.DeviceListResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &lifecyclepb.DeviceListResponse{Devices: map[string]*lifecyclepb.Device{}}
+	for i := int32(0); i < m.vfCount; i++ {
+		id := fmt.Sprintf("0000:01:00.%d", i+1)
+		resp.Devices[id] = &lifecyclepb.Device{Id: id, Health: "Healthy"}
+	}
+	return resp, nil
+}
+
+func (m *mockVsp) SetNumVfs(ctx context.Context, req *lifecyclepb.SetNumVfsRequest) (*lifecyclepb.VfCount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if req.VfCnt < 0 {
+		return nil, errors.New("invalid VF Count")
+	}
+	m.vfCount = req.VfCnt
+	return &lifecyclepb.VfCount{VfCnt: req.VfCnt}, nil
+}
+
+func (m *mockVsp) CreateBridgePort(ctx context.Context, req *evpnpb.CreateBridgePortRequest) (*evpnpb.BridgePort, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !bridgePortNameRegexp.MatchString(req.BridgePort.Name) {
+		return nil, errors.New("no VFId Match Found")
+	}
+	m.bridgePorts[req.BridgePort.Name] = req.BridgePort
+	return &evpnpb.BridgePort{Name: "bridge_port/" + req.BridgePort.Name, Spec: req.BridgePort.Spec}, nil
+}
+
+func (m *mockVsp) DeleteBridgePort(ctx context.Context, req *evpnpb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.bridgePorts[req.Name]; !ok {
+		return nil, errors.New("no VFId Match Found")
+	}
+	delete(m.bridgePorts, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (m *mockVsp) CreateNetworkFunction(ctx context.Context, req *nfapi.NFRequest) (*nfapi.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.networkFunctions[[2]string{req.Input, req.Output}] = true
+	return &nfapi.Empty{}, nil
+}
+
+func (m *mockVsp) DeleteNetworkFunction(ctx context.Context, req *nfapi.NFRequest) (*nfapi.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.networkFunctions, [2]string{req.Input, req.Output})
+	return &nfapi.Empty{}, nil
+}
+
+func (m *mockVsp) ListNetworkFunctions(ctx context.Context, req *nfapi.Empty) (*nfapi.NFList, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &nfapi.NFList{}
+	for nf := range m.networkFunctions {
+		resp.NetworkFunctions = append(resp.NetworkFunctions, &nfapi.NFRequest{Input: nf[0], Output: nf[1]})
+	}
+	return resp, nil
+}
+
+// startMockVsp starts a mock Marvell VSP on a unix socket, like the real one,
+// and returns it with its endpoint.
+func startMockVsp(t *testing.T) (*mockVsp, string) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "vendor-plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	vsp := &mockVsp{
+		bridgePorts:      make(map[string]*evpnpb.BridgePort),
+		networkFunctions: make(map[[2]string]bool),
+	}
+	lifecyclepb.RegisterDeviceServiceServer(server, vsp)
+	evpnpb.RegisterBridgePortServiceServer(server, vsp)
+	nfapi.RegisterNetworkFunctionServiceServer(server, vsp)
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	return vsp, "unix://" + socket
+}
@@ -21,11 +21,19 @@ package marvell
 import (
 	"context"
 	"fmt"
+	"net"
+	"regexp"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/openshift/dpu-operator/pkg/metrics"
 	"github.com/openshift/dpu-operator/pkg/opi"
 	"github.com/openshift/dpu-operator/pkg/plugin"
+	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -38,6 +46,11 @@ const (
 
 	// PluginVersion is the current version of this plugin.
 	PluginVersion = "1.0.0"
+
+	// DefaultVSPEndpoint is the gRPC endpoint of the Marvell VSP, which serves
+	// the bridge port, network function and device services on the vendor
+	// plugin socket of the DPU daemon.
+	DefaultVSPEndpoint = "unix:///var/run/dpu-daemon/vendor-plugin/vendor-plugin.sock"
 )
 
 // Supported PCI device IDs for Marvell DPU devices.
@@ -55,7 +68,7 @@ type OcteonPlugin struct {
 	config      plugin.PluginConfig
 	initialized bool
 
-	// OPI endpoint for opi-marvell-bridge communication
+	// gRPC endpoint of the Marvell VSP
 	opiEndpoint string
 
 	// Cache of discovered devices
@@ -72,6 +85,12 @@ func New() *OcteonPlugin {
 	}
 }
 
+// NewInstance returns a new plugin instance, so that each DPU can be served
+// on its own endpoint.
+func (p *OcteonPlugin) NewInstance() plugin.Plugin {
+	return New()
+}
+
 // Info returns metadata about this plugin.
 func (p *OcteonPlugin) Info() plugin.PluginInfo {
 	return plugin.PluginInfo{
@@ -96,14 +115,14 @@ func (p *OcteonPlugin) Initialize(ctx context.Context, config plugin.PluginConfi
 	p.config = config
 	p.opiEndpoint = config.OPIEndpoint
 	if p.opiEndpoint == "" {
-		p.opiEndpoint = plugin.DefaultOPIEndpoint
+		p.opiEndpoint = DefaultVSPEndpoint
 	}
 
 	p.log.Info("Initializing Marvell Octeon plugin",
 		"opiEndpoint", p.opiEndpoint,
 		"logLevel", config.LogLevel)
 
-	// Initialize gRPC connection to opi-marvell-bridge
+	// Initialize gRPC connection to the Marvell VSP
 	var err error
 	p.opiClient, err = opi.NewClient(p.opiEndpoint)
 	if err != nil {
@@ -224,6 +243,43 @@ func (p *OcteonPlugin) GetInventory(ctx context.Context, deviceID string) (*plug
 
 // --- NetworkPlugin interface implementation ---
 
+// The Marvell VSP maps bridge ports to DPU VFs by their name, which must
+// identify the host PF and VF as "host<pf>-<vf>".
+var bridgePortNameRegexp = regexp.MustCompile(`^host(\d+)-(\d+)$`)
+
+// call invokes an operation of the Marvell VSP, records its metrics and
+// converts its error.
+func (p *OcteonPlugin) call(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	metrics.RecordOPICall(PluginVendor, op, time.Since(start).Seconds(), err)
+	metrics.RecordNetworkOperation(PluginVendor, op, err == nil)
+	if err == nil {
+		return nil
+	}
+
+	switch status.Code(err) {
+	case codes.Unimplemented:
+		p.log.Info("Marvell VSP does not implement "+op, "error", err.Error())
+		return plugin.NewPluginError(PluginName, op, plugin.ErrNotImplemented)
+	case codes.NotFound:
+		return plugin.NewPluginErrorWithDetails(PluginName, op, plugin.ErrResourceNotFound, status.Convert(err).Message())
+	default:
+		return plugin.NewPluginErrorWithDetails(PluginName, op, plugin.ErrOperationFailed, status.Convert(err).Message())
+	}
+}
+
+// bridgePortName returns the name of the bridge port of a request.
+func bridgePortName(request *plugin.BridgePortRequest) (string, error) {
+	if request.PF != nil && request.VF != nil {
+		return fmt.Sprintf("host%d-%d", *request.PF, *request.VF), nil
+	}
+	if !bridgePortNameRegexp.MatchString(request.Name) {
+		return "", fmt.Errorf("bridge port name %q does not identify a host VF", request.Name)
+	}
+	return request.Name, nil
+}
+
 // CreateBridgePort creates a new bridge port for a network function.
 func (p *OcteonPlugin) CreateBridgePort(ctx context.Context, request *plugin.BridgePortRequest) (*plugin.BridgePort, error) {
 	p.mu.RLock()
@@ -233,8 +289,50 @@ func (p *OcteonPlugin) CreateBridgePort(ctx context.Context, request *plugin.Bri
 		return nil, plugin.ErrNotInitialized
 	}
 
-	p.log.Info("CreateBridgePort not implemented for Marvell Octeon plugin", "name", request.Name)
-	return nil, plugin.ErrNotImplemented
+	name, err := bridgePortName(request)
+	if err != nil {
+		return nil, plugin.NewPluginError(PluginName, "CreateBridgePort", err)
+	}
+	mac, err := net.ParseMAC(request.MACAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address: %w", err)
+	}
+
+	p.log.Info("Creating bridge port", "name", name, "mac", request.MACAddress)
+
+	spec := &evpnpb.BridgePortSpec{
+		MacAddress: mac,
+		Ptype:      evpnpb.BridgePortType_BRIDGE_PORT_TYPE_ACCESS,
+	}
+	if request.VLANID != nil {
+		spec.LogicalBridges = []string{strconv.Itoa(*request.VLANID)}
+	}
+
+	err = p.call("CreateBridgePort", func() error {
+		_, err := p.opiClient.Network().CreateBridgePort(ctx, &evpnpb.CreateBridgePortRequest{
+			BridgePortId: name,
+			BridgePort: &evpnpb.BridgePort{
+				Name: name,
+				Spec: spec,
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The VSP deletes bridge ports by the name they were created with, so
+	// that name identifies the port rather than the one the VSP returns.
+	port := &plugin.BridgePort{
+		ID:         name,
+		Name:       name,
+		MACAddress: mac.String(),
+		VLANID:     request.VLANID,
+		Status:     "Active",
+	}
+	p.log.Info("Bridge port created", "portID", port.ID)
+	return port, nil
 }
 
 // DeleteBridgePort removes a bridge port.
@@ -246,8 +344,29 @@ func (p *OcteonPlugin) DeleteBridgePort(ctx context.Context, portID string) erro
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("DeleteBridgePort not implemented for Marvell Octeon plugin", "portID", portID)
-	return plugin.ErrNotImplemented
+	p.log.Info("Deleting bridge port", "portID", portID)
+
+	return p.call("DeleteBridgePort", func() error {
+		return p.opiClient.Network().DeleteBridgePort(ctx, portID)
+	})
+}
+
+// bridgePortFromOPI converts a bridge port reported by the Marvell VSP.
+func bridgePortFromOPI(bp *evpnpb.BridgePort) *plugin.BridgePort {
+	port := &plugin.BridgePort{
+		ID:     bp.GetName(),
+		Name:   bp.GetName(),
+		Status: "Active",
+	}
+	if mac := bp.GetSpec().GetMacAddress(); len(mac) > 0 {
+		port.MACAddress = net.HardwareAddr(mac).String()
+	}
+	if bridges := bp.GetSpec().GetLogicalBridges(); len(bridges) == 1 {
+		if vlanID, err := strconv.Atoi(bridges[0]); err == nil {
+			port.VLANID = &vlanID
+		}
+	}
+	return port
 }
 
 // GetBridgePort retrieves information about a bridge port.
@@ -259,7 +378,16 @@ func (p *OcteonPlugin) GetBridgePort(ctx context.Context, portID string) (*plugi
 		return nil, plugin.ErrNotInitialized
 	}
 
-	return nil, plugin.ErrNotImplemented
+	var resp *evpnpb.BridgePort
+	err := p.call("GetBridgePort", func() error {
+		var err error
+		resp, err = p.opiClient.Network().GetBridgePort(ctx, portID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return bridgePortFromOPI(resp), nil
 }
 
 // ListBridgePorts lists all bridge ports managed by this plugin.
@@ -271,7 +399,21 @@ func (p *OcteonPlugin) ListBridgePorts(ctx context.Context) ([]*plugin.BridgePor
 		return nil, plugin.ErrNotInitialized
 	}
 
-	return nil, plugin.ErrNotImplemented
+	var resp *evpnpb.ListBridgePortsResponse
+	err := p.call("ListBridgePorts", func() error {
+		var err error
+		resp, err = p.opiClient.Network().ListBridgePorts(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []*plugin.BridgePort
+	for _, bp := range resp.BridgePorts {
+		ports = append(ports, bridgePortFromOPI(bp))
+	}
+	return ports, nil
 }
 
 // SetVFCount configures the number of virtual functions.
@@ -283,11 +425,16 @@ func (p *OcteonPlugin) SetVFCount(ctx context.Context, deviceID string, count in
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("SetVFCount not implemented for Marvell Octeon plugin", "deviceID", deviceID, "count", count)
-	return plugin.ErrNotImplemented
+	p.log.Info("Setting VF count", "deviceID", deviceID, "count", count)
+
+	return p.call("SetNumVfs", func() error {
+		_, err := p.opiClient.Lifecycle().SetNumVfs(ctx, int32(count))
+		return err
+	})
 }
 
-// GetVFCount returns the current number of virtual functions.
+// GetVFCount returns the current number of virtual functions. On the host,
+// the Marvell VSP reports every VF of the Octeon as a device.
 func (p *OcteonPlugin) GetVFCount(ctx context.Context, deviceID string) (int, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -296,7 +443,13 @@ func (p *OcteonPlugin) GetVFCount(ctx context.Context, deviceID string) (int, er
 		return 0, plugin.ErrNotInitialized
 	}
 
-	return 0, plugin.ErrNotImplemented
+	count := 0
+	err := p.call("GetDevices", func() error {
+		resp, err := p.opiClient.Lifecycle().GetDevices(ctx)
+		count = len(resp.GetDevices())
+		return err
+	})
+	return count, err
 }
 
 // CreateNetworkFunction sets up a network function between input and output ports.
@@ -308,8 +461,11 @@ func (p *OcteonPlugin) CreateNetworkFunction(ctx context.Context, input, output
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("CreateNetworkFunction not implemented for Marvell Octeon plugin", "input", input, "output", output)
-	return plugin.ErrNotImplemented
+	p.log.Info("Creating network function", "input", input, "output", output)
+
+	return p.call("CreateNetworkFunction", func() error {
+		return p.opiClient.NetworkFunction().CreateNetworkFunction(ctx, input, output)
+	})
 }
 
 // DeleteNetworkFunction removes a network function.
@@ -321,14 +477,38 @@ func (p *OcteonPlugin) DeleteNetworkFunction(ctx context.Context, input, output
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("DeleteNetworkFunction not implemented for Marvell Octeon plugin", "input", input, "output", output)
-	return plugin.ErrNotImplemented
+	p.log.Info("Deleting network function", "input", input, "output", output)
+
+	return p.call("DeleteNetworkFunction", func() error {
+		return p.opiClient.NetworkFunction().DeleteNetworkFunction(ctx, input, output)
+	})
+}
+
+// ListNetworkFunctions lists the network functions set up on the device.
+func (p *OcteonPlugin) ListNetworkFunctions(ctx context.Context) ([]*plugin.NetworkFunction, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	var result []*plugin.NetworkFunction
+	err := p.call("ListNetworkFunctions", func() error {
+		nfs, err := p.opiClient.NetworkFunction().ListNetworkFunctions(ctx)
+		for _, nf := range nfs {
+			result = append(result, &plugin.NetworkFunction{Input: nf.GetInput(), Output: nf.GetOutput()})
+		}
+		return err
+	})
+	return result, err
 }
 
 // Ensure OcteonPlugin implements the required interfaces.
 var (
-	_ plugin.Plugin        = (*OcteonPlugin)(nil)
-	_ plugin.NetworkPlugin = (*OcteonPlugin)(nil)
+	_ plugin.Plugin         = (*OcteonPlugin)(nil)
+	_ plugin.NetworkPlugin  = (*OcteonPlugin)(nil)
+	_ plugin.InstancePlugin = (*OcteonPlugin)(nil)
 )
 
 // init registers the plugin with the global registry.
@@ -2,6 +2,7 @@ package marvell
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/go-logr/logr"
@@ -91,3 +92,120 @@ func TestOcteonPlugin_DiscoverDevicesWithoutInit(t *testing.T) {
 		t.Errorf("expected ErrNotInitialized, got: %v", err)
 	}
 }
+
+func newConnectedTestPlugin(t *testing.T) (*OcteonPlugin, *mockVsp) {
+	t.Helper()
+	vsp, endpoint := startMockVsp(t)
+
+	p := newTestPlugin(t)
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{OPIEndpoint: endpoint}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p, vsp
+}
+
+func TestOcteonPlugin_DefaultEndpoint(t *testing.T) {
+	p := newTestPlugin(t)
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer p.Shutdown(context.Background())
+	if p.opiEndpoint != DefaultVSPEndpoint {
+		t.Errorf("expected endpoint %q, got %q", DefaultVSPEndpoint, p.opiEndpoint)
+	}
+}
+
+func TestOcteonPlugin_BridgePorts(t *testing.T) {
+	p, vsp := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	pf, vf := 0, 3
+	port, err := p.CreateBridgePort(ctx, &plugin.BridgePortRequest{
+		Name:       "ignored",
+		MACAddress: "AA:BB:CC:DD:EE:03",
+		PF:         &pf,
+		VF:         &vf,
+	})
+	if err != nil {
+		t.Fatalf("CreateBridgePort failed: %v", err)
+	}
+	if port.ID != "host0-3" || port.MACAddress != "aa:bb:cc:dd:ee:03" {
+		t.Errorf("unexpected bridge port %+v", port)
+	}
+	if _, ok := vsp.bridgePorts["host0-3"]; !ok {
+		t.Error("expected the bridge port to be created on the VSP")
+	}
+
+	if _, err := p.CreateBridgePort(ctx, &plugin.BridgePortRequest{Name: "port-1", MACAddress: "aa:bb:cc:dd:ee:01"}); err == nil {
+		t.Error("expected an error for a name that does not identify a host VF")
+	}
+
+	if err := p.DeleteBridgePort(ctx, port.ID); err != nil {
+		t.Fatalf("DeleteBridgePort failed: %v", err)
+	}
+	err = p.DeleteBridgePort(ctx, port.ID)
+	if !errors.Is(err, plugin.ErrOperationFailed) {
+		t.Errorf("expected ErrOperationFailed when deleting a missing port, got: %v", err)
+	}
+	var pluginErr *plugin.PluginError
+	if !errors.As(err, &pluginErr) || pluginErr.Plugin != PluginName || pluginErr.Op != "DeleteBridgePort" {
+		t.Errorf("expected a marvell DeleteBridgePort PluginError, got: %v", err)
+	}
+}
+
+func TestOcteonPlugin_UnimplementedBridgePortQueries(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	if _, err := p.GetBridgePort(ctx, "host0-1"); !plugin.IsNotImplemented(err) {
+		t.Errorf("expected ErrNotImplemented from GetBridgePort, got: %v", err)
+	}
+	if _, err := p.ListBridgePorts(ctx); !plugin.IsNotImplemented(err) {
+		t.Errorf("expected ErrNotImplemented from ListBridgePorts, got: %v", err)
+	}
+}
+
+func TestOcteonPlugin_VFCount(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	if err := p.SetVFCount(ctx, "octeon0", 6); err != nil {
+		t.Fatalf("SetVFCount failed: %v", err)
+	}
+	count, err := p.GetVFCount(ctx, "octeon0")
+	if err != nil {
+		t.Fatalf("GetVFCount failed: %v", err)
+	}
+	if count != 6 {
+		t.Errorf("expected 6 VFs, got %d", count)
+	}
+	if err := p.SetVFCount(ctx, "octeon0", -1); !errors.Is(err, plugin.ErrOperationFailed) {
+		t.Errorf("expected ErrOperationFailed for a negative VF count, got: %v", err)
+	}
+}
+
+func TestOcteonPlugin_NetworkFunctions(t *testing.T) {
+	p, vsp := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	if err := p.CreateNetworkFunction(ctx, "in", "out"); err != nil {
+		t.Fatalf("CreateNetworkFunction failed: %v", err)
+	}
+	if !vsp.networkFunctions[[2]string{"in", "out"}] {
+		t.Error("expected the network function to be created on the VSP")
+	}
+	nfs, err := p.ListNetworkFunctions(ctx)
+	if err != nil {
+		t.Fatalf("ListNetworkFunctions failed: %v", err)
+	}
+	if len(nfs) != 1 || nfs[0].Input != "in" || nfs[0].Output != "out" {
+		t.Errorf("expected the network function to be listed, got %v", nfs)
+	}
+	if err := p.DeleteNetworkFunction(ctx, "in", "out"); err != nil {
+		t.Fatalf("DeleteNetworkFunction failed: %v", err)
+	}
+	if len(vsp.networkFunctions) != 0 {
+		t.Error("expected the network function to be deleted on the VSP")
+	}
+}
@@ -21,8 +21,10 @@ package nvidia
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 
 	"github.com/go-logr/logr"
@@ -56,8 +58,9 @@ var supportedDevices = []plugin.PCIDeviceID{
 	{VendorID: "15b3", DeviceID: "a2d8", Description: "NVIDIA BlueField-3 Integrated ConnectX-7"},
 }
 
-// BlueFieldPlugin implements the plugin.Plugin and plugin.NetworkPlugin interfaces
-// for NVIDIA BlueField DPUs (BlueField-2 and BlueField-3).
+// BlueFieldPlugin implements the plugin.Plugin, plugin.NetworkPlugin and
+// plugin.StoragePlugin interfaces for NVIDIA BlueField DPUs (BlueField-2 and
+// BlueField-3).
 type BlueFieldPlugin struct {
 	mu          sync.RWMutex
 	log         logr.Logger
@@ -78,6 +81,9 @@ type BlueFieldPlugin struct {
 
 	// gRPC client for EVPN-GW network operations (optional)
 	opiNetworkClient *opi.Client
+
+	// Serializes network function setup, which allocates logical bridge VLANs
+	nfMu sync.Mutex
 }
 
 // New creates a new NVIDIA BlueField plugin instance.
@@ -87,6 +93,12 @@ func New() *BlueFieldPlugin {
 	}
 }
 
+// NewInstance returns a new plugin instance, so that each DPU can be served
+// on its own endpoint.
+func (p *BlueFieldPlugin) NewInstance() plugin.Plugin {
+	return New()
+}
+
 func (p *BlueFieldPlugin) networkClient() *opi.Client {
 	if p.opiNetworkClient != nil {
 		return p.opiNetworkClient
@@ -104,8 +116,9 @@ func (p *BlueFieldPlugin) Info() plugin.PluginInfo {
 		SupportedDevices: supportedDevices,
 		Capabilities: []plugin.Capability{
 			plugin.CapabilityNetworking,
-			// Storage and security capabilities are planned once the OPI bridges
-			// and plugin implementations support those APIs end-to-end.
+			plugin.CapabilityStorage,
+			// Security capabilities are planned once the OPI bridges and plugin
+			// implementations support those APIs end-to-end.
 		},
 	}
 }
@@ -541,7 +554,8 @@ func (p *BlueFieldPlugin) SetVFCount(ctx context.Context, deviceID string, count
 	return nil
 }
 
-// GetVFCount returns the current number of virtual functions.
+// GetVFCount returns the current number of virtual functions. The nvidia
+// bridge reports every VF it exposes to the host as a device.
 func (p *BlueFieldPlugin) GetVFCount(ctx context.Context, deviceID string) (int, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -550,10 +564,151 @@ func (p *BlueFieldPlugin) GetVFCount(ctx context.Context, deviceID string) (int,
 		return 0, plugin.ErrNotInitialized
 	}
 
-	return 0, plugin.ErrNotImplemented
+	resp, err := p.opiClient.Lifecycle().GetDevices(ctx)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			p.log.Info("OPI bridge does not implement DeviceService", "error", err.Error())
+			return 0, plugin.ErrNotImplemented
+		}
+		return 0, fmt.Errorf("OPI GetDevices failed: %w", err)
+	}
+	return len(resp.Devices), nil
+}
+
+// Network functions are mapped onto a logical bridge dedicated to the pair of
+// ports, with an access port for the input and one for the output. The VLANs
+// of these logical bridges are taken from the top of the VLAN range so that
+// they do not clash with the VLANs of host bridge ports.
+const (
+	nfVlanMin = 3800
+	nfVlanMax = 4094
+)
+
+func bridgePortResourceName(id string) string {
+	return fmt.Sprintf("//network.opiproject.org/ports/%s", id)
+}
+
+// networkFunctionIDs returns the IDs of the logical bridge and the input and
+// output bridge ports of a network function.
+func networkFunctionIDs(input, output net.HardwareAddr) (bridgeID, inputID, outputID string) {
+	bridgeID = fmt.Sprintf("nf-%s-%s", hex.EncodeToString(input), hex.EncodeToString(output))
+	return bridgeID, bridgeID + "-in", bridgeID + "-out"
 }
 
-// CreateNetworkFunction sets up a network function between input and output ports.
+func parseNetworkFunctionPorts(input, output string) (net.HardwareAddr, net.HardwareAddr, error) {
+	inputMac, err := net.ParseMAC(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid input port %q: %w", input, err)
+	}
+	outputMac, err := net.ParseMAC(output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid output port %q: %w", output, err)
+	}
+	return inputMac, outputMac, nil
+}
+
+// networkFunctionFromBridge returns the network function a logical bridge was
+// created for, or nil if the logical bridge is not a network function bridge.
+func networkFunctionFromBridge(name string) *plugin.NetworkFunction {
+	id, ok := strings.CutPrefix(name, logicalBridgeResourceName("nf-"))
+	if !ok {
+		return nil
+	}
+	input, output, ok := strings.Cut(id, "-")
+	if !ok {
+		return nil
+	}
+	inputMac, err := hex.DecodeString(input)
+	if err != nil || len(inputMac) == 0 {
+		return nil
+	}
+	outputMac, err := hex.DecodeString(output)
+	if err != nil || len(outputMac) == 0 {
+		return nil
+	}
+	return &plugin.NetworkFunction{
+		Input:  net.HardwareAddr(inputMac).String(),
+		Output: net.HardwareAddr(outputMac).String(),
+	}
+}
+
+// ensureNetworkFunctionBridge returns the logical bridge of a network
+// function, creating it on a free VLAN if it does not exist yet.
+func (p *BlueFieldPlugin) ensureNetworkFunctionBridge(ctx context.Context, bridgeID string) (string, error) {
+	bridgeName := logicalBridgeResourceName(bridgeID)
+	_, err := p.networkClient().Network().GetLogicalBridge(ctx, bridgeName)
+	if err == nil {
+		return bridgeName, nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return "", err
+	}
+
+	resp, err := p.networkClient().Network().ListLogicalBridges(ctx)
+	if err != nil {
+		return "", err
+	}
+	used := make(map[uint32]bool)
+	for _, lb := range resp.LogicalBridges {
+		used[lb.GetSpec().GetVlanId()] = true
+	}
+	vlanID := uint32(0)
+	for vlan := uint32(nfVlanMin); vlan <= nfVlanMax; vlan++ {
+		if !used[vlan] {
+			vlanID = vlan
+			break
+		}
+	}
+	if vlanID == 0 {
+		return "", fmt.Errorf("no free VLAN left for network function logical bridges")
+	}
+
+	_, err = p.networkClient().Network().CreateLogicalBridge(ctx, &evpnpb.CreateLogicalBridgeRequest{
+		LogicalBridgeId: bridgeID,
+		LogicalBridge: &evpnpb.LogicalBridge{
+			Spec: &evpnpb.LogicalBridgeSpec{
+				VlanId: vlanID,
+			},
+		},
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return bridgeName, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	p.log.Info("Created network function logical bridge", "name", bridgeName, "vlan", vlanID)
+	return bridgeName, nil
+}
+
+// ensureAccessPort creates an access port on a logical bridge unless it exists.
+func (p *BlueFieldPlugin) ensureAccessPort(ctx context.Context, portID string, mac net.HardwareAddr, bridgeName string) error {
+	_, err := p.networkClient().Network().GetBridgePort(ctx, bridgePortResourceName(portID))
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return err
+	}
+
+	_, err = p.networkClient().Network().CreateBridgePort(ctx, &evpnpb.CreateBridgePortRequest{
+		BridgePortId: portID,
+		BridgePort: &evpnpb.BridgePort{
+			Spec: &evpnpb.BridgePortSpec{
+				MacAddress:     mac,
+				Ptype:          evpnpb.BridgePortType_BRIDGE_PORT_TYPE_ACCESS,
+				LogicalBridges: []string{bridgeName},
+			},
+		},
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return nil
+	}
+	return err
+}
+
+// CreateNetworkFunction sets up a network function between input and output
+// ports. Calling it again for an existing network function is a no-op.
 func (p *BlueFieldPlugin) CreateNetworkFunction(ctx context.Context, input, output string) error {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -562,11 +717,36 @@ func (p *BlueFieldPlugin) CreateNetworkFunction(ctx context.Context, input, outp
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("CreateNetworkFunction not implemented for NVIDIA BlueField plugin", "input", input, "output", output)
-	return plugin.ErrNotImplemented
+	inputMac, outputMac, err := parseNetworkFunctionPorts(input, output)
+	if err != nil {
+		return err
+	}
+	bridgeID, inputID, outputID := networkFunctionIDs(inputMac, outputMac)
+
+	p.log.Info("Creating network function", "input", input, "output", output, "logicalBridge", bridgeID)
+
+	p.nfMu.Lock()
+	defer p.nfMu.Unlock()
+
+	bridgeName, err := p.ensureNetworkFunctionBridge(ctx, bridgeID)
+	if err == nil {
+		err = p.ensureAccessPort(ctx, inputID, inputMac, bridgeName)
+	}
+	if err == nil {
+		err = p.ensureAccessPort(ctx, outputID, outputMac, bridgeName)
+	}
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			p.log.Info("OPI bridge does not implement EVPN network functions", "error", err.Error())
+			return plugin.ErrNotImplemented
+		}
+		return fmt.Errorf("OPI create network function failed: %w", err)
+	}
+	return nil
 }
 
-// DeleteNetworkFunction removes a network function.
+// DeleteNetworkFunction removes a network function. Parts of the network
+// function that are already gone are skipped, so that retries succeed.
 func (p *BlueFieldPlugin) DeleteNetworkFunction(ctx context.Context, input, output string) error {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -575,14 +755,74 @@ func (p *BlueFieldPlugin) DeleteNetworkFunction(ctx context.Context, input, outp
 		return plugin.ErrNotInitialized
 	}
 
-	p.log.Info("DeleteNetworkFunction not implemented for NVIDIA BlueField plugin", "input", input, "output", output)
-	return plugin.ErrNotImplemented
+	inputMac, outputMac, err := parseNetworkFunctionPorts(input, output)
+	if err != nil {
+		return err
+	}
+	bridgeID, inputID, outputID := networkFunctionIDs(inputMac, outputMac)
+
+	p.log.Info("Deleting network function", "input", input, "output", output, "logicalBridge", bridgeID)
+
+	network := p.networkClient().Network()
+	for _, del := range []func() error{
+		func() error { return network.DeleteBridgePort(ctx, bridgePortResourceName(inputID)) },
+		func() error { return network.DeleteBridgePort(ctx, bridgePortResourceName(outputID)) },
+		func() error { return network.DeleteLogicalBridge(ctx, logicalBridgeResourceName(bridgeID)) },
+	} {
+		err := del()
+		switch status.Code(err) {
+		case codes.OK, codes.NotFound:
+		case codes.Unimplemented:
+			p.log.Info("OPI bridge does not implement EVPN network functions", "error", err.Error())
+			return plugin.ErrNotImplemented
+		default:
+			return fmt.Errorf("OPI delete network function failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListNetworkFunctions lists the network functions from their logical
+// bridges.
+func (p *BlueFieldPlugin) ListNetworkFunctions(ctx context.Context) ([]*plugin.NetworkFunction, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	resp, err := p.networkClient().Network().ListLogicalBridges(ctx)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			p.log.Info("OPI bridge does not implement EVPN network functions", "error", err.Error())
+			return nil, plugin.ErrNotImplemented
+		}
+		return nil, fmt.Errorf("OPI list network functions failed: %w", err)
+	}
+
+	var result []*plugin.NetworkFunction
+	for _, lb := range resp.GetLogicalBridges() {
+		if nf := networkFunctionFromBridge(lb.GetName()); nf != nil {
+			result = append(result, nf)
+		}
+	}
+	return result, nil
+}
+
+// ChainsNetworkFunctions reports that network functions can be chained:
+// each one is a logical bridge of its own ports.
+func (p *BlueFieldPlugin) ChainsNetworkFunctions() bool {
+	return true
 }
 
 // Ensure BlueFieldPlugin implements the required interfaces.
 var (
-	_ plugin.Plugin        = (*BlueFieldPlugin)(nil)
-	_ plugin.NetworkPlugin = (*BlueFieldPlugin)(nil)
+	_ plugin.Plugin         = (*BlueFieldPlugin)(nil)
+	_ plugin.NetworkPlugin  = (*BlueFieldPlugin)(nil)
+	_ plugin.ChainPlugin    = (*BlueFieldPlugin)(nil)
+	_ plugin.StoragePlugin  = (*BlueFieldPlugin)(nil)
+	_ plugin.InstancePlugin = (*BlueFieldPlugin)(nil)
 )
 
 // init registers the plugin with the global registry.
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/openshift/dpu-operator/pkg/plugin"
+	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 )
 
 func newTestPlugin(t *testing.T) *BlueFieldPlugin {
@@ -95,29 +96,119 @@ func TestBlueFieldPlugin_HealthCheckNilOpiClient(t *testing.T) {
 	}
 }
 
-func TestBlueFieldPlugin_GetVFCountReturnsNotImplemented(t *testing.T) {
+func newConnectedTestPlugin(t *testing.T) (*BlueFieldPlugin, *mockNetworkServer) {
+	t.Helper()
+	network, address, cleanup := startMockServer(t)
+	t.Cleanup(cleanup)
+
 	p := newTestPlugin(t)
-	p.initialized = true
-	_, err := p.GetVFCount(context.Background(), "any-device")
-	if err != plugin.ErrNotImplemented {
-		t.Errorf("expected ErrNotImplemented from GetVFCount, got: %v", err)
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{OPIEndpoint: address}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
 	}
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p, network
 }
 
-func TestBlueFieldPlugin_CreateNetworkFunctionReturnsNotImplemented(t *testing.T) {
-	p := newTestPlugin(t)
-	p.initialized = true
-	err := p.CreateNetworkFunction(context.Background(), "in", "out")
-	if err != plugin.ErrNotImplemented {
-		t.Errorf("expected ErrNotImplemented from CreateNetworkFunction, got: %v", err)
+func TestBlueFieldPlugin_GetVFCount(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	count, err := p.GetVFCount(context.Background(), "any-device")
+	if err != nil {
+		t.Fatalf("GetVFCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 VF, got %d", count)
 	}
 }
 
-func TestBlueFieldPlugin_DeleteNetworkFunctionReturnsNotImplemented(t *testing.T) {
-	p := newTestPlugin(t)
-	p.initialized = true
-	err := p.DeleteNetworkFunction(context.Background(), "in", "out")
-	if err != plugin.ErrNotImplemented {
-		t.Errorf("expected ErrNotImplemented from DeleteNetworkFunction, got: %v", err)
+func TestBlueFieldPlugin_CreateNetworkFunction(t *testing.T) {
+	p, network := newConnectedTestPlugin(t)
+	ctx := context.Background()
+	input, output := "aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"
+
+	// Creating the network function twice must be idempotent
+	for i := 0; i < 2; i++ {
+		if err := p.CreateNetworkFunction(ctx, input, output); err != nil {
+			t.Fatalf("CreateNetworkFunction attempt %d failed: %v", i, err)
+		}
+	}
+	if len(network.logicalBridges) != 1 {
+		t.Fatalf("expected 1 logical bridge, got %d", len(network.logicalBridges))
+	}
+	bridgeName := logicalBridgeResourceName("nf-aabbccddee01-aabbccddee02")
+	bridge, ok := network.logicalBridges[bridgeName]
+	if !ok {
+		t.Fatalf("expected logical bridge %s", bridgeName)
+	}
+	if bridge.Spec.VlanId != nfVlanMin {
+		t.Errorf("expected VLAN %d, got %d", nfVlanMin, bridge.Spec.VlanId)
+	}
+	if len(network.bridgePorts) != 2 {
+		t.Fatalf("expected 2 bridge ports, got %d", len(network.bridgePorts))
+	}
+	for _, bp := range network.bridgePorts {
+		if bp.Spec.Ptype != evpnpb.BridgePortType_BRIDGE_PORT_TYPE_ACCESS {
+			t.Errorf("expected access port, got %v", bp.Spec.Ptype)
+		}
+		if len(bp.Spec.LogicalBridges) != 1 || bp.Spec.LogicalBridges[0] != bridgeName {
+			t.Errorf("expected port on %s, got %v", bridgeName, bp.Spec.LogicalBridges)
+		}
+	}
+
+	// Another pair gets its own logical bridge on the next free VLAN
+	if err := p.CreateNetworkFunction(ctx, "aa:bb:cc:dd:ee:03", "aa:bb:cc:dd:ee:04"); err != nil {
+		t.Fatalf("CreateNetworkFunction failed: %v", err)
+	}
+	other := network.logicalBridges[logicalBridgeResourceName("nf-aabbccddee03-aabbccddee04")]
+	if other == nil || other.Spec.VlanId != nfVlanMin+1 {
+		t.Errorf("expected the second logical bridge on VLAN %d, got %v", nfVlanMin+1, other)
+	}
+}
+
+func TestBlueFieldPlugin_ListNetworkFunctions(t *testing.T) {
+	p, network := newConnectedTestPlugin(t)
+	ctx := context.Background()
+
+	if err := p.CreateNetworkFunction(ctx, "AA:BB:CC:DD:EE:01", "aa:bb:cc:dd:ee:02"); err != nil {
+		t.Fatalf("CreateNetworkFunction failed: %v", err)
+	}
+	// Logical bridges of host bridge ports are not network functions
+	network.logicalBridges[logicalBridgeResourceName("100")] = &evpnpb.LogicalBridge{Name: logicalBridgeResourceName("100")}
+
+	nfs, err := p.ListNetworkFunctions(ctx)
+	if err != nil {
+		t.Fatalf("ListNetworkFunctions failed: %v", err)
+	}
+	if len(nfs) != 1 {
+		t.Fatalf("expected 1 network function, got %d", len(nfs))
+	}
+	if nfs[0].Input != "aa:bb:cc:dd:ee:01" || nfs[0].Output != "aa:bb:cc:dd:ee:02" {
+		t.Errorf("expected the network function from aa:bb:cc:dd:ee:01 to aa:bb:cc:dd:ee:02, got %v", nfs[0])
+	}
+}
+
+func TestBlueFieldPlugin_CreateNetworkFunctionInvalidPort(t *testing.T) {
+	p, _ := newConnectedTestPlugin(t)
+	if err := p.CreateNetworkFunction(context.Background(), "in", "out"); err == nil {
+		t.Error("expected an error for ports that are not MAC addresses")
+	}
+}
+
+func TestBlueFieldPlugin_DeleteNetworkFunction(t *testing.T) {
+	p, network := newConnectedTestPlugin(t)
+	ctx := context.Background()
+	input, output := "aa:bb:cc:dd:ee:01", "aa:bb:cc:dd:ee:02"
+
+	if err := p.CreateNetworkFunction(ctx, input, output); err != nil {
+		t.Fatalf("CreateNetworkFunction failed: %v", err)
+	}
+	// Deleting the network function twice must be idempotent
+	for i := 0; i < 2; i++ {
+		if err := p.DeleteNetworkFunction(ctx, input, output); err != nil {
+			t.Fatalf("DeleteNetworkFunction attempt %d failed: %v", i, err)
+		}
+	}
+	if len(network.logicalBridges) != 0 || len(network.bridgePorts) != 0 {
+		t.Errorf("expected everything to be deleted, got %d logical bridges and %d bridge ports",
+			len(network.logicalBridges), len(network.bridgePorts))
 	}
 }
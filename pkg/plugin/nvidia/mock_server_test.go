@@ -19,12 +19,15 @@ package nvidia
 import (
 	"context"
 	"net"
+	"sync"
 	"testing"
 
 	evpnpb "github.com/opiproject/opi-api/network/evpn-gw/v1alpha1/gen/go"
 	lifecyclepb "github.com/opiproject/opi-api/v1/gen/go/lifecycle"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -55,39 +58,112 @@ func (m *mockLifecycleServer) Ping(ctx context.Context, req *lifecyclepb.PingReq
 	return &lifecyclepb.PingResponse{Healthy: true}, nil
 }
 
-// mockNetworkServer implements the network service for testing
+// mockNetworkServer implements the network service for testing. Bridge ports
+// and logical bridges are kept so that tests can check what was created.
 type mockNetworkServer struct {
 	evpnpb.UnimplementedBridgePortServiceServer
 	evpnpb.UnimplementedLogicalBridgeServiceServer
 	evpnpb.UnimplementedVrfServiceServer
 	evpnpb.UnimplementedSviServiceServer
+
+	mu             sync.Mutex
+	bridgePorts    map[string]*evpnpb.BridgePort
+	logicalBridges map[string]*evpnpb.LogicalBridge
+}
+
+func newMockNetworkServer() *mockNetworkServer {
+	return &mockNetworkServer{
+		bridgePorts:    make(map[string]*evpnpb.BridgePort),
+		logicalBridges: make(map[string]*evpnpb.LogicalBridge),
+	}
 }
 
 func (m *mockNetworkServer) CreateBridgePort(ctx context.Context, req *evpnpb.CreateBridgePortRequest) (*evpnpb.BridgePort, error) {
-	return req.BridgePort, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := bridgePortResourceName(req.BridgePortId)
+	if _, ok := m.bridgePorts[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "bridge port %s already exists", name)
+	}
+	for _, lb := range req.BridgePort.GetSpec().GetLogicalBridges() {
+		if _, ok := m.logicalBridges[lb]; !ok {
+			return nil, status.Errorf(codes.NotFound, "logical bridge %s not found", lb)
+		}
+	}
+	bp := &evpnpb.BridgePort{Name: name, Spec: req.BridgePort.GetSpec()}
+	m.bridgePorts[name] = bp
+	return bp, nil
 }
 
 func (m *mockNetworkServer) GetBridgePort(ctx context.Context, req *evpnpb.GetBridgePortRequest) (*evpnpb.BridgePort, error) {
-	return &evpnpb.BridgePort{Name: req.Name}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bp, ok := m.bridgePorts[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s not found", req.Name)
+	}
+	return bp, nil
 }
 
 func (m *mockNetworkServer) ListBridgePorts(ctx context.Context, req *evpnpb.ListBridgePortsRequest) (*evpnpb.ListBridgePortsResponse, error) {
-	return &evpnpb.ListBridgePortsResponse{}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &evpnpb.ListBridgePortsResponse{}
+	for _, bp := range m.bridgePorts {
+		resp.BridgePorts = append(resp.BridgePorts, bp)
+	}
+	return resp, nil
 }
 
 func (m *mockNetworkServer) DeleteBridgePort(ctx context.Context, req *evpnpb.DeleteBridgePortRequest) (*emptypb.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.bridgePorts[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "bridge port %s not found", req.Name)
+	}
+	delete(m.bridgePorts, req.Name)
 	return &emptypb.Empty{}, nil
 }
 
 func (m *mockNetworkServer) CreateLogicalBridge(ctx context.Context, req *evpnpb.CreateLogicalBridgeRequest) (*evpnpb.LogicalBridge, error) {
-	return req.LogicalBridge, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := logicalBridgeResourceName(req.LogicalBridgeId)
+	if _, ok := m.logicalBridges[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "logical bridge %s already exists", name)
+	}
+	lb := &evpnpb.LogicalBridge{Name: name, Spec: req.LogicalBridge.GetSpec()}
+	m.logicalBridges[name] = lb
+	return lb, nil
 }
 
 func (m *mockNetworkServer) GetLogicalBridge(ctx context.Context, req *evpnpb.GetLogicalBridgeRequest) (*evpnpb.LogicalBridge, error) {
-	return &evpnpb.LogicalBridge{Name: req.Name}, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lb, ok := m.logicalBridges[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "logical bridge %s not found", req.Name)
+	}
+	return lb, nil
+}
+
+func (m *mockNetworkServer) ListLogicalBridges(ctx context.Context, req *evpnpb.ListLogicalBridgesRequest) (*evpnpb.ListLogicalBridgesResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	resp := &evpnpb.ListLogicalBridgesResponse{}
+	for _, lb := range m.logicalBridges {
+		resp.LogicalBridges = append(resp.LogicalBridges, lb)
+	}
+	return resp, nil
 }
 
 func (m *mockNetworkServer) DeleteLogicalBridge(ctx context.Context, req *evpnpb.DeleteLogicalBridgeRequest) (*emptypb.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.logicalBridges[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "logical bridge %s not found", req.Name)
+	}
+	delete(m.logicalBridges, req.Name)
 	return &emptypb.Empty{}, nil
 }
 
@@ -115,8 +191,8 @@ func (m *mockNetworkServer) DeleteSvi(ctx context.Context, req *evpnpb.DeleteSvi
 	return &emptypb.Empty{}, nil
 }
 
-// startMockServer starts a mock gRPC server and returns the server address and cleanup function
-func startMockServer(t *testing.T) (string, func()) {
+// startMockServer starts a mock gRPC server and returns the network server, the server address and cleanup function
+func startMockServer(t *testing.T) (*mockNetworkServer, string, func()) {
 	listener, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
 		t.Fatalf("Failed to listen: %v", err)
@@ -124,7 +200,7 @@ func startMockServer(t *testing.T) (string, func()) {
 
 	server := grpc.NewServer()
 	mockLifecycle := &mockLifecycleServer{}
-	mockNetwork := &mockNetworkServer{}
+	mockNetwork := newMockNetworkServer()
 
 	lifecyclepb.RegisterLifeCycleServiceServer(server, mockLifecycle)
 	lifecyclepb.RegisterDeviceServiceServer(server, mockLifecycle)
@@ -155,5 +231,5 @@ func startMockServer(t *testing.T) (string, func()) {
 		server.Stop()
 	}
 
-	return address, cleanup
+	return mockNetwork, address, cleanup
 }
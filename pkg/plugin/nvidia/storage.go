@@ -0,0 +1,518 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/dpu-operator/pkg/metrics"
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	storagepb "github.com/opiproject/opi-api/storage/v1alpha1/gen/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The BlueField emulates NVMe functions towards the host. They are addressed
+// like the BlueField representors of the host functions, "pf<pf>" for a
+// physical function and "pf<pf>vf<vf>" for a virtual function.
+var pcieFunctionRegexp = regexp.MustCompile(`^pf(\d+)(?:vf(\d+))?$`)
+
+var invalidResourceIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// resourceID turns a name such as an NQN into a valid OPI resource ID, so that
+// the same request always maps to the same resource.
+func resourceID(name string) string {
+	return strings.Trim(invalidResourceIDChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func nvmeSubsystemResourceName(id string) string {
+	return fmt.Sprintf("nvmeSubsystems/%s", id)
+}
+
+// storageCall invokes an operation of the OPI storage APIs, records its
+// metrics and converts its error.
+func (p *BlueFieldPlugin) storageCall(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	metrics.RecordOPICall(PluginVendor, op, time.Since(start).Seconds(), err)
+	metrics.RecordStorageOperation(PluginVendor, op, err == nil)
+	if err == nil {
+		return nil
+	}
+
+	switch status.Code(err) {
+	case codes.Unimplemented:
+		p.log.Info("OPI bridge does not implement "+op, "error", err.Error())
+		return plugin.NewPluginError(PluginName, op, plugin.ErrNotImplemented)
+	case codes.NotFound:
+		return plugin.NewPluginErrorWithDetails(PluginName, op, plugin.ErrResourceNotFound, status.Convert(err).Message())
+	}
+	return plugin.NewPluginErrorWithDetails(PluginName, op, plugin.ErrOperationFailed, err.Error())
+}
+
+func nvmeSubsystemFromOPI(s *storagepb.NvmeSubsystem) *plugin.NVMeSubsystem {
+	return &plugin.NVMeSubsystem{
+		ID:           s.GetName(),
+		NQN:          s.GetSpec().GetNqn(),
+		SerialNumber: s.GetSpec().GetSerialNumber(),
+		Status:       "Active",
+	}
+}
+
+// CreateNVMeSubsystem creates an NVMe subsystem. The subsystem is named after
+// its NQN, so creating it again returns the existing subsystem.
+func (p *BlueFieldPlugin) CreateNVMeSubsystem(ctx context.Context, request *plugin.NVMeSubsystemRequest) (*plugin.NVMeSubsystem, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+	if request == nil || request.NQN == "" {
+		return nil, fmt.Errorf("NQN is required")
+	}
+
+	id := resourceID(request.NQN)
+	p.log.Info("Creating NVMe subsystem", "nqn", request.NQN, "id", id)
+
+	var resp *storagepb.NvmeSubsystem
+	err := p.storageCall("CreateNVMeSubsystem", func() (err error) {
+		resp, err = p.opiClient.Storage().CreateNvmeSubsystem(ctx, &storagepb.CreateNvmeSubsystemRequest{
+			NvmeSubsystemId: id,
+			NvmeSubsystem: &storagepb.NvmeSubsystem{
+				Spec: &storagepb.NvmeSubsystemSpec{
+					Nqn:           request.NQN,
+					SerialNumber:  request.SerialNumber,
+					MaxNamespaces: int64(request.MaxNamespaces),
+				},
+			},
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			resp, err = p.opiClient.Storage().GetNvmeSubsystem(ctx, nvmeSubsystemResourceName(id))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subsystem := nvmeSubsystemFromOPI(resp)
+	if subsystem.ID == "" {
+		subsystem.ID = nvmeSubsystemResourceName(id)
+	}
+	if subsystem.NQN == "" {
+		subsystem.NQN = request.NQN
+	}
+	return subsystem, nil
+}
+
+// DeleteNVMeSubsystem removes an NVMe subsystem.
+func (p *BlueFieldPlugin) DeleteNVMeSubsystem(ctx context.Context, subsystemID string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return plugin.ErrNotInitialized
+	}
+
+	p.log.Info("Deleting NVMe subsystem", "id", subsystemID)
+	return p.storageCall("DeleteNVMeSubsystem", func() error {
+		return p.opiClient.Storage().DeleteNvmeSubsystem(ctx, subsystemID)
+	})
+}
+
+// GetNVMeSubsystem retrieves information about an NVMe subsystem.
+func (p *BlueFieldPlugin) GetNVMeSubsystem(ctx context.Context, subsystemID string) (*plugin.NVMeSubsystem, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	var resp *storagepb.NvmeSubsystem
+	err := p.storageCall("GetNVMeSubsystem", func() (err error) {
+		resp, err = p.opiClient.Storage().GetNvmeSubsystem(ctx, subsystemID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nvmeSubsystemFromOPI(resp), nil
+}
+
+// ListNVMeSubsystems lists all NVMe subsystems.
+func (p *BlueFieldPlugin) ListNVMeSubsystems(ctx context.Context) ([]*plugin.NVMeSubsystem, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	var resp *storagepb.ListNvmeSubsystemsResponse
+	err := p.storageCall("ListNVMeSubsystems", func() (err error) {
+		resp, err = p.opiClient.Storage().ListNvmeSubsystems(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	subsystems := make([]*plugin.NVMeSubsystem, 0, len(resp.GetNvmeSubsystems()))
+	for _, s := range resp.GetNvmeSubsystems() {
+		subsystems = append(subsystems, nvmeSubsystemFromOPI(s))
+	}
+	return subsystems, nil
+}
+
+// parsePCIeFunction parses the address of an emulated host function into an
+// OPI PCIe endpoint.
+func parsePCIeFunction(address string) (*storagepb.PciEndpoint, error) {
+	match := pcieFunctionRegexp.FindStringSubmatch(address)
+	if match == nil {
+		return nil, fmt.Errorf("PCIe address %q invalid: expected pf<pf> or pf<pf>vf<vf>", address)
+	}
+	// OPI numbers the VFs of a PF from 1, 0 being the PF itself, while the
+	// representors number them from 0.
+	pf, _ := strconv.Atoi(match[1])
+	vf := 0
+	if match[2] != "" {
+		vf, _ = strconv.Atoi(match[2])
+		vf++
+	}
+	return &storagepb.PciEndpoint{
+		PortId:           wrapperspb.Int32(0),
+		PhysicalFunction: wrapperspb.Int32(int32(pf)),
+		VirtualFunction:  wrapperspb.Int32(int32(vf)),
+	}, nil
+}
+
+// pcieFunctionAddress is the inverse of parsePCIeFunction.
+func pcieFunctionAddress(endpoint *storagepb.PciEndpoint) string {
+	if endpoint == nil {
+		return ""
+	}
+	address := fmt.Sprintf("pf%d", endpoint.GetPhysicalFunction().GetValue())
+	if vf := endpoint.GetVirtualFunction().GetValue(); vf > 0 {
+		address += fmt.Sprintf("vf%d", vf-1)
+	}
+	return address
+}
+
+// CreateNVMeController exposes an NVMe subsystem to the host through an
+// emulated PCIe function. Creating it again returns the existing controller.
+func (p *BlueFieldPlugin) CreateNVMeController(ctx context.Context, request *plugin.NVMeControllerRequest) (*plugin.NVMeController, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+	if request == nil || request.SubsystemID == "" {
+		return nil, fmt.Errorf("subsystem is required")
+	}
+	endpoint, err := parsePCIeFunction(request.PCIeAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	id := resourceID(request.Name)
+	if id == "" {
+		id = request.PCIeAddress
+	}
+	name := request.SubsystemID + "/nvmeControllers/" + id
+	p.log.Info("Creating NVMe controller", "subsystem", request.SubsystemID, "id", id, "pcieAddress", request.PCIeAddress)
+
+	var resp *storagepb.NvmeController
+	err = p.storageCall("CreateNVMeController", func() (err error) {
+		resp, err = p.opiClient.Storage().CreateNvmeController(ctx, &storagepb.CreateNvmeControllerRequest{
+			Parent:           request.SubsystemID,
+			NvmeControllerId: id,
+			NvmeController: &storagepb.NvmeController{
+				Spec: &storagepb.NvmeControllerSpec{
+					Trtype:   storagepb.NvmeTransportType_NVME_TRANSPORT_TYPE_PCIE,
+					Endpoint: &storagepb.NvmeControllerSpec_PcieId{PcieId: endpoint},
+				},
+			},
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			resp, err = p.opiClient.Storage().GetNvmeController(ctx, name)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	controller := &plugin.NVMeController{
+		ID:          resp.GetName(),
+		Name:        id,
+		SubsystemID: request.SubsystemID,
+		PCIeAddress: pcieFunctionAddress(resp.GetSpec().GetPcieId()),
+		Status:      "Inactive",
+	}
+	if controller.ID == "" {
+		controller.ID = name
+	}
+	if controller.PCIeAddress == "" {
+		controller.PCIeAddress = request.PCIeAddress
+	}
+	if resp.GetStatus() == nil || resp.GetStatus().GetActive() {
+		controller.Status = "Active"
+	}
+	return controller, nil
+}
+
+// DeleteNVMeController removes an NVMe controller.
+func (p *BlueFieldPlugin) DeleteNVMeController(ctx context.Context, controllerID string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return plugin.ErrNotInitialized
+	}
+
+	p.log.Info("Deleting NVMe controller", "id", controllerID)
+	return p.storageCall("DeleteNVMeController", func() error {
+		return p.opiClient.Storage().DeleteNvmeController(ctx, controllerID)
+	})
+}
+
+// nvmeNamespaceRegexp matches the resource name of a namespace created by
+// CreateNVMeNamespace.
+var nvmeNamespaceRegexp = regexp.MustCompile(`^nvmeSubsystems/([^/]+)/nvmeNamespaces/([^/]+)$`)
+
+// nvmePathID is the ID of the single path of the remote controller of a
+// namespace.
+const nvmePathID = "primary"
+
+func nvmeRemoteControllerResourceName(id string) string {
+	return fmt.Sprintf("nvmeRemoteControllers/%s", id)
+}
+
+// nvmeRemoteControllerID returns the ID of the remote controller backing the
+// namespace of a subsystem. Every namespace has its own remote controller, so
+// that deleting the namespace can delete it.
+func nvmeRemoteControllerID(subsystemID string, namespaceID string) string {
+	return resourceID(strings.TrimPrefix(subsystemID, "nvmeSubsystems/") + "-" + namespaceID)
+}
+
+// nvmeOFPath returns the OPI path to the remote NVMe-oF subsystem of target.
+func nvmeOFPath(target *plugin.NVMeOFTarget) (*storagepb.NvmePath, error) {
+	path := &storagepb.NvmePath{
+		Traddr: target.Address,
+		Fabrics: &storagepb.FabricsPath{
+			Trsvcid: int64(target.Port),
+			Subnqn:  target.NQN,
+			Hostnqn: target.HostNQN,
+		},
+	}
+	switch target.Transport {
+	case plugin.NVMeOFTransportTCP:
+		path.Trtype = storagepb.NvmeTransportType_NVME_TRANSPORT_TYPE_TCP
+	case plugin.NVMeOFTransportRDMA:
+		path.Trtype = storagepb.NvmeTransportType_NVME_TRANSPORT_TYPE_RDMA
+	default:
+		return nil, fmt.Errorf("NVMe-oF transport %q invalid: expected %s or %s", target.Transport, plugin.NVMeOFTransportTCP, plugin.NVMeOFTransportRDMA)
+	}
+	ip := net.ParseIP(target.Address)
+	switch {
+	case ip == nil:
+		return nil, fmt.Errorf("NVMe-oF target address %q invalid: expected an IP address", target.Address)
+	case ip.To4() != nil:
+		path.Fabrics.Adrfam = storagepb.NvmeAddressFamily_NVME_ADRFAM_IPV4
+	default:
+		path.Fabrics.Adrfam = storagepb.NvmeAddressFamily_NVME_ADRFAM_IPV6
+	}
+	if target.Port < 1 || target.Port > 65535 {
+		return nil, fmt.Errorf("NVMe-oF target port %d invalid: value must be in the range 1-65535", target.Port)
+	}
+	if target.NQN == "" {
+		return nil, fmt.Errorf("NVMe-oF target NQN is required")
+	}
+	return path, nil
+}
+
+// CreateNVMeNamespace exposes a namespace of a remote NVMe-oF subsystem as a
+// namespace of a local subsystem. The DPU connects to the target through a
+// remote controller of its own, and the namespace refers to the volume of the
+// remote namespace. Creating it again returns the existing namespace.
+func (p *BlueFieldPlugin) CreateNVMeNamespace(ctx context.Context, request *plugin.NVMeNamespaceRequest) (*plugin.NVMeNamespace, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+	if request == nil || request.SubsystemID == "" {
+		return nil, fmt.Errorf("subsystem is required")
+	}
+	if request.NSID < 1 {
+		return nil, fmt.Errorf("namespace ID %d invalid: value must be at least 1", request.NSID)
+	}
+	if request.Target == nil {
+		return nil, fmt.Errorf("an NVMe-oF target is required")
+	}
+	path, err := nvmeOFPath(request.Target)
+	if err != nil {
+		return nil, err
+	}
+	remoteNSID := request.Target.NSID
+	if remoteNSID == 0 {
+		remoteNSID = 1
+	}
+
+	id := fmt.Sprintf("ns%d", request.NSID)
+	name := request.SubsystemID + "/nvmeNamespaces/" + id
+	remoteName := nvmeRemoteControllerResourceName(nvmeRemoteControllerID(request.SubsystemID, id))
+	p.log.Info("Creating NVMe namespace", "subsystem", request.SubsystemID, "nsid", request.NSID,
+		"target", request.Target.NQN, "address", request.Target.Address)
+
+	err = p.storageCall("CreateNVMeRemoteController", func() error {
+		remote := &storagepb.NvmeRemoteController{Multipath: storagepb.NvmeMultipath_NVME_MULTIPATH_DISABLE}
+		if request.Target.Transport == plugin.NVMeOFTransportTCP {
+			remote.Tcp = &storagepb.TcpController{}
+		}
+		_, err := p.opiClient.Storage().CreateNvmeRemoteController(ctx, &storagepb.CreateNvmeRemoteControllerRequest{
+			NvmeRemoteControllerId: strings.TrimPrefix(remoteName, "nvmeRemoteControllers/"),
+			NvmeRemoteController:   remote,
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.storageCall("CreateNVMePath", func() error {
+		_, err := p.opiClient.Storage().CreateNvmePath(ctx, &storagepb.CreateNvmePathRequest{
+			Parent:     remoteName,
+			NvmePathId: nvmePathID,
+			NvmePath:   path,
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		p.deleteRemoteController(ctx, remoteName)
+		return nil, err
+	}
+
+	// The bridge names the volume of a remote namespace after its controller
+	// and NSID, as SPDK names the bdevs of an attached controller.
+	volume := fmt.Sprintf("%sn%d", strings.TrimPrefix(remoteName, "nvmeRemoteControllers/"), remoteNSID)
+	var resp *storagepb.NvmeNamespace
+	exists := false
+	err = p.storageCall("CreateNVMeNamespace", func() (err error) {
+		resp, err = p.opiClient.Storage().CreateNvmeNamespace(ctx, &storagepb.CreateNvmeNamespaceRequest{
+			Parent:          request.SubsystemID,
+			NvmeNamespaceId: id,
+			NvmeNamespace: &storagepb.NvmeNamespace{
+				Spec: &storagepb.NvmeNamespaceSpec{
+					HostNsid:      int32(request.NSID),
+					VolumeNameRef: volume,
+				},
+			},
+		})
+		if status.Code(err) == codes.AlreadyExists {
+			exists = true
+			resp, err = p.opiClient.Storage().GetNvmeNamespace(ctx, name)
+		}
+		return err
+	})
+	if err != nil {
+		// An existing namespace still uses the remote controller.
+		if !exists {
+			p.deleteRemoteController(ctx, remoteName)
+		}
+		return nil, err
+	}
+
+	namespace := &plugin.NVMeNamespace{
+		ID:     resp.GetName(),
+		NSID:   int(resp.GetSpec().GetHostNsid()),
+		Size:   request.Size,
+		Status: "Active",
+	}
+	if namespace.ID == "" {
+		namespace.ID = name
+	}
+	if namespace.NSID == 0 {
+		namespace.NSID = request.NSID
+	}
+	return namespace, nil
+}
+
+// deleteRemoteController disconnects the DPU from the target of a namespace.
+// A remote controller or path that is already gone is not an error.
+func (p *BlueFieldPlugin) deleteRemoteController(ctx context.Context, remoteName string) error {
+	err := p.storageCall("DeleteNVMePath", func() error {
+		return p.opiClient.Storage().DeleteNvmePath(ctx, remoteName+"/nvmePaths/"+nvmePathID)
+	})
+	if err != nil && !plugin.IsNotFound(err) {
+		p.log.Error(err, "Failed to delete NVMe path", "remoteController", remoteName)
+		return err
+	}
+	err = p.storageCall("DeleteNVMeRemoteController", func() error {
+		return p.opiClient.Storage().DeleteNvmeRemoteController(ctx, remoteName)
+	})
+	if err != nil && !plugin.IsNotFound(err) {
+		p.log.Error(err, "Failed to delete NVMe remote controller", "remoteController", remoteName)
+		return err
+	}
+	return nil
+}
+
+// DeleteNVMeNamespace removes an NVMe namespace and disconnects the DPU from
+// its target.
+func (p *BlueFieldPlugin) DeleteNVMeNamespace(ctx context.Context, namespaceID string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return plugin.ErrNotInitialized
+	}
+
+	p.log.Info("Deleting NVMe namespace", "id", namespaceID)
+	err := p.storageCall("DeleteNVMeNamespace", func() error {
+		return p.opiClient.Storage().DeleteNvmeNamespace(ctx, namespaceID)
+	})
+	if err != nil && !plugin.IsNotFound(err) {
+		return err
+	}
+	// The remote controller is deleted even when the namespace is gone, as
+	// a previous delete may have failed after deleting the namespace.
+	if match := nvmeNamespaceRegexp.FindStringSubmatch(namespaceID); match != nil {
+		remoteName := nvmeRemoteControllerResourceName(nvmeRemoteControllerID(match[1], match[2]))
+		if remoteErr := p.deleteRemoteController(ctx, remoteName); remoteErr != nil {
+			return remoteErr
+		}
+	}
+	return err
+}
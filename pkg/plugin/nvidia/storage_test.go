@@ -0,0 +1,509 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nvidia
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openshift/dpu-operator/pkg/plugin"
+	storagepb "github.com/opiproject/opi-api/storage/v1alpha1/gen/go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeStorageServer is an in-process OPI storage server keeping the
+// subsystems, controllers, namespaces, remote controllers and paths it was
+// asked to create.
+type fakeStorageServer struct {
+	storagepb.UnimplementedFrontendNvmeServiceServer
+	storagepb.UnimplementedNvmeRemoteControllerServiceServer
+
+	mu                sync.Mutex
+	subsystems        map[string]*storagepb.NvmeSubsystem
+	controllers       map[string]*storagepb.NvmeController
+	namespaces        map[string]*storagepb.NvmeNamespace
+	remoteControllers map[string]*storagepb.NvmeRemoteController
+	paths             map[string]*storagepb.NvmePath
+}
+
+func newFakeStorageServer() *fakeStorageServer {
+	return &fakeStorageServer{
+		subsystems:        make(map[string]*storagepb.NvmeSubsystem),
+		controllers:       make(map[string]*storagepb.NvmeController),
+		namespaces:        make(map[string]*storagepb.NvmeNamespace),
+		remoteControllers: make(map[string]*storagepb.NvmeRemoteController),
+		paths:             make(map[string]*storagepb.NvmePath),
+	}
+}
+
+func (f *fakeStorageServer) CreateNvmeSubsystem(ctx context.Context, req *storagepb.CreateNvmeSubsystemRequest) (*storagepb.NvmeSubsystem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := nvmeSubsystemResourceName(req.NvmeSubsystemId)
+	if _, ok := f.subsystems[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "subsystem %s already exists", name)
+	}
+	s := &storagepb.NvmeSubsystem{Name: name, Spec: req.NvmeSubsystem.GetSpec()}
+	f.subsystems[name] = s
+	return s, nil
+}
+
+func (f *fakeStorageServer) GetNvmeSubsystem(ctx context.Context, req *storagepb.GetNvmeSubsystemRequest) (*storagepb.NvmeSubsystem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.subsystems[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "subsystem %s not found", req.Name)
+	}
+	return s, nil
+}
+
+func (f *fakeStorageServer) ListNvmeSubsystems(ctx context.Context, req *storagepb.ListNvmeSubsystemsRequest) (*storagepb.ListNvmeSubsystemsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := &storagepb.ListNvmeSubsystemsResponse{}
+	for _, s := range f.subsystems {
+		resp.NvmeSubsystems = append(resp.NvmeSubsystems, s)
+	}
+	return resp, nil
+}
+
+func (f *fakeStorageServer) DeleteNvmeSubsystem(ctx context.Context, req *storagepb.DeleteNvmeSubsystemRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subsystems[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "subsystem %s not found", req.Name)
+	}
+	for name := range f.controllers {
+		if strings.HasPrefix(name, req.Name+"/") {
+			return nil, status.Errorf(codes.FailedPrecondition, "subsystem %s has controllers", req.Name)
+		}
+	}
+	delete(f.subsystems, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeStorageServer) CreateNvmeController(ctx context.Context, req *storagepb.CreateNvmeControllerRequest) (*storagepb.NvmeController, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subsystems[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "subsystem %s not found", req.Parent)
+	}
+	name := req.Parent + "/nvmeControllers/" + req.NvmeControllerId
+	if _, ok := f.controllers[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "controller %s already exists", name)
+	}
+	c := &storagepb.NvmeController{
+		Name:   name,
+		Spec:   req.NvmeController.GetSpec(),
+		Status: &storagepb.NvmeControllerStatus{Active: true},
+	}
+	f.controllers[name] = c
+	return c, nil
+}
+
+func (f *fakeStorageServer) GetNvmeController(ctx context.Context, req *storagepb.GetNvmeControllerRequest) (*storagepb.NvmeController, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.controllers[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "controller %s not found", req.Name)
+	}
+	return c, nil
+}
+
+func (f *fakeStorageServer) DeleteNvmeController(ctx context.Context, req *storagepb.DeleteNvmeControllerRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.controllers[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "controller %s not found", req.Name)
+	}
+	delete(f.controllers, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeStorageServer) CreateNvmeNamespace(ctx context.Context, req *storagepb.CreateNvmeNamespaceRequest) (*storagepb.NvmeNamespace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subsystems[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "subsystem %s not found", req.Parent)
+	}
+	name := req.Parent + "/nvmeNamespaces/" + req.NvmeNamespaceId
+	if _, ok := f.namespaces[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "namespace %s already exists", name)
+	}
+	n := &storagepb.NvmeNamespace{Name: name, Spec: req.NvmeNamespace.GetSpec()}
+	f.namespaces[name] = n
+	return n, nil
+}
+
+func (f *fakeStorageServer) GetNvmeNamespace(ctx context.Context, req *storagepb.GetNvmeNamespaceRequest) (*storagepb.NvmeNamespace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, ok := f.namespaces[req.Name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "namespace %s not found", req.Name)
+	}
+	return n, nil
+}
+
+func (f *fakeStorageServer) DeleteNvmeNamespace(ctx context.Context, req *storagepb.DeleteNvmeNamespaceRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.namespaces[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "namespace %s not found", req.Name)
+	}
+	delete(f.namespaces, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeStorageServer) CreateNvmeRemoteController(ctx context.Context, req *storagepb.CreateNvmeRemoteControllerRequest) (*storagepb.NvmeRemoteController, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := "nvmeRemoteControllers/" + req.NvmeRemoteControllerId
+	if _, ok := f.remoteControllers[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "remote controller %s already exists", name)
+	}
+	c := req.NvmeRemoteController
+	c.Name = name
+	f.remoteControllers[name] = c
+	return c, nil
+}
+
+func (f *fakeStorageServer) DeleteNvmeRemoteController(ctx context.Context, req *storagepb.DeleteNvmeRemoteControllerRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.remoteControllers[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "remote controller %s not found", req.Name)
+	}
+	for name := range f.paths {
+		if strings.HasPrefix(name, req.Name+"/") {
+			return nil, status.Errorf(codes.FailedPrecondition, "remote controller %s has paths", req.Name)
+		}
+	}
+	delete(f.remoteControllers, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+func (f *fakeStorageServer) CreateNvmePath(ctx context.Context, req *storagepb.CreateNvmePathRequest) (*storagepb.NvmePath, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.remoteControllers[req.Parent]; !ok {
+		return nil, status.Errorf(codes.NotFound, "remote controller %s not found", req.Parent)
+	}
+	name := req.Parent + "/nvmePaths/" + req.NvmePathId
+	if _, ok := f.paths[name]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "path %s already exists", name)
+	}
+	path := req.NvmePath
+	path.Name = name
+	f.paths[name] = path
+	return path, nil
+}
+
+func (f *fakeStorageServer) DeleteNvmePath(ctx context.Context, req *storagepb.DeleteNvmePathRequest) (*emptypb.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.paths[req.Name]; !ok {
+		return nil, status.Errorf(codes.NotFound, "path %s not found", req.Name)
+	}
+	delete(f.paths, req.Name)
+	return &emptypb.Empty{}, nil
+}
+
+// newStorageTestPlugin returns a plugin connected to a fake storage server.
+func newStorageTestPlugin(t *testing.T) (*BlueFieldPlugin, *fakeStorageServer) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	fake := newFakeStorageServer()
+	storagepb.RegisterFrontendNvmeServiceServer(server, fake)
+	storagepb.RegisterNvmeRemoteControllerServiceServer(server, fake)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	p := newTestPlugin(t)
+	if err := p.Initialize(context.Background(), plugin.PluginConfig{OPIEndpoint: listener.Addr().String()}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p, fake
+}
+
+func TestBlueFieldPlugin_ImplementsStoragePlugin(t *testing.T) {
+	checker := plugin.NewPluginChecker(newTestPlugin(t))
+	if !checker.IsStoragePlugin() {
+		t.Error("expected the plugin to implement StoragePlugin")
+	}
+	if !checker.SupportsCapability(plugin.CapabilityStorage) {
+		t.Error("expected CapabilityStorage in Capabilities")
+	}
+}
+
+func TestBlueFieldPlugin_NVMeSubsystem(t *testing.T) {
+	p, fake := newStorageTestPlugin(t)
+	ctx := context.Background()
+	request := &plugin.NVMeSubsystemRequest{
+		NQN:           "nqn.2024-01.io.openshift:vol1",
+		SerialNumber:  "SN0001",
+		MaxNamespaces: 8,
+	}
+
+	// Creating the subsystem twice must return the same subsystem
+	var subsystem *plugin.NVMeSubsystem
+	for i := 0; i < 2; i++ {
+		var err error
+		subsystem, err = p.CreateNVMeSubsystem(ctx, request)
+		if err != nil {
+			t.Fatalf("CreateNVMeSubsystem attempt %d failed: %v", i, err)
+		}
+	}
+	if subsystem.ID != "nvmeSubsystems/nqn-2024-01-io-openshift-vol1" {
+		t.Errorf("unexpected subsystem ID %q", subsystem.ID)
+	}
+	if subsystem.NQN != request.NQN || subsystem.SerialNumber != request.SerialNumber {
+		t.Errorf("unexpected subsystem %+v", subsystem)
+	}
+	if got := fake.subsystems[subsystem.ID].GetSpec().GetMaxNamespaces(); got != 8 {
+		t.Errorf("expected 8 max namespaces, got %d", got)
+	}
+
+	got, err := p.GetNVMeSubsystem(ctx, subsystem.ID)
+	if err != nil {
+		t.Fatalf("GetNVMeSubsystem failed: %v", err)
+	}
+	if got.NQN != request.NQN {
+		t.Errorf("expected NQN %q, got %q", request.NQN, got.NQN)
+	}
+
+	list, err := p.ListNVMeSubsystems(ctx)
+	if err != nil {
+		t.Fatalf("ListNVMeSubsystems failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("expected 1 subsystem, got %d", len(list))
+	}
+
+	if err := p.DeleteNVMeSubsystem(ctx, subsystem.ID); err != nil {
+		t.Fatalf("DeleteNVMeSubsystem failed: %v", err)
+	}
+	if _, err := p.GetNVMeSubsystem(ctx, subsystem.ID); !plugin.IsNotFound(err) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+	if err := p.DeleteNVMeSubsystem(ctx, subsystem.ID); !plugin.IsNotFound(err) {
+		t.Errorf("expected not found when deleting again, got %v", err)
+	}
+}
+
+func TestBlueFieldPlugin_NVMeController(t *testing.T) {
+	p, fake := newStorageTestPlugin(t)
+	ctx := context.Background()
+
+	subsystem, err := p.CreateNVMeSubsystem(ctx, &plugin.NVMeSubsystemRequest{NQN: "nqn.2024-01.io.openshift:vol1"})
+	if err != nil {
+		t.Fatalf("CreateNVMeSubsystem failed: %v", err)
+	}
+
+	request := &plugin.NVMeControllerRequest{SubsystemID: subsystem.ID, Name: "ctrl0", PCIeAddress: "pf0vf2"}
+	var controller *plugin.NVMeController
+	for i := 0; i < 2; i++ {
+		controller, err = p.CreateNVMeController(ctx, request)
+		if err != nil {
+			t.Fatalf("CreateNVMeController attempt %d failed: %v", i, err)
+		}
+	}
+	if controller.ID != subsystem.ID+"/nvmeControllers/ctrl0" {
+		t.Errorf("unexpected controller ID %q", controller.ID)
+	}
+	if controller.PCIeAddress != "pf0vf2" || controller.Status != "Active" {
+		t.Errorf("unexpected controller %+v", controller)
+	}
+	spec := fake.controllers[controller.ID].GetSpec()
+	if spec.GetTrtype() != storagepb.NvmeTransportType_NVME_TRANSPORT_TYPE_PCIE {
+		t.Errorf("expected a PCIe controller, got %v", spec.GetTrtype())
+	}
+	if vf := spec.GetPcieId().GetVirtualFunction().GetValue(); vf != 3 {
+		t.Errorf("expected OPI virtual function 3, got %d", vf)
+	}
+
+	if err := p.DeleteNVMeController(ctx, controller.ID); err != nil {
+		t.Fatalf("DeleteNVMeController failed: %v", err)
+	}
+	if len(fake.controllers) != 0 {
+		t.Errorf("expected no controllers, got %d", len(fake.controllers))
+	}
+}
+
+func TestBlueFieldPlugin_NVMeControllerInvalidPCIeAddress(t *testing.T) {
+	p, _ := newStorageTestPlugin(t)
+	for _, address := range []string{"", "0000:3b:00.2", "vf1", "pf0vf"} {
+		_, err := p.CreateNVMeController(context.Background(), &plugin.NVMeControllerRequest{
+			SubsystemID: "nvmeSubsystems/s1",
+			PCIeAddress: address,
+		})
+		if err == nil {
+			t.Errorf("expected an error for PCIe address %q", address)
+		}
+	}
+}
+
+func TestBlueFieldPlugin_NVMeNamespace(t *testing.T) {
+	p, fake := newStorageTestPlugin(t)
+	ctx := context.Background()
+
+	subsystem, err := p.CreateNVMeSubsystem(ctx, &plugin.NVMeSubsystemRequest{NQN: "nqn.2024-01.io.openshift:vol1"})
+	if err != nil {
+		t.Fatalf("CreateNVMeSubsystem failed: %v", err)
+	}
+
+	request := &plugin.NVMeNamespaceRequest{
+		SubsystemID: subsystem.ID,
+		NSID:        1,
+		Size:        1 << 30,
+		Target: &plugin.NVMeOFTarget{
+			Transport: plugin.NVMeOFTransportTCP,
+			Address:   "192.0.2.10",
+			Port:      4420,
+			NQN:       "nqn.2024-01.io.example:target",
+			NSID:      2,
+		},
+	}
+	// Creating the namespace twice must return the same namespace
+	var namespace *plugin.NVMeNamespace
+	for i := 0; i < 2; i++ {
+		namespace, err = p.CreateNVMeNamespace(ctx, request)
+		if err != nil {
+			t.Fatalf("CreateNVMeNamespace attempt %d failed: %v", i, err)
+		}
+	}
+	if namespace.ID != subsystem.ID+"/nvmeNamespaces/ns1" || namespace.NSID != 1 || namespace.Size != 1<<30 {
+		t.Errorf("unexpected namespace %+v", namespace)
+	}
+
+	remoteName := "nvmeRemoteControllers/nqn-2024-01-io-openshift-vol1-ns1"
+	remote, ok := fake.remoteControllers[remoteName]
+	if !ok {
+		t.Fatalf("expected remote controller %s, got %v", remoteName, fake.remoteControllers)
+	}
+	if remote.GetMultipath() != storagepb.NvmeMultipath_NVME_MULTIPATH_DISABLE || remote.Tcp == nil {
+		t.Errorf("unexpected remote controller %+v", remote)
+	}
+	path, ok := fake.paths[remoteName+"/nvmePaths/primary"]
+	if !ok {
+		t.Fatalf("expected a path to the target, got %v", fake.paths)
+	}
+	if path.GetTrtype() != storagepb.NvmeTransportType_NVME_TRANSPORT_TYPE_TCP || path.GetTraddr() != "192.0.2.10" {
+		t.Errorf("unexpected path %+v", path)
+	}
+	fabrics := path.GetFabrics()
+	if fabrics.GetTrsvcid() != 4420 || fabrics.GetSubnqn() != request.Target.NQN || fabrics.GetAdrfam() != storagepb.NvmeAddressFamily_NVME_ADRFAM_IPV4 {
+		t.Errorf("unexpected fabrics path %+v", fabrics)
+	}
+	spec := fake.namespaces[namespace.ID].GetSpec()
+	if spec.GetHostNsid() != 1 || spec.GetVolumeNameRef() != "nqn-2024-01-io-openshift-vol1-ns1n2" {
+		t.Errorf("unexpected namespace spec %+v", spec)
+	}
+
+	if err := p.DeleteNVMeNamespace(ctx, namespace.ID); err != nil {
+		t.Fatalf("DeleteNVMeNamespace failed: %v", err)
+	}
+	if len(fake.namespaces) != 0 || len(fake.paths) != 0 || len(fake.remoteControllers) != 0 {
+		t.Errorf("expected no namespaces, paths and remote controllers, got %d, %d and %d",
+			len(fake.namespaces), len(fake.paths), len(fake.remoteControllers))
+	}
+}
+
+func TestBlueFieldPlugin_NVMeNamespaceInvalidTarget(t *testing.T) {
+	p, fake := newStorageTestPlugin(t)
+	valid := plugin.NVMeOFTarget{Transport: plugin.NVMeOFTransportRDMA, Address: "2001:db8::10", Port: 4420, NQN: "nqn.2024-01.io.example:target"}
+	for name, mutate := range map[string]func(*plugin.NVMeOFTarget){
+		"transport": func(target *plugin.NVMeOFTarget) { target.Transport = "fc" },
+		"address":   func(target *plugin.NVMeOFTarget) { target.Address = "target.example.com" },
+		"port":      func(target *plugin.NVMeOFTarget) { target.Port = 0 },
+		"nqn":       func(target *plugin.NVMeOFTarget) { target.NQN = "" },
+	} {
+		target := valid
+		mutate(&target)
+		_, err := p.CreateNVMeNamespace(context.Background(), &plugin.NVMeNamespaceRequest{
+			SubsystemID: "nvmeSubsystems/s1",
+			NSID:        1,
+			Target:      &target,
+		})
+		if err == nil {
+			t.Errorf("expected an error for an invalid %s", name)
+		}
+	}
+	_, err := p.CreateNVMeNamespace(context.Background(), &plugin.NVMeNamespaceRequest{SubsystemID: "nvmeSubsystems/s1", NSID: 1})
+	if err == nil {
+		t.Error("expected an error without a target")
+	}
+	if len(fake.remoteControllers) != 0 {
+		t.Errorf("expected no remote controllers, got %d", len(fake.remoteControllers))
+	}
+}
+
+func TestBlueFieldPlugin_NVMeNamespaceFailureDisconnectsTarget(t *testing.T) {
+	p, fake := newStorageTestPlugin(t)
+	// The subsystem does not exist, so the namespace cannot be created
+	_, err := p.CreateNVMeNamespace(context.Background(), &plugin.NVMeNamespaceRequest{
+		SubsystemID: "nvmeSubsystems/s1",
+		NSID:        1,
+		Target:      &plugin.NVMeOFTarget{Transport: plugin.NVMeOFTransportTCP, Address: "192.0.2.10", Port: 4420, NQN: "nqn.2024-01.io.example:target"},
+	})
+	if !plugin.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if len(fake.paths) != 0 || len(fake.remoteControllers) != 0 {
+		t.Errorf("expected no paths and remote controllers, got %d and %d", len(fake.paths), len(fake.remoteControllers))
+	}
+}
+
+func TestBlueFieldPlugin_DeleteNVMeNamespace(t *testing.T) {
+	p, fake := newStorageTestPlugin(t)
+	name := "nvmeSubsystems/s1/nvmeNamespaces/ns1"
+	fake.namespaces[name] = &storagepb.NvmeNamespace{Name: name}
+
+	// Deleting the namespace twice removes it, then reports it missing
+	if err := p.DeleteNVMeNamespace(context.Background(), name); err != nil {
+		t.Fatalf("DeleteNVMeNamespace failed: %v", err)
+	}
+	if len(fake.namespaces) != 0 {
+		t.Errorf("expected no namespaces, got %d", len(fake.namespaces))
+	}
+	if err := p.DeleteNVMeNamespace(context.Background(), name); !plugin.IsNotFound(err) {
+		t.Errorf("expected not found when deleting again, got %v", err)
+	}
+}
+
+func TestBlueFieldPlugin_StorageNotInitialized(t *testing.T) {
+	p := newTestPlugin(t)
+	if _, err := p.CreateNVMeSubsystem(context.Background(), &plugin.NVMeSubsystemRequest{NQN: "nqn"}); err != plugin.ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+	if err := p.DeleteNVMeNamespace(context.Background(), "nvmeSubsystems/s1/nvmeNamespaces/ns1"); err != plugin.ErrNotInitialized {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+}
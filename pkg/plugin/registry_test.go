@@ -18,6 +18,8 @@ package plugin
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -412,6 +414,10 @@ func (m *MockNetworkPlugin) DeleteNetworkFunction(ctx context.Context, input, ou
 	return nil
 }
 
+func (m *MockNetworkPlugin) ListNetworkFunctions(ctx context.Context) ([]*NetworkFunction, error) {
+	return nil, nil
+}
+
 func TestPluginCheckerNetworkPlugin(t *testing.T) {
 	// Test with a plugin that implements NetworkPlugin
 	netPlugin := &MockNetworkPlugin{
@@ -442,6 +448,125 @@ func TestPluginCheckerNetworkPlugin(t *testing.T) {
 	}
 }
 
+// MockFirmwarePlugin implements both Plugin and FirmwarePlugin for testing.
+type MockFirmwarePlugin struct {
+	MockPlugin
+}
+
+func (m *MockFirmwarePlugin) GetFirmwareVersions(ctx context.Context, deviceID string) ([]FirmwareVersion, error) {
+	return nil, nil
+}
+
+func (m *MockFirmwarePlugin) StageFirmware(ctx context.Context, deviceID string, image *FirmwareImage) error {
+	return nil
+}
+
+func (m *MockFirmwarePlugin) ActivateFirmware(ctx context.Context, deviceID string) (*FirmwareActivation, error) {
+	return &FirmwareActivation{Reset: FirmwareResetNone}, nil
+}
+
+func TestPluginCheckerFirmwarePlugin(t *testing.T) {
+	fwPlugin := &MockFirmwarePlugin{
+		MockPlugin: MockPlugin{
+			info: PluginInfo{
+				Name:         "fw-test",
+				Capabilities: []Capability{CapabilityFirmware},
+			},
+		},
+	}
+
+	checker := NewPluginChecker(fwPlugin)
+
+	if !checker.IsFirmwarePlugin() {
+		t.Error("expected IsFirmwarePlugin to return true")
+	}
+
+	if checker.AsFirmwarePlugin() == nil {
+		t.Error("expected AsFirmwarePlugin to return non-nil")
+	}
+
+	if !checker.SupportsCapability(CapabilityFirmware) {
+		t.Error("expected firmware capability to be supported")
+	}
+
+	if NewPluginChecker(NewMockPlugin("test", "Test", nil, nil)).IsFirmwarePlugin() {
+		t.Error("expected IsFirmwarePlugin to return false for a plain plugin")
+	}
+}
+
+// MockRebootPlugin implements both Plugin and RebootPlugin for testing.
+type MockRebootPlugin struct {
+	MockPlugin
+}
+
+func (m *MockRebootPlugin) RebootDevice(ctx context.Context, deviceID string) error {
+	return nil
+}
+
+func TestPluginCheckerRebootPlugin(t *testing.T) {
+	rebootPlugin := &MockRebootPlugin{
+		MockPlugin: MockPlugin{
+			info: PluginInfo{
+				Name:         "reboot-test",
+				Capabilities: []Capability{CapabilityReboot},
+			},
+		},
+	}
+
+	checker := NewPluginChecker(rebootPlugin)
+
+	if !checker.IsRebootPlugin() {
+		t.Error("expected IsRebootPlugin to return true")
+	}
+
+	if checker.AsRebootPlugin() == nil {
+		t.Error("expected AsRebootPlugin to return non-nil")
+	}
+
+	if !checker.SupportsCapability(CapabilityReboot) {
+		t.Error("expected reboot capability to be supported")
+	}
+
+	if NewPluginChecker(NewMockPlugin("test", "Test", nil, nil)).AsRebootPlugin() != nil {
+		t.Error("expected AsRebootPlugin to return nil for a plain plugin")
+	}
+}
+
+// MockInstancePlugin implements both Plugin and InstancePlugin for testing.
+type MockInstancePlugin struct {
+	MockPlugin
+}
+
+func (m *MockInstancePlugin) NewInstance() Plugin {
+	return &MockInstancePlugin{MockPlugin: MockPlugin{info: m.info}}
+}
+
+func TestPluginCheckerInstancePlugin(t *testing.T) {
+	instancePlugin := &MockInstancePlugin{
+		MockPlugin: MockPlugin{
+			info: PluginInfo{Name: "instance-test"},
+		},
+	}
+
+	checker := NewPluginChecker(instancePlugin)
+
+	if !checker.IsInstancePlugin() {
+		t.Error("expected IsInstancePlugin to return true")
+	}
+
+	instance := checker.AsInstancePlugin().NewInstance()
+	if instance == Plugin(instancePlugin) {
+		t.Error("expected NewInstance to return a separate instance")
+	}
+	if instance.Info().Name != "instance-test" {
+		t.Errorf("expected instance of instance-test, got %s", instance.Info().Name)
+	}
+
+	if NewPluginChecker(NewMockPlugin("test", "Test", nil, nil)).AsInstancePlugin() != nil {
+		t.Error("expected AsInstancePlugin to return nil for a plain plugin")
+	}
+}
+
 func TestPluginCheckerSupportsCapability(t *testing.T) {
 	plugin := NewMockPlugin("test", "Test", nil, []Capability{
 		CapabilityNetworking,
@@ -623,3 +748,26 @@ func TestMockPluginImplementation(t *testing.T) {
 		t.Error("expected plugin to be shutdown")
 	}
 }
+
+func TestIPsecKeysRedacted(t *testing.T) {
+	keys := &IPsecKeys{
+		EncryptionKey:     "00112233445566778899aabbccddeeff",
+		AuthenticationKey: "ffeeddccbbaa99887766554433221100",
+		SPI:               4096,
+	}
+
+	for _, out := range []string{
+		fmt.Sprintf("%v", keys),
+		fmt.Sprintf("%+v", *keys),
+		fmt.Sprintf("%#v", keys),
+		fmt.Sprintf("%s", keys),
+		fmt.Sprintf("%v", keys.MarshalLog()),
+	} {
+		if strings.Contains(out, keys.EncryptionKey) || strings.Contains(out, keys.AuthenticationKey) {
+			t.Errorf("key material leaked: %s", out)
+		}
+		if !strings.Contains(out, "4096") {
+			t.Errorf("SPI missing: %s", out)
+		}
+	}
+}
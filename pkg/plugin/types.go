@@ -33,6 +33,10 @@ const (
 	CapabilitySecurity Capability = "security"
 	// CapabilityAIML indicates the plugin supports AI/ML inference offload.
 	CapabilityAIML Capability = "aiml"
+	// CapabilityFirmware indicates the plugin can update the firmware of its devices.
+	CapabilityFirmware Capability = "firmware"
+	// CapabilityReboot indicates the plugin can reboot its devices on request.
+	CapabilityReboot Capability = "reboot"
 )
 
 // PluginInfo contains metadata about a vendor plugin.
@@ -71,6 +71,12 @@ func New() *XSightPlugin {
 	}
 }
 
+// NewInstance returns a new plugin instance, so that each DPU can be served
+// on its own endpoint.
+func (p *XSightPlugin) NewInstance() plugin.Plugin {
+	return New()
+}
+
 // Info returns metadata about this plugin.
 func (p *XSightPlugin) Info() plugin.PluginInfo {
 	return plugin.PluginInfo{
@@ -324,10 +330,23 @@ func (p *XSightPlugin) DeleteNetworkFunction(ctx context.Context, input, output
 	return plugin.ErrNotImplemented
 }
 
+// ListNetworkFunctions lists the network functions set up on the device.
+func (p *XSightPlugin) ListNetworkFunctions(ctx context.Context) ([]*plugin.NetworkFunction, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.initialized {
+		return nil, plugin.ErrNotInitialized
+	}
+
+	return nil, plugin.ErrNotImplemented
+}
+
 // Ensure XSightPlugin implements the required interfaces.
 var (
-	_ plugin.Plugin        = (*XSightPlugin)(nil)
-	_ plugin.NetworkPlugin = (*XSightPlugin)(nil)
+	_ plugin.Plugin         = (*XSightPlugin)(nil)
+	_ plugin.NetworkPlugin  = (*XSightPlugin)(nil)
+	_ plugin.InstancePlugin = (*XSightPlugin)(nil)
 )
 
 // init registers the plugin with the global registry.
@@ -16,7 +16,24 @@ type Drainer struct {
 	drainer drain.DrainInterface
 }
 
-func NewDrainer(config *rest.Config) (*Drainer, error) {
+// WithResourcePrefix limits a drain that is not a full node drain to the pods
+// requesting a resource with the prefix, e.g. the DPU resource.
+func WithResourcePrefix(prefix string) func(*drainerOptions) {
+	return func(o *drainerOptions) {
+		o.resourcePrefix = prefix
+	}
+}
+
+type drainerOptions struct {
+	resourcePrefix string
+}
+
+func NewDrainer(config *rest.Config, opts ...func(*drainerOptions)) (*Drainer, error) {
+	options := &drainerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	platform, err := platforms.NewDefaultPlatformHelper()
 	if err != nil {
 		return nil, err
@@ -25,6 +42,9 @@ func NewDrainer(config *rest.Config) (*Drainer, error) {
 	// TODO: Make sriov network operator drain module modular from the rest of the repo.
 	// In the meantime we will need to set arguments for drain interface creation via sriov's vars package
 	vars.Config = config
+	if options.resourcePrefix != "" {
+		vars.ResourcePrefix = options.resourcePrefix
+	}
 
 	drainerInstance, err := drain.NewDrainer(platform)
 	if err != nil {
@@ -19,6 +19,28 @@ func init() {
 }
 
 const (
-	MetricsServiceName               = "dpu-operator-controller-manager-metrics-service"
-	DpuConfigVFCountAnnotationPrefix = "dpu.config.openshift.io/vf-count/"
+	MetricsServiceName = "dpu-operator-controller-manager-metrics-service"
+
+	// Annotations the DataProcessingUnitConfig controller used to write on
+	// DPUs before the effective config moved to the DPU spec. They are only
+	// kept to clean them up.
+	LegacyDpuConfigAnnotationPrefix        = "dpu.config.openshift.io/config-"
+	LegacyDpuConfigVFCountAnnotationPrefix = "dpu.config.openshift.io/vf-count/"
+
+	// FirmwareRebootPendingAnnotation marks a node whose DPU firmware runs
+	// after a reboot. Its value is a JSON object with the boot ID of the node
+	// when the firmware was activated, so the daemon can tell once the node
+	// rebooted.
+	FirmwareRebootPendingAnnotation = "dpu.config.openshift.io/firmware-reboot-pending"
+
+	// DpuRebootHoldAnnotation marks a node the daemon cordoned while DPUs
+	// reset. Its value is a JSON object mapping each of these DPUs to whether
+	// the node was drained for it, so the daemon can uncordon the node once
+	// the last one is back, even after a restart.
+	DpuRebootHoldAnnotation = "dpu.config.openshift.io/dpu-reboot-hold"
+
+	// DpuNodeLabel holds the node of a DPU, hashed when the node name is too
+	// long for a label value. The daemons only watch the DPUs labeled with
+	// their node.
+	DpuNodeLabel = "dpu.config.openshift.io/node"
 )